@@ -198,7 +198,7 @@ func updateActionHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *
 	err = action.UpdateActionDB(tx, a, c.User.ID)
 	if err != nil {
 		log.Warning("updateAction: Cannot update action: %s\n", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		WriteError(w, r, err)
 		return
 	}
 	log.Notice("Action %s updated\n", a.Name)
@@ -249,7 +249,7 @@ func addActionHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *con
 	err = action.InsertAction(tx, a, true)
 	if err != nil {
 		log.Warning("Action: Cannot insert action: %s\n", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		WriteError(w, r, err)
 		return
 	}
 
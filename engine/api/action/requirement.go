@@ -1,6 +1,8 @@
 package action
 
 import (
+	"database/sql"
+
 	"github.com/ovh/cds/engine/api/database"
 	"github.com/ovh/cds/sdk"
 )
@@ -10,7 +12,7 @@ import (
 func LoadAllActionRequirements(db database.Querier) ([]sdk.Requirement, error) {
 	var req []sdk.Requirement
 
-	query := `SELECT name, type, value FROM action_requirement`
+	query := `SELECT name, type, value, version FROM action_requirement`
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
@@ -20,11 +22,13 @@ func LoadAllActionRequirements(db database.Querier) ([]sdk.Requirement, error) {
 	for rows.Next() {
 		var r sdk.Requirement
 		var t string
-		err = rows.Scan(&r.Name, &t, &r.Value)
+		var version sql.NullString
+		err = rows.Scan(&r.Name, &t, &r.Value, &version)
 		if err != nil {
 			return nil, err
 		}
 		r.Type = sdk.RequirementType(t)
+		r.Version = version.String
 		req = append(req, r)
 	}
 
@@ -35,7 +39,7 @@ func LoadAllActionRequirements(db database.Querier) ([]sdk.Requirement, error) {
 func LoadActionRequirements(db database.Querier, actionID int64) ([]sdk.Requirement, error) {
 	var req []sdk.Requirement
 
-	query := `SELECT name, type, value FROM action_requirement WHERE action_id = $1 ORDER BY name`
+	query := `SELECT name, type, value, version FROM action_requirement WHERE action_id = $1 ORDER BY name`
 	rows, err := db.Query(query, actionID)
 	if err != nil {
 		return nil, err
@@ -45,11 +49,13 @@ func LoadActionRequirements(db database.Querier, actionID int64) ([]sdk.Requirem
 	for rows.Next() {
 		var r sdk.Requirement
 		var t string
-		err = rows.Scan(&r.Name, &t, &r.Value)
+		var version sql.NullString
+		err = rows.Scan(&r.Name, &t, &r.Value, &version)
 		if err != nil {
 			return nil, err
 		}
 		r.Type = sdk.RequirementType(t)
+		r.Version = version.String
 		req = append(req, r)
 	}
 
@@ -58,9 +64,13 @@ func LoadActionRequirements(db database.Querier, actionID int64) ([]sdk.Requirem
 
 // InsertActionRequirement inserts given requirement in database
 func InsertActionRequirement(db database.Executer, actionID int64, r sdk.Requirement) error {
-	query := `INSERT INTO action_requirement (action_id, name, type, value) VALUES ($1, $2, $3, $4)`
+	if err := r.CheckVersion(); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO action_requirement (action_id, name, type, value, version) VALUES ($1, $2, $3, $4, $5)`
 
-	_, err := db.Exec(query, actionID, r.Name, string(r.Type), r.Value)
+	_, err := db.Exec(query, actionID, r.Name, string(r.Type), r.Value, sql.NullString{String: r.Version, Valid: r.Version != ""})
 	if err != nil {
 		return err
 	}
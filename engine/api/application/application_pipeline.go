@@ -116,6 +116,14 @@ func DeleteAllApplicationPipeline(db database.Executer, applicationID int64) err
 		return err
 	}
 
+	query = `
+		DELETE FROM application_pipeline_concurrency WHERE application_pipeline_id IN (
+			SELECT id FROM application_pipeline WHERE application_id = $1
+		)`
+	if _, err := db.Exec(query, applicationID); err != nil {
+		return err
+	}
+
 	query = `DELETE FROM application_pipeline WHERE application_id= $1`
 	if _, err := db.Exec(query, applicationID); err != nil {
 		return err
@@ -126,6 +134,11 @@ func DeleteAllApplicationPipeline(db database.Executer, applicationID int64) err
 		return err
 	}
 
+	query = `DELETE FROM pipeline_schedule WHERE application_id = $1`
+	if _, err := db.Exec(query, applicationID); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -176,6 +189,17 @@ func RemovePipeline(db *sql.Tx, key, appName, pipelineName string) error {
 		return err
 	}
 
+	// Delete schedules
+	query = `DELETE FROM pipeline_schedule
+		WHERE
+		pipeline_id = (select pipeline.id from pipeline JOIN project ON project.id = pipeline.project_id WHERE pipeline.name = $1 AND projectkey = $3)
+		AND
+		application_id = (SELECT application.id FROM application JOIN project ON project.id = application.project_id WHERE application.name = $2 AND projectkey = $3)`
+	_, err = db.Exec(query, pipelineName, appName, key)
+	if err != nil {
+		return err
+	}
+
 	err = trigger.DeleteApplicationPipelineTriggers(db, key, appName, pipelineName)
 	if err != nil {
 		return fmt.Errorf("RemovePipeline> cannot delete app trigger> %s", err)
@@ -208,6 +232,22 @@ func RemovePipeline(db *sql.Tx, key, appName, pipelineName string) error {
 		return err
 	}
 
+	// Delete application_pipeline_concurrency
+	query = `
+		DELETE	FROM application_pipeline_concurrency
+		USING 	application_pipeline, application, project, pipeline
+		WHERE 	application_pipeline_concurrency.application_pipeline_id = application_pipeline.id
+		AND 	application.project_id = project.id
+		AND 	application.id = application_pipeline.application_id
+		AND 	pipeline.id = application_pipeline.pipeline_id
+		AND 	application.name = $1
+		AND 	project.projectKey = $2
+		AND  	pipeline.name = $3`
+	_, err = db.Exec(query, appName, key, pipelineName)
+	if err != nil {
+		return err
+	}
+
 	// Delete application_pipeline link
 	query = `DELETE FROM application_pipeline
 	          USING application, project, pipeline
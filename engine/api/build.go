@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/ovh/cds/engine/api/permission"
 	"github.com/ovh/cds/engine/api/pipeline"
 	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/api/queue"
 	"github.com/ovh/cds/engine/api/stats"
 	"github.com/ovh/cds/engine/api/worker"
 	"github.com/ovh/cds/engine/log"
@@ -151,6 +153,20 @@ func deleteBuildHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 			return
 		}
 
+		err = build.DeleteTestCaseRuns(tx, result.ID)
+		if err != nil {
+			log.Warning("deleteBuildHandler> Cannot delete test case runs of pipeline build %d: %s\n", result.ID, err)
+			WriteError(w, r, err)
+			return
+		}
+
+		err = build.DeleteCodeReports(tx, result.ID)
+		if err != nil {
+			log.Warning("deleteBuildHandler> Cannot delete code reports of pipeline build %d: %s\n", result.ID, err)
+			WriteError(w, r, err)
+			return
+		}
+
 		// Delete history
 		queryDeletePipelineHistory := `DELETE FROM pipeline_history WHERE pipeline_build_id = $1`
 		_, err = tx.Exec(queryDeletePipelineHistory, result.ID)
@@ -323,6 +339,31 @@ func getBuildStateHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c
 		result.Start = pb.Start
 		result.Trigger = pb.Trigger
 	}
+
+	// Archived builds only keep the stages which ran, their graph follows the current stages of the pipeline
+	stages := result.Stages
+	if inHistory {
+		stages, err = pipeline.LoadStages(db, p.ID)
+		if err != nil {
+			log.Warning("getBuildStateHandler> Cannot load pipeline stages: %s\n", err)
+			WriteError(w, r, err)
+			return
+		}
+		for i := range stages {
+			for _, s := range result.Stages {
+				if s.ID == stages[i].ID {
+					stages[i].ActionBuilds = s.ActionBuilds
+				}
+			}
+		}
+	}
+	dag, err := sdk.NewStageDAG(stages)
+	if err != nil {
+		log.Warning("getBuildStateHandler> Cannot resolve stage needs of pipeline %s: %s\n", pipelineName, err)
+	} else {
+		result.Graph = dag.Graph(stages)
+	}
+
 	result.Environment = *env
 	result.Application = *a
 	result.Pipeline = *p
@@ -432,7 +473,7 @@ func takeActionBuildHandler(w http.ResponseWriter, r *http.Request, db *sql.DB,
 	// update database
 	ab, err := build.TakeActionBuild(db, id, caller)
 	if err != nil {
-		if err != build.ErrAlreadyTaken {
+		if err != build.ErrAlreadyTaken && err != build.ErrWorkersLimitReached {
 			log.Warning("takeActionBuildHandler> Cannot give ActionBuild %s: %s\n", id, err)
 		}
 		w.WriteHeader(http.StatusBadRequest)
@@ -578,6 +619,93 @@ func getQueueHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *cont
 	WriteJSON(w, r, queue, http.StatusOK)
 }
 
+const (
+	// queueWaitTimeout is how long a worker waits for action builds when it does not say
+	queueWaitTimeout = 30 * time.Second
+	// queueWaitMaxTimeout is the longest a worker may wait for action builds
+	queueWaitMaxTimeout = 60 * time.Second
+	// queueWaitRecheck is how often the queue is loaded again while waiting. Pushes in queue wake up
+	// the waiting workers of all the API instances, but nothing tells when action builds become
+	// available to a group again as builds of other groups end. Without PostgreSQL notifications,
+	// pushes by other instances are also only seen on recheck.
+	queueWaitRecheck = 15 * time.Second
+)
+
+// waitQueueHandler holds the request of a worker until action builds it can run are in queue
+func waitQueueHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	if c.Worker.ID == "" {
+		WriteError(w, r, sdk.ErrForbidden)
+		return
+	}
+
+	timeout := queueWaitTimeout
+	if t := r.FormValue("timeout"); t != "" {
+		s, err := strconv.Atoi(t)
+		if err != nil || s < 0 {
+			log.Warning("waitQueueHandler> Invalid timeout %s\n", t)
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+		timeout = time.Duration(s) * time.Second
+		if timeout > queueWaitMaxTimeout {
+			timeout = queueWaitMaxTimeout
+		}
+	}
+
+	// Load calling worker
+	caller, err := worker.LoadWorker(db, c.Worker.ID)
+	if err != nil {
+		log.Warning("waitQueueHandler> cannot load calling worker: %s\n", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if caller.Status != sdk.StatusWaiting {
+		log.Debug("waitQueueHandler> worker %s is not available to build (status = %s)\n", caller.ID, caller.Status)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var closed <-chan bool
+	if cn, ok := w.(http.CloseNotifier); ok {
+		closed = cn.CloseNotify()
+	}
+	deadline := time.After(timeout)
+	recheck := time.NewTicker(queueWaitRecheck)
+	defer recheck.Stop()
+
+	for {
+		// Get the signal before loading the queue, so no update is missed in between
+		updated := build.QueueUpdates()
+
+		queue, err := build.LoadGroupWaitingQueue(db, caller.GroupID)
+		if err != nil {
+			log.Warning("waitQueueHandler> Cannot load queue from db: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		queue, err = worker.FilterQueue(db, caller.Model, queue)
+		if err != nil {
+			log.Warning("waitQueueHandler> Cannot filter queue for worker %s: %s\n", caller.ID, err)
+			WriteError(w, r, err)
+			return
+		}
+		if len(queue) > 0 {
+			WriteJSON(w, r, queue, http.StatusOK)
+			return
+		}
+
+		select {
+		case <-updated:
+		case <-recheck.C:
+		case <-deadline:
+			WriteJSON(w, r, queue, http.StatusOK)
+			return
+		case <-closed:
+			return
+		}
+	}
+}
+
 func requirementsErrorHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -596,9 +724,53 @@ func requirementsErrorHandler(w http.ResponseWriter, r *http.Request, db *sql.DB
 		}
 
 		log.Warning("%s (%s) > %s", c.Worker.ID, caller.Name, string(body))
+
+		// Older workers do not tell which action build they were checking
+		var actionBuildID int64
+		if id := r.FormValue("actionBuild"); id != "" {
+			actionBuildID, err = strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				log.Warning("requirementsErrorHandler> invalid action build id %s\n", id)
+				WriteError(w, r, sdk.ErrWrongRequest)
+				return
+			}
+		}
+		if err := queue.InsertRequirementError(db, caller, actionBuildID, string(body)); err != nil {
+			log.Warning("requirementsErrorHandler> cannot record requirement error: %s\n", err)
+			WriteError(w, r, err)
+			return
+		}
 	}
 }
 
+// explainQueueHandler tells why an action build in queue is not taken by a worker
+func explainQueueHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Warning("explainQueueHandler> invalid action build id %s\n", vars["id"])
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	e, err := queue.Explain(db, id)
+	if err != nil {
+		if err != sdk.ErrActionBuildNotFound {
+			log.Warning("explainQueueHandler> cannot explain action build %d: %s\n", id, err)
+		}
+		WriteError(w, r, err)
+		return
+	}
+
+	if !permission.AccessToPipeline(sdk.DefaultEnv.ID, e.ActionBuild.PipelineID, c.User, permission.PermissionRead) {
+		log.Warning("explainQueueHandler> user %s cannot read pipeline %d\n", c.User.Username, e.ActionBuild.PipelineID)
+		WriteError(w, r, sdk.ErrForbidden)
+		return
+	}
+
+	WriteJSON(w, r, e, http.StatusOK)
+}
+
 func addBuildVariableHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
 	// Get pipeline and action name in URL
 	vars := mux.Vars(r)
@@ -820,10 +992,33 @@ func addBuildTestResultsHandler(w http.ResponseWriter, r *http.Request, db *sql.
 		tests.TotalSkipped += ts.Skip
 	}
 
-	err = build.UpdateTestResults(db, pb.ID, tests)
+	tx, err := db.Begin()
+	if err != nil {
+		log.Warning("addBuildTestsResultsHandler> Cannot start transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	err = build.UpdateTestResults(tx, pb.ID, tests)
 	if err != nil {
 		log.Warning("addBuildTestsResultsHandler> Cannot insert tests results: %s\n", err)
 		WriteError(w, r, err)
+		return
+	}
+
+	// Index test cases to follow them across builds
+	err = build.InsertTestCaseRuns(tx, &pb, new.TestSuites)
+	if err != nil {
+		log.Warning("addBuildTestsResultsHandler> Cannot insert test case runs: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warning("addBuildTestsResultsHandler> Cannot commit transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
 	}
 
 	stats.TestEvent(db, p.ProjectID, a.ID, tests)
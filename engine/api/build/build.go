@@ -9,6 +9,7 @@ import (
 
 	"github.com/ovh/cds/engine/api/action"
 	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/group"
 	"github.com/ovh/cds/engine/api/notification"
 	"github.com/ovh/cds/engine/log"
 	"github.com/ovh/cds/sdk"
@@ -17,6 +18,8 @@ import (
 var (
 	// ErrAlreadyTaken Action already taken by a worker
 	ErrAlreadyTaken = fmt.Errorf("cds: action already taken")
+	// ErrWorkersLimitReached The group owning the action uses all the workers it is allowed to
+	ErrWorkersLimitReached = fmt.Errorf("cds: workers limit reached")
 )
 
 // LoadBuildByPipelineBuildID Load all actions_build by pipeline ID
@@ -31,13 +34,17 @@ func LoadBuildByPipelineBuildID(db *sql.DB, pipelineBuildID int64) ([]sdk.Action
 			action_build.queued,
 			action_build.start,
 			action_build.done ,
+			action_build.attempt,
+			action_build.retried,
+			action_build.awol,
+			action_build.timeout,
 			pipeline_action.pipeline_stage_id,
 			action.name, action.id
 		   FROM action_build
 		   JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
 		   JOIN action ON action.id = pipeline_action.action_id
 		   WHERE pipeline_build_id = $1
-		   ORDER BY action.name,action_build.pipeline_action_id`
+		   ORDER BY action.name,action_build.pipeline_action_id,action_build.attempt`
 	builds := []sdk.ActionBuild{}
 
 	rows, err := db.Query(query, pipelineBuildID)
@@ -51,7 +58,9 @@ func LoadBuildByPipelineBuildID(db *sql.DB, pipelineBuildID int64) ([]sdk.Action
 		var done interface{}
 		var sStatus string
 		var actionID int64
-		err = rows.Scan(&b.ID, &b.PipelineActionID, &argsJSON, &sStatus, &b.PipelineBuildID, &b.Queued, &b.Start, &done, &b.PipelineStageID, &b.ActionName, &actionID)
+		var attempt, timeout sql.NullInt64
+		var retried, awol sql.NullBool
+		err = rows.Scan(&b.ID, &b.PipelineActionID, &argsJSON, &sStatus, &b.PipelineBuildID, &b.Queued, &b.Start, &done, &attempt, &retried, &awol, &timeout, &b.PipelineStageID, &b.ActionName, &actionID)
 		b.Status = sdk.StatusFromString(sStatus)
 		if err != nil {
 			return nil, err
@@ -59,6 +68,10 @@ func LoadBuildByPipelineBuildID(db *sql.DB, pipelineBuildID int64) ([]sdk.Action
 		if done != nil {
 			b.Done = done.(time.Time)
 		}
+		b.Attempt = int(attempt.Int64)
+		b.Retried = retried.Bool
+		b.AWOL = awol.Bool
+		b.Timeout = timeout.Int64
 
 		if b.Status == sdk.StatusWaiting {
 			requirements, err := action.LoadActionRequirements(db, actionID)
@@ -104,9 +117,9 @@ func UpdateActionBuildStatus(db *sql.Tx, build *sdk.ActionBuild, status sdk.Stat
 	var err error
 	log.Debug("UpdateActionBuildStatus> Updating action_build %d to %s\n", build.ID, status)
 
-	query = `SELECT status FROM action_build WHERE id = $1 FOR UPDATE`
+	query = `SELECT status, pipeline_build_id FROM action_build WHERE id = $1 FOR UPDATE`
 	var currentStatus string
-	err = db.QueryRow(query, build.ID).Scan(&currentStatus)
+	err = db.QueryRow(query, build.ID).Scan(&currentStatus, &build.PipelineBuildID)
 	if err != nil {
 		return err
 	}
@@ -122,7 +135,7 @@ func UpdateActionBuildStatus(db *sql.Tx, build *sdk.ActionBuild, status sdk.Stat
 		_, err = db.Exec(query, status.String(), time.Now(), build.ID)
 		break
 
-	case sdk.StatusFail, sdk.StatusSuccess, sdk.StatusDisabled, sdk.StatusSkipped:
+	case sdk.StatusFail, sdk.StatusSuccess, sdk.StatusDisabled, sdk.StatusSkipped, sdk.StatusStopped:
 		if currentStatus != string(sdk.StatusBuilding) && status != sdk.StatusDisabled && status != sdk.StatusSkipped {
 			log.Info("Status is %, cannot update %d to %s", currentStatus, build.ID, status)
 			// too late, Nate
@@ -131,6 +144,10 @@ func UpdateActionBuildStatus(db *sql.Tx, build *sdk.ActionBuild, status sdk.Stat
 
 		query = `UPDATE action_build SET status = $1, done = $2 WHERE id = $3`
 		_, err = db.Exec(query, status.String(), time.Now(), build.ID)
+		if err == nil {
+			// The pipeline build may go on
+			WakeScheduler(db, build.PipelineBuildID)
+		}
 	default:
 		err = fmt.Errorf("Cannot update ActionBuild %d to status %v", build.ID, status.String())
 	}
@@ -143,11 +160,13 @@ func UpdateActionBuildStatus(db *sql.Tx, build *sdk.ActionBuild, status sdk.Stat
 
 	notification.SendActionBuild(db, build, sdk.UpdateNotifEvent, status)
 
-	if status == sdk.StatusFail || status == sdk.StatusDisabled || status == sdk.StatusSkipped {
+	if status == sdk.StatusFail || status == sdk.StatusDisabled || status == sdk.StatusSkipped || status == sdk.StatusStopped {
 		var log string
 		switch status {
 		case sdk.StatusFail:
 			log = fmt.Sprintf("Action finished with status: %s\n", status)
+		case sdk.StatusStopped:
+			log = fmt.Sprintf("Action stopped\n")
 		case sdk.StatusDisabled:
 			log = fmt.Sprintf("Action disabled\n")
 		case sdk.StatusSkipped:
@@ -159,23 +178,68 @@ func UpdateActionBuildStatus(db *sql.Tx, build *sdk.ActionBuild, status sdk.Stat
 	return nil
 }
 
+// QueueMaxWait is the time after which a waiting action build of the lowest priority catches up
+// with a fresh one of the highest priority: waiting builds gain priority points at the rate which
+// covers the whole priority range in this time, so low priority pipelines are not starved by a steady flow of urgent ones
+var QueueMaxWait = time.Hour
+
+// QueuePriority returns the SQL expression of the priority of a waiting action build:
+// the priority of its pipeline build, raised by the time it has been waiting
+func QueuePriority() string {
+	return fmt.Sprintf("COALESCE(pipeline_build.priority, 0) + FLOOR(EXTRACT(EPOCH FROM NOW() - COALESCE(action_build.queued, NOW())) * %d / %d)::INT",
+		sdk.PriorityMax-sdk.PriorityMin, int64(QueueMaxWait.Seconds()))
+}
+
+// waitingQueueRequest loads waiting action builds in fair-share order. Each group gets a part of
+// the building workers proportional to its share: the next build of a group goes after the builds
+// of the groups using less than their part, and the groups having reached their workers limit go last.
+// Builds of a same group are ordered by priority and age.
+const waitingQueueRequest = `
+WITH %s,
+queue AS (
+	SELECT action_build.id,
+		action_build.pipeline_action_id,
+		action.id AS action_id,
+		action.name AS action_name,
+		action_build.args,
+		action_build.status, action_build.pipeline_build_id,
+		pipeline_build.pipeline_id,
+		pipeline_build.build_number,
+		action_build.timeout,
+		%s AS priority,
+		COALESCE(owner.group_id, 0) AS group_id
+	FROM action_build
+	JOIN pipeline_build ON pipeline_build.id = action_build.pipeline_build_id
+	JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
+	JOIN action ON action.id = pipeline_action.action_id
+	JOIN pipeline ON pipeline.id = pipeline_build.pipeline_id
+	LEFT JOIN owner ON owner.pipeline_id = pipeline_build.pipeline_id
+	%s
+	WHERE action_build.status = $1
+	%s
+),
+ranked AS (
+	SELECT queue.*, ROW_NUMBER() OVER (PARTITION BY queue.group_id ORDER BY queue.priority DESC, queue.pipeline_build_id, queue.action_name, queue.pipeline_action_id) AS rank
+	FROM queue
+)
+SELECT ranked.id, ranked.pipeline_action_id, ranked.action_id, ranked.action_name, ranked.args,
+	ranked.status, ranked.pipeline_build_id, ranked.pipeline_id, ranked.build_number, ranked.timeout, ranked.priority
+FROM ranked
+LEFT JOIN building ON building.group_id = ranked.group_id
+LEFT JOIN group_share ON group_share.group_id = ranked.group_id
+ORDER BY COALESCE(group_share.max_workers, 0) > 0 AND COALESCE(building.count, 0) >= group_share.max_workers,
+	(COALESCE(building.count, 0) + ranked.rank)::FLOAT / COALESCE(group_share.share, 1),
+	ranked.priority DESC, ranked.pipeline_build_id, ranked.action_name, ranked.pipeline_action_id
+LIMIT 100
+`
+
+func waitingQueueQuery(joins, conditions string) string {
+	return fmt.Sprintf(waitingQueueRequest, group.FairShareTables, QueuePriority(), joins, conditions)
+}
+
 // LoadWaitingQueue Load Waiting action_build
 func LoadWaitingQueue(db *sql.DB) ([]sdk.ActionBuild, error) {
-	query := `SELECT action_build.id,
-			 action_build.pipeline_action_id,
-			 action.id,
-			 action.name,
-			 action_build.args,
-			 action_build.status, action_build.pipeline_build_id,
-			 pipeline_build.pipeline_id,
-			 pipeline_build.build_number
-		  FROM action_build
-		  JOIN pipeline_build ON pipeline_build.id = action_build.pipeline_build_id
-		  JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
-		  JOIN action ON action.id = pipeline_action.action_id
-		  WHERE action_build.status = $1
-		  ORDER BY pipeline_build.id,action.name,action_build.pipeline_action_id
-			LIMIT 100`
+	query := waitingQueueQuery("", "")
 	var queue []sdk.ActionBuild
 
 	rows, err := db.Query(query, sdk.StatusWaiting.String())
@@ -200,27 +264,9 @@ func LoadGroupWaitingQueue(db *sql.DB, groupID int64) ([]sdk.ActionBuild, error)
 	//log.Notice("LoadGroupWaitingQueue for group %d\n", groupID)
 	var queue []sdk.ActionBuild
 
-	query := `
-			 SELECT action_build.id,
-			 action_build.pipeline_action_id,
-			 action.id,
-			 action.name,
-			 action_build.args,
-			 action_build.status, action_build.pipeline_build_id,
-			 pipeline_build.pipeline_id,
-			 pipeline_build.build_number
-		  FROM action_build
-		  JOIN pipeline_build ON pipeline_build.id = action_build.pipeline_build_id
-		  JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
-		  JOIN action ON action.id = pipeline_action.action_id
-		  JOIN pipeline ON pipeline.id = pipeline_build.pipeline_id
-			JOIN pipeline_group ON pipeline_group.pipeline_id = pipeline.id
-			WHERE action_build.status = $1
-			AND pipeline_group.group_id = $2
-			AND pipeline_group.role > 4
-			ORDER BY pipeline_build.id,action.name,action_build.pipeline_action_id
-			LIMIT 100
-			`
+	query := waitingQueueQuery(
+		"JOIN pipeline_group ON pipeline_group.pipeline_id = pipeline.id",
+		"AND pipeline_group.group_id = $2 AND pipeline_group.role > 4")
 
 	rows, err := db.Query(query, sdk.StatusWaiting.String(), groupID)
 	if err != nil {
@@ -255,26 +301,9 @@ func LoadUserWaitingQueue(db *sql.DB, u *sdk.User) ([]sdk.ActionBuild, error) {
 		return queue, nil
 	}
 
-	query := `
-			 SELECT action_build.id,
-			 action_build.pipeline_action_id,
-			 action.id,
-			 action.name,
-			 action_build.args,
-			 action_build.status, action_build.pipeline_build_id,
-			 pipeline_build.pipeline_id,
-			 pipeline_build.build_number
-		  FROM action_build
-		  JOIN pipeline_build ON pipeline_build.id = action_build.pipeline_build_id
-		  JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
-		  JOIN action ON action.id = pipeline_action.action_id
-		  JOIN pipeline ON pipeline.id = pipeline_build.pipeline_id
-			JOIN pipeline_group ON pipeline_group.pipeline_id = pipeline.id
-			JOIN group_user ON group_user.group_id = pipeline_group.group_id
-			WHERE action_build.status = $1 AND group_user.user_id = $2
-		  ORDER BY pipeline_build.id,action.name,action_build.pipeline_action_id
-			LIMIT 100
-			`
+	query := waitingQueueQuery(
+		"JOIN pipeline_group ON pipeline_group.pipeline_id = pipeline.id JOIN group_user ON group_user.group_id = pipeline_group.group_id",
+		"AND group_user.user_id = $2")
 
 	rows, err := db.Query(query, sdk.StatusWaiting.String(), u.ID)
 	if err != nil {
@@ -297,8 +326,10 @@ func loadQueue(db *sql.DB, s database.Scanner) (sdk.ActionBuild, error) {
 	var b sdk.ActionBuild
 	var argsJSON, actionName, sStatus string
 	var actionID int64
-	err := s.Scan(&b.ID, &b.PipelineActionID, &actionID, &actionName, &argsJSON, &sStatus, &b.PipelineBuildID, &b.PipelineID, &b.BuildNumber)
+	var timeout sql.NullInt64
+	err := s.Scan(&b.ID, &b.PipelineActionID, &actionID, &actionName, &argsJSON, &sStatus, &b.PipelineBuildID, &b.PipelineID, &b.BuildNumber, &timeout, &b.Priority)
 	b.Status = sdk.StatusFromString(sStatus)
+	b.Timeout = timeout.Int64
 	if err != nil {
 		return b, err
 	}
@@ -346,14 +377,17 @@ func TakeActionBuild(db *sql.DB, buildID string, worker *sdk.Worker) (sdk.Action
 			 action_build.args,
 			 action_build.status,
 			 action_build.pipeline_build_id,
-			 pipeline_build.build_number
+			 pipeline_build.build_number,
+			 action_build.timeout
 	     FROM action_build
 	     JOIN pipeline_build ON pipeline_build.id = action_build.pipeline_build_id
 			 WHERE action_build.id = $1 FOR UPDATE`
 
 	var sStatus string
-	err = tx.QueryRow(query, buildID).Scan(&b.ID, &b.PipelineActionID, &argsJSON, &sStatus, &b.PipelineBuildID, &b.BuildNumber)
+	var timeout sql.NullInt64
+	err = tx.QueryRow(query, buildID).Scan(&b.ID, &b.PipelineActionID, &argsJSON, &sStatus, &b.PipelineBuildID, &b.BuildNumber, &timeout)
 	b.Status = sdk.StatusFromString(sStatus)
+	b.Timeout = timeout.Int64
 	if err != nil {
 		return b, err
 	}
@@ -367,6 +401,14 @@ func TakeActionBuild(db *sql.DB, buildID string, worker *sdk.Worker) (sdk.Action
 		return b, ErrAlreadyTaken
 	}
 
+	reached, err := workersLimitReached(tx, b.PipelineBuildID)
+	if err != nil {
+		return b, err
+	}
+	if reached {
+		return b, ErrWorkersLimitReached
+	}
+
 	query = ` update action_build set worker_model_name = worker_model.name from worker_model where worker_model.id=$2 and action_build.id = $1`
 	if _, err := tx.Exec(query, b.ID, worker.Model); err != nil {
 		log.Warning("Cannot update model on action_build : %s", err)
@@ -380,6 +422,33 @@ func TakeActionBuild(db *sql.DB, buildID string, worker *sdk.Worker) (sdk.Action
 	return b, tx.Commit()
 }
 
+// workersLimitReached returns true if the group owning given pipeline build has as many building
+// actions as its workers limit. It locks the limit of the group until the end of the transaction.
+func workersLimitReached(tx *sql.Tx, pipelineBuildID int64) (bool, error) {
+	query := fmt.Sprintf(`WITH %s
+		SELECT group_share.group_id, group_share.max_workers FROM pipeline_build
+		JOIN owner ON owner.pipeline_id = pipeline_build.pipeline_id
+		JOIN group_share ON group_share.group_id = owner.group_id
+		WHERE pipeline_build.id = $1 AND group_share.max_workers > 0
+		FOR UPDATE OF group_share`, group.FairShareTables)
+	var groupID, max int64
+	err := tx.QueryRow(query, pipelineBuildID).Scan(&groupID, &max)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	query = fmt.Sprintf(`WITH %s
+		SELECT COALESCE(SUM(building.count), 0) FROM building WHERE building.group_id = $1`, group.FairShareTables)
+	var building int64
+	if err := tx.QueryRow(query, groupID).Scan(&building); err != nil {
+		return false, err
+	}
+	return building >= max, nil
+}
+
 // DeleteActionBuild Delete Action Build
 func DeleteActionBuild(db database.QueryExecuter, pipelineActionIDs []int64) error {
 	for _, id := range pipelineActionIDs {
@@ -3,6 +3,7 @@ package build
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ovh/cds/engine/api/database"
@@ -18,6 +19,83 @@ func InsertLog(db database.Executer, actionBuildID int64, step string, value str
 	return err
 }
 
+// MaxLogSize is the size, in bytes, of the logs kept for an action build. Next lines are dropped, 0 means no limit.
+var MaxLogSize int64
+
+// logInsertChunk is the number of lines inserted by a single query, to stay under the parameters limit
+const logInsertChunk = 1000
+
+// InsertLogs inserts a batch of log lines of an action build. Once the action build logs exceed
+// MaxLogSize, they are truncated with a notice and the following lines are dropped.
+func InsertLogs(db *sql.DB, actionBuildID int64, logs []sdk.Log) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var size int64
+	query := `SELECT COALESCE(log_size, 0) FROM action_build WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(query, actionBuildID).Scan(&size); err != nil {
+		if err == sql.ErrNoRows {
+			return sdk.ErrActionBuildNotFound
+		}
+		return err
+	}
+
+	logs, size = truncateLogs(logs, size, MaxLogSize)
+	now := time.Now()
+	for start := 0; start < len(logs); start += logInsertChunk {
+		end := start + logInsertChunk
+		if end > len(logs) {
+			end = len(logs)
+		}
+
+		values := make([]string, 0, end-start)
+		args := make([]interface{}, 0, 4*(end-start))
+		for i, l := range logs[start:end] {
+			values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d)", 4*i+1, 4*i+2, 4*i+3, 4*i+4))
+			args = append(args, actionBuildID, now, l.Step, l.Value)
+		}
+		query := `INSERT INTO build_log (action_build_id, timestamp, step, value) VALUES ` + strings.Join(values, ", ")
+		if _, err := tx.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE action_build SET log_size = $1 WHERE id = $2`, size, actionBuildID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// truncateLogs keeps the lines fitting in maxSize given the size of the logs already stored,
+// replacing the first line over it with a notice. It returns the lines to insert and the new size.
+func truncateLogs(logs []sdk.Log, size, maxSize int64) ([]sdk.Log, int64) {
+	if maxSize <= 0 {
+		for _, l := range logs {
+			size += int64(len(l.Value))
+		}
+		return logs, size
+	}
+	if size >= maxSize {
+		return nil, size
+	}
+
+	for i, l := range logs {
+		if size+int64(len(l.Value)) > maxSize {
+			notice := sdk.Log{
+				ActionBuildID: l.ActionBuildID,
+				Step:          "SYSTEM",
+				Value:         fmt.Sprintf("Log truncated: it exceeds the maximum size of %d bytes, next lines are dropped\n", maxSize),
+			}
+			return append(logs[:i:i], notice), maxSize
+		}
+		size += int64(len(l.Value))
+	}
+	return logs, size
+}
+
 // LoadLogs retrieves build logs from databse given an offset and a size
 func LoadLogs(db *sql.DB, actionBuildID int64, tail int64, start int64) ([]sdk.Log, error) {
 	query := `SELECT * FROM build_log WHERE action_build_id = $1`
@@ -56,7 +134,7 @@ func LoadPipelineActionBuildLogs(db *sql.DB, pipelineBuildID, pipelineActionID i
 	buildLogResult := sdk.BuildState{}
 
 	// load all build id for pipeline build
-	query := `SELECT id, status FROM action_build WHERE pipeline_build_id = $1 AND pipeline_action_id=$2`
+	query := `SELECT id, status, retried FROM action_build WHERE pipeline_build_id = $1 AND pipeline_action_id=$2 ORDER BY attempt`
 	rows, err := db.Query(query, pipelineBuildID, pipelineActionID)
 	if err != nil {
 		return buildLogResult, err
@@ -67,8 +145,10 @@ func LoadPipelineActionBuildLogs(db *sql.DB, pipelineBuildID, pipelineActionID i
 	for rows.Next() {
 		var b sdk.ActionBuild
 		var sStatus string
-		err = rows.Scan(&b.ID, &sStatus)
+		var retried sql.NullBool
+		err = rows.Scan(&b.ID, &sStatus, &retried)
 		b.Status = sdk.StatusFromString(sStatus)
+		b.Retried = retried.Bool
 		if err != nil {
 			return buildLogResult, err
 		}
@@ -86,8 +166,15 @@ func LoadPipelineActionBuildLogs(db *sql.DB, pipelineBuildID, pipelineActionID i
 	}
 
 	buildLogResult.Logs = pipelinelogs
-	if len(actionBuilds) == 1 {
-		buildLogResult.Status = actionBuilds[0].Status
+	// Previous attempts logs come first, status is the one of the current attempt
+	var current []sdk.ActionBuild
+	for _, b := range actionBuilds {
+		if !b.Retried {
+			current = append(current, b)
+		}
+	}
+	if len(current) == 1 {
+		buildLogResult.Status = current[0].Status
 	}
 
 	return buildLogResult, nil
@@ -0,0 +1,37 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestTruncateLogs(t *testing.T) {
+	logs := []sdk.Log{
+		{Step: "Script", Value: "12345\n"},
+		{Step: "Script", Value: "67890\n"},
+		{Step: "Script", Value: "abcde\n"},
+	}
+
+	kept, size := truncateLogs(logs, 0, 0)
+	assert.Len(t, kept, 3)
+	assert.Equal(t, int64(18), size)
+
+	kept, size = truncateLogs(logs, 0, 100)
+	assert.Len(t, kept, 3)
+	assert.Equal(t, int64(18), size)
+
+	kept, size = truncateLogs(logs, 2, 14)
+	assert.Len(t, kept, 3)
+	assert.Equal(t, "67890\n", kept[1].Value)
+	assert.Equal(t, "SYSTEM", kept[2].Step)
+	assert.Contains(t, kept[2].Value, "Log truncated")
+	assert.Equal(t, int64(14), size)
+	assert.Equal(t, "abcde\n", logs[2].Value)
+
+	kept, size = truncateLogs(logs, 14, 14)
+	assert.Len(t, kept, 0)
+	assert.Equal(t, int64(14), size)
+}
@@ -0,0 +1,77 @@
+package build
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/notification"
+	"github.com/ovh/cds/sdk"
+)
+
+// RequestCancel asks the workers running actions of given pipeline build to stop them
+func RequestCancel(db database.Executer, pipelineBuildID int64) error {
+	query := `UPDATE action_build SET cancel_requested = now()
+		WHERE pipeline_build_id = $1 AND status = $2 AND cancel_requested IS NULL`
+	_, err := db.Exec(query, pipelineBuildID, sdk.StatusBuilding.String())
+	return err
+}
+
+// IsCancelRequested returns true if the worker running given action build has to stop it
+func IsCancelRequested(db database.Querier, actionBuildID int64) (bool, error) {
+	query := `SELECT cancel_requested IS NOT NULL FROM action_build WHERE id = $1 AND status = $2`
+	var cancel bool
+	err := db.QueryRow(query, actionBuildID, sdk.StatusBuilding.String()).Scan(&cancel)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return cancel, err
+}
+
+// LoadUnresponsiveCancels returns the action builds still running after their worker
+// has been asked to stop them for longer than given grace period
+func LoadUnresponsiveCancels(db database.Querier, grace time.Duration) ([]int64, error) {
+	query := `SELECT id FROM action_build
+		WHERE status = $1 AND cancel_requested IS NOT NULL
+		AND cancel_requested + $2 * INTERVAL '1 second' < NOW()`
+	rows, err := db.Query(query, sdk.StatusBuilding.String(), int64(grace.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ForceStop marks given action build as stopped without waiting for its worker.
+// It returns false if the action build already ended.
+func ForceStop(tx *sql.Tx, actionBuildID int64, reason string) (bool, error) {
+	var status string
+	query := `SELECT status FROM action_build WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(query, actionBuildID).Scan(&status); err != nil {
+		return false, err
+	}
+	if status != sdk.StatusBuilding.String() {
+		return false, nil
+	}
+
+	if err := InsertLog(tx, actionBuildID, "SYSTEM", reason); err != nil {
+		return false, err
+	}
+
+	query = `UPDATE action_build SET status = $1, done = $2 WHERE id = $3`
+	if _, err := tx.Exec(query, sdk.StatusStopped.String(), time.Now(), actionBuildID); err != nil {
+		return false, err
+	}
+	notification.SendActionBuild(tx, &sdk.ActionBuild{ID: actionBuildID, Status: sdk.StatusStopped}, sdk.UpdateNotifEvent, sdk.StatusStopped)
+
+	return true, nil
+}
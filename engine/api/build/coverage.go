@@ -0,0 +1,133 @@
+package build
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/sdk"
+)
+
+// LoadCoverage retrieves the code coverage of a specific build in database
+func LoadCoverage(db database.Querier, pbID int64) (sdk.Coverage, error) {
+	c := sdk.Coverage{PipelineBuildID: pbID, Files: []sdk.FileCoverage{}}
+
+	var data string
+	err := db.QueryRow(`SELECT coverage FROM pipeline_build_coverage WHERE pipeline_build_id = $1`, pbID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	err = json.Unmarshal([]byte(data), &c)
+	return c, err
+}
+
+// UpdateCoverage replaces the code coverage of a specific build in database.
+// It deletes then inserts, so it runs in a transaction.
+func UpdateCoverage(tx *sql.Tx, pbID int64, c sdk.Coverage) error {
+	c.Reference = nil
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM pipeline_build_coverage WHERE pipeline_build_id = $1`, pbID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO pipeline_build_coverage (pipeline_build_id, coverage) VALUES ($1, $2)`, pbID, string(data))
+	return err
+}
+
+// LoadReferenceCoverage retrieves the code coverage of the last successful build of the pipeline
+// on the branch of given build, if any
+func LoadReferenceCoverage(db database.Querier, pb *sdk.PipelineBuild) (*sdk.CoverageReference, error) {
+	if pb.Trigger.VCSChangesBranch == "" {
+		return nil, nil
+	}
+
+	query := `
+		SELECT built.id, built.build_number, pipeline_build_coverage.coverage FROM (
+			SELECT id, build_number FROM pipeline_build
+			WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3 AND vcs_changes_branch = $4 AND status = $5 AND build_number < $6
+			UNION ALL
+			SELECT pipeline_build_id, build_number FROM pipeline_history
+			WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3 AND vcs_changes_branch = $4 AND status = $5 AND build_number < $6
+		) AS built
+		JOIN pipeline_build_coverage ON pipeline_build_coverage.pipeline_build_id = built.id
+		ORDER BY built.build_number DESC LIMIT 1`
+
+	var ref sdk.CoverageReference
+	var data string
+	err := db.QueryRow(query, pb.Application.ID, pb.Pipeline.ID, pb.Environment.ID, pb.Trigger.VCSChangesBranch, string(sdk.StatusSuccess), pb.BuildNumber).
+		Scan(&ref.PipelineBuildID, &ref.BuildNumber, &data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var c sdk.Coverage
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		return nil, err
+	}
+	ref.Percent = c.Percent
+	return &ref, nil
+}
+
+// LoadFindings retrieves the findings of static analyzers in a specific build in database
+func LoadFindings(db database.Querier, pbID int64) (sdk.Findings, error) {
+	f := sdk.Findings{PipelineBuildID: pbID, Findings: []sdk.Finding{}}
+
+	var data string
+	err := db.QueryRow(`SELECT findings FROM pipeline_build_findings WHERE pipeline_build_id = $1`, pbID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+
+	err = json.Unmarshal([]byte(data), &f)
+	return f, err
+}
+
+// UpdateFindings replaces the findings of static analyzers in a specific build in database.
+// It deletes then inserts, so it runs in a transaction.
+func UpdateFindings(tx *sql.Tx, pbID int64, f sdk.Findings) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM pipeline_build_findings WHERE pipeline_build_id = $1`, pbID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO pipeline_build_findings (pipeline_build_id, findings) VALUES ($1, $2)`, pbID, string(data))
+	return err
+}
+
+// DeleteCodeReports removes from database the code coverage and the findings of a specific pipeline build
+func DeleteCodeReports(db database.Executer, pbID int64) error {
+	if _, err := db.Exec(`DELETE FROM pipeline_build_coverage WHERE pipeline_build_id = $1`, pbID); err != nil {
+		return err
+	}
+	_, err := db.Exec(`DELETE FROM pipeline_build_findings WHERE pipeline_build_id = $1`, pbID)
+	return err
+}
+
+// DeletePipelineCodeReports removes from database the code coverage and the findings of the builds of a specific pipeline,
+// archived ones included
+func DeletePipelineCodeReports(db database.Executer, pipID int64) error {
+	builds := `(SELECT id FROM pipeline_build WHERE pipeline_id = $1
+		UNION SELECT pipeline_build_id FROM pipeline_history WHERE pipeline_id = $1)`
+	if _, err := db.Exec(`DELETE FROM pipeline_build_coverage WHERE pipeline_build_id IN `+builds, pipID); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`DELETE FROM pipeline_build_findings WHERE pipeline_build_id IN `+builds, pipID)
+	return err
+}
@@ -0,0 +1,68 @@
+package build
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/log"
+)
+
+// QueueChannel is the channel of the notifications telling all the API instances that action builds were pushed in queue
+const QueueChannel = "cds_queue"
+
+// queueSignal is closed, then replaced, each time action builds are pushed in queue
+var queueSignal = struct {
+	sync.Mutex
+	c chan struct{}
+}{c: make(chan struct{})}
+
+// QueueUpdated wakes up the workers waiting for action builds in queue on all the API instances.
+// It has to be called once the action builds are committed.
+func QueueUpdated(db database.Executer) {
+	wakeQueue()
+	if err := database.Notify(db, QueueChannel, ""); err != nil {
+		log.Warning("QueueUpdated> Cannot notify queue update: %s\n", err)
+	}
+}
+
+// QueueUpdates returns a channel closed the next time action builds are pushed in queue
+func QueueUpdates() <-chan struct{} {
+	queueSignal.Lock()
+	defer queueSignal.Unlock()
+	return queueSignal.c
+}
+
+func wakeQueue() {
+	queueSignal.Lock()
+	close(queueSignal.c)
+	queueSignal.c = make(chan struct{})
+	queueSignal.Unlock()
+}
+
+// ListenQueue is a goroutine waking up the workers waiting on this instance when
+// another instance of the API pushes action builds in queue
+func ListenQueue() {
+	listener, err := database.NewListener(QueueChannel)
+	if err != nil {
+		log.Warning("ListenQueue> Cannot listen to queue notifications: %s\n", err)
+		return
+	}
+	if listener == nil {
+		return
+	}
+
+	ping := time.NewTicker(time.Minute)
+	defer ping.Stop()
+
+	for {
+		select {
+		// A nil notification means some may have been lost while reconnecting, waking up is harmless
+		case <-listener.NotificationChannel():
+			wakeQueue()
+		case <-ping.C:
+			// Detect a dead listener connection
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package build
+
+import (
+	"strconv"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/log"
+)
+
+// SchedulerChannel is the channel of the notifications waking up the scheduler for a pipeline build
+const SchedulerChannel = "cds_pipeline_build"
+
+// WakeScheduler notifies the schedulers of all the API instances that given pipeline build has changed.
+// The notification is sent once the transaction of db is committed.
+func WakeScheduler(db database.Executer, pipelineBuildID int64) {
+	if err := database.Notify(db, SchedulerChannel, strconv.FormatInt(pipelineBuildID, 10)); err != nil {
+		log.Warning("WakeScheduler> Cannot notify pipeline build %d: %s\n", pipelineBuildID, err)
+	}
+}
@@ -48,30 +48,14 @@ func InsertTestResults(db database.Executer, pbID int64, tests sdk.Tests) error
 }
 
 // UpdateTestResults update test results of a specific pipeline build in database
-func UpdateTestResults(db *sql.DB, pbID int64, tests sdk.Tests) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
+func UpdateTestResults(db database.Executer, pbID int64, tests sdk.Tests) error {
 	query := `DELETE FROM pipeline_build_test WHERE pipeline_build_id = $1`
-	_, err = tx.Exec(query, pbID)
-	if err != nil {
-		return err
-	}
-
-	err = InsertTestResults(tx, pbID, tests)
-	if err != nil {
-		return err
-	}
-
-	err = tx.Commit()
+	_, err := db.Exec(query, pbID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return InsertTestResults(db, pbID, tests)
 }
 
 // DeleteTestResults removes from database test results for a specific pipeline build
@@ -0,0 +1,149 @@
+package build
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/sdk"
+)
+
+// InsertTestCaseRuns records the result of each test case of given suites in a pipeline build,
+// replacing the results recorded for these suites by a previous upload
+func InsertTestCaseRuns(db database.Executer, pb *sdk.PipelineBuild, suites []sdk.TestSuite) error {
+	deleteQuery := `DELETE FROM test_case_run WHERE pipeline_build_id = $1 AND suite = $2`
+	insertQuery := `INSERT INTO test_case_run (application_id, pipeline_id, environment_id, pipeline_build_id, build_number, vcs_changes_branch, vcs_changes_hash, suite, name, status, duration)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	for _, s := range suites {
+		if _, err := db.Exec(deleteQuery, pb.ID, s.Name); err != nil {
+			return err
+		}
+		for _, t := range s.Tests {
+			duration, _ := strconv.ParseFloat(t.Time, 64)
+			if _, err := db.Exec(insertQuery, pb.Application.ID, pb.Pipeline.ID, pb.Environment.ID, pb.ID, pb.BuildNumber,
+				pb.Trigger.VCSChangesBranch, pb.Trigger.VCSChangesHash, s.Name, t.Name, sdk.TestCaseStatus(t), duration); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteTestCaseRuns removes the test case runs of a pipeline build. Runs are kept when the build
+// is archived, to follow test cases across builds, so this is only called when the build is deleted.
+func DeleteTestCaseRuns(db database.Executer, pbID int64) error {
+	_, err := db.Exec(`DELETE FROM test_case_run WHERE pipeline_build_id = $1`, pbID)
+	return err
+}
+
+// LoadTestCaseHistory loads the latest runs of a test case on a pipeline, and summarizes them
+func LoadTestCaseHistory(db database.Querier, applicationID, pipelineID, environmentID int64, suite, name string, limit int) (*sdk.TestCaseHistory, error) {
+	h := &sdk.TestCaseHistory{Suite: suite, Name: name, Runs: []sdk.TestCaseRun{}}
+
+	query := `SELECT pipeline_build_id, build_number, vcs_changes_branch, vcs_changes_hash, status, duration, created
+		FROM test_case_run
+		WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3 AND suite = $4 AND name = $5
+		ORDER BY build_number DESC, id DESC LIMIT $6`
+	rows, err := db.Query(query, applicationID, pipelineID, environmentID, suite, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r sdk.TestCaseRun
+		var branch, hash sql.NullString
+		var duration sql.NullFloat64
+		if err := rows.Scan(&r.PipelineBuildID, &r.BuildNumber, &branch, &hash, &r.Status, &duration, &r.Created); err != nil {
+			return nil, err
+		}
+		r.Branch = branch.String
+		r.Hash = hash.String
+		r.Duration = duration.Float64
+		h.Runs = append(h.Runs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	summarizeTestCaseHistory(h)
+	return h, nil
+}
+
+// summarizeTestCaseHistory computes the average duration of the runs which were not skipped,
+// finds the run which started the latest series of failures, and flags the test case as flaky
+// when it both passed and failed on a same commit. Runs are expected latest first.
+func summarizeTestCaseHistory(h *sdk.TestCaseHistory) {
+	var total float64
+	var count int
+	var seriesOver bool
+	statuses := map[string]string{}
+
+	h.AverageDuration, h.FirstFailure, h.Flaky = 0, nil, false
+	for i := range h.Runs {
+		r := &h.Runs[i]
+		if r.Status == sdk.TestCaseSkip {
+			continue
+		}
+		total += r.Duration
+		count++
+
+		switch {
+		case r.Status == sdk.TestCaseFail && !seriesOver:
+			h.FirstFailure = r
+		case r.Status == sdk.TestCasePass && h.FirstFailure != nil:
+			seriesOver = true
+		}
+
+		if r.Hash == "" {
+			continue
+		}
+		if s, ok := statuses[r.Hash]; ok && s != r.Status {
+			h.Flaky = true
+		}
+		statuses[r.Hash] = r.Status
+	}
+
+	if count > 0 {
+		h.AverageDuration = total / float64(count)
+	}
+}
+
+// LoadFlakyTests lists the test cases which both passed and failed on a same commit in the latest builds of a pipeline
+func LoadFlakyTests(db database.Querier, applicationID, pipelineID, environmentID int64, builds int) ([]sdk.FlakyTest, error) {
+	flaky := []sdk.FlakyTest{}
+
+	query := `SELECT suite, name, vcs_changes_hash,
+			SUM(CASE WHEN status = $4 THEN 1 ELSE 0 END) AS passes,
+			SUM(CASE WHEN status = $5 THEN 1 ELSE 0 END) AS failures,
+			MAX(created) AS last_seen
+		FROM test_case_run
+		WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3 AND vcs_changes_hash <> ''
+		AND build_number > (
+			SELECT COALESCE(MAX(build_number), 0) FROM test_case_run
+			WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3
+		) - $6
+		GROUP BY suite, name, vcs_changes_hash
+		HAVING SUM(CASE WHEN status = $4 THEN 1 ELSE 0 END) > 0 AND SUM(CASE WHEN status = $5 THEN 1 ELSE 0 END) > 0
+		ORDER BY last_seen DESC`
+	rows, err := db.Query(query, applicationID, pipelineID, environmentID, sdk.TestCasePass, sdk.TestCaseFail, builds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// A test flipping on several commits is listed once, with its latest commit
+	seen := map[[2]string]bool{}
+	for rows.Next() {
+		var f sdk.FlakyTest
+		if err := rows.Scan(&f.Suite, &f.Name, &f.Hash, &f.Passes, &f.Failures, &f.LastSeen); err != nil {
+			return nil, err
+		}
+		if seen[[2]string{f.Suite, f.Name}] {
+			continue
+		}
+		seen[[2]string{f.Suite, f.Name}] = true
+		flaky = append(flaky, f)
+	}
+	return flaky, rows.Err()
+}
@@ -0,0 +1,35 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestSummarizeTestCaseHistory(t *testing.T) {
+	h := &sdk.TestCaseHistory{Runs: []sdk.TestCaseRun{
+		{BuildNumber: 6, Hash: "f00", Status: sdk.TestCaseFail, Duration: 2},
+		{BuildNumber: 5, Hash: "e00", Status: sdk.TestCaseSkip},
+		{BuildNumber: 4, Hash: "d00", Status: sdk.TestCaseFail, Duration: 3},
+		{BuildNumber: 3, Hash: "c00", Status: sdk.TestCasePass, Duration: 1},
+		{BuildNumber: 2, Hash: "b00", Status: sdk.TestCaseFail, Duration: 2},
+	}}
+	summarizeTestCaseHistory(h)
+	assert.Equal(t, 2.0, h.AverageDuration)
+	assert.Equal(t, int64(4), h.FirstFailure.BuildNumber)
+	assert.False(t, h.Flaky)
+
+	// Passing again on a commit which failed
+	h.Runs = append([]sdk.TestCaseRun{{BuildNumber: 7, Hash: "f00", Status: sdk.TestCasePass, Duration: 2}}, h.Runs...)
+	summarizeTestCaseHistory(h)
+	assert.Equal(t, int64(4), h.FirstFailure.BuildNumber)
+	assert.True(t, h.Flaky)
+
+	h.Runs = []sdk.TestCaseRun{{Status: sdk.TestCasePass, Duration: 1}, {Status: sdk.TestCaseSkip}, {Status: sdk.TestCasePass}}
+	summarizeTestCaseHistory(h)
+	assert.Nil(t, h.FirstFailure)
+	assert.False(t, h.Flaky)
+	assert.Equal(t, 0.5, h.AverageDuration)
+}
@@ -0,0 +1,64 @@
+package build
+
+import (
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/sdk"
+)
+
+// LoadTestQuarantine loads the quarantined tests of a project
+func LoadTestQuarantine(db database.Querier, projectID int64) ([]sdk.TestQuarantine, error) {
+	quarantine := []sdk.TestQuarantine{}
+
+	query := `SELECT id, suite, name, reason, author, created FROM test_quarantine WHERE project_id = $1 ORDER BY suite, name`
+	rows, err := db.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var q sdk.TestQuarantine
+		if err := rows.Scan(&q.ID, &q.Suite, &q.Name, &q.Reason, &q.Author, &q.Created); err != nil {
+			return nil, err
+		}
+		quarantine = append(quarantine, q)
+	}
+	return quarantine, rows.Err()
+}
+
+// QuarantineTest quarantines a test of a project, updating the reason of an already quarantined test
+func QuarantineTest(db database.Executer, projectID int64, q sdk.TestQuarantine) error {
+	if q.Suite == "" || q.Name == "" {
+		return sdk.ErrInvalidTestQuarantine
+	}
+
+	query := `UPDATE test_quarantine SET reason = $4, author = $5 WHERE project_id = $1 AND suite = $2 AND name = $3`
+	res, err := db.Exec(query, projectID, q.Suite, q.Name, q.Reason, q.Author)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	query = `INSERT INTO test_quarantine (project_id, suite, name, reason, author) VALUES ($1, $2, $3, $4, $5)`
+	_, err = db.Exec(query, projectID, q.Suite, q.Name, q.Reason, q.Author)
+	return err
+}
+
+// UnquarantineTest releases a test of a project from quarantine
+func UnquarantineTest(db database.Executer, projectID int64, suite, name string) error {
+	query := `DELETE FROM test_quarantine WHERE project_id = $1 AND suite = $2 AND name = $3`
+	res, err := db.Exec(query, projectID, suite, name)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sdk.ErrTestQuarantineNotFound
+	}
+	return nil
+}
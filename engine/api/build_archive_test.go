@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/archivist"
+	"github.com/ovh/cds/engine/api/build"
+	"github.com/ovh/cds/engine/api/testwithdb"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+func TestArchivedBuildKeepsTestCaseRuns(t *testing.T) {
+	if testwithdb.DBDriver == "" {
+		t.SkipNow()
+		return
+	}
+	db, err := testwithdb.SetupPG(t)
+	assert.NoError(t, err)
+
+	proj, p, app := insertSchedulerTestPipeline(t, db, &exportentities.Pipeline{
+		Name:   "build",
+		Type:   string(sdk.BuildPipeline),
+		Stages: []exportentities.Stage{{Name: "Compile", Jobs: []exportentities.Job{testJob("make")}}},
+	})
+	defer deleteSchedulerTestPipeline(t, db, proj, p, app)
+
+	pb := runTestPipeline(t, db, proj, p, app, "master")
+	suites := []sdk.TestSuite{{Name: "unit", Total: 1, Tests: []sdk.Test{{Name: "TestFoo", Time: "0.1"}}}}
+	assert.NoError(t, build.InsertTestCaseRuns(db, &pb, suites))
+
+	assert.Equal(t, 1, endActionBuilds(t, db, pb, sdk.StatusSuccess))
+	scheduleTestBuild(t, db, pb)
+	assert.NoError(t, archivist.ArchiveBuild(db, pb.ID))
+
+	h, err := build.LoadTestCaseHistory(db, app.ID, p.ID, sdk.DefaultEnv.ID, "unit", "TestFoo", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, h.Runs, 1) {
+		assert.Equal(t, pb.ID, h.Runs[0].PipelineBuildID)
+	}
+}
+
+func TestArchivedBuildKeepsReferenceCoverage(t *testing.T) {
+	if testwithdb.DBDriver == "" {
+		t.SkipNow()
+		return
+	}
+	db, err := testwithdb.SetupPG(t)
+	assert.NoError(t, err)
+
+	proj, p, app := insertSchedulerTestPipeline(t, db, &exportentities.Pipeline{
+		Name:   "build",
+		Type:   string(sdk.BuildPipeline),
+		Stages: []exportentities.Stage{{Name: "Compile", Jobs: []exportentities.Job{testJob("make")}}},
+	})
+	defer deleteSchedulerTestPipeline(t, db, proj, p, app)
+
+	reference := runTestPipeline(t, db, proj, p, app, "master")
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, build.UpdateCoverage(tx, reference.ID, sdk.Coverage{Covered: 8, Total: 10, Percent: 80}))
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, 1, endActionBuilds(t, db, reference, sdk.StatusSuccess))
+	scheduleTestBuild(t, db, reference)
+	assert.NoError(t, archivist.ArchiveBuild(db, reference.ID))
+
+	c, err := build.LoadCoverage(db, reference.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 80.0, c.Percent)
+
+	// The next build on the branch is still compared to the archived one
+	pb := runTestPipeline(t, db, proj, p, app, "master")
+	ref, err := build.LoadReferenceCoverage(db, &pb)
+	assert.NoError(t, err)
+	if assert.NotNil(t, ref) {
+		assert.Equal(t, reference.BuildNumber, ref.BuildNumber)
+	}
+	c = sdk.Coverage{Covered: 7, Total: 10, Percent: 70, Reference: ref}
+	_, failed := c.Drop(5)
+	assert.True(t, failed)
+}
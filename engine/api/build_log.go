@@ -1,12 +1,16 @@
 package main
 
 import (
+	"compress/gzip"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -20,6 +24,9 @@ import (
 	"github.com/ovh/cds/sdk"
 )
 
+// maxLogBatchSize is the size, in bytes, of the uncompressed logs a worker can send in a single request
+const maxLogBatchSize = 16 * 1024 * 1024
+
 func getBuildLogsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
 
 	// Get pipeline and action name in URL
@@ -245,7 +252,7 @@ func getActionBuildLogsHandler(w http.ResponseWriter, r *http.Request, db *sql.D
 		for _, stage := range ph.Stages {
 			for _, ab := range stage.ActionBuilds {
 				if ab.PipelineActionID == actionID {
-					pipelinelogs.Logs = []sdk.Log{sdk.Log{Value: ab.Logs}}
+					pipelinelogs.Logs = append(pipelinelogs.Logs, sdk.Log{Value: ab.Logs})
 				}
 			}
 		}
@@ -269,20 +276,36 @@ func addBuildLogHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 	id := vars["id"]
 
 	// Load Queue
-	_, err := build.LoadActionBuild(db, id)
+	ab, err := build.LoadActionBuild(db, id)
 	if err != nil {
 		log.Warning("addBuildLogHandler> Cannot load build %s from db: %s\n", id, err)
 		WriteError(w, r, err)
 		return
 	}
 
-	// Get body
-	data, err := ioutil.ReadAll(r.Body)
+	// Get body, workers send gzipped batches
+	body := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			log.Warning("addBuildLogHandler> Cannot read gzipped body: %s\n", err)
+			WriteError(w, r, sdk.ErrWrongRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxLogBatchSize+1))
 	if err != nil {
 		log.Warning("addBuildLogHandler> Cannot read body: %s\n", err)
 		WriteError(w, r, err)
 		return
 	}
+	if len(data) > maxLogBatchSize {
+		log.Warning("addBuildLogHandler> Logs of build %d exceed %d bytes\n", ab.ID, maxLogBatchSize)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
 
 	// Unmarshal into results
 	var logs []sdk.Log
@@ -294,14 +317,75 @@ func addBuildLogHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 		return
 	}
 
+	// Workers mask secrets already, do it again in case a plugin or an old worker did not
+	masker, err := actionBuildMasker(db, ab.ID)
+	if err != nil {
+		// Never store unmasked lines, the worker sends the batch again later
+		log.Warning("addBuildLogHandler> Cannot load secrets of build %d: %s\n", ab.ID, err)
+		WriteError(w, r, sdk.ErrServiceUnavailable)
+		return
+	}
 	for i := range logs {
-		err = build.InsertLog(db, logs[i].ActionBuildID, logs[i].Step, logs[i].Value)
-		if err != nil {
-			log.Warning("addBuildLogHandler> Cannot insert log line:  %s\n", err)
-			WriteError(w, r, err)
-			return
+		logs[i].Value = masker.Mask(logs[i].Value)
+	}
+	if err := build.InsertLogs(db, ab.ID, logs); err != nil {
+		log.Warning("addBuildLogHandler> Cannot insert log lines: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	// Tell the worker if the action has been stopped
+	cancel, err := build.IsCancelRequested(db, ab.ID)
+	if err != nil {
+		log.Warning("addBuildLogHandler> Cannot check cancellation of %d: %s\n", ab.ID, err)
+		WriteError(w, r, err)
+		return
+	}
+	WriteJSON(w, r, sdk.ActionBuildCancel{ActionBuildID: ab.ID, Cancel: cancel}, http.StatusOK)
+}
+
+// logMaskerTTL is how long the secret masker of an action build is kept once it stops sending logs
+const logMaskerTTL = 10 * time.Minute
+
+// logMaskers caches the secret maskers of the action builds sending logs, so their secrets are loaded once
+var logMaskers = struct {
+	sync.Mutex
+	m map[int64]*logMasker
+}{m: map[int64]*logMasker{}}
+
+type logMasker struct {
+	masker *sdk.SecretMasker
+	used   time.Time
+}
+
+// actionBuildMasker returns the secret masker of an action build
+func actionBuildMasker(db *sql.DB, abID int64) (*sdk.SecretMasker, error) {
+	now := time.Now()
+	logMaskers.Lock()
+	for id, m := range logMaskers.m {
+		if now.Sub(m.used) > logMaskerTTL {
+			delete(logMaskers.m, id)
 		}
 	}
+	m, ok := logMaskers.m[abID]
+	if ok {
+		m.used = now
+	}
+	logMaskers.Unlock()
+	if ok {
+		return m.masker, nil
+	}
+
+	secrets, err := loadActionBuildSecrets(db, abID)
+	if err != nil {
+		return nil, err
+	}
+	masker := sdk.NewSecretMasker(secrets)
+
+	logMaskers.Lock()
+	logMaskers.m[abID] = &logMasker{masker: masker, used: now}
+	logMaskers.Unlock()
+	return masker, nil
 }
 
 func setEngineLogLevel(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/build"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// loadCodeReportBuild loads the pipeline build of a code report route, from the current builds or the history
+func loadCodeReportBuild(r *http.Request, db *sql.DB, c *context.Context, perm int) (sdk.PipelineBuild, error) {
+	var pb sdk.PipelineBuild
+	vars := mux.Vars(r)
+	key := vars["key"]
+	appName := vars["permApplicationName"]
+	pipelineName := vars["permPipelineKey"]
+	buildNumberS := vars["build"]
+
+	if err := r.ParseForm(); err != nil {
+		return pb, sdk.ErrWrongRequest
+	}
+	envName := r.Form.Get("envName")
+
+	env := &sdk.DefaultEnv
+	if envName != "" && envName != sdk.DefaultEnv.Name {
+		var err error
+		env, err = environment.LoadEnvironmentByName(db, key, envName)
+		if err != nil {
+			return pb, sdk.ErrUnknownEnv
+		}
+		if !permission.AccessToEnvironment(env.ID, c.User, perm) {
+			return pb, sdk.ErrForbidden
+		}
+	}
+
+	p, err := pipeline.LoadPipeline(db, key, pipelineName, false)
+	if err != nil {
+		return pb, err
+	}
+
+	a, err := application.LoadApplicationByName(db, key, appName)
+	if err != nil {
+		return pb, err
+	}
+
+	var buildNumber int64
+	if buildNumberS == "last" {
+		buildNumber, _, err = pipeline.GetProbableLastBuildNumber(db, p.ID, a.ID, env.ID)
+		if err != nil {
+			return pb, sdk.ErrNoPipelineBuild
+		}
+	} else {
+		buildNumber, err = strconv.ParseInt(buildNumberS, 10, 64)
+		if err != nil {
+			return pb, sdk.ErrWrongRequest
+		}
+	}
+
+	pb, err = pipeline.LoadPipelineBuild(db, p.ID, a.ID, buildNumber, env.ID)
+	if err != sdk.ErrNoPipelineBuild {
+		return pb, err
+	}
+
+	pb, err = pipeline.LoadPipelineHistoryBuild(db, p.ID, a.ID, buildNumber, env.ID)
+	if err != nil {
+		return pb, sdk.ErrNoPipelineBuild
+	}
+	return pb, nil
+}
+
+func addBuildCoverageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	pb, err := loadCodeReportBuild(r, db, c, permission.PermissionReadExecute)
+	if err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot load pipeline build: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	var uploaded sdk.Coverage
+	if err := json.Unmarshal(data, &uploaded); err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot unmarshal coverage: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot start transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	// Several reports of a build are merged, a file uploaded again replacing the previous one
+	coverage, err := build.LoadCoverage(tx, pb.ID)
+	if err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot load coverage: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	coverage.Merge(uploaded)
+
+	if err := build.UpdateCoverage(tx, pb.ID, coverage); err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot insert coverage: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot commit transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	coverage.Reference, err = build.LoadReferenceCoverage(db, &pb)
+	if err != nil {
+		log.Warning("addBuildCoverageHandler> Cannot load reference coverage: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, coverage, http.StatusOK)
+}
+
+func getBuildCoverageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	pb, err := loadCodeReportBuild(r, db, c, permission.PermissionRead)
+	if err != nil {
+		log.Warning("getBuildCoverageHandler> Cannot load pipeline build: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	coverage, err := build.LoadCoverage(db, pb.ID)
+	if err != nil {
+		log.Warning("getBuildCoverageHandler> Cannot load coverage: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	coverage.Reference, err = build.LoadReferenceCoverage(db, &pb)
+	if err != nil {
+		log.Warning("getBuildCoverageHandler> Cannot load reference coverage: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, coverage, http.StatusOK)
+}
+
+func addBuildFindingsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	pb, err := loadCodeReportBuild(r, db, c, permission.PermissionReadExecute)
+	if err != nil {
+		log.Warning("addBuildFindingsHandler> Cannot load pipeline build: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	var uploaded sdk.Findings
+	if err := json.Unmarshal(data, &uploaded); err != nil {
+		log.Warning("addBuildFindingsHandler> Cannot unmarshal findings: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Warning("addBuildFindingsHandler> Cannot start transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	// Findings of a tool uploaded again replace the previous ones
+	findings, err := build.LoadFindings(tx, pb.ID)
+	if err != nil {
+		log.Warning("addBuildFindingsHandler> Cannot load findings: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	findings.Merge(uploaded)
+
+	if err := build.UpdateFindings(tx, pb.ID, findings); err != nil {
+		log.Warning("addBuildFindingsHandler> Cannot insert findings: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warning("addBuildFindingsHandler> Cannot commit transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, findings, http.StatusOK)
+}
+
+func getBuildFindingsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	pb, err := loadCodeReportBuild(r, db, c, permission.PermissionRead)
+	if err != nil {
+		log.Warning("getBuildFindingsHandler> Cannot load pipeline build: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	findings, err := build.LoadFindings(db, pb.ID)
+	if err != nil {
+		log.Warning("getBuildFindingsHandler> Cannot load findings: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, findings, http.StatusOK)
+}
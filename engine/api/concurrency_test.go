@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/build"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/testwithdb"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+func TestPipelineConcurrencyQueue(t *testing.T) {
+	if testwithdb.DBDriver == "" {
+		t.SkipNow()
+		return
+	}
+	db, err := testwithdb.SetupPG(t)
+	assert.NoError(t, err)
+
+	proj, p, app := insertSchedulerTestPipeline(t, db, &exportentities.Pipeline{
+		Name:   "build",
+		Type:   string(sdk.BuildPipeline),
+		Stages: []exportentities.Stage{{Name: "Compile", Jobs: []exportentities.Job{testJob("make")}}},
+	})
+	defer deleteSchedulerTestPipeline(t, db, proj, p, app)
+	assert.NoError(t, pipeline.UpdateConcurrencyPolicy(db, app.ID, p.ID, sdk.DefaultEnv.ID, sdk.ConcurrencyQueue))
+
+	first := runTestPipeline(t, db, proj, p, app, "master")
+	second := runTestPipeline(t, db, proj, p, app, "feature")
+	assert.Equal(t, sdk.StatusBuilding, first.Status)
+	assert.Equal(t, sdk.StatusWaiting, second.Status)
+
+	// The second build waits as long as the first one runs
+	scheduleTestBuild(t, db, first)
+	assert.NoError(t, pipeline.StartQueuedPipelineBuilds(db))
+	assert.Equal(t, sdk.StatusWaiting, loadTestPipelineBuild(t, db, second).Status)
+
+	assert.Equal(t, 1, endActionBuilds(t, db, first, sdk.StatusSuccess))
+	scheduleTestBuild(t, db, first)
+	assert.Equal(t, sdk.StatusSuccess, loadTestPipelineBuild(t, db, first).Status)
+
+	assert.NoError(t, pipeline.StartQueuedPipelineBuilds(db))
+	assert.Equal(t, sdk.StatusBuilding, loadTestPipelineBuild(t, db, second).Status)
+}
+
+func TestPipelineConcurrencyCancelPrevious(t *testing.T) {
+	if testwithdb.DBDriver == "" {
+		t.SkipNow()
+		return
+	}
+	db, err := testwithdb.SetupPG(t)
+	assert.NoError(t, err)
+
+	proj, p, app := insertSchedulerTestPipeline(t, db, &exportentities.Pipeline{
+		Name:   "build",
+		Type:   string(sdk.BuildPipeline),
+		Stages: []exportentities.Stage{{Name: "Compile", Jobs: []exportentities.Job{testJob("make")}}},
+	})
+	defer deleteSchedulerTestPipeline(t, db, proj, p, app)
+	assert.NoError(t, pipeline.UpdateConcurrencyPolicy(db, app.ID, p.ID, sdk.DefaultEnv.ID, sdk.ConcurrencyCancelPrevious))
+
+	first := runTestPipeline(t, db, proj, p, app, "master")
+	scheduleTestBuild(t, db, first)
+	assert.Equal(t, 1, endActionBuilds(t, db, first, sdk.StatusBuilding))
+
+	// A build on another branch does not cancel anything
+	other := runTestPipeline(t, db, proj, p, app, "feature")
+	assert.Equal(t, sdk.StatusBuilding, other.Status)
+	assert.Equal(t, sdk.StatusBuilding, loadTestPipelineBuild(t, db, first).Status)
+
+	// A new build on the same branch stops the first one and asks its workers to cancel
+	second := runTestPipeline(t, db, proj, p, app, "master")
+	assert.Equal(t, sdk.StatusBuilding, second.Status)
+	assert.Equal(t, sdk.StatusStopped, loadTestPipelineBuild(t, db, first).Status)
+	assert.Equal(t, sdk.StatusBuilding, loadTestPipelineBuild(t, db, other).Status)
+
+	abs, err := build.LoadBuildByPipelineBuildID(db, first.ID)
+	assert.NoError(t, err)
+	assert.Len(t, abs, 1)
+	cancelled, err := build.IsCancelRequested(db, abs[0].ID)
+	assert.NoError(t, err)
+	assert.True(t, cancelled)
+}
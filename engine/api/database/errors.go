@@ -0,0 +1,11 @@
+package database
+
+import (
+	"github.com/lib/pq"
+)
+
+// IsLockNotAvailable returns true when a FOR UPDATE NOWAIT failed because someone else holds the lock
+func IsLockNotAvailable(err error) bool {
+	pqerr, ok := err.(*pq.Error)
+	return ok && pqerr.Code == "55P03"
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notify sends a notification on given channel to all the listeners, once the transaction of db is committed.
+// It does nothing when the database is not PostgreSQL.
+func Notify(db Executer, channel, payload string) error {
+	if dbDriver != "postgres" {
+		return nil
+	}
+	_, err := db.Exec(`SELECT pg_notify($1, $2)`, channel, payload)
+	return err
+}
+
+// NewListener opens a connection listening to the notifications sent on given channels.
+// It returns nil when the database is not PostgreSQL, as notifications are then not available.
+// The listener reconnects by itself, and sends a nil notification once reconnected
+// since the notifications sent meanwhile are lost.
+func NewListener(channels ...string) (*pq.Listener, error) {
+	if dbDriver != "postgres" {
+		return nil, nil
+	}
+
+	dsn := fmt.Sprintf("user=%s password=%s dbname=%s host=%s port=%s sslmode=%s connect_timeout=10", dbUser, dbPassword, dbName, dbHost, dbPort, dbSSLMode)
+	l := pq.NewListener(dsn, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Database> listener: %s\n", err)
+		}
+	})
+
+	for _, c := range channels {
+		if err := l.Listen(c); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
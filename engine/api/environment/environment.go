@@ -237,6 +237,22 @@ func DeleteEnvironment(db *sql.Tx, environmentID int64) error {
 		return err
 	}
 
+	//Delete concurrency policies on this environment
+	query = `DELETE FROM application_pipeline_concurrency WHERE environment_id = $1`
+	_, err = db.Exec(query, environmentID)
+	if err != nil {
+		log.Warning("DeleteEnvironment> Cannot delete environment application_pipeline_concurrency: %s\n", err)
+		return err
+	}
+
+	//Delete schedules on this environment
+	query = `DELETE FROM pipeline_schedule WHERE environment_id = $1`
+	_, err = db.Exec(query, environmentID)
+	if err != nil {
+		log.Warning("DeleteEnvironment> Cannot delete environment pipeline_schedule: %s\n", err)
+		return err
+	}
+
 	// FINALY delete environment
 	query = `DELETE FROM environment WHERE id=$1`
 	_, err = db.Exec(query, environmentID)
@@ -273,6 +289,22 @@ func DeleteAllEnvironment(db database.Executer, projectID int64) error {
 		return err
 	}
 
+	//Delete concurrency policies on these environments
+	query = `DELETE FROM application_pipeline_concurrency WHERE environment_id IN (SELECT id FROM environment WHERE project_id = $1)`
+	_, err = db.Exec(query, projectID)
+	if err != nil {
+		log.Warning("DeleteEnvironment> Cannot delete environment application_pipeline_concurrency: %s\n", err)
+		return err
+	}
+
+	//Delete schedules on these environments
+	query = `DELETE FROM pipeline_schedule WHERE environment_id IN (SELECT id FROM environment WHERE project_id = $1)`
+	_, err = db.Exec(query, projectID)
+	if err != nil {
+		log.Warning("DeleteEnvironment> Cannot delete environment pipeline_schedule: %s\n", err)
+		return err
+	}
+
 	query = `DELETE FROM environment WHERE project_id=$1`
 	_, err = db.Exec(query, projectID)
 	if err != nil {
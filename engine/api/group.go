@@ -35,6 +35,13 @@ func getGroupHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *cont
 		return
 	}
 
+	g.Share, err = group.LoadGroupShare(db, g.ID)
+	if err != nil {
+		log.Warning("getGroupHandler: Cannot load group share from db: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	WriteJSON(w, r, g, http.StatusOK)
 }
 
@@ -410,3 +417,37 @@ func removeUserGroupAdminHandler(w http.ResponseWriter, r *http.Request, db *sql
 		return
 	}
 }
+
+func updateGroupShareHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	// Get group name in URL
+	vars := mux.Vars(r)
+	name := vars["permGroupName"]
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	var share sdk.GroupShare
+	if err := json.Unmarshal(data, &share); err != nil {
+		log.Warning("updateGroupShareHandler: Cannot unmarshal body: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	g, err := group.LoadGroup(db, name)
+	if err != nil {
+		log.Warning("updateGroupShareHandler: Cannot load %s: %s\n", name, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := group.UpdateGroupShare(db, g.ID, share); err != nil {
+		log.Warning("updateGroupShareHandler: Cannot update share of group %s: %s\n", name, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, share, http.StatusOK)
+}
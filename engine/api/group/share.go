@@ -0,0 +1,106 @@
+package group
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/sdk"
+)
+
+// pipelineOwners selects for each pipeline the group its builds are charged to in the fair-share
+// scheduling of the queue: the group with the highest role on the pipeline, shared.infra aside
+const pipelineOwners = `SELECT DISTINCT ON (pipeline_group.pipeline_id) pipeline_group.pipeline_id, pipeline_group.group_id
+	FROM pipeline_group
+	JOIN "group" ON "group".id = pipeline_group.group_id
+	WHERE "group".name != '` + SharedInfraGroup + `'
+	ORDER BY pipeline_group.pipeline_id, pipeline_group.role DESC, pipeline_group.group_id`
+
+// FairShareTables are the common table expressions of the fair-share queries:
+// owner gives the group of each pipeline, building the number of building actions of each group
+var FairShareTables = fmt.Sprintf(`owner AS (%s),
+building AS (
+	SELECT owner.group_id, COUNT(action_build.id) AS count
+	FROM action_build
+	JOIN pipeline_build ON pipeline_build.id = action_build.pipeline_build_id
+	JOIN owner ON owner.pipeline_id = pipeline_build.pipeline_id
+	WHERE action_build.status = '%s'
+	GROUP BY owner.group_id
+)`, pipelineOwners, sdk.StatusBuilding)
+
+// LoadGroupShare returns the share of a group in the build queue
+func LoadGroupShare(db database.Querier, groupID int64) (*sdk.GroupShare, error) {
+	s := &sdk.GroupShare{Share: sdk.DefaultGroupShare}
+	query := `SELECT share, max_workers FROM group_share WHERE group_id = $1`
+	if err := db.QueryRow(query, groupID).Scan(&s.Share, &s.MaxWorkers); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return s, nil
+}
+
+// UpdateGroupShare sets the share of a group in the build queue
+func UpdateGroupShare(db database.Executer, groupID int64, s sdk.GroupShare) error {
+	if err := s.Check(); err != nil {
+		return err
+	}
+
+	query := `UPDATE group_share SET share = $2, max_workers = $3 WHERE group_id = $1`
+	res, err := db.Exec(query, groupID, s.Share, s.MaxWorkers)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	query = `INSERT INTO group_share (group_id, share, max_workers) VALUES ($1, $2, $3)`
+	_, err = db.Exec(query, groupID, s.Share, s.MaxWorkers)
+	return err
+}
+
+func deleteGroupShare(db database.Executer, g *sdk.Group) error {
+	query := `DELETE FROM group_share WHERE group_id = $1`
+	_, err := db.Exec(query, g.ID)
+	return err
+}
+
+// LoadGroupsUsage returns the use of the worker pool by the builds of each group having building or waiting builds
+func LoadGroupsUsage(db database.Querier) ([]sdk.GroupUsage, error) {
+	query := fmt.Sprintf(`WITH owner AS (%s)
+		SELECT "group".name, COALESCE(group_share.share, $3), COALESCE(group_share.max_workers, 0),
+			COUNT(action_build.id) FILTER (WHERE action_build.status = $1),
+			COUNT(action_build.id) FILTER (WHERE action_build.status = $2)
+		FROM action_build
+		JOIN pipeline_build ON pipeline_build.id = action_build.pipeline_build_id
+		JOIN owner ON owner.pipeline_id = pipeline_build.pipeline_id
+		JOIN "group" ON "group".id = owner.group_id
+		LEFT JOIN group_share ON group_share.group_id = owner.group_id
+		WHERE action_build.status = ANY(ARRAY[$1, $2])
+		GROUP BY "group".name, group_share.share, group_share.max_workers
+		ORDER BY "group".name`, pipelineOwners)
+	rows, err := db.Query(query, sdk.StatusBuilding.String(), sdk.StatusWaiting.String(), sdk.DefaultGroupShare)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usages := []sdk.GroupUsage{}
+	var shares, building int64
+	for rows.Next() {
+		var u sdk.GroupUsage
+		if err := rows.Scan(&u.Group, &u.Share, &u.MaxWorkers, &u.Building, &u.Waiting); err != nil {
+			return nil, err
+		}
+		shares += u.Share
+		building += u.Building
+		usages = append(usages, u)
+	}
+
+	for i := range usages {
+		usages[i].FairShare = float64(usages[i].Share) / float64(shares)
+		if building > 0 {
+			usages[i].Usage = float64(usages[i].Building) / float64(building)
+		}
+	}
+	return usages, nil
+}
@@ -22,6 +22,8 @@ type Hatchery struct {
 	GroupID  int64     `json:"group_id"`
 	LastBeat time.Time `json:"-"`
 	Model    sdk.Model `json:"model"`
+	// MaxWorker is the number of workers the hatchery may run at once, 0 when unknown
+	MaxWorker int64 `json:"max_worker"`
 }
 
 // InsertHatchery registers in database new hatchery
@@ -38,8 +40,8 @@ func InsertHatchery(db *sql.DB, h *Hatchery) error {
 		return err
 	}
 
-	query := `INSERT INTO hatchery (name, group_id, last_beat, uid) VALUES ($1, $2, NOW(), $3) RETURNING id`
-	err = tx.QueryRow(query, h.Name, h.GroupID, h.UID).Scan(&h.ID)
+	query := `INSERT INTO hatchery (name, group_id, last_beat, uid, max_worker) VALUES ($1, $2, NOW(), $3, $4) RETURNING id`
+	err = tx.QueryRow(query, h.Name, h.GroupID, h.UID, h.MaxWorker).Scan(&h.ID)
 	if err != nil {
 		return err
 	}
@@ -59,8 +61,8 @@ func InsertHatchery(db *sql.DB, h *Hatchery) error {
 	}
 
 	for _, c := range h.Model.Capabilities {
-		query = `INSERT INTO worker_capability (worker_model_id, type, name, argument) VALUES ($1, $2, $3, $4)`
-		_, err = tx.Exec(query, h.Model.ID, string(c.Type), c.Name, c.Value)
+		query = `INSERT INTO worker_capability (worker_model_id, type, name, argument, version) VALUES ($1, $2, $3, $4, $5)`
+		_, err = tx.Exec(query, h.Model.ID, string(c.Type), c.Name, c.Value, sql.NullString{String: c.Version, Valid: c.Version != ""})
 		if err != nil {
 			log.Warning("Cannot insert capability: %s\n", err)
 			return err
@@ -180,7 +182,7 @@ func LoadHatchery(db *sql.DB, uid string) (*Hatchery, error) {
 func LoadHatcheries(db *sql.DB) ([]Hatchery, error) {
 	var hatcheries []Hatchery
 
-	query := `SELECT id, uid, name, last_beat, group_id, worker_model_id
+	query := `SELECT id, uid, name, last_beat, group_id, worker_model_id, COALESCE(max_worker, 0)
 							FROM hatchery
 							LEFT JOIN hatchery_model ON hatchery_model.hatchery_id = hatchery.id
 							LIMIT 10000`
@@ -193,7 +195,7 @@ func LoadHatcheries(db *sql.DB) ([]Hatchery, error) {
 	var wmID sql.NullInt64
 	for rows.Next() {
 		var h Hatchery
-		err = rows.Scan(&h.ID, &h.UID, &h.Name, &h.LastBeat, &h.GroupID, &wmID)
+		err = rows.Scan(&h.ID, &h.UID, &h.Name, &h.LastBeat, &h.GroupID, &wmID, &h.MaxWorker)
 		if err != nil {
 			return nil, err
 		}
@@ -294,6 +294,10 @@ func TriggerPipeline(tx *sql.Tx, h sdk.Hook, branch string, hash string, author
 		}
 	}
 
+	if skip, err := pipeline.SkipBuiltCommit(tx, p, a.ID, sdk.DefaultEnv.ID, trigger, sdk.SkipOriginHook); err != nil || skip {
+		return false, err
+	}
+
 	// FIXME add possibility to trigger a pipeline on a specific env
 	_, err = pipeline.InsertPipelineBuild(tx, projectData, p, a, applicationPipelineArgs, args, &sdk.DefaultEnv, 0, trigger)
 	if err != nil {
@@ -18,6 +18,7 @@ import (
 	"github.com/ovh/cds/engine/api/archivist"
 	"github.com/ovh/cds/engine/api/artifact"
 	"github.com/ovh/cds/engine/api/auth"
+	"github.com/ovh/cds/engine/api/build"
 	"github.com/ovh/cds/engine/api/cache"
 	"github.com/ovh/cds/engine/api/database"
 	"github.com/ovh/cds/engine/api/group"
@@ -28,11 +29,13 @@ import (
 	"github.com/ovh/cds/engine/api/pipeline"
 	"github.com/ovh/cds/engine/api/repositoriesmanager"
 	"github.com/ovh/cds/engine/api/repositoriesmanager/polling"
+	"github.com/ovh/cds/engine/api/schedule"
 	"github.com/ovh/cds/engine/api/scheduler"
 	"github.com/ovh/cds/engine/api/secret"
 	"github.com/ovh/cds/engine/api/sessionstore"
 	"github.com/ovh/cds/engine/api/stats"
 	"github.com/ovh/cds/engine/api/worker"
+	"github.com/ovh/cds/engine/api/workspacecache"
 	"github.com/ovh/cds/engine/log"
 )
 
@@ -65,6 +68,8 @@ var mainCmd = &cobra.Command{
 			viper.GetString("artifact_basedir")); err != nil {
 			log.Fatalf("Cannot initialize storage: %s\n", err)
 		}
+		workspacecache.Quota = int64(viper.GetInt("workspace_cache_quota")) * 1024 * 1024
+		build.MaxLogSize = int64(viper.GetInt("max_log_size")) * 1024 * 1024
 
 		db, err := database.Init()
 		if err != nil {
@@ -179,7 +184,9 @@ var mainCmd = &cobra.Command{
 
 		go archivist.Archive(viper.GetInt("interval_archive_seconds"), viper.GetInt("archived_build_hours"))
 		go scheduler.Schedule()
+		go build.ListenQueue()
 		go pipeline.AWOLPipelineKiller()
+		go pipeline.TimeoutKiller()
 		//go pipeline.HistoryCleaningRoutine(db)
 		go worker.Heartbeat()
 		go hatchery.Heartbeat()
@@ -192,6 +199,7 @@ var mainCmd = &cobra.Command{
 		go hookRecoverer()
 		go polling.Initialize()
 		go polling.ExecutionCleaner()
+		go schedule.Runner()
 
 		s := &http.Server{
 			Addr:           ":" + viper.GetString("listen_port"),
@@ -236,6 +244,7 @@ func (router *Router) init() {
 	router.Handle("/group/{permGroupName}/user/{user}", DELETE(removeUserFromGroupHandler))
 	router.Handle("/group/{permGroupName}/user/{user}/admin", POST(setUserGroupAdminHandler), DELETE(removeUserGroupAdminHandler))
 	router.Handle("/group/{permGroupName}/token/{expiration}", POST(generateTokenHandler))
+	router.Handle("/group/{permGroupName}/share", NeedAdmin(true), PUT(updateGroupShareHandler))
 
 	// Hatchery
 	router.Handle("/hatchery", Auth(false), POST(registerHatchery))
@@ -264,7 +273,9 @@ func (router *Router) init() {
 
 	// Project
 	router.Handle("/project", GET(getProjects), POST(addProject))
+	router.Handle("/project/import", POST(importProjectHandler))
 	router.Handle("/project/{permProjectKey}", GET(getProject), PUT(updateProject), DELETE(deleteProject))
+	router.Handle("/project/{permProjectKey}/export", GET(exportProjectHandler))
 	router.Handle("/project/{permProjectKey}/group", POST(addGroupInProject), PUT(updateGroupsInProject))
 	router.Handle("/project/{permProjectKey}/group/{group}", PUT(updateGroupRoleOnProjectHandler), DELETE(deleteGroupFromProjectHandler))
 	router.Handle("/project/{permProjectKey}/variable", GET(getVariablesInProjectHandler), PUT(updateVariablesInProjectHandler))
@@ -272,6 +283,7 @@ func (router *Router) init() {
 	router.Handle("/project/{key}/variable/audit/{auditID}", PUT(restoreProjectVariableAuditHandler))
 	router.Handle("/project/{permProjectKey}/variable/{name}", POST(addVariableInProjectHandler), PUT(updateVariableInProjectHandler), DELETE(deleteVariableFromProjectHandler))
 	router.Handle("/project/{permProjectKey}/applications", GET(getApplicationsHandler), POST(addApplicationHandler))
+	router.Handle("/project/{permProjectKey}/test/quarantine", GET(getTestQuarantineHandler), POST(addTestQuarantineHandler), DELETE(deleteTestQuarantineHandler))
 
 	// Application
 	router.Handle("/project/{key}/application/{permApplicationName}", GET(getApplicationHandler), PUT(updateApplicationHandler), DELETE(deleteApplicationHandler))
@@ -286,6 +298,7 @@ func (router *Router) init() {
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline", GET(getPipelinesInApplicationHandler), PUT(updatePipelinesToApplicationHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}", POST(attachPipelineToApplicationHandler), PUT(updatePipelineToApplicationHandler), DELETE(removePipelineFromApplicationHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/notification", GET(getUserNotificationApplicationPipelineHandler), PUT(updateUserNotificationApplicationPipelineHandler), DELETE(deleteUserNotificationApplicationPipelineHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/concurrency", GET(getPipelineConcurrencyHandler), PUT(updatePipelineConcurrencyHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/tree", GET(getApplicationTreeHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/variable", GET(getVariablesInApplicationHandler), PUT(updateVariablesInApplicationHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/variable/audit", GET(getVariablesAuditInApplicationHandler))
@@ -294,20 +307,32 @@ func (router *Router) init() {
 
 	// Pipeline
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/history", GET(getPipelineHistoryHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/skipped", GET(getPipelineBuildSkipsHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/test/history", GET(getTestCaseHistoryHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/test/flaky", GET(getFlakyTestsHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/log", GET(getBuildLogsHandler))
 	router.Handle("/project/{key}/application/{app}/pipeline/{permPipelineKey}/build/{build}/test", POSTEXECUTE(addBuildTestResultsHandler), GET(getBuildTestResultsHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/coverage", POSTEXECUTE(addBuildCoverageHandler), GET(getBuildCoverageHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/findings", POSTEXECUTE(addBuildFindingsHandler), GET(getBuildFindingsHandler))
 	router.Handle("/project/{key}/application/{app}/pipeline/{permPipelineKey}/build/{build}/variable", POSTEXECUTE(addBuildVariableHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/action/{actionID}/log", GET(getActionBuildLogsHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}", GET(getBuildStateHandler), DELETE(deleteBuildHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/triggered", GET(getPipelineBuildTriggeredHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/stop", POSTEXECUTE(stopPipelineBuildHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/restart", POSTEXECUTE(restartPipelineBuildHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/stage/{stageID}/approve", POSTEXECUTE(approveStageHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/stage/{stageID}/approval", GET(getStageApprovalHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/build/{build}/commits", GET(getPipelineBuildCommitsHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/commits", GET(getPipelineCommitsHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/run", POSTEXECUTE(runPipelineHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/rollback", POSTEXECUTE(rollbackPipelineHandler))
 	router.Handle("/project/{permProjectKey}/pipeline", GET(getPipelinesHandler), POST(addPipeline))
+	router.Handle("/project/{permProjectKey}/pipeline/import", POST(importPipelineHandler))
 	router.Handle("/project/{key}/pipeline/{permPipelineKey}/application", GET(getApplicationUsingPipelineHandler))
+	router.Handle("/project/{key}/pipeline/{permPipelineKey}/audit", GET(getPipelineAuditHandler))
+	router.Handle("/project/{key}/pipeline/{permPipelineKey}/audit/diff", GET(getPipelineAuditDiffHandler))
+	router.Handle("/project/{key}/pipeline/{permPipelineKey}/audit/{version}", PUT(restorePipelineAuditHandler))
+	router.Handle("/project/{key}/pipeline/{permPipelineKey}/export", GET(exportPipelineHandler))
 	router.Handle("/project/{key}/pipeline/{permPipelineKey}/group", POST(addGroupInPipelineHandler), PUT(updateGroupsOnPipelineHandler))
 	router.Handle("/project/{key}/pipeline/{permPipelineKey}/group/{group}", PUT(updateGroupRoleOnPipelineHandler), DELETE(deleteGroupFromPipelineHandler))
 	router.Handle("/project/{key}/pipeline/{permPipelineKey}/parameter", GET(getParametersInPipelineHandler), PUT(updateParametersInPipelineHandler))
@@ -340,6 +365,10 @@ func (router *Router) init() {
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/artifact/{tag}", GET(listArtifactsHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/{buildNumber}/artifact", GET(listArtifactsBuildHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/{buildNumber}/artifact/{tag}", POSTEXECUTE(uploadArtifactHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/cache", GET(resolveCacheHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/cache/{cacheKey}", GET(downloadCacheHandler), POSTEXECUTE(uploadCacheHandler))
+	router.Handle("/project/{permProjectKey}/cache", GET(listCachesHandler))
+	router.Handle("/project/{permProjectKey}/cache/{cacheKey}", DELETE(deleteCacheHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/artifact/download/{id}", GET(downloadArtifactHandler))
 	router.Handle("/artifact/{hash}", Auth(false), GET(downloadArtifactDirectHandler))
 
@@ -348,13 +377,19 @@ func (router *Router) init() {
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/hook", POST(addHook), GET(getHooks))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/hook/{id}", PUT(updateHookHandler), DELETE(deleteHook))
 
+	// Schedules
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/schedule", POST(addPipelineScheduleHandler), GET(getPipelineSchedulesHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/schedule/{id}", PUT(updatePipelineScheduleHandler), DELETE(deletePipelineScheduleHandler))
+
 	// Pollers
 	router.Handle("/project/{key}/application/{permApplicationName}/polling", GET(getApplicationPollersHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/polling", POST(addPollerHandler), GET(getPollersHandler), PUT(updatePollerHandler), DELETE(deletePollerHandler))
 
 	// Build queue
 	router.Handle("/queue", GET(getQueueHandler))
+	router.Handle("/queue/wait", GET(waitQueueHandler))
 	router.Handle("/queue/requirements/errors", POST(requirementsErrorHandler))
+	router.Handle("/queue/{id}/explain", GET(explainQueueHandler))
 	router.Handle("/queue/{id}/take", POST(takeActionBuildHandler))
 	router.Handle("/queue/{id}/result", POST(addQueueResultHandler))
 	router.Handle("/build/{id}/log", POST(addBuildLogHandler))
@@ -384,6 +419,7 @@ func (router *Router) init() {
 	router.Handle("/project/{key}/repositories_manager/{name}/application/{permApplicationName}/detach", POST(detachRepositoriesManager))
 	router.Handle("/project/{key}/application/{permApplicationName}/repositories_manager", GET(getRepositoriesManagerForApplicationsHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/repositories_manager/{name}/commits", GET(getApplicationCommitsHandler))
+	router.Handle("/project/{key}/application/{permApplicationName}/repository", GET(getApplicationRepositoryHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/repositories_manager/{name}/hook", POST(addHookOnRepositoriesManagerHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/repositories_manager/{name}/hook/{hookId}", DELETE(deleteHookOnRepositoriesManagerHandler))
 
@@ -464,6 +500,12 @@ func init() {
 	viper.BindPFlag("artifact_password", flags.Lookup("artifact-password"))
 	viper.BindPFlag("artifact_basedir", flags.Lookup("artifact-basedir"))
 
+	flags.Int("workspace-cache-quota", 1024, "Size of the workspace caches kept by each project (MB), least recently used caches are evicted beyond")
+	viper.BindPFlag("workspace_cache_quota", flags.Lookup("workspace-cache-quota"))
+
+	flags.Int("max-log-size", 10, "Size of the logs kept for an action build (MB), next lines are dropped. 0 for no limit")
+	viper.BindPFlag("max_log_size", flags.Lookup("max-log-size"))
+
 	flags.Bool("no-smtp", true, "No SMTP mode: true or false")
 	flags.String("smtp-host", "", "SMTP Host")
 	flags.String("smtp-port", "", "SMTP Port")
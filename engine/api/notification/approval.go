@@ -0,0 +1,91 @@
+package notification
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// SendStageApproval notifies approvers of a stage, by jabber and email, that a pipeline build waits for their decision
+func SendStageApproval(db database.QueryExecuter, pb *sdk.PipelineBuild, s *sdk.Stage, gate *sdk.StageGate) {
+	log.Debug("notification.SendStageApproval> pb:%d stage:%d gate:%d", pb.ID, s.ID, gate.ID)
+
+	approvers, err := loadStageApprovers(db, s.Approval.Groups)
+	if err != nil {
+		log.Warning("notification.SendStageApproval> Cannot load approvers of stage %s: %s", s.Name, err)
+		return
+	}
+	if len(approvers) == 0 {
+		log.Warning("notification.SendStageApproval> No approver in groups %v for stage %s", s.Approval.Groups, s.Name)
+		return
+	}
+
+	title := fmt.Sprintf("[CDS] %s/%s/%s #%d is waiting for your approval", pb.Pipeline.ProjectKey, pb.Application.Name, pb.Pipeline.Name, pb.BuildNumber)
+	message := fmt.Sprintf("Stage %s of pipeline %s on application %s (environment %s) needs %d approval(s) to go on.\n",
+		s.Name, pb.Pipeline.Name, pb.Application.Name, pb.Environment.Name, gate.Required)
+	if gate.Timeout > 0 {
+		message += fmt.Sprintf("The build will fail if it is not approved within %s.\n", time.Duration(gate.Timeout)*time.Second)
+	}
+	message += fmt.Sprintf("Approve or reject it on %s/#/project/%s/application/%s/pipeline/%s/build/%d?env=%s&tab=detail\n",
+		baseURL, pb.Pipeline.ProjectKey, pb.Application.Name, pb.Pipeline.Name, pb.BuildNumber, pb.Environment.Name)
+
+	jabber := &sdk.Notif{
+		DateNotif:   time.Now().Unix(),
+		Status:      sdk.StatusWaitingApproval,
+		NotifType:   sdk.UserNotif,
+		Destination: "jabber",
+		Title:       title,
+		Message:     message,
+	}
+	email := &sdk.Notif{
+		DateNotif:   time.Now().Unix(),
+		Status:      sdk.StatusWaitingApproval,
+		NotifType:   sdk.UserNotif,
+		Destination: "email",
+		Title:       title,
+		Message:     message,
+	}
+	for _, u := range approvers {
+		jabber.Recipients = append(jabber.Recipients, u.Username)
+		if u.Email != "" {
+			email.Recipients = append(email.Recipients, u.Email)
+		}
+	}
+
+	log.Notice("Notification[Approval]> Send approval request '%s'", title)
+	go post(jabber)
+	go SendMailNotif(email)
+}
+
+func loadStageApprovers(db database.Querier, groups []string) ([]sdk.User, error) {
+	query := `
+		SELECT DISTINCT "user".username, "user".data FROM "user"
+		JOIN group_user ON group_user.user_id = "user".id
+		JOIN "group" ON "group".id = group_user.group_id
+		WHERE "group".name = ANY($1)
+	`
+	rows, err := db.Query(query, pq.Array(groups))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []sdk.User
+	for rows.Next() {
+		var username, data string
+		if err := rows.Scan(&username, &data); err != nil {
+			return nil, err
+		}
+		u, err := sdk.NewUser(username).FromJSON([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, nil
+}
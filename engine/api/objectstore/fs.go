@@ -113,6 +113,50 @@ func (fss *FilesystemStore) DeletePlugin(art sdk.ActionPlugin) error {
 	return os.RemoveAll(dst)
 }
 
+// StoreCache writes the archive of a workspace cache on disk, in a new file for each upload
+func (fss *FilesystemStore) StoreCache(c sdk.Cache, data io.ReadCloser) (string, error) {
+	name, err := cacheObjectName(c)
+	if err != nil {
+		return "", err
+	}
+	p := path.Join(fss.basedir, "cache", c.ProjectKey, name)
+	log.Info("FilesystemStore.StoreCache> New cache '%s' in %s\n", c.Key, p)
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", err
+	}
+
+	f, err := os.OpenFile(p, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(f, data); err != nil {
+		f.Close()
+		os.Remove(p)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(p)
+		return "", err
+	}
+	return p, nil
+}
+
+// FetchCache opens the archive of a workspace cache on disk
+func (fss *FilesystemStore) FetchCache(c sdk.Cache) (io.ReadCloser, error) {
+	return os.Open(c.ObjectPath)
+}
+
+// DeleteCache removes the archive of a workspace cache from disk
+func (fss *FilesystemStore) DeleteCache(c sdk.Cache) error {
+	err := os.Remove(c.ObjectPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
 func (fss *FilesystemStore) path(art sdk.Artifact) string {
 	dir := fmt.Sprintf("%s/%s/%s/%s", art.Project, art.Application, art.Environment, art.Pipeline)
 	return path.Join(fss.basedir, dir, art.Tag, art.Name)
@@ -1,6 +1,8 @@
 package objectstore
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 
@@ -66,6 +68,40 @@ func DeletePlugin(art sdk.ActionPlugin) error {
 	return fmt.Errorf("store not initialized")
 }
 
+//StoreCache stores the archive of a workspace cache with default objectstore driver
+func StoreCache(c sdk.Cache, data io.ReadCloser) (string, error) {
+	if storage != nil {
+		return storage.StoreCache(c, data)
+	}
+	return "", fmt.Errorf("store not initialized")
+}
+
+//FetchCache fetches the archive of a workspace cache with default objectstore driver
+func FetchCache(c sdk.Cache) (io.ReadCloser, error) {
+	if storage != nil {
+		return storage.FetchCache(c)
+	}
+	return nil, fmt.Errorf("store not initialized")
+}
+
+//DeleteCache deletes the archive of a workspace cache with default objectstore driver
+func DeleteCache(c sdk.Cache) error {
+	if storage != nil {
+		return storage.DeleteCache(c)
+	}
+	return fmt.Errorf("store not initialized")
+}
+
+// cacheObjectName returns a name unique to each upload of a workspace cache, so an upload never
+// overwrites the archive of the same key, restored by builds meanwhile or kept if the upload is rejected
+func cacheObjectName(c sdk.Cache) (string, error) {
+	bs := make([]byte, 8)
+	if _, err := rand.Read(bs); err != nil {
+		return "", err
+	}
+	return c.Key + "." + hex.EncodeToString(bs) + ".tar.gz", nil
+}
+
 // Driver allows artifact to be stored and retrieve the same way to any backend
 // - Openstack ObjectStore
 // - Filesystem
@@ -77,6 +113,9 @@ type Driver interface {
 	StorePlugin(art sdk.ActionPlugin, data io.ReadCloser) (string, error)
 	FetchPlugin(art sdk.ActionPlugin) (io.ReadCloser, error)
 	DeletePlugin(art sdk.ActionPlugin) error
+	StoreCache(c sdk.Cache, data io.ReadCloser) (string, error)
+	FetchCache(c sdk.Cache) (io.ReadCloser, error)
+	DeleteCache(c sdk.Cache) error
 }
 
 // Initialize setup wanted ObjectStore driver
@@ -162,6 +162,56 @@ func (ops *OpenstackStore) DeletePlugin(art sdk.ActionPlugin) error {
 	return nil
 }
 
+// StoreCache stores the archive of a workspace cache in openstack, in a new object for each upload
+func (ops *OpenstackStore) StoreCache(c sdk.Cache, data io.ReadCloser) (string, error) {
+	name, err := cacheObjectName(c)
+	if err != nil {
+		return "", err
+	}
+	container, object := ops.format(name, c.ProjectKey, "cache")
+	log.Info("OpenstackStore> Storing /%s/%s\n", container, object)
+
+	// Create container if it doesn't exist
+	err = createContainer(ops.token.ID, ops.endpoint, container)
+	if err != nil {
+		log.Warning("OpenstackStore.StoreCache> Cannot create container: %s\n", err)
+		return "", err
+	}
+
+	err = createObject(ops.token.ID, ops.endpoint, container, object, data)
+	if err != nil {
+		log.Warning("OpenstackStore.StoreCache> Cannot create object: %s\n", err)
+		return "", err
+	}
+
+	return container + "/" + object, nil
+}
+
+// FetchCache retrieves the archive of a workspace cache from openstack
+func (ops *OpenstackStore) FetchCache(c sdk.Cache) (io.ReadCloser, error) {
+	container, object := cacheObject(c)
+	log.Info("OpenstackStore> Fetching /%s/%s\n", container, object)
+
+	return fetchObject(ops.token.ID, ops.endpoint, container, object)
+}
+
+// DeleteCache removes the archive of a workspace cache from openstack
+func (ops *OpenstackStore) DeleteCache(c sdk.Cache) error {
+	container, object := cacheObject(c)
+	log.Info("OpenstackStore> Deleting /%s/%s\n", container, object)
+
+	return deleteObject(ops.token.ID, ops.endpoint, container, object)
+}
+
+// cacheObject splits the object path of a workspace cache into its container and object
+func cacheObject(c sdk.Cache) (container string, object string) {
+	t := strings.SplitN(c.ObjectPath, "/", 2)
+	if len(t) != 2 {
+		return "", c.ObjectPath
+	}
+	return t[0], t[1]
+}
+
 func (ops *OpenstackStore) format(x string, y ...string) (container string, object string) {
 	container = strings.Join(y, "-")
 
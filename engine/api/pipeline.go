@@ -289,6 +289,14 @@ func runPipelineHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 		return
 	}
 
+	if request.Priority != nil {
+		if err := pipeline.UpdatePipelineBuildPriority(tx, pb, *request.Priority); err != nil {
+			log.Warning("runPipelineHandler> Cannot set build priority: %s\n", err)
+			WriteError(w, r, err)
+			return
+		}
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Warning("runPipelineHandler> Cannot commit tx: %s", err)
@@ -339,6 +347,20 @@ func updatePipelineActionHandler(w http.ResponseWriter, r *http.Request, db *sql
 		return
 	}
 
+	if pipelineAction.Timeout < 0 {
+		log.Warning("updatePipelineActionHandler>Invalid timeout %d\n", pipelineAction.Timeout)
+		WriteError(w, r, sdk.ErrInvalidTimeout)
+		return
+	}
+
+	if pipelineAction.Retry != nil {
+		if err := pipelineAction.Retry.Check(); err != nil {
+			log.Warning("updatePipelineActionHandler>Invalid retry policy: %s\n", err)
+			WriteError(w, r, err)
+			return
+		}
+	}
+
 	args, err := json.Marshal(pipelineAction.Parameters)
 	if err != nil {
 		log.Warning("updatePipelineActionHandler>Cannot marshal parameters: %s\n", err)
@@ -375,6 +397,12 @@ func updatePipelineActionHandler(w http.ResponseWriter, r *http.Request, db *sql
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, pipelineData.ID, c.User, "Job update"); err != nil {
+		log.Warning("updatePipelineActionHandler> Cannot create pipeline audit: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Warning("updatePipelineActionHandler> Cannot commit transaction: %s\n", err)
@@ -469,6 +497,12 @@ func deletePipelineActionHandler(w http.ResponseWriter, r *http.Request, db *sql
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, pipelineData.ID, c.User, "Job delete"); err != nil {
+		log.Warning("deletePipelineActionHandler> Cannot create pipeline audit: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Warning("deletePipelineActionHandler> Cannot commit transaction: %s", err)
@@ -513,6 +547,18 @@ func updatePipelineHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c
 		return
 	}
 
+	if p.Timeout < 0 {
+		log.Warning("updatePipelineHandler: Invalid timeout %d", p.Timeout)
+		WriteError(w, r, sdk.ErrInvalidTimeout)
+		return
+	}
+
+	if err := sdk.CheckPriority(p.Priority); err != nil {
+		log.Warning("updatePipelineHandler: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	pipelineDB, err := pipeline.LoadPipeline(db, key, name, false)
 	if err != nil {
 		log.Warning("updatePipelineHandler> cannot load pipeline %s: %s\n", name, err)
@@ -522,14 +568,37 @@ func updatePipelineHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c
 
 	pipelineDB.Name = p.Name
 	pipelineDB.Type = p.Type
+	pipelineDB.Timeout = p.Timeout
+	pipelineDB.Priority = p.Priority
+	pipelineDB.SkipBuiltCommits = p.SkipBuiltCommits
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Warning("updatePipelineHandler> Cannot start transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
 
-	err = pipeline.UpdatePipeline(db, pipelineDB)
+	err = pipeline.UpdatePipeline(tx, pipelineDB)
 	if err != nil {
 		log.Warning("updatePipelineHandler> cannot update pipeline %s: %s\n", name, err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, pipelineDB.ID, c.User, "Pipeline update"); err != nil {
+		log.Warning("updatePipelineHandler> Cannot create pipeline audit: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warning("updatePipelineHandler> Cannot commit transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	cache.DeleteAll(cache.Key("application", key, "*"))
 	cache.Delete(cache.Key("pipeline", key, name))
 
@@ -593,6 +662,18 @@ func addPipeline(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.
 		return
 	}
 
+	if p.Timeout < 0 {
+		log.Warning("AddPipeline: Invalid timeout %d", p.Timeout)
+		WriteError(w, r, sdk.ErrInvalidTimeout)
+		return
+	}
+
+	if err := sdk.CheckPriority(p.Priority); err != nil {
+		log.Warning("AddPipeline: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	// Check that pipeline does not already exists
 	exist, err := pipeline.ExistPipeline(db, project.ID, p.Name)
 	if err != nil {
@@ -670,6 +751,12 @@ func addPipeline(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.
 		}
 	}
 
+	if _, err := pipeline.CreateAudit(tx, p.ID, c.User, "Pipeline creation"); err != nil {
+		log.Warning("addPipelineHandler> Cannot create pipeline audit: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Warning("addPipelineHandler> Cannot commit transaction: %s\n", err)
@@ -815,6 +902,24 @@ func getPipelineHistoryHandler(w http.ResponseWriter, r *http.Request, db *sql.D
 	WriteJSON(w, r, pbs, http.StatusOK)
 }
 
+func getPipelineBuildSkipsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	app, pip, env, err := loadConcurrencyTarget(r, db, c, permission.PermissionRead)
+	if err != nil {
+		log.Warning("getPipelineBuildSkipsHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	skips, err := pipeline.LoadBuildSkips(db, app.ID, pip.ID, env.ID, 50)
+	if err != nil {
+		log.Warning("getPipelineBuildSkipsHandler> Cannot load skipped builds of pipeline %s: %s\n", pip.Name, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, skips, http.StatusOK)
+}
+
 func deletePipeline(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
 	// Get pipeline and action name in URL
 	vars := mux.Vars(r)
@@ -950,7 +1055,7 @@ func addJoinedActionToPipelineHandler(w http.ResponseWriter, r *http.Request, db
 	err = action.InsertAction(tx, a, false)
 	if err != nil {
 		log.Warning("addJoinedActionToPipelineHandler> Cannot insert action: %s\n", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		WriteError(w, r, err)
 		return
 	}
 
@@ -978,6 +1083,12 @@ func addJoinedActionToPipelineHandler(w http.ResponseWriter, r *http.Request, db
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, pip.ID, c.User, "Job add"); err != nil {
+		log.Warning("addActionToPipelineHandler> Cannot create pipeline audit: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		WriteError(w, r, err)
@@ -1131,6 +1242,12 @@ func updateJoinedAction(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, pip.ID, c.User, "Job update"); err != nil {
+		log.Warning("updateJoinedAction> Cannot create pipeline audit: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Warning("updateJoinedAction> Cannot commit transaction: %s\n", err)
@@ -1187,6 +1304,12 @@ func deleteJoinedAction(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, pip.ID, c.User, "Job delete"); err != nil {
+		log.Warning("deleteJoinedAction> Cannot create pipeline audit: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Warning("deleteJoinedAction> Cannot commit transation: %s", err)
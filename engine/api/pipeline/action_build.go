@@ -16,7 +16,8 @@ func loadActionBuildsByStagePosition(db database.Querier, pipelineBuildID int64,
 		  JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
 		  JOIN action ON action.id = pipeline_action.action_id
 		  JOIN pipeline_stage ON pipeline_stage.id = pipeline_action.pipeline_stage_id
-		  WHERE action_build.pipeline_build_id = $1 and pipeline_stage.build_order = $2`
+		  WHERE action_build.pipeline_build_id = $1 and pipeline_stage.build_order = $2
+		  AND action_build.retried = false`
 	rows, err := db.Query(query, pipelineBuildID, stagePosition)
 	if err != nil {
 		return actionBuilds, err
@@ -37,7 +38,7 @@ func loadActionBuildsByStagePosition(db database.Querier, pipelineBuildID int64,
 
 // LoadActionStatus  Load status of action_build for the given pipeline_action
 func LoadActionStatus(db database.Querier, pipelineActionID int64, pipelineBuildID int64) (sdk.Status, error) {
-	query := `SELECT status FROM action_build WHERE pipeline_action_id = $1 AND pipeline_build_id = $2`
+	query := `SELECT status FROM action_build WHERE pipeline_action_id = $1 AND pipeline_build_id = $2 AND retried = false`
 	var status string
 
 	err := db.QueryRow(query, pipelineActionID, pipelineBuildID).Scan(&status)
@@ -45,6 +46,45 @@ func LoadActionStatus(db database.Querier, pipelineActionID int64, pipelineBuild
 	return statusAction, err
 }
 
+// LoadCurrentAttempt loads the last attempt of the given pipeline_action in a pipeline build
+func LoadCurrentAttempt(db database.Querier, pipelineActionID int64, pipelineBuildID int64) (sdk.ActionBuild, error) {
+	query := `SELECT id, status, done, attempt, awol, timed_out FROM action_build
+		  WHERE pipeline_action_id = $1 AND pipeline_build_id = $2 AND retried = false`
+	ab := sdk.ActionBuild{
+		PipelineActionID: pipelineActionID,
+		PipelineBuildID:  pipelineBuildID,
+	}
+	var status string
+	var done pq.NullTime
+	var attempt sql.NullInt64
+	var awol, timedOut sql.NullBool
+	if err := db.QueryRow(query, pipelineActionID, pipelineBuildID).Scan(&ab.ID, &status, &done, &attempt, &awol, &timedOut); err != nil {
+		return ab, err
+	}
+	ab.Status = sdk.StatusFromString(status)
+	ab.Done = done.Time
+	ab.Attempt = int(attempt.Int64)
+	if ab.Attempt == 0 {
+		ab.Attempt = 1
+	}
+	ab.AWOL = awol.Bool
+	ab.TimedOut = timedOut.Bool
+	return ab, nil
+}
+
+// SetAttemptRetried flags an attempt as superseded by a new one, and its pipeline build as retried
+func SetAttemptRetried(db database.Executer, ab *sdk.ActionBuild) error {
+	query := `UPDATE action_build SET retried = true WHERE id = $1`
+	if _, err := db.Exec(query, ab.ID); err != nil {
+		return err
+	}
+	ab.Retried = true
+
+	query = `UPDATE pipeline_build SET retried = true WHERE id = $1`
+	_, err := db.Exec(query, ab.PipelineBuildID)
+	return err
+}
+
 func loadStageAndActionBuilds(db database.Querier, pb *sdk.PipelineBuild) error {
 	query := LoadPipelineBuildStage
 	stagesRows, err := db.Query(query, pb.ID)
@@ -88,7 +128,8 @@ func loadAllActionBuilds(db database.Querier, pipelineBuildID int64) ([]sdk.Acti
 		  FROM action_build
 		  JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
 		  JOIN action ON action.id = pipeline_action.action_id
-		  WHERE action_build.pipeline_build_id = $1`
+		  WHERE action_build.pipeline_build_id = $1
+		  AND action_build.retried = false`
 	rows, err := db.Query(query, pipelineBuildID)
 	if err != nil {
 		return actionBuilds, err
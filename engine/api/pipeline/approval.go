@@ -0,0 +1,249 @@
+package pipeline
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/ovh/cds/engine/api/build"
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/notification"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// LoadCurrentGate returns the last approval request of given stage in given pipeline build, nil if there is none
+func LoadCurrentGate(db database.Querier, pbID, stageID int64) (*sdk.StageGate, error) {
+	query := `SELECT id, pipeline_build_id, pipeline_stage_id, status, required, timeout, requested, done
+		FROM pipeline_build_gate
+		WHERE pipeline_build_id = $1 AND pipeline_stage_id = $2
+		ORDER BY id DESC LIMIT 1`
+
+	gate, err := scanGate(db.QueryRow(query, pbID, stageID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return gate, err
+}
+
+// LoadGates returns all approval requests of given stage in given pipeline build with their decisions, oldest first
+func LoadGates(db database.Querier, pbID, stageID int64) ([]sdk.StageGate, error) {
+	query := `SELECT id, pipeline_build_id, pipeline_stage_id, status, required, timeout, requested, done
+		FROM pipeline_build_gate
+		WHERE pipeline_build_id = $1 AND pipeline_stage_id = $2
+		ORDER BY id`
+
+	rows, err := db.Query(query, pbID, stageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	gates := []sdk.StageGate{}
+	for rows.Next() {
+		gate, err := scanGate(rows)
+		if err != nil {
+			return nil, err
+		}
+		gates = append(gates, *gate)
+	}
+	rows.Close()
+
+	for i := range gates {
+		if err := loadGateDecisions(db, &gates[i]); err != nil {
+			return nil, err
+		}
+	}
+	return gates, nil
+}
+
+func scanGate(s database.Scanner) (*sdk.StageGate, error) {
+	var gate sdk.StageGate
+	var status string
+	var timeout sql.NullInt64
+	var done pq.NullTime
+	if err := s.Scan(&gate.ID, &gate.PipelineBuildID, &gate.StageID, &status, &gate.Required, &timeout, &gate.Requested, &done); err != nil {
+		return nil, err
+	}
+	gate.Status = sdk.StatusFromString(status)
+	gate.Timeout = timeout.Int64
+	gate.Done = done.Time
+	return &gate, nil
+}
+
+func loadGateDecisions(db database.Querier, gate *sdk.StageGate) error {
+	query := `SELECT id, user_id, username, approved, comment, decided
+		FROM pipeline_build_approval
+		WHERE gate_id = $1
+		ORDER BY id`
+
+	rows, err := db.Query(query, gate.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	gate.Decisions = []sdk.StageApprovalDecision{}
+	for rows.Next() {
+		var d sdk.StageApprovalDecision
+		if err := rows.Scan(&d.ID, &d.UserID, &d.Username, &d.Approved, &d.Comment, &d.Date); err != nil {
+			return err
+		}
+		gate.Decisions = append(gate.Decisions, d)
+	}
+	return nil
+}
+
+// RequestApproval opens a new gate on given stage and notifies the approvers. The stage waits
+// until it is approved while the pipeline build keeps building the stages which do not need it,
+// the scheduler then sets it waiting for approval.
+func RequestApproval(db database.QueryExecuter, pb *sdk.PipelineBuild, s *sdk.Stage) (*sdk.StageGate, error) {
+	gate := &sdk.StageGate{
+		PipelineBuildID: pb.ID,
+		StageID:         s.ID,
+		Status:          sdk.StatusWaitingApproval,
+		Required:        s.Approval.RequiredApprovals(),
+		Timeout:         s.Approval.Timeout,
+		Requested:       time.Now(),
+		Decisions:       []sdk.StageApprovalDecision{},
+	}
+
+	query := `INSERT INTO pipeline_build_gate (pipeline_build_id, pipeline_stage_id, status, required, timeout, requested)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	if err := db.QueryRow(query, gate.PipelineBuildID, gate.StageID, gate.Status.String(), gate.Required, gate.Timeout, gate.Requested).Scan(&gate.ID); err != nil {
+		return nil, err
+	}
+
+	notification.SendStageApproval(db, pb, s, gate)
+	return gate, nil
+}
+
+// DecideApproval records the decision of given user on the gate of given stage.
+// The pipeline build goes on once enough users approved, and fails as soon as one rejects.
+func DecideApproval(tx *sql.Tx, pb *sdk.PipelineBuild, s *sdk.Stage, u *sdk.User, d *sdk.StageApprovalDecision) (*sdk.StageGate, error) {
+	if strings.TrimSpace(d.Comment) == "" {
+		return nil, sdk.ErrApprovalCommentRequired
+	}
+	if s.Approval == nil {
+		return nil, sdk.ErrNoPendingApproval
+	}
+	if !isStageApprover(u, s.Approval) {
+		return nil, sdk.ErrNotStageApprover
+	}
+
+	// Lock the pipeline build as the scheduler does, so its status is not changed meanwhile
+	var status string
+	query := `SELECT status FROM pipeline_build WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(query, pb.ID).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sdk.ErrNoPendingApproval
+		}
+		return nil, err
+	}
+
+	query = `SELECT id, pipeline_build_id, pipeline_stage_id, status, required, timeout, requested, done
+		FROM pipeline_build_gate
+		WHERE pipeline_build_id = $1 AND pipeline_stage_id = $2
+		ORDER BY id DESC LIMIT 1 FOR UPDATE`
+	gate, err := scanGate(tx.QueryRow(query, pb.ID, s.ID))
+	if err == sql.ErrNoRows {
+		return nil, sdk.ErrNoPendingApproval
+	}
+	if err != nil {
+		return nil, err
+	}
+	if gate.Status != sdk.StatusWaitingApproval {
+		return nil, sdk.ErrNoPendingApproval
+	}
+
+	if err := loadGateDecisions(tx, gate); err != nil {
+		return nil, err
+	}
+	for _, o := range gate.Decisions {
+		if o.UserID == u.ID {
+			return nil, sdk.ErrStageAlreadyDecided
+		}
+	}
+
+	d.UserID = u.ID
+	d.Username = u.Username
+	d.Date = time.Now()
+	query = `INSERT INTO pipeline_build_approval (gate_id, user_id, username, approved, comment, decided)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	if err := tx.QueryRow(query, gate.ID, d.UserID, d.Username, d.Approved, d.Comment, d.Date).Scan(&d.ID); err != nil {
+		return nil, err
+	}
+	gate.Decisions = append(gate.Decisions, *d)
+
+	switch {
+	case !d.Approved:
+		log.Info("DecideApproval> Stage %s of pipeline build %d rejected by %s\n", s.Name, pb.ID, u.Username)
+		if err := closeGate(tx, gate, sdk.StatusFail); err != nil {
+			return nil, err
+		}
+		// Stages which do not need the rejected one may be running, stop them
+		if err := StopPipelineBuild(tx, pb.ID); err != nil {
+			return nil, err
+		}
+		if err := UpdatePipelineBuildStatus(tx, *pb, sdk.StatusFail); err != nil {
+			return nil, err
+		}
+	case gate.Approvals() >= gate.Required:
+		log.Info("DecideApproval> Stage %s of pipeline build %d approved\n", s.Name, pb.ID)
+		if err := closeGate(tx, gate, sdk.StatusSuccess); err != nil {
+			return nil, err
+		}
+		// Other stages may still be building, otherwise the pipeline build was waiting for approval
+		if status != sdk.StatusWaitingApproval.String() {
+			build.WakeScheduler(tx, pb.ID)
+		} else if err := UpdatePipelineBuildStatus(tx, *pb, sdk.StatusBuilding); err != nil {
+			return nil, err
+		}
+	}
+
+	return gate, nil
+}
+
+func closeGate(db database.Executer, gate *sdk.StageGate, status sdk.Status) error {
+	gate.Status = status
+	gate.Done = time.Now()
+	query := `UPDATE pipeline_build_gate SET status = $1, done = $2 WHERE id = $3`
+	_, err := db.Exec(query, status.String(), gate.Done, gate.ID)
+	return err
+}
+
+func isStageApprover(u *sdk.User, a *sdk.StageApproval) bool {
+	for _, g := range u.Groups {
+		if sdk.IsInArray(g.Name, a.Groups) {
+			return true
+		}
+	}
+	return false
+}
+
+// stageApprovalJSON returns the value of pipeline_stage.approval for given policy
+func stageApprovalJSON(a *sdk.StageApproval) (sql.NullString, error) {
+	if a == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// parseStageApproval reads the value of pipeline_stage.approval
+func parseStageApproval(s sql.NullString) (*sdk.StageApproval, error) {
+	if !s.Valid || s.String == "" {
+		return nil, nil
+	}
+	var a sdk.StageApproval
+	if err := json.Unmarshal([]byte(s.String), &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/sdk"
+)
+
+// CreateAudit snapshots the current definition of a pipeline as its next version and returns the version number.
+// It must be called in the transaction changing the pipeline: the pipeline row is locked until the end of the
+// transaction so concurrent changes get consecutive versions.
+func CreateAudit(db database.QueryExecuter, pipelineID int64, u *sdk.User, change string) (int64, error) {
+	var id int64
+	if err := db.QueryRow(`SELECT id FROM pipeline WHERE id = $1 FOR UPDATE`, pipelineID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, sdk.ErrPipelineNotFound
+		}
+		return 0, err
+	}
+
+	p, err := LoadPipelineByID(db, pipelineID)
+	if err != nil {
+		return 0, err
+	}
+	p, err = LoadPipeline(db, p.ProjectKey, p.Name, true)
+	if err != nil {
+		return 0, err
+	}
+	p.LastPipelineBuild = nil
+	p.AttachedApplication = nil
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return 0, err
+	}
+
+	var userID sql.NullInt64
+	if u != nil && u.ID != 0 {
+		userID = sql.NullInt64{Int64: u.ID, Valid: true}
+	}
+
+	query := `INSERT INTO pipeline_audit (pipeline_id, version, user_id, change, versionned, pipeline_json)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, NOW(), $4 FROM pipeline_audit WHERE pipeline_id = $1
+		RETURNING version`
+	var version int64
+	if err := db.QueryRow(query, pipelineID, userID, change, string(data)).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// CurrentVersion returns the last version of a pipeline definition, 0 if it has never been versionned
+func CurrentVersion(db database.Querier, pipelineID int64) (int64, error) {
+	var version sql.NullInt64
+	query := `SELECT MAX(version) FROM pipeline_audit WHERE pipeline_id = $1`
+	if err := db.QueryRow(query, pipelineID).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version.Int64, nil
+}
+
+// LoadAudits loads all versions of a pipeline definition, most recent first
+func LoadAudits(db database.Querier, pipelineID int64) ([]sdk.PipelineAudit, error) {
+	audits := []sdk.PipelineAudit{}
+	query := `
+		SELECT pipeline_audit.id, pipeline_audit.version, pipeline_audit.change, pipeline_audit.versionned,
+			pipeline_audit.pipeline_json, "user".username
+		FROM pipeline_audit
+		LEFT JOIN "user" ON "user".id = pipeline_audit.user_id
+		WHERE pipeline_audit.pipeline_id = $1
+		ORDER BY pipeline_audit.version DESC`
+	rows, err := db.Query(query, pipelineID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		a, err := scanAudit(rows)
+		if err != nil {
+			return nil, err
+		}
+		a.PipelineID = pipelineID
+		audits = append(audits, *a)
+	}
+	return audits, nil
+}
+
+// LoadAudit loads given version of a pipeline definition
+func LoadAudit(db database.Querier, pipelineID, version int64) (*sdk.PipelineAudit, error) {
+	query := `
+		SELECT pipeline_audit.id, pipeline_audit.version, pipeline_audit.change, pipeline_audit.versionned,
+			pipeline_audit.pipeline_json, "user".username
+		FROM pipeline_audit
+		LEFT JOIN "user" ON "user".id = pipeline_audit.user_id
+		WHERE pipeline_audit.pipeline_id = $1 AND pipeline_audit.version = $2`
+	a, err := scanAudit(db.QueryRow(query, pipelineID, version))
+	if err == sql.ErrNoRows {
+		return nil, sdk.ErrPipelineAuditNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.PipelineID = pipelineID
+	return a, nil
+}
+
+func scanAudit(s database.Scanner) (*sdk.PipelineAudit, error) {
+	var a sdk.PipelineAudit
+	var data string
+	var username sql.NullString
+	if err := s.Scan(&a.ID, &a.Version, &a.Change, &a.Versionned, &data, &username); err != nil {
+		return nil, err
+	}
+	a.User.Username = username.String
+	if err := json.Unmarshal([]byte(data), &a.Pipeline); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -60,12 +60,16 @@ func killAWOLAction(db *sql.DB, actionBuildID int64) error {
 	defer tx.Rollback()
 
 	build.InsertLog(tx, actionBuildID, "SYSTEM", "Killed (Reason: Timeout)\n")
+	query := `UPDATE action_build SET awol = true WHERE id = $1`
+	if _, err := tx.Exec(query, actionBuildID); err != nil {
+		return err
+	}
 	err = build.UpdateActionBuildStatus(tx, &sdk.ActionBuild{ID: actionBuildID}, sdk.StatusFail)
 	if err != nil {
 		return err
 	}
 
-	query := `UPDATE worker SET status = $1, action_build_id = NULL WHERE action_build_id = $2`
+	query = `UPDATE worker SET status = $1, action_build_id = NULL WHERE action_build_id = $2`
 	_, err = tx.Exec(query, string(sdk.StatusDisabled), actionBuildID)
 	if err != nil {
 		return err
@@ -0,0 +1,282 @@
+package pipeline
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/ovh/cds/engine/api/build"
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// LoadConcurrency returns the concurrency policy of a pipeline on an application and an environment,
+// with the builds holding its lock and the builds waiting for it
+func LoadConcurrency(db database.Querier, applicationID, pipelineID, environmentID int64) (*sdk.PipelineConcurrency, error) {
+	c := &sdk.PipelineConcurrency{Policy: sdk.ConcurrencyAllow}
+
+	query := `SELECT application_pipeline_concurrency.policy FROM application_pipeline_concurrency
+		JOIN application_pipeline ON application_pipeline.id = application_pipeline_concurrency.application_pipeline_id
+		WHERE application_pipeline.application_id = $1 AND application_pipeline.pipeline_id = $2 AND application_pipeline_concurrency.environment_id = $3`
+	if err := db.QueryRow(query, applicationID, pipelineID, environmentID).Scan(&c.Policy); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var err error
+	c.Running, err = loadConcurrentBuilds(db, applicationID, pipelineID, environmentID, sdk.StatusBuilding, sdk.StatusWaitingApproval)
+	if err != nil {
+		return nil, err
+	}
+	c.Queued, err = loadConcurrentBuilds(db, applicationID, pipelineID, environmentID, sdk.StatusWaiting)
+	if err != nil {
+		return nil, err
+	}
+	c.Locked = c.Policy == sdk.ConcurrencyQueue && len(c.Running) > 0
+
+	return c, nil
+}
+
+func loadConcurrentBuilds(db database.Querier, applicationID, pipelineID, environmentID int64, status ...sdk.Status) ([]sdk.PipelineBuild, error) {
+	var statuses []string
+	for _, s := range status {
+		statuses = append(statuses, s.String())
+	}
+
+	query := fmt.Sprintf(LoadPipelineBuildRequest, "", "pb.application_id = $1 AND pb.pipeline_id = $2 AND pb.environment_id = $3 AND pb.status = ANY($4)", "")
+	rows, err := db.Query(query, applicationID, pipelineID, environmentID, pq.Array(statuses))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pbs := []sdk.PipelineBuild{}
+	for rows.Next() {
+		var pb sdk.PipelineBuild
+		if err := scanPbShort(&pb, rows); err != nil {
+			return nil, err
+		}
+		pbs = append(pbs, pb)
+	}
+	return pbs, nil
+}
+
+// UpdateConcurrencyPolicy sets the concurrency policy of a pipeline on an application and an environment
+func UpdateConcurrencyPolicy(db database.QueryExecuter, applicationID, pipelineID, environmentID int64, policy string) error {
+	if err := sdk.CheckConcurrencyPolicy(policy); err != nil {
+		return err
+	}
+
+	// Allow is the default policy, it needs no lock
+	if policy == sdk.ConcurrencyAllow {
+		query := `DELETE FROM application_pipeline_concurrency
+			USING application_pipeline
+			WHERE application_pipeline.id = application_pipeline_concurrency.application_pipeline_id
+			AND application_pipeline.application_id = $1 AND application_pipeline.pipeline_id = $2 AND application_pipeline_concurrency.environment_id = $3`
+		_, err := db.Exec(query, applicationID, pipelineID, environmentID)
+		return err
+	}
+
+	query := `UPDATE application_pipeline_concurrency SET policy = $4
+		FROM application_pipeline
+		WHERE application_pipeline.id = application_pipeline_concurrency.application_pipeline_id
+		AND application_pipeline.application_id = $1 AND application_pipeline.pipeline_id = $2 AND application_pipeline_concurrency.environment_id = $3`
+	res, err := db.Exec(query, applicationID, pipelineID, environmentID, policy)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	query = `INSERT INTO application_pipeline_concurrency (application_pipeline_id, environment_id, policy)
+		SELECT id, $3, $4 FROM application_pipeline WHERE application_id = $1 AND pipeline_id = $2`
+	res, err = db.Exec(query, applicationID, pipelineID, environmentID, policy)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sdk.ErrPipelineNotAttached
+	}
+	return nil
+}
+
+// lockConcurrency locks the concurrency policy of a pipeline with a FOR UPDATE NOWAIT,
+// so only one instance of the API starts builds of this pipeline at a time
+func lockConcurrency(tx *sql.Tx, applicationID, pipelineID, environmentID int64) (string, error) {
+	query := `SELECT application_pipeline_concurrency.policy FROM application_pipeline_concurrency
+		JOIN application_pipeline ON application_pipeline.id = application_pipeline_concurrency.application_pipeline_id
+		WHERE application_pipeline.application_id = $1 AND application_pipeline.pipeline_id = $2 AND application_pipeline_concurrency.environment_id = $3
+		FOR UPDATE OF application_pipeline_concurrency NOWAIT`
+
+	var policy string
+	err := tx.QueryRow(query, applicationID, pipelineID, environmentID).Scan(&policy)
+	if err == sql.ErrNoRows {
+		return sdk.ConcurrencyAllow, nil
+	}
+	return policy, err
+}
+
+// concurrencyStatus returns the status a new build of a pipeline starts with according
+// to its concurrency policy: Waiting when it has to queue, Building otherwise
+func concurrencyStatus(tx *sql.Tx, applicationID, pipelineID, environmentID int64, branch string) (sdk.Status, error) {
+	policy, err := lockConcurrency(tx, applicationID, pipelineID, environmentID)
+	if database.IsLockNotAvailable(err) {
+		// Another build of this pipeline is being started, the scheduler will start this one afterwards
+		return sdk.StatusWaiting, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch policy {
+	case sdk.ConcurrencyQueue:
+		n, err := countConcurrentBuilds(tx, applicationID, pipelineID, environmentID, sdk.StatusBuilding, sdk.StatusWaitingApproval, sdk.StatusWaiting)
+		if err != nil {
+			return "", err
+		}
+		if n > 0 {
+			return sdk.StatusWaiting, nil
+		}
+	case sdk.ConcurrencyCancelPrevious:
+		if err := cancelPreviousBuilds(tx, applicationID, pipelineID, environmentID, branch, 0); err != nil {
+			return "", err
+		}
+	}
+	return sdk.StatusBuilding, nil
+}
+
+func countConcurrentBuilds(db database.Querier, applicationID, pipelineID, environmentID int64, status ...sdk.Status) (int, error) {
+	var statuses []string
+	for _, s := range status {
+		statuses = append(statuses, s.String())
+	}
+
+	query := `SELECT COUNT(id) FROM pipeline_build
+		WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3 AND status = ANY($4)`
+	var n int
+	err := db.QueryRow(query, applicationID, pipelineID, environmentID, pq.Array(statuses)).Scan(&n)
+	return n, err
+}
+
+// cancelPreviousBuilds stops in-flight builds of given branch older than given build, all of them if beforeID is 0
+func cancelPreviousBuilds(tx *sql.Tx, applicationID, pipelineID, environmentID int64, branch string, beforeID int64) error {
+	query := `SELECT id FROM pipeline_build
+		WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3 AND vcs_changes_branch = $4
+		AND status = ANY($5) AND ($6 = 0 OR id < $6)`
+	statuses := []string{sdk.StatusBuilding.String(), sdk.StatusWaitingApproval.String(), sdk.StatusWaiting.String()}
+	rows, err := tx.Query(query, applicationID, pipelineID, environmentID, branch, pq.Array(statuses), beforeID)
+	if err != nil {
+		return err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		log.Info("cancelPreviousBuilds> Cancelling pipeline build %d on branch %s\n", id, branch)
+		if err := StopPipelineBuild(tx, id); err != nil {
+			return err
+		}
+		// A build between two stages has no action build to stop
+		query = `UPDATE pipeline_build SET status = $1, done = now() WHERE id = $2 AND status = $3`
+		if _, err := tx.Exec(query, sdk.StatusStopped.String(), id, sdk.StatusBuilding.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartQueuedPipelineBuilds starts the pipeline builds waiting for the lock of their pipeline
+func StartQueuedPipelineBuilds(db *sql.DB) error {
+	query := `SELECT DISTINCT application_id, pipeline_id, environment_id FROM pipeline_build WHERE status = $1`
+	rows, err := db.Query(query, sdk.StatusWaiting.String())
+	if err != nil {
+		return err
+	}
+	var queues [][3]int64
+	for rows.Next() {
+		var q [3]int64
+		if err := rows.Scan(&q[0], &q[1], &q[2]); err != nil {
+			rows.Close()
+			return err
+		}
+		queues = append(queues, q)
+	}
+	rows.Close()
+
+	for _, q := range queues {
+		if err := startQueuedPipelineBuild(db, q[0], q[1], q[2]); err != nil {
+			log.Warning("StartQueuedPipelineBuilds> Cannot start queued build of pipeline %d on application %d: %s\n", q[1], q[0], err)
+		}
+	}
+	return nil
+}
+
+// startQueuedPipelineBuild starts the oldest queued build of a pipeline once its policy allows it
+func startQueuedPipelineBuild(db *sql.DB, applicationID, pipelineID, environmentID int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	policy, err := lockConcurrency(tx, applicationID, pipelineID, environmentID)
+	if database.IsLockNotAvailable(err) {
+		// Someone else is on it
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var id int64
+	var branch string
+	query := `SELECT id, vcs_changes_branch FROM pipeline_build
+		WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3 AND status = $4
+		ORDER BY id LIMIT 1
+		FOR UPDATE`
+	err = tx.QueryRow(query, applicationID, pipelineID, environmentID, sdk.StatusWaiting.String()).Scan(&id, &branch)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch policy {
+	case sdk.ConcurrencyQueue:
+		n, err := countConcurrentBuilds(tx, applicationID, pipelineID, environmentID, sdk.StatusBuilding, sdk.StatusWaitingApproval)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return nil
+		}
+	case sdk.ConcurrencyCancelPrevious:
+		if err := cancelPreviousBuilds(tx, applicationID, pipelineID, environmentID, branch, id); err != nil {
+			return err
+		}
+	}
+
+	log.Info("startQueuedPipelineBuild> Starting queued pipeline build %d\n", id)
+	query = `UPDATE pipeline_build SET status = $1, start = now() WHERE id = $2`
+	if _, err := tx.Exec(query, sdk.StatusBuilding.String(), id); err != nil {
+		return err
+	}
+	build.WakeScheduler(tx, id)
+
+	return tx.Commit()
+}
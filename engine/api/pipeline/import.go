@@ -0,0 +1,370 @@
+package pipeline
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/ovh/cds/engine/api/action"
+	"github.com/ovh/cds/engine/api/group"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+// Import creates given pipeline in project with its parameters, stages and joined actions.
+// If the pipeline carries no group permission, project groups are granted on the new pipeline,
+// as done when a pipeline is created from scratch.
+func Import(tx *sql.Tx, proj *sdk.Project, p *sdk.Pipeline, userID int64) error {
+	p.ProjectID = proj.ID
+	p.ProjectKey = proj.Key
+	if err := InsertPipeline(tx, p); err != nil {
+		return fmt.Errorf("Import> cannot insert pipeline: %s", err)
+	}
+
+	groups := p.GroupPermission
+	if len(groups) == 0 {
+		if err := group.LoadGroupByProject(tx, proj); err != nil {
+			return fmt.Errorf("Import> cannot load groups of project %s: %s", proj.Key, err)
+		}
+		groups = proj.ProjectGroups
+	}
+	sharedInfraPresent := false
+	for _, g := range groups {
+		if g.Group.Name == group.SharedInfraGroup {
+			sharedInfraPresent = true
+			break
+		}
+	}
+	if err := group.InsertGroupsInPipeline(tx, groups, p.ID); err != nil {
+		return fmt.Errorf("Import> cannot add groups on pipeline: %s", err)
+	}
+	if !sharedInfraPresent {
+		if err := group.AddGlobalGroupToPipeline(tx, p.ID); err != nil {
+			return fmt.Errorf("Import> cannot add global infra group: %s", err)
+		}
+	}
+
+	for i := range p.Parameter {
+		if err := InsertParameterInPipeline(tx, p.ID, &p.Parameter[i]); err != nil {
+			return fmt.Errorf("Import> cannot insert parameter %s: %s", p.Parameter[i].Name, err)
+		}
+	}
+
+	for i := range p.Stages {
+		if err := importStage(tx, p, &p.Stages[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ImportUpdate computes the differences between given pipeline and the one stored
+// in database, then applies only what changed: parameters, stages (matched by name)
+// and joined actions (matched by name inside their stage).
+func ImportUpdate(tx *sql.Tx, proj *sdk.Project, p *sdk.Pipeline, userID int64) error {
+	old, err := LoadPipeline(tx, proj.Key, p.Name, true)
+	if err != nil {
+		return err
+	}
+	p.ID = old.ID
+	p.ProjectID = old.ProjectID
+	p.ProjectKey = proj.Key
+
+	if old.Type != p.Type || old.Timeout != p.Timeout || old.Priority != p.Priority || old.SkipBuiltCommits != p.SkipBuiltCommits {
+		log.Debug("ImportUpdate> Updating pipeline %s", p.Name)
+		if err := UpdatePipeline(tx, p); err != nil {
+			return fmt.Errorf("ImportUpdate> cannot update pipeline: %s", err)
+		}
+	}
+
+	if err := importUpdateParameters(tx, p, old.Parameter); err != nil {
+		return err
+	}
+
+	// Match stages by name, first come first served
+	oldStages := map[string]*sdk.Stage{}
+	for i := range old.Stages {
+		if _, ok := oldStages[old.Stages[i].Name]; !ok {
+			oldStages[old.Stages[i].Name] = &old.Stages[i]
+		}
+	}
+	matched := map[int64]*sdk.Stage{}
+	for i := range p.Stages {
+		if s, ok := oldStages[p.Stages[i].Name]; ok {
+			matched[s.ID] = &p.Stages[i]
+			delete(oldStages, s.Name)
+		}
+	}
+
+	// Remove stages which are not in the new definition
+	var deleted bool
+	for i := range old.Stages {
+		s := &old.Stages[i]
+		if _, ok := matched[s.ID]; ok {
+			continue
+		}
+		log.Debug("ImportUpdate> Deleting stage %s of pipeline %s", s.Name, p.Name)
+		if err := DeleteStageByID(tx, s, userID); err != nil {
+			return fmt.Errorf("ImportUpdate> cannot delete stage %s: %s", s.Name, err)
+		}
+		deleted = true
+	}
+
+	// Update kept stages, insert the new ones
+	for i := range old.Stages {
+		s := &old.Stages[i]
+		n, ok := matched[s.ID]
+		if !ok {
+			continue
+		}
+		// Deleting stages shifted build orders, so they must all be rewritten
+		if deleted {
+			s.BuildOrder = 0
+		}
+		if err := importUpdateStage(tx, p, s, n, userID); err != nil {
+			return err
+		}
+	}
+	for i := range p.Stages {
+		if p.Stages[i].ID != 0 {
+			continue
+		}
+		if err := importStage(tx, p, &p.Stages[i]); err != nil {
+			return err
+		}
+	}
+
+	return UpdatePipelineLastModified(tx, p.ID)
+}
+
+func importUpdateParameters(tx *sql.Tx, p *sdk.Pipeline, oldParams []sdk.Parameter) error {
+	olds := map[string]sdk.Parameter{}
+	for _, param := range oldParams {
+		olds[param.Name] = param
+	}
+
+	for i := range p.Parameter {
+		param := &p.Parameter[i]
+		o, ok := olds[param.Name]
+		if !ok {
+			if err := InsertParameterInPipeline(tx, p.ID, param); err != nil {
+				return fmt.Errorf("ImportUpdate> cannot insert parameter %s: %s", param.Name, err)
+			}
+			continue
+		}
+		delete(olds, param.Name)
+		param.ID = o.ID
+		if o.Type == param.Type && o.Value == param.Value && o.Description == param.Description {
+			continue
+		}
+		if err := UpdateParameterInPipeline(tx, p.ID, *param); err != nil {
+			return fmt.Errorf("ImportUpdate> cannot update parameter %s: %s", param.Name, err)
+		}
+	}
+
+	for name := range olds {
+		if err := DeleteParameterFromPipeline(tx, p.ID, name); err != nil {
+			return fmt.Errorf("ImportUpdate> cannot delete parameter %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// importStage inserts a new stage and all its joined actions
+func importStage(tx *sql.Tx, p *sdk.Pipeline, s *sdk.Stage) error {
+	s.PipelineID = p.ID
+	enabled := s.Enabled
+	if err := InsertStage(tx, s); err != nil {
+		return fmt.Errorf("Import> cannot insert stage %s: %s", s.Name, err)
+	}
+	if !enabled {
+		s.Enabled = false
+		if err := UpdateStage(tx, s); err != nil {
+			return fmt.Errorf("Import> cannot disable stage %s: %s", s.Name, err)
+		}
+	}
+
+	for i := range s.Actions {
+		if err := importJoinedAction(tx, p, s, &s.Actions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importUpdateStage applies the differences between stage stored in database and its new definition
+func importUpdateStage(tx *sql.Tx, p *sdk.Pipeline, old, s *sdk.Stage, userID int64) error {
+	s.ID = old.ID
+	s.PipelineID = p.ID
+
+	if old.BuildOrder != s.BuildOrder || old.Enabled != s.Enabled || old.Timeout != s.Timeout || !reflect.DeepEqual(old.Approval, s.Approval) || !reflect.DeepEqual(old.Needs, s.Needs) || !samePrerequisites(old.Prerequisites, s.Prerequisites) {
+		log.Debug("ImportUpdate> Updating stage %s of pipeline %s", s.Name, p.Name)
+		if err := UpdateStage(tx, s); err != nil {
+			return fmt.Errorf("ImportUpdate> cannot update stage %s: %s", s.Name, err)
+		}
+	}
+
+	olds := map[string]*sdk.Action{}
+	for i := range old.Actions {
+		if _, ok := olds[old.Actions[i].Name]; !ok {
+			olds[old.Actions[i].Name] = &old.Actions[i]
+			continue
+		}
+		// Duplicated names cannot be matched, drop them
+		if err := action.DeleteAction(tx, old.Actions[i].ID, userID); err != nil {
+			return fmt.Errorf("ImportUpdate> cannot delete joined action %s: %s", old.Actions[i].Name, err)
+		}
+	}
+
+	for i := range s.Actions {
+		a := &s.Actions[i]
+		o, ok := olds[a.Name]
+		if !ok {
+			if err := importJoinedAction(tx, p, s, a); err != nil {
+				return err
+			}
+			continue
+		}
+		delete(olds, a.Name)
+
+		if err := resolveJoinedAction(tx, a); err != nil {
+			return err
+		}
+		a.ID = o.ID
+		a.PipelineActionID = o.PipelineActionID
+		a.PipelineStageID = s.ID
+
+		if jobChanged(o, a) {
+			log.Debug("ImportUpdate> Updating joined action %s in stage %s", a.Name, s.Name)
+			if err := action.UpdateActionDB(tx, a, userID); err != nil {
+				return fmt.Errorf("ImportUpdate> cannot update joined action %s: %s", a.Name, err)
+			}
+		}
+		if o.Enabled != a.Enabled || o.Timeout != a.Timeout || !reflect.DeepEqual(o.Retry, a.Retry) {
+			if err := UpdatePipelineAction(tx, *a, "[]"); err != nil {
+				return fmt.Errorf("ImportUpdate> cannot update joined action %s state: %s", a.Name, err)
+			}
+		}
+	}
+
+	for _, o := range olds {
+		log.Debug("ImportUpdate> Deleting joined action %s in stage %s", o.Name, s.Name)
+		if err := action.DeleteAction(tx, o.ID, userID); err != nil {
+			return fmt.Errorf("ImportUpdate> cannot delete joined action %s: %s", o.Name, err)
+		}
+	}
+	return nil
+}
+
+// importJoinedAction inserts a joined action and attaches it to the stage
+func importJoinedAction(tx *sql.Tx, p *sdk.Pipeline, s *sdk.Stage, a *sdk.Action) error {
+	if err := resolveJoinedAction(tx, a); err != nil {
+		return err
+	}
+
+	enabled := a.Enabled
+	a.Type = sdk.JoinedAction
+	a.Enabled = true
+	if err := action.InsertAction(tx, a, false); err != nil {
+		return fmt.Errorf("Import> cannot insert joined action %s: %s", a.Name, err)
+	}
+
+	id, err := InsertPipelineAction(tx, p.ProjectKey, p.Name, a.ID, "[]", s.ID)
+	if err != nil {
+		return fmt.Errorf("Import> cannot attach joined action %s to stage %s: %s", a.Name, s.Name, err)
+	}
+	a.PipelineActionID = id
+	a.PipelineStageID = s.ID
+
+	if !enabled || a.Retry != nil || a.Timeout != 0 {
+		a.Enabled = enabled
+		if err := UpdatePipelineAction(tx, *a, "[]"); err != nil {
+			return fmt.Errorf("Import> cannot update joined action %s state: %s", a.Name, err)
+		}
+	}
+	return nil
+}
+
+// resolveJoinedAction loads children of a joined action by name, so parameters get
+// their type and default value, and merges children requirements into the joined action.
+func resolveJoinedAction(tx *sql.Tx, a *sdk.Action) error {
+	for i := range a.Actions {
+		c := &a.Actions[i]
+		def, err := action.LoadPublicAction(tx, c.Name)
+		if err != nil {
+			if err == sdk.ErrNoAction {
+				return fmt.Errorf("action %s used in %s does not exist", c.Name, a.Name)
+			}
+			return err
+		}
+
+		params := def.Parameters
+		for _, up := range c.Parameters {
+			found := false
+			for j := range params {
+				if params[j].Name == up.Name {
+					params[j].Value = up.Value
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("action %s used in %s has no parameter %s", c.Name, a.Name, up.Name)
+			}
+		}
+
+		c.ID = def.ID
+		c.Type = def.Type
+		c.Description = def.Description
+		c.Parameters = params
+		c.Requirements = def.Requirements
+	}
+
+	// Requirements of children are requirement of parent
+	for _, c := range a.Actions {
+		for _, cr := range c.Requirements {
+			found := false
+			for _, pr := range a.Requirements {
+				if pr.Type == cr.Type && pr.Value == cr.Value {
+					found = true
+					break
+				}
+			}
+			if !found {
+				a.Requirements = append(a.Requirements, cr)
+			}
+		}
+	}
+	return nil
+}
+
+// jobChanged compares the portable definitions of two joined actions, ignoring
+// their state and retry policy which are stored on pipeline_action
+func jobChanged(old, a *sdk.Action) bool {
+	o, n := exportentities.NewJob(*old), exportentities.NewJob(*a)
+	o.Enabled, n.Enabled = nil, nil
+	o.Retry, n.Retry = nil, nil
+	o.Timeout, n.Timeout = 0, 0
+	return !reflect.DeepEqual(o, n)
+}
+
+func samePrerequisites(a, b []sdk.Prerequisite) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, pa := range a {
+		found := false
+		for _, pb := range b {
+			if pa == pb {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
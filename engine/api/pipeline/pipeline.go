@@ -49,11 +49,13 @@ func LoadPipeline(db database.Querier, projectKey, name string, deep bool) (*sdk
 
 	var pType string
 	var lastModified time.Time
-	query := `SELECT pipeline.id, pipeline.name, pipeline.project_id, pipeline.type, pipeline.last_modified FROM pipeline
+	query := `SELECT pipeline.id, pipeline.name, pipeline.project_id, pipeline.type, pipeline.last_modified, pipeline.timeout, pipeline.priority, pipeline.skip_built_commits FROM pipeline
 	 		JOIN project on pipeline.project_id = project.id
 	 		WHERE pipeline.name = $1 AND project.projectKey = $2`
 
-	err := db.QueryRow(query, name, projectKey).Scan(&p.ID, &p.Name, &p.ProjectID, &pType, &lastModified)
+	var timeout, priority sql.NullInt64
+	var skipBuiltCommits sql.NullBool
+	err := db.QueryRow(query, name, projectKey).Scan(&p.ID, &p.Name, &p.ProjectID, &pType, &lastModified, &timeout, &priority, &skipBuiltCommits)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, sdk.ErrPipelineNotFound
@@ -63,6 +65,9 @@ func LoadPipeline(db database.Querier, projectKey, name string, deep bool) (*sdk
 	p.LastModified = lastModified.Unix()
 	p.Type = sdk.PipelineTypeFromString(pType)
 	p.ProjectKey = projectKey
+	p.Timeout = timeout.Int64
+	p.Priority = priority.Int64
+	p.SkipBuiltCommits = skipBuiltCommits.Bool
 
 	if deep {
 		// load pipeline actions by stage
@@ -90,11 +95,13 @@ func LoadPipeline(db database.Querier, projectKey, name string, deep bool) (*sdk
 func LoadPipelineByID(db database.Querier, pipelineID int64) (*sdk.Pipeline, error) {
 	var p sdk.Pipeline
 	var pType string
-	query := `SELECT pipeline.name, pipeline.type, project.projectKey FROM pipeline
+	var timeout, priority sql.NullInt64
+	var skipBuiltCommits sql.NullBool
+	query := `SELECT pipeline.name, pipeline.type, project.projectKey, pipeline.timeout, pipeline.priority, pipeline.skip_built_commits FROM pipeline
 	JOIN project on pipeline.project_id = project.id
 	WHERE pipeline.id = $1`
 
-	err := db.QueryRow(query, pipelineID).Scan(&p.Name, &pType, &p.ProjectKey)
+	err := db.QueryRow(query, pipelineID).Scan(&p.Name, &pType, &p.ProjectKey, &timeout, &priority, &skipBuiltCommits)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, sdk.ErrPipelineNotFound
@@ -104,6 +111,9 @@ func LoadPipelineByID(db database.Querier, pipelineID int64) (*sdk.Pipeline, err
 
 	p.Type = sdk.PipelineTypeFromString(pType)
 	p.ID = pipelineID
+	p.Timeout = timeout.Int64
+	p.Priority = priority.Int64
+	p.SkipBuiltCommits = skipBuiltCommits.Bool
 	return &p, nil
 }
 
@@ -151,6 +161,12 @@ func DeletePipeline(db database.QueryExecuter, pipelineID int64, userID int64) e
 		return err
 	}
 
+	// Delete coverage and findings
+	err = build.DeletePipelineCodeReports(db, pipelineID)
+	if err != nil {
+		return err
+	}
+
 	var pipelineBuildIDs []int64
 	query = `SELECT id FROM pipeline_build where pipeline_id = $1`
 	rows, err := db.Query(query, pipelineID)
@@ -187,6 +203,13 @@ func DeletePipeline(db database.QueryExecuter, pipelineID int64, userID int64) e
 		return err
 	}
 
+	// Delete definition versions
+	query = `DELETE FROM pipeline_audit WHERE pipeline_id = $1`
+	_, err = db.Exec(query, pipelineID)
+	if err != nil {
+		return err
+	}
+
 	// Delete application_pipeline_notif
 	query = `
 		DELETE FROM application_pipeline_notif WHERE application_pipeline_id IN (
@@ -196,6 +219,21 @@ func DeletePipeline(db database.QueryExecuter, pipelineID int64, userID int64) e
 		return err
 	}
 
+	// Delete application_pipeline_concurrency
+	query = `
+		DELETE FROM application_pipeline_concurrency WHERE application_pipeline_id IN (
+			SELECT id FROM application_pipeline WHERE pipeline_id = $1
+		)`
+	if _, err := db.Exec(query, pipelineID); err != nil {
+		return err
+	}
+
+	// Delete schedules
+	query = `DELETE FROM pipeline_schedule WHERE pipeline_id = $1`
+	if _, err := db.Exec(query, pipelineID); err != nil {
+		return err
+	}
+
 	// Delete pipeline
 	query = `DELETE FROM pipeline WHERE id = $1`
 	_, err = db.Exec(query, pipelineID)
@@ -346,20 +384,20 @@ func UpdatePipeline(db database.Executer, p *sdk.Pipeline) error {
 	}
 
 	//Update pipeline
-	query = `UPDATE pipeline SET name=$1, type=$2, last_modified = current_timestamp WHERE id=$3`
-	_, err = db.Exec(query, p.Name, string(p.Type), p.ID)
+	query = `UPDATE pipeline SET name=$1, type=$2, timeout=$4, priority=$5, skip_built_commits=$6, last_modified = current_timestamp WHERE id=$3`
+	_, err = db.Exec(query, p.Name, string(p.Type), p.ID, p.Timeout, p.Priority, p.SkipBuiltCommits)
 	return err
 }
 
 // InsertPipeline inserts pipeline informations in database
 func InsertPipeline(db database.QueryExecuter, p *sdk.Pipeline) error {
-	query := `INSERT INTO pipeline (name, project_id, type) VALUES ($1,$2,$3) RETURNING id`
+	query := `INSERT INTO pipeline (name, project_id, type, timeout, priority, skip_built_commits) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id`
 
 	if p.Name == "" {
 		return sdk.ErrInvalidName
 	}
 
-	if err := db.QueryRow(query, p.Name, p.ProjectID, string(p.Type)).Scan(&p.ID); err != nil {
+	if err := db.QueryRow(query, p.Name, p.ProjectID, string(p.Type), p.Timeout, p.Priority, p.SkipBuiltCommits).Scan(&p.ID); err != nil {
 		return err
 	}
 
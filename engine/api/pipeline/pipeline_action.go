@@ -1,6 +1,8 @@
 package pipeline
 
 import (
+	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	"github.com/ovh/cds/engine/api/action"
@@ -176,9 +178,18 @@ func InsertPipelineAction(db database.QueryExecuter, projectKey, pipelineName st
 
 // UpdatePipelineAction Update an action in a pipeline
 func UpdatePipelineAction(db database.Executer, action sdk.Action, args string) error {
-	query := `UPDATE pipeline_action set action_id=$1, args=$2, pipeline_stage_id=$3, enabled=$5  WHERE id=$4`
+	query := `UPDATE pipeline_action set action_id=$1, args=$2, pipeline_stage_id=$3, enabled=$5, retry=$6, timeout=$7  WHERE id=$4`
 
-	_, err := db.Exec(query, action.ID, args, action.PipelineStageID, action.PipelineActionID, action.Enabled)
+	var retry sql.NullString
+	if action.Retry != nil {
+		retryJSON, err := json.Marshal(action.Retry)
+		if err != nil {
+			return err
+		}
+		retry = sql.NullString{String: string(retryJSON), Valid: true}
+	}
+
+	_, err := db.Exec(query, action.ID, args, action.PipelineStageID, action.PipelineActionID, action.Enabled, retry, action.Timeout)
 	if err != nil {
 		return err
 	}
@@ -30,7 +30,8 @@ SELECT  pb.pipeline_id, pb.application_id, pb.environment_id, pb.id, project.id
 	pb.build_number, pb.version, pb.status,
 	pb.start, pb.done,
 	pb.manual_trigger, pb.triggered_by, pb.parent_pipeline_build_id, pb.vcs_changes_branch, pb.vcs_changes_hash, pb.vcs_changes_author,
-	"user".username, pipTriggerFrom.name as pipTriggerFrom, pbTriggerFrom.version as versionTriggerFrom
+	"user".username, pipTriggerFrom.name as pipTriggerFrom, pbTriggerFrom.version as versionTriggerFrom,
+	pb.retried, pb.scheduled_trigger, pb.pipeline_version
 FROM pipeline_build pb
 JOIN environment ON environment.id = pb.environment_id
 JOIN application ON application.id = pb.application_id
@@ -55,7 +56,7 @@ LEFT OUTER JOIN (
     SELECT pipeline_action.id, pipeline_action.pipeline_stage_id, action_build.start, action_build.done
     FROM pipeline_action
     JOIN action_build ON action_build.pipeline_action_id = pipeline_action.id
-    WHERE pipeline_build_id = $1
+    WHERE pipeline_build_id = $1 AND action_build.retried = false
 ) AS pipeline_action_R ON pipeline_action_R.pipeline_stage_id = pipeline_stage.id
 WHERE pipeline_build.id = $1
 ORDER BY pipeline_stage.build_order ASC, pipeline_action_R.id;
@@ -82,7 +83,7 @@ JOIN project ON pipeline.project_id = project.id
 JOIN pipeline_stage ON pipeline_stage.pipeline_id = pipeline.id
 JOIN pipeline_action ON pipeline_action.pipeline_stage_id = pipeline_stage.id
 JOIN action ON action.id = pipeline_action.action_id
-LEFT JOIN action_build ON action_build.pipeline_build_id = pb.id AND action_build.pipeline_action_id = pipeline_action.id
+LEFT JOIN action_build ON action_build.pipeline_build_id = pb.id AND action_build.pipeline_action_id = pipeline_action.id AND action_build.retried = false
 WHERE %s
 ORDER BY project.projectkey, application.name, pb.id, pipeline_stage.build_order
 `
@@ -424,11 +425,25 @@ func InsertBuildVariable(db *sql.DB, pbID int64, v sdk.Variable) error {
 
 // LoadBuildingPipelines retrieves pipelines in database having a build running
 func LoadBuildingPipelines(db *sql.DB, args ...FuncArg) ([]sdk.PipelineBuild, error) {
-	query := `
+	query := fmt.Sprintf(buildingPipelinesQuery, "")
+	return loadBuildingPipelines(db, query, []interface{}{sdk.StatusBuilding.String()}, args...)
+}
+
+// LoadBuildingPipelinesLike retrieves the running build to schedule among the builds of
+// the application, pipeline, environment and branch of given pipeline build
+func LoadBuildingPipelinesLike(db *sql.DB, pipelineBuildID int64, args ...FuncArg) ([]sdk.PipelineBuild, error) {
+	query := fmt.Sprintf(buildingPipelinesQuery, `AND EXISTS (SELECT 1 FROM pipeline_build other
+	WHERE other.id = $2 AND other.application_id = pb.application_id AND other.pipeline_id = pb.pipeline_id
+	AND other.environment_id = pb.environment_id AND other.vcs_changes_branch IS NOT DISTINCT FROM pb.vcs_changes_branch)`)
+	return loadBuildingPipelines(db, query, []interface{}{sdk.StatusBuilding.String(), pipelineBuildID}, args...)
+}
+
+// buildingPipelinesQuery loads the oldest running build of each application, pipeline, environment and branch
+const buildingPipelinesQuery = `
 SELECT DISTINCT ON (project.projectkey, application.name, pb.application_id, pb.pipeline_id, pb.environment_id, pb.vcs_changes_branch)
 	pb.pipeline_id, pb.application_id, pb.environment_id, pb.id, project.id as project_id,
 	environment.name as envName, application.name as appName, pipeline.name as pipName, project.projectkey,
-	pipeline.type,
+	pipeline.type, pipeline.timeout,
 	pb.build_number, pb.version, pb.status, pb.args,
 	pb.start, pb.done,
 	pb.manual_trigger, pb.triggered_by, pb.parent_pipeline_build_id, pb.vcs_changes_branch, pb.vcs_changes_hash, pb.vcs_changes_author,
@@ -441,10 +456,12 @@ JOIN project ON project.id = pipeline.project_id
 LEFT JOIN "user" ON "user".id = pb.triggered_by
 LEFT JOIN pipeline_build as pbTriggerFrom ON pbTriggerFrom.id = pb.parent_pipeline_build_id
 LEFT JOIN pipeline as pipTriggerFrom ON pipTriggerFrom.id = pbTriggerFrom.pipeline_id
-WHERE pb.status = $1
+WHERE pb.status = $1 %s
 ORDER BY project.projectkey, application.name, pb.application_id, pb.pipeline_id, pb.environment_id, pb.vcs_changes_branch, pb.id
 LIMIT 1000`
-	rows, err := db.Query(query, sdk.StatusBuilding.String())
+
+func loadBuildingPipelines(db *sql.DB, query string, queryArgs []interface{}, args ...FuncArg) ([]sdk.PipelineBuild, error) {
+	rows, err := db.Query(query, queryArgs...)
 	if err != nil {
 		log.Warning("LoadBuildingPipelines>Cannot load buliding pipelines: %s", err)
 		return nil, err
@@ -456,12 +473,12 @@ LIMIT 1000`
 
 		var status, typePipeline, argsJSON string
 		var manual sql.NullBool
-		var trigBy, pPbID, version sql.NullInt64
+		var trigBy, pPbID, version, timeout sql.NullInt64
 		var branch, hash, author, fromUser, fromPipeline sql.NullString
 
 		err := rows.Scan(&p.Pipeline.ID, &p.Application.ID, &p.Environment.ID, &p.ID, &p.Pipeline.ProjectID,
 			&p.Environment.Name, &p.Application.Name, &p.Pipeline.Name, &p.Pipeline.ProjectKey,
-			&typePipeline,
+			&typePipeline, &timeout,
 			&p.BuildNumber, &p.Version, &status, &argsJSON,
 			&p.Start, &p.Done,
 			&manual, &trigBy, &pPbID, &branch, &hash, &author,
@@ -472,6 +489,7 @@ LIMIT 1000`
 		}
 		p.Status = sdk.StatusFromString(status)
 		p.Pipeline.Type = sdk.PipelineTypeFromString(typePipeline)
+		p.Pipeline.Timeout = timeout.Int64
 		p.Application.ProjectKey = p.Pipeline.ProjectKey
 		loadPbTrigger(&p, manual, pPbID, branch, hash, author, fromUser, fromPipeline, version)
 
@@ -497,6 +515,20 @@ LIMIT 1000`
 	return pip, nil
 }
 
+// UpdatePipelineBuildPriority overrides the priority a pipeline build got from its pipeline
+func UpdatePipelineBuildPriority(db database.Executer, pb *sdk.PipelineBuild, priority int64) error {
+	if err := sdk.CheckPriority(priority); err != nil {
+		return err
+	}
+
+	query := `UPDATE pipeline_build SET priority = $1 WHERE id = $2`
+	if _, err := db.Exec(query, priority, pb.ID); err != nil {
+		return err
+	}
+	pb.Priority = priority
+	return nil
+}
+
 // UpdatePipelineBuildStatus Update status of pipeline_build
 func UpdatePipelineBuildStatus(db database.QueryExecuter, pb sdk.PipelineBuild, status sdk.Status) error {
 	query := `UPDATE pipeline_build SET status = $1, done = $3 WHERE id = $2`
@@ -505,6 +537,7 @@ func UpdatePipelineBuildStatus(db database.QueryExecuter, pb sdk.PipelineBuild,
 	if err != nil {
 		return err
 	}
+	build.WakeScheduler(db, pb.ID)
 
 	pb.Status = status
 
@@ -554,7 +587,7 @@ func GetAllLastBuildByApplicationAndVersion(db database.Querier, applicationID i
 			build_number, version, status,
 			start, done,
 			manual_trigger, triggered_by, parent_pipeline_build_id, vcs_changes_branch, vcs_changes_hash, vcs_changes_author,
-			username, pipTriggerFrom, versionTriggerFrom
+			username, pipTriggerFrom, versionTriggerFrom, retried, scheduled_trigger
 		FROM (
 			(SELECT
 				distinct on (pipeline_id, environment_id) pipeline_id, environment_id, application_id, project_id,
@@ -563,7 +596,7 @@ func GetAllLastBuildByApplicationAndVersion(db database.Querier, applicationID i
 				build_number, version, status,
 				start, done,
 				manual_trigger, triggered_by, parent_pipeline_build_id, vcs_changes_branch, vcs_changes_hash, vcs_changes_author,
-				username, pipTriggerFrom, versionTriggerFrom
+				username, pipTriggerFrom, versionTriggerFrom, retried, scheduled_trigger
 			FROM load_pb
 			ORDER BY pipeline_id, environment_id, build_number DESC)
 
@@ -576,7 +609,7 @@ func GetAllLastBuildByApplicationAndVersion(db database.Querier, applicationID i
 				build_number, version, status,
 				start, done,
 				manual_trigger, triggered_by, parent_pipeline_build_id, vcs_changes_branch, vcs_changes_hash, vcs_changes_author,
-				username, pipTriggerFrom, versionTriggerFrom
+				username, pipTriggerFrom, versionTriggerFrom, retried, scheduled_trigger
 			FROM load_history
 			ORDER BY pipeline_id, environment_id, build_number DESC)
 		) as pb
@@ -621,7 +654,7 @@ func GetAllLastBuildByApplication(db database.Querier, applicationID int64, bran
 			build_number, version, status,
 			start, done,
 			manual_trigger, triggered_by, parent_pipeline_build_id, vcs_changes_branch, vcs_changes_hash, vcs_changes_author,
-			username, pipTriggerFrom, versionTriggerFrom
+			username, pipTriggerFrom, versionTriggerFrom, retried, scheduled_trigger
 		FROM (
 			(SELECT
 				distinct on (pipeline_id, environment_id) pipeline_id, environment_id, application_id, project_id,
@@ -630,7 +663,7 @@ func GetAllLastBuildByApplication(db database.Querier, applicationID int64, bran
 				build_number, version, status,
 				start, done,
 				manual_trigger, triggered_by, parent_pipeline_build_id, vcs_changes_branch, vcs_changes_hash, vcs_changes_author,
-				username, pipTriggerFrom, versionTriggerFrom
+				username, pipTriggerFrom, versionTriggerFrom, retried, scheduled_trigger
 			FROM load_pb
 			ORDER BY pipeline_id, environment_id, build_number DESC)
 
@@ -643,7 +676,7 @@ func GetAllLastBuildByApplication(db database.Querier, applicationID int64, bran
 				build_number, version, status,
 				start, done,
 				manual_trigger, triggered_by, parent_pipeline_build_id, vcs_changes_branch, vcs_changes_hash, vcs_changes_author,
-				username, pipTriggerFrom, versionTriggerFrom
+				username, pipTriggerFrom, versionTriggerFrom, retried, scheduled_trigger
 			FROM load_history
 			ORDER BY pipeline_id, environment_id, build_number DESC)
 		) as pb
@@ -698,6 +731,8 @@ func scanPbShort(p *sdk.PipelineBuild, rows database.Scanner) error {
 	var manual sql.NullBool
 	var trigBy, pPbID, version sql.NullInt64
 	var branch, hash, author, fromUser, fromPipeline sql.NullString
+	var retried, scheduled sql.NullBool
+	var pipelineVersion sql.NullInt64
 
 	err := rows.Scan(&p.Pipeline.ID, &p.Application.ID, &p.Environment.ID, &p.ID, &p.Pipeline.ProjectID,
 		&p.Environment.Name, &p.Application.Name, &p.Pipeline.Name, &p.Pipeline.ProjectKey,
@@ -705,12 +740,15 @@ func scanPbShort(p *sdk.PipelineBuild, rows database.Scanner) error {
 		&p.BuildNumber, &p.Version, &status,
 		&p.Start, &p.Done,
 		&manual, &trigBy, &pPbID, &branch, &hash, &author,
-		&fromUser, &fromPipeline, &version)
+		&fromUser, &fromPipeline, &version, &retried, &scheduled, &pipelineVersion)
 	if err != nil {
 		log.Warning("scanPbShort> Error while loading build information: %s", err)
 		return err
 	}
 	p.Status = sdk.StatusFromString(status)
+	p.Retried = retried.Bool
+	p.Trigger.ScheduledTrigger = scheduled.Bool
+	p.PipelineVersion = pipelineVersion.Int64
 	p.Pipeline.Type = sdk.PipelineTypeFromString(typePipeline)
 	p.Application.ProjectKey = p.Pipeline.ProjectKey
 	loadPbTrigger(p, manual, pPbID, branch, hash, author, fromUser, fromPipeline, version)
@@ -990,13 +1028,33 @@ func InsertPipelineBuild(tx *sql.Tx, project *sdk.Project, p *sdk.Pipeline, appl
 		return pb, err
 	}
 
+	// Record which version of the pipeline definition is run, the first build of a pipeline never versionned creates it
+	pb.PipelineVersion, err = CurrentVersion(tx, p.ID)
+	if err != nil {
+		log.Warning("InsertPipelineBuild> Cannot load pipeline version: %s\n", err)
+		return pb, err
+	}
+	if pb.PipelineVersion == 0 {
+		pb.PipelineVersion, err = CreateAudit(tx, p.ID, trigger.TriggeredBy, "Initial version")
+		if err != nil {
+			log.Warning("InsertPipelineBuild> Cannot create pipeline version: %s\n", err)
+			return pb, err
+		}
+	}
+
+	// Queue the build or stop older ones according to the concurrency policy of the pipeline
+	pb.Status, err = concurrencyStatus(tx, applicationData.ID, p.ID, env.ID, pb.Trigger.VCSChangesBranch)
+	if err != nil {
+		log.Warning("InsertPipelineBuild> Cannot apply concurrency policy: %s\n", err)
+		return pb, err
+	}
+
 	err = insertPipelineBuild(tx, string(argsJSON), applicationData.ID, p.ID, &pb, env.ID)
 	if err != nil {
 		log.Warning("InsertPipelineBuild> Cannot insert pipeline build: %s\n", err)
 		return pb, err
 	}
 
-	pb.Status = sdk.StatusBuilding
 	pb.Pipeline = *p
 	pb.Parameters = params
 	pb.Application = *applicationData
@@ -1040,14 +1098,15 @@ func InsertPipelineBuild(tx *sql.Tx, project *sdk.Project, p *sdk.Pipeline, appl
 		}
 	}
 
-	notification.SendPipeline(tx, &pb, sdk.CreateNotifEvent, sdk.StatusBuilding, previous)
+	notification.SendPipeline(tx, &pb, sdk.CreateNotifEvent, pb.Status, previous)
 
 	return pb, nil
 }
 
 func insertPipelineBuild(db database.QueryExecuter, args string, applicationID, pipelineID int64, pb *sdk.PipelineBuild, envID int64) error {
-	query := `INSERT INTO pipeline_build (pipeline_id, build_number, version, status, args, start, application_id,environment_id, done, manual_trigger, triggered_by, parent_pipeline_build_id, vcs_changes_branch, vcs_changes_hash, vcs_changes_author)
-						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id`
+	// Builds start with the priority of their pipeline
+	query := `INSERT INTO pipeline_build (pipeline_id, build_number, version, status, args, start, application_id,environment_id, done, manual_trigger, triggered_by, parent_pipeline_build_id, vcs_changes_branch, vcs_changes_hash, vcs_changes_author, pipeline_version, scheduled_trigger, priority)
+						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, COALESCE((SELECT priority FROM pipeline WHERE id = $1), 0)) RETURNING id, priority`
 
 	var triggeredBy, parentPipelineID int64
 	if pb.Trigger.TriggeredBy != nil {
@@ -1058,16 +1117,20 @@ func insertPipelineBuild(db database.QueryExecuter, args string, applicationID,
 	}
 
 	statement := db.QueryRow(
-		query, pipelineID, pb.BuildNumber, pb.Version, sdk.StatusBuilding.String(),
+		query, pipelineID, pb.BuildNumber, pb.Version, pb.Status.String(),
 		args, time.Now(), applicationID, envID, time.Now(), pb.Trigger.ManualTrigger,
 		sql.NullInt64{Int64: triggeredBy, Valid: triggeredBy != 0},
 		sql.NullInt64{Int64: parentPipelineID, Valid: parentPipelineID != 0},
-		pb.Trigger.VCSChangesBranch, pb.Trigger.VCSChangesHash, pb.Trigger.VCSChangesAuthor)
-	err := statement.Scan(&pb.ID)
+		pb.Trigger.VCSChangesBranch, pb.Trigger.VCSChangesHash, pb.Trigger.VCSChangesAuthor, pb.PipelineVersion, pb.Trigger.ScheduledTrigger)
+	err := statement.Scan(&pb.ID, &pb.Priority)
 	if err != nil {
 		return fmt.Errorf("App:%d,Pip:%d,Env:%d> %s", applicationID, pipelineID, envID, err)
 	}
 
+	if pb.Status == sdk.StatusBuilding {
+		build.WakeScheduler(db, pb.ID)
+	}
+
 	return nil
 }
 
@@ -1324,13 +1387,40 @@ func LoadPipelineBuildChildren(db *sql.DB, pipelineID int64, applicationID int64
 }
 
 // StopPipelineBuild fails all currently building actions
-func StopPipelineBuild(db *sql.DB, pbID int64) error {
-	query := `UPDATE action_build SET status = $1, done = now() WHERE pipeline_build_id = $2 AND status IN ( $3, $4 )`
-	_, err := db.Exec(query, string(sdk.StatusFail), pbID, string(sdk.StatusBuilding), string(sdk.StatusWaiting))
+func StopPipelineBuild(db database.Executer, pbID int64) error {
+	query := `UPDATE action_build SET status = $1, done = now() WHERE pipeline_build_id = $2 AND status = $3`
+	_, err := db.Exec(query, string(sdk.StatusStopped), pbID, string(sdk.StatusWaiting))
 	if err != nil {
 		return err
 	}
 
+	// Running actions are stopped by their worker, which reports them as Stopped
+	if err := build.RequestCancel(db, pbID); err != nil {
+		return err
+	}
+
+	// Stages waiting for approval will not start
+	query = `UPDATE pipeline_build_gate SET status = $1, done = now() WHERE pipeline_build_id = $2 AND status = $3`
+	res, err := db.Exec(query, string(sdk.StatusFail), pbID, string(sdk.StatusWaitingApproval))
+	if err != nil {
+		return err
+	}
+	gates, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// A pipeline build queued, or waiting for approval with nothing else running, has no action build to stop
+	statuses := []string{string(sdk.StatusWaitingApproval), string(sdk.StatusWaiting)}
+	if gates > 0 {
+		statuses = append(statuses, string(sdk.StatusBuilding))
+	}
+	query = `UPDATE pipeline_build SET status = $1, done = now() WHERE id = $2 AND status = ANY($3)`
+	if _, err := db.Exec(query, string(sdk.StatusStopped), pbID, pq.Array(statuses)); err != nil {
+		return err
+	}
+	build.WakeScheduler(db, pbID)
+
 	// TODO: Add log to inform user
 
 	return nil
@@ -1356,7 +1446,7 @@ func RestartPipelineBuild(db *sql.DB, pb sdk.PipelineBuild) error {
 	defer tx.Rollback()
 
 	for _, ab := range actionBuilds {
-		if ab.Status != sdk.StatusDisabled && ab.Status != sdk.StatusSkipped && (ab.Status == sdk.StatusFail || pb.Status == sdk.StatusSuccess) {
+		if ab.Status != sdk.StatusDisabled && ab.Status != sdk.StatusSkipped && (ab.Status == sdk.StatusFail || ab.Status == sdk.StatusStopped || pb.Status == sdk.StatusSuccess) {
 			log.Notice("RestartPipelineBuild: Action %s: restarting\n", ab.ActionName)
 			err = RestartActionBuild(tx, ab.ID)
 			if err != nil {
@@ -1425,6 +1515,7 @@ func RestartPipelineBuild(db *sql.DB, pb sdk.PipelineBuild) error {
 	if err != nil {
 		return fmt.Errorf("RestartPipelineBuild> Cannot commit tx: %s", err)
 	}
+	build.QueueUpdated(db)
 
 	return nil
 }
@@ -1447,8 +1538,25 @@ func RestartActionBuild(db *sql.Tx, actionBuildID int64) error {
 		return err
 	}
 
+	// Delete previous attempts, restarted action build starts counting again
+	query = `DELETE FROM build_log WHERE action_build_id IN (
+			SELECT previous.id FROM action_build previous
+			JOIN action_build ON action_build.pipeline_build_id = previous.pipeline_build_id AND action_build.pipeline_action_id = previous.pipeline_action_id
+			WHERE action_build.id = $1 AND previous.retried = true)`
+	_, err = db.Exec(query, actionBuildID)
+	if err != nil {
+		return err
+	}
+	query = `DELETE FROM action_build previous USING action_build
+		WHERE action_build.id = $1 AND previous.retried = true
+		AND previous.pipeline_build_id = action_build.pipeline_build_id AND previous.pipeline_action_id = action_build.pipeline_action_id`
+	_, err = db.Exec(query, actionBuildID)
+	if err != nil {
+		return err
+	}
+
 	// Update status to Waiting
-	query = `UPDATE action_build SET status = $1 WHERE id = $2`
+	query = `UPDATE action_build SET status = $1, attempt = 1, awol = false, cancel_requested = NULL WHERE id = $2`
 	res, err := db.Exec(query, sdk.StatusWaiting.String(), actionBuildID)
 	if err != nil {
 		return err
@@ -1489,6 +1597,9 @@ func LoadCompletePipelineBuildToArchive(db database.Querier, pipelineBuildID int
 			 action_build.start,
 			 action_build.done,
 			 action_build.worker_model_name,
+			 action_build.attempt,
+			 action_build.retried,
+			 action_build.awol,
 			 action.name,
 			 pipeline_stage.id,
 			 pipeline_stage.name,
@@ -1504,7 +1615,10 @@ func LoadCompletePipelineBuildToArchive(db database.Querier, pipelineBuildID int
 			 pipeline_build.vcs_changes_author,
 			 "user".username,
 			 triggeredFromPip.name as trigPipName,
-			 triggeredFromPb.version as versionTriggerFrom
+			 triggeredFromPb.version as versionTriggerFrom,
+			 pipeline_build.pipeline_version,
+			 pipeline_build.retried,
+			 pipeline_build.scheduled_trigger
 		FROM pipeline_build
 		JOIN application ON application.id = pipeline_build.application_id
 		JOIN pipeline ON pipeline.id = pipeline_build.pipeline_id
@@ -1520,7 +1634,8 @@ func LoadCompletePipelineBuildToArchive(db database.Querier, pipelineBuildID int
 			SELECT pipeline_build_id, version, pipeline_id FROM pipeline_history
 		) as triggeredFromPb ON triggeredFromPb.id = pipeline_build.parent_pipeline_build_id
 		LEFT JOIN pipeline triggeredFromPip ON triggeredFromPip.id = triggeredFromPb.pipeline_id
-	 	WHERE pipeline_build.id = $1 AND (pipeline_build.status = $2 OR pipeline_build.status = $3)`
+	 	WHERE pipeline_build.id = $1 AND (pipeline_build.status = $2 OR pipeline_build.status = $3)
+		ORDER BY action_build.id`
 
 	rows, err := db.Query(query, pipelineBuildID, string(sdk.StatusSuccess), string(sdk.StatusFail))
 	if err != nil {
@@ -1539,7 +1654,8 @@ func LoadCompletePipelineBuildToArchive(db database.Querier, pipelineBuildID int
 		var manual sql.NullBool
 		var stageBuildOrder, stageID, actionBuildID, actionBuildPipelineActionID, trigBy, parentID sql.NullInt64
 		var stageName, actionBuildStatusTmp, actionBuildArgs, actionBuildActionName, branch, hash, author, username, trigPipname, actionBuildWorkerModelName sql.NullString
-		var version sql.NullInt64
+		var version, pipelineVersion, actionBuildAttempt sql.NullInt64
+		var actionBuildRetried, actionBuildAWOL, pbRetried, scheduled sql.NullBool
 
 		err = rows.Scan(
 			&pb.ID,
@@ -1560,6 +1676,9 @@ func LoadCompletePipelineBuildToArchive(db database.Querier, pipelineBuildID int
 			&actionStart,
 			&actionDone,
 			&actionBuildWorkerModelName,
+			&actionBuildAttempt,
+			&actionBuildRetried,
+			&actionBuildAWOL,
 			&actionBuildActionName,
 			&stageID,
 			&stageName,
@@ -1576,6 +1695,9 @@ func LoadCompletePipelineBuildToArchive(db database.Querier, pipelineBuildID int
 			&username,
 			&trigPipname,
 			&version,
+			&pipelineVersion,
+			&pbRetried,
+			&scheduled,
 		)
 		if err != nil {
 			log.Warning("LoadCompletePipelineBuildToArchive> Error scanning : %s", err)
@@ -1585,12 +1707,18 @@ func LoadCompletePipelineBuildToArchive(db database.Querier, pipelineBuildID int
 		if pbDone.Valid {
 			pb.Done = pbDone.Time
 		}
+		pb.PipelineVersion = pipelineVersion.Int64
+		pb.Retried = pbRetried.Bool
+		pb.Trigger.ScheduledTrigger = scheduled.Bool
 
 		if actionBuildID.Valid {
 			actionBuild.ID = actionBuildID.Int64
 			actionBuild.PipelineActionID = actionBuildPipelineActionID.Int64
 			actionBuild.ActionName = actionBuildActionName.String
 			actionBuild.Queued = actionQueued.Time
+			actionBuild.Attempt = int(actionBuildAttempt.Int64)
+			actionBuild.Retried = actionBuildRetried.Bool
+			actionBuild.AWOL = actionBuildAWOL.Bool
 			actionBuildStatus = actionBuildStatusTmp.String
 			abArgs = actionBuildArgs.String
 		}
@@ -1774,6 +1902,17 @@ func DeletePipelineBuild(db database.QueryExecuter, pipelineBuildID int64) error
 		return err
 	}
 
+	// Test case runs, coverage and findings outlive archived builds, not deleted ones
+	err = build.DeleteTestCaseRuns(db, pipelineBuildID)
+	if err != nil {
+		return err
+	}
+
+	err = build.DeleteCodeReports(db, pipelineBuildID)
+	if err != nil {
+		return err
+	}
+
 	// Then delete pipeline build data
 	err = build.DeleteBuild(db, pipelineBuildID)
 	if err != nil {
@@ -19,7 +19,8 @@ SELECT  ph.pipeline_id, ph.application_id, ph.environment_id, ph.pipeline_build_
 	ph.build_number, ph.version, ph.status,
 	ph.start, ph.done,
 	ph.manual_trigger, ph.triggered_by, ph.parent_pipeline_build_id, ph.vcs_changes_branch, ph.vcs_changes_hash, ph.vcs_changes_author,
-	"user".username, pipTriggerFrom.name as pipTriggerFrom, pbTriggerFrom.version as versionTriggerFrom
+	"user".username, pipTriggerFrom.name as pipTriggerFrom, pbTriggerFrom.version as versionTriggerFrom,
+	ph.retried, ph.scheduled_trigger, NULL as pipeline_version
 FROM pipeline_history ph
 JOIN environment ON environment.id = ph.environment_id
 JOIN application ON application.id = ph.application_id
@@ -153,7 +154,7 @@ func insertHistory(db database.Executer, data string, pb sdk.PipelineBuild) erro
 		version, done, manual_trigger,
 		triggered_by, parent_pipeline_build_id,
 		vcs_changes_branch, vcs_changes_hash, vcs_changes_author,
-		start, pipeline_build_id) VALUES (
+		start, pipeline_build_id, retried, scheduled_trigger) VALUES (
 		$1, $2,
 		$3, $4,
 		$5,
@@ -161,7 +162,7 @@ func insertHistory(db database.Executer, data string, pb sdk.PipelineBuild) erro
 		$7, $8, $9,
 		$10, $11,
 		$12, $13, $14,
-		$15, $16)`
+		$15, $16, $17, $18)`
 	_, err := db.Exec(query,
 		pb.Pipeline.ID, pb.Application.ID,
 		pb.BuildNumber, string(pb.Status),
@@ -170,7 +171,7 @@ func insertHistory(db database.Executer, data string, pb sdk.PipelineBuild) erro
 		pb.Version, pb.Done, pb.Trigger.ManualTrigger,
 		userID, pbParentID,
 		pb.Trigger.VCSChangesBranch, pb.Trigger.VCSChangesHash, pb.Trigger.VCSChangesAuthor,
-		pb.Start, pb.ID,
+		pb.Start, pb.ID, pb.Retried, pb.Trigger.ScheduledTrigger,
 	)
 	return err
 }
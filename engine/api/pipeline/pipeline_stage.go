@@ -25,7 +25,7 @@ var (
 // LoadStage Get a stage from its ID and pipeline ID
 func LoadStage(db database.Querier, pipelineID int64, stageID int64) (*sdk.Stage, error) {
 	query := `
-		SELECT pipeline_stage.id, pipeline_stage.pipeline_id, pipeline_stage.name, pipeline_stage.build_order, pipeline_stage.enabled, pipeline_stage_prerequisite.parameter, pipeline_stage_prerequisite.expected_value
+		SELECT pipeline_stage.id, pipeline_stage.pipeline_id, pipeline_stage.name, pipeline_stage.build_order, pipeline_stage.enabled, pipeline_stage.timeout, pipeline_stage.approval, pipeline_stage.needs, pipeline_stage_prerequisite.parameter, pipeline_stage_prerequisite.expected_value
 		FROM pipeline_stage
 		LEFT OUTER JOIN pipeline_stage_prerequisite ON pipeline_stage_prerequisite.pipeline_stage_id = pipeline_stage.id
 		WHERE pipeline_stage.pipeline_id = $1 
@@ -45,8 +45,16 @@ func LoadStage(db database.Querier, pipelineID int64, stageID int64) (*sdk.Stage
 	defer rows.Close()
 
 	for rows.Next() {
-		var parameter, expectedValue sql.NullString
-		rows.Scan(&stage.ID, &stage.PipelineID, &stage.Name, &stage.BuildOrder, &stage.Enabled, &parameter, &expectedValue)
+		var parameter, expectedValue, approval, needs sql.NullString
+		var timeout sql.NullInt64
+		rows.Scan(&stage.ID, &stage.PipelineID, &stage.Name, &stage.BuildOrder, &stage.Enabled, &timeout, &approval, &needs, &parameter, &expectedValue)
+		stage.Timeout = timeout.Int64
+		if stage.Approval, err = parseStageApproval(approval); err != nil {
+			return nil, err
+		}
+		if stage.Needs, err = parseStageNeeds(needs); err != nil {
+			return nil, err
+		}
 		if parameter.Valid && expectedValue.Valid {
 			p := sdk.Prerequisite{
 				Parameter:     parameter.String,
@@ -62,9 +70,17 @@ func LoadStage(db database.Querier, pipelineID int64, stageID int64) (*sdk.Stage
 // InsertStage insert given stage into given database
 func InsertStage(db database.QueryExecuter, s *sdk.Stage) error {
 	s.Enabled = true
-	query := `INSERT INTO "pipeline_stage" (pipeline_id, name, build_order, enabled) VALUES($1,$2,$3,$4) RETURNING id`
+	query := `INSERT INTO "pipeline_stage" (pipeline_id, name, build_order, enabled, timeout, approval, needs) VALUES($1,$2,$3,$4,$5,$6,$7) RETURNING id`
 
-	if err := db.QueryRow(query, s.PipelineID, s.Name, s.BuildOrder, true).Scan(&s.ID); err != nil {
+	approval, err := stageApprovalJSON(s.Approval)
+	if err != nil {
+		return err
+	}
+	needs, err := stageNeedsJSON(s.Needs)
+	if err != nil {
+		return err
+	}
+	if err := db.QueryRow(query, s.PipelineID, s.Name, s.BuildOrder, true, s.Timeout, approval, needs).Scan(&s.ID); err != nil {
 		return err
 	}
 	return InsertStagePrequisites(db, s)
@@ -96,7 +112,7 @@ func LoadStages(db *sql.DB, pipelineID int64) ([]sdk.Stage, error) {
 	var stages []sdk.Stage
 
 	query := `
-		SELECT pipeline_stage.id, pipeline_stage.name, pipeline_stage.enabled, pipeline_stage_prerequisite.parameter, pipeline_stage_prerequisite.expected_value
+		SELECT pipeline_stage.id, pipeline_stage.name, pipeline_stage.build_order, pipeline_stage.enabled, pipeline_stage.timeout, pipeline_stage.approval, pipeline_stage.needs, pipeline_stage_prerequisite.parameter, pipeline_stage_prerequisite.expected_value
 		FROM pipeline_stage
 		LEFT OUTER JOIN pipeline_stage_prerequisite ON pipeline_stage_prerequisite.pipeline_stage_id = pipeline_stage.id
 	 	WHERE pipeline_id = $1 
@@ -113,9 +129,11 @@ func LoadStages(db *sql.DB, pipelineID int64) ([]sdk.Stage, error) {
 
 	for rows.Next() {
 		var id int64
+		var buildOrder int
 		var enabled bool
-		var name, parameter, expectedValue sql.NullString
-		err = rows.Scan(&id, &name, &enabled, &parameter, &expectedValue)
+		var name, approval, needs, parameter, expectedValue sql.NullString
+		var timeout sql.NullInt64
+		err = rows.Scan(&id, &name, &buildOrder, &enabled, &timeout, &approval, &needs, &parameter, &expectedValue)
 		if err != nil {
 			return stages, err
 		}
@@ -123,9 +141,17 @@ func LoadStages(db *sql.DB, pipelineID int64) ([]sdk.Stage, error) {
 		var stageData = mapStages[id]
 		if stageData == nil {
 			stageData = &sdk.Stage{
-				ID:      id,
-				Name:    name.String,
-				Enabled: enabled,
+				ID:         id,
+				Name:       name.String,
+				BuildOrder: buildOrder,
+				Enabled:    enabled,
+				Timeout:    timeout.Int64,
+			}
+			if stageData.Approval, err = parseStageApproval(approval); err != nil {
+				return stages, err
+			}
+			if stageData.Needs, err = parseStageNeeds(needs); err != nil {
+				return stages, err
 			}
 			mapStages[id] = stageData
 		}
@@ -153,13 +179,13 @@ func loadPipelineStage(db database.Querier, p *sdk.Pipeline, args ...FuncArg) er
 
 	query := `
 	SELECT  pipeline_stage_R.id as stage_id, pipeline_stage_R.pipeline_id, pipeline_stage_R.name, pipeline_stage_R.last_modified, 
-			pipeline_stage_R.build_order, pipeline_stage_R.enabled, pipeline_stage_R.parameter, 
+			pipeline_stage_R.build_order, pipeline_stage_R.enabled, pipeline_stage_R.timeout, pipeline_stage_R.approval, pipeline_stage_R.needs, pipeline_stage_R.parameter, 
 			pipeline_stage_R.expected_value, pipeline_action_R.id as pipeline_action_id, pipeline_action_R.action_id, pipeline_action_R.action_last_modified,
-			pipeline_action_R.action_args, pipeline_action_R.action_enabled
+			pipeline_action_R.action_args, pipeline_action_R.action_enabled, pipeline_action_R.action_retry, pipeline_action_R.action_timeout
 	FROM (
 		SELECT  pipeline_stage.id, pipeline_stage.pipeline_id, 
 				pipeline_stage.name, pipeline_stage.last_modified ,pipeline_stage.build_order, 
-				pipeline_stage.enabled, 
+				pipeline_stage.enabled, pipeline_stage.timeout, pipeline_stage.approval, pipeline_stage.needs, 
 				pipeline_stage_prerequisite.parameter, pipeline_stage_prerequisite.expected_value
 		FROM pipeline_stage
 		LEFT OUTER JOIN pipeline_stage_prerequisite ON pipeline_stage.id = pipeline_stage_prerequisite.pipeline_stage_id
@@ -168,7 +194,7 @@ func loadPipelineStage(db database.Querier, p *sdk.Pipeline, args ...FuncArg) er
 	LEFT OUTER JOIN (
 		SELECT  pipeline_action.id, action.id as action_id, action.name as action_name, action.last_modified as action_last_modified, 
 				pipeline_action.args as action_args, pipeline_action.enabled as action_enabled, 
-				pipeline_action.pipeline_stage_id
+				pipeline_action.retry as action_retry, pipeline_action.timeout as action_timeout, pipeline_action.pipeline_stage_id
 		FROM action
 		JOIN pipeline_action ON pipeline_action.action_id = action.id
 	) as pipeline_action_R ON pipeline_action_R.pipeline_stage_id = pipeline_stage_R.id
@@ -184,22 +210,23 @@ func loadPipelineStage(db database.Querier, p *sdk.Pipeline, args ...FuncArg) er
 	mapAllActions := map[int64]*sdk.Action{}
 	mapActionsStages := map[int64][]sdk.Action{}
 	mapArgs := map[int64][]string{}
+	mapRetries := map[int64]*sdk.ActionRetry{}
 	stagesPtr := []*sdk.Stage{}
 
 	for rows.Next() {
 		var stageID, pipelineID int64
 		var stageBuildOrder int
-		var pipelineActionID, actionID sql.NullInt64
+		var pipelineActionID, actionID, stageTimeout, actionTimeout sql.NullInt64
 		var stageName string
-		var stagePrerequisiteParameter, stagePrerequisiteExpectedValue, actionArgs sql.NullString
+		var stageApproval, stageNeeds, stagePrerequisiteParameter, stagePrerequisiteExpectedValue, actionArgs, actionRetry sql.NullString
 		var stageEnabled, actionEnabled sql.NullBool
 		var stageLastModified, actionLastModified pq.NullTime
 
 		err = rows.Scan(
 			&stageID, &pipelineID, &stageName, &stageLastModified,
-			&stageBuildOrder, &stageEnabled, &stagePrerequisiteParameter,
+			&stageBuildOrder, &stageEnabled, &stageTimeout, &stageApproval, &stageNeeds, &stagePrerequisiteParameter,
 			&stagePrerequisiteExpectedValue, &pipelineActionID, &actionID, &actionLastModified,
-			&actionArgs, &actionEnabled)
+			&actionArgs, &actionEnabled, &actionRetry, &actionTimeout)
 		if err != nil {
 			return err
 		}
@@ -214,6 +241,13 @@ func loadPipelineStage(db database.Querier, p *sdk.Pipeline, args ...FuncArg) er
 				Enabled:      stageEnabled.Bool,
 				BuildOrder:   stageBuildOrder,
 				LastModified: stageLastModified.Time.Unix(),
+				Timeout:      stageTimeout.Int64,
+			}
+			if stageData.Approval, err = parseStageApproval(stageApproval); err != nil {
+				return err
+			}
+			if stageData.Needs, err = parseStageNeeds(stageNeeds); err != nil {
+				return err
 			}
 			mapStages[stageID] = stageData
 			stagesPtr = append(stagesPtr, stageData)
@@ -249,10 +283,19 @@ func loadPipelineStage(db database.Querier, p *sdk.Pipeline, args ...FuncArg) er
 					ID:               actionID.Int64,
 					Enabled:          actionEnabled.Bool,
 					LastModified:     actionLastModified.Time.Unix(),
+					Timeout:          actionTimeout.Int64,
 				}
 				mapAllActions[pipelineActionID.Int64] = a
 				mapActionsStages[stageID] = append(mapActionsStages[stageID], *a)
 				mapArgs[stageID] = append(mapArgs[stageID], actionArgs.String)
+
+				if actionRetry.Valid && actionRetry.String != "" {
+					var retry sdk.ActionRetry
+					if err := json.Unmarshal([]byte(actionRetry.String), &retry); err != nil {
+						return err
+					}
+					mapRetries[pipelineActionID.Int64] = &retry
+				}
 			}
 		}
 	}
@@ -276,6 +319,8 @@ func loadPipelineStage(db database.Querier, p *sdk.Pipeline, args ...FuncArg) er
 			a.Enabled = mapActionsStages[id][index].Enabled
 			a.PipelineStageID = id
 			a.PipelineActionID = mapActionsStages[id][index].PipelineActionID
+			a.Retry = mapRetries[a.PipelineActionID]
+			a.Timeout = mapActionsStages[id][index].Timeout
 
 			var pipelineActionParameter []sdk.Parameter
 			var isUpdated bool
@@ -303,8 +348,16 @@ func loadPipelineStage(db database.Querier, p *sdk.Pipeline, args ...FuncArg) er
 
 // UpdateStage update Stage and all its prequisites
 func UpdateStage(db database.QueryExecuter, s *sdk.Stage) error {
-	query := `UPDATE pipeline_stage SET name=$1, build_order=$2, enabled=$3 WHERE id=$4`
-	_, err := db.Exec(query, s.Name, s.BuildOrder, s.Enabled, s.ID)
+	query := `UPDATE pipeline_stage SET name=$1, build_order=$2, enabled=$3, timeout=$5, approval=$6, needs=$7 WHERE id=$4`
+	approval, err := stageApprovalJSON(s.Approval)
+	if err != nil {
+		return err
+	}
+	needs, err := stageNeedsJSON(s.Needs)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(query, s.Name, s.BuildOrder, s.Enabled, s.ID, s.Timeout, approval, needs)
 	if err != nil {
 		return err
 	}
@@ -421,32 +474,19 @@ func DeleteAllStage(db database.QueryExecuter, pipelineID int64, userID int64) e
 }
 
 // MoveStage Move a stage
-func MoveStage(db *sql.DB, stageToMove *sdk.Stage, newBuildOrder int) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
+func MoveStage(db database.QueryExecuter, stageToMove *sdk.Stage, newBuildOrder int) error {
 	if stageToMove.BuildOrder > newBuildOrder {
-		err = moveUpStages(tx, stageToMove.PipelineID, stageToMove.BuildOrder, newBuildOrder)
-		if err != nil {
+		if err := moveUpStages(db, stageToMove.PipelineID, stageToMove.BuildOrder, newBuildOrder); err != nil {
 			return err
 		}
 	} else if stageToMove.BuildOrder < newBuildOrder {
-		err = moveDownStages(tx, stageToMove.PipelineID, stageToMove.BuildOrder, newBuildOrder)
-		if err != nil {
+		if err := moveDownStages(db, stageToMove.PipelineID, stageToMove.BuildOrder, newBuildOrder); err != nil {
 			return err
 		}
 	}
 
 	stageToMove.BuildOrder = newBuildOrder
-	err = UpdateStage(tx, stageToMove)
-	if err != nil {
-		return err
-	}
-
-	return tx.Commit()
+	return UpdateStage(db, stageToMove)
 }
 
 func moveUpStages(db database.Executer, pipelineID int64, oldPosition, newPosition int) error {
@@ -469,6 +509,30 @@ func moveDownStages(db database.Executer, pipelineID int64, oldPosition, newPosi
 	return err
 }
 
+// stageNeedsJSON returns the value of pipeline_stage.needs for given stage names
+func stageNeedsJSON(needs []string) (sql.NullString, error) {
+	if len(needs) == 0 {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(needs)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// parseStageNeeds reads the value of pipeline_stage.needs
+func parseStageNeeds(s sql.NullString) ([]string, error) {
+	if !s.Valid || s.String == "" {
+		return nil, nil
+	}
+	var needs []string
+	if err := json.Unmarshal([]byte(s.String), &needs); err != nil {
+		return nil, err
+	}
+	return needs, nil
+}
+
 // CheckPrerequisites verifies that all prerequisite are matched before scheduling
 func CheckPrerequisites(s sdk.Stage, pb sdk.PipelineBuild) (bool, error) {
 	for {
@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"database/sql"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// SkipBuiltCommit checks whether the commit of a trigger was already built successfully by the pipeline
+// on the application and the environment, whatever the branch, when the pipeline skips already built commits.
+// If so, the skipped trigger is recorded with a link to the existing build, and SkipBuiltCommit returns true.
+func SkipBuiltCommit(db database.QueryExecuter, p *sdk.Pipeline, applicationID, environmentID int64, trigger sdk.PipelineBuildTrigger, origin string) (bool, error) {
+	if !p.SkipBuiltCommits || trigger.VCSChangesHash == "" {
+		return false, nil
+	}
+
+	query := `
+		SELECT id, build_number, vcs_changes_branch FROM (
+			SELECT id, build_number, vcs_changes_branch FROM pipeline_build
+			WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3 AND vcs_changes_hash = $4 AND status = $5
+			UNION ALL
+			SELECT pipeline_build_id, build_number, vcs_changes_branch FROM pipeline_history
+			WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3 AND vcs_changes_hash = $4 AND status = $5
+		) AS built
+		ORDER BY build_number DESC LIMIT 1`
+
+	var id, buildNumber sql.NullInt64
+	var branch sql.NullString
+	err := db.QueryRow(query, applicationID, p.ID, environmentID, trigger.VCSChangesHash, string(sdk.StatusSuccess)).Scan(&id, &buildNumber, &branch)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	query = `INSERT INTO pipeline_build_skipped (application_id, pipeline_id, environment_id, origin, vcs_changes_branch, vcs_changes_hash, vcs_changes_author, pipeline_build_id, build_number, build_branch)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	if _, err := db.Exec(query, applicationID, p.ID, environmentID, origin, trigger.VCSChangesBranch, trigger.VCSChangesHash, trigger.VCSChangesAuthor, id, buildNumber, branch); err != nil {
+		return false, err
+	}
+
+	log.Notice("SkipBuiltCommit> Skipping %s of pipeline %s on %s: commit %s already built by #%d on %s\n", origin, p.Name, trigger.VCSChangesBranch, trigger.VCSChangesHash, buildNumber.Int64, branch.String)
+	return true, nil
+}
+
+// LoadBuildSkips loads the latest triggers skipped since their commit was already built
+func LoadBuildSkips(db database.Querier, applicationID, pipelineID, environmentID int64, limit int) ([]sdk.PipelineBuildSkip, error) {
+	skips := []sdk.PipelineBuildSkip{}
+
+	query := `SELECT id, application_id, pipeline_id, environment_id, origin, vcs_changes_branch, vcs_changes_hash, vcs_changes_author, pipeline_build_id, build_number, build_branch, skipped
+		FROM pipeline_build_skipped
+		WHERE application_id = $1 AND pipeline_id = $2 AND environment_id = $3
+		ORDER BY skipped DESC LIMIT $4`
+	rows, err := db.Query(query, applicationID, pipelineID, environmentID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s sdk.PipelineBuildSkip
+		var branch, author, buildBranch sql.NullString
+		var pbID, buildNumber sql.NullInt64
+		if err := rows.Scan(&s.ID, &s.ApplicationID, &s.PipelineID, &s.EnvironmentID, &s.Origin, &branch, &s.Hash, &author, &pbID, &buildNumber, &buildBranch, &s.Skipped); err != nil {
+			return nil, err
+		}
+		s.Branch = branch.String
+		s.Author = author.String
+		s.PipelineBuildID = pbID.Int64
+		s.BuildNumber = buildNumber.Int64
+		s.BuildBranch = buildBranch.String
+		skips = append(skips, s)
+	}
+	return skips, rows.Err()
+}
@@ -0,0 +1,235 @@
+package pipeline
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ovh/cds/engine/api/build"
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/notification"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// Kinds of timeout an action build can hit
+const (
+	actionTimeout   = "action"
+	stageTimeout    = "stage"
+	pipelineTimeout = "pipeline"
+)
+
+type timedOutAction struct {
+	id      int64
+	timeout int64
+	kind    string
+}
+
+// TimeoutKiller will search in database for actions :
+// - Building for longer than their own timeout
+// - Waiting or building in a stage or a pipeline build running for longer than its timeout
+// and for stage gates waiting for approval for longer than their timeout
+func TimeoutKiller() {
+	// If this goroutine exits, then it's a crash
+	defer log.Fatalf("Goroutine of pipeline.TimeoutKiller exited - Exit CDS Engine")
+
+	for {
+		time.Sleep(10 * time.Second)
+		db := database.DB()
+
+		if db != nil {
+			actions, err := loadTimedOutActionBuild(db)
+			if err != nil {
+				log.Warning("TimeoutKiller> Cannot load timed out actions: %s\n", err)
+			}
+
+			for _, a := range actions {
+				if err := killTimedOutAction(db, a); err != nil {
+					log.Warning("TimeoutKiller> Cannot kill action build %d: %s\n", a.id, err)
+					time.Sleep(1 * time.Second) // Do not spam an unavailable database
+				}
+			}
+
+			gates, err := loadTimedOutGates(db)
+			if err != nil {
+				log.Warning("TimeoutKiller> Cannot load timed out stage gates: %s\n", err)
+			}
+
+			for _, id := range gates {
+				if err := failTimedOutGate(db, id); err != nil {
+					log.Warning("TimeoutKiller> Cannot fail stage gate %d: %s\n", id, err)
+					time.Sleep(1 * time.Second) // Do not spam an unavailable database
+				}
+			}
+		}
+	}
+}
+
+func killTimedOutAction(db *sql.DB, a timedOutAction) error {
+	log.Warning("killTimedOutAction> Killing action_build %d (%s timeout)\n", a.id, a.kind)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Action may have ended since it was loaded
+	var status string
+	query := `SELECT status FROM action_build WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(query, a.id).Scan(&status); err != nil {
+		return err
+	}
+	if status != sdk.StatusWaiting.String() && status != sdk.StatusBuilding.String() {
+		return nil
+	}
+
+	msg := fmt.Sprintf("Timed out after %s (%s timeout)\n", time.Duration(a.timeout)*time.Second, a.kind)
+	if err := build.InsertLog(tx, a.id, "SYSTEM", msg); err != nil {
+		return err
+	}
+
+	// Waiting actions cannot go through build.UpdateActionBuildStatus
+	query = `UPDATE action_build SET status = $1, done = $2, timed_out = true WHERE id = $3`
+	if _, err := tx.Exec(query, sdk.StatusFail.String(), time.Now(), a.id); err != nil {
+		return err
+	}
+	notification.SendActionBuild(tx, &sdk.ActionBuild{ID: a.id, Status: sdk.StatusFail}, sdk.UpdateNotifEvent, sdk.StatusFail)
+
+	// The worker kills the action on its side once its timeout is reached, it is free for another one
+	query = `UPDATE worker SET status = $1, action_build_id = NULL WHERE action_build_id = $2`
+	if _, err := tx.Exec(query, sdk.StatusWaiting.String(), a.id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// loadTimedOutActionBuild returns waiting and building actions which exceeded
+// their action timeout, their stage timeout or their pipeline timeout
+func loadTimedOutActionBuild(db *sql.DB) ([]timedOutAction, error) {
+	queries := map[string]string{
+		actionTimeout: `
+		SELECT action_build.id, action_build.timeout FROM action_build
+		WHERE action_build.status = 'Building'
+		AND action_build.timeout > 0
+		AND action_build.start + action_build.timeout * INTERVAL '1 second' < NOW()`,
+		stageTimeout: `
+		SELECT action_build.id, pipeline_stage.timeout FROM action_build
+		JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
+		JOIN pipeline_stage ON pipeline_stage.id = pipeline_action.pipeline_stage_id
+		JOIN (
+			SELECT ab.pipeline_build_id, pa.pipeline_stage_id, MIN(ab.queued) AS started FROM action_build ab
+			JOIN pipeline_action pa ON pa.id = ab.pipeline_action_id
+			JOIN pipeline_build pb ON pb.id = ab.pipeline_build_id
+			WHERE pb.status = 'Building'
+			GROUP BY ab.pipeline_build_id, pa.pipeline_stage_id
+		) stage_build ON stage_build.pipeline_build_id = action_build.pipeline_build_id AND stage_build.pipeline_stage_id = pipeline_stage.id
+		WHERE action_build.status IN ('Waiting', 'Building')
+		AND pipeline_stage.timeout > 0
+		AND stage_build.started + pipeline_stage.timeout * INTERVAL '1 second' < NOW()`,
+		pipelineTimeout: `
+		SELECT action_build.id, pipeline.timeout FROM action_build
+		JOIN pipeline_build ON pipeline_build.id = action_build.pipeline_build_id
+		JOIN pipeline ON pipeline.id = pipeline_build.pipeline_id
+		WHERE action_build.status IN ('Waiting', 'Building')
+		AND pipeline.timeout > 0
+		AND pipeline_build.start + pipeline.timeout * INTERVAL '1 second' < NOW()`,
+	}
+
+	var actions []timedOutAction
+	seen := map[int64]bool{}
+	// Report the narrowest timeout first
+	for _, kind := range []string{actionTimeout, stageTimeout, pipelineTimeout} {
+		rows, err := db.Query(queries[kind])
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			a := timedOutAction{kind: kind}
+			if err := rows.Scan(&a.id, &a.timeout); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if !seen[a.id] {
+				seen[a.id] = true
+				actions = append(actions, a)
+			}
+		}
+		rows.Close()
+	}
+
+	return actions, nil
+}
+
+// loadTimedOutGates returns stage gates waiting for approval for longer than their timeout
+func loadTimedOutGates(db *sql.DB) ([]int64, error) {
+	query := `SELECT id FROM pipeline_build_gate
+		WHERE status = $1
+		AND timeout > 0
+		AND requested + timeout * INTERVAL '1 second' < NOW()`
+
+	rows, err := db.Query(query, sdk.StatusWaitingApproval.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// failTimedOutGate closes a stage gate nobody approved in time, stops the other stages and fails its pipeline build
+func failTimedOutGate(db *sql.DB, gateID int64) error {
+	var pipelineID, applicationID, environmentID, buildNumber int64
+	query := `SELECT pipeline_build.pipeline_id, pipeline_build.application_id, pipeline_build.environment_id, pipeline_build.build_number
+		FROM pipeline_build_gate
+		JOIN pipeline_build ON pipeline_build.id = pipeline_build_gate.pipeline_build_id
+		WHERE pipeline_build_gate.id = $1`
+	if err := db.QueryRow(query, gateID).Scan(&pipelineID, &applicationID, &environmentID, &buildNumber); err != nil {
+		return err
+	}
+	pb, err := LoadPipelineBuild(db, pipelineID, applicationID, buildNumber, environmentID, WithParameters())
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Gate may have been approved or rejected since it was loaded
+	var status string
+	var timeout int64
+	query = `SELECT status, timeout FROM pipeline_build_gate WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(query, gateID).Scan(&status, &timeout); err != nil {
+		return err
+	}
+	if status != sdk.StatusWaitingApproval.String() {
+		return nil
+	}
+
+	log.Warning("failTimedOutGate> Pipeline build %d not approved after %s\n", pb.ID, time.Duration(timeout)*time.Second)
+	if err := closeGate(tx, &sdk.StageGate{ID: gateID}, sdk.StatusFail); err != nil {
+		return err
+	}
+	// Stages which do not need the gated one may be running, stop them
+	if err := StopPipelineBuild(tx, pb.ID); err != nil {
+		return err
+	}
+	if err := UpdatePipelineBuildStatus(tx, pb, sdk.StatusFail); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/api/sanity"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+func getPipelineAuditHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+	pipName := vars["permPipelineKey"]
+
+	p, err := pipeline.LoadPipeline(db, key, pipName, false)
+	if err != nil {
+		log.Warning("getPipelineAuditHandler> Cannot load pipeline %s: %s\n", pipName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	audits, err := pipeline.LoadAudits(db, p.ID)
+	if err != nil {
+		log.Warning("getPipelineAuditHandler> Cannot load audit of pipeline %s: %s\n", pipName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, audits, http.StatusOK)
+}
+
+// getPipelineAuditDiffHandler compares two versions of a pipeline definition.
+// Without "to", the version is compared to the current definition.
+func getPipelineAuditDiffHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+	pipName := vars["permPipelineKey"]
+
+	if err := r.ParseForm(); err != nil {
+		log.Warning("getPipelineAuditDiffHandler> Cannot parse form: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	fromVersion, err := strconv.ParseInt(r.Form.Get("from"), 10, 64)
+	if err != nil {
+		log.Warning("getPipelineAuditDiffHandler> from version must be an int: %s\n", err)
+		WriteError(w, r, sdk.ErrInvalidID)
+		return
+	}
+
+	current, err := pipeline.LoadPipeline(db, key, pipName, true)
+	if err != nil {
+		log.Warning("getPipelineAuditDiffHandler> Cannot load pipeline %s: %s\n", pipName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	from, err := pipeline.LoadAudit(db, current.ID, fromVersion)
+	if err != nil {
+		log.Warning("getPipelineAuditDiffHandler> Cannot load version %d of pipeline %s: %s\n", fromVersion, pipName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	to := current
+	if r.Form.Get("to") != "" {
+		toVersion, err := strconv.ParseInt(r.Form.Get("to"), 10, 64)
+		if err != nil {
+			log.Warning("getPipelineAuditDiffHandler> to version must be an int: %s\n", err)
+			WriteError(w, r, sdk.ErrInvalidID)
+			return
+		}
+		a, err := pipeline.LoadAudit(db, current.ID, toVersion)
+		if err != nil {
+			log.Warning("getPipelineAuditDiffHandler> Cannot load version %d of pipeline %s: %s\n", toVersion, pipName, err)
+			WriteError(w, r, err)
+			return
+		}
+		to = &a.Pipeline
+	}
+
+	changes := exportentities.Diff(exportentities.NewPipeline(&from.Pipeline), exportentities.NewPipeline(to))
+	if changes == nil {
+		changes = []sdk.PipelineChange{}
+	}
+	WriteJSON(w, r, changes, http.StatusOK)
+}
+
+// restorePipelineAuditHandler applies a previous version of the pipeline definition.
+// The pipeline keeps its current name, restoring creates a new version.
+func restorePipelineAuditHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+	pipName := vars["permPipelineKey"]
+
+	version, err := strconv.ParseInt(vars["version"], 10, 64)
+	if err != nil {
+		log.Warning("restorePipelineAuditHandler> Version must be an int: %s\n", err)
+		WriteError(w, r, sdk.ErrInvalidID)
+		return
+	}
+
+	proj, err := project.LoadProject(db, key, c.User)
+	if err != nil {
+		log.Warning("restorePipelineAuditHandler> Cannot load project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	current, err := pipeline.LoadPipeline(db, key, pipName, false)
+	if err != nil {
+		log.Warning("restorePipelineAuditHandler> Cannot load pipeline %s: %s\n", pipName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	audit, err := pipeline.LoadAudit(db, current.ID, version)
+	if err != nil {
+		log.Warning("restorePipelineAuditHandler> Cannot load version %d of pipeline %s: %s\n", version, pipName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	// Go through the portable definition so joined actions are rebuilt from their steps
+	p, err := exportentities.NewPipeline(&audit.Pipeline).Pipeline()
+	if err != nil {
+		log.Warning("restorePipelineAuditHandler> Invalid definition in version %d of pipeline %s: %s\n", version, pipName, err)
+		WriteError(w, r, err)
+		return
+	}
+	p.Name = current.Name
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Warning("restorePipelineAuditHandler> Cannot start transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := pipeline.ImportUpdate(tx, proj, p, c.User.ID); err != nil {
+		log.Warning("restorePipelineAuditHandler> Cannot restore version %d of pipeline %s: %s\n", version, pipName, err)
+		if _, ok := err.(*sdk.Error); !ok {
+			err = sdk.NewError(sdk.ErrWrongRequest, err)
+		}
+		WriteError(w, r, err)
+		return
+	}
+
+	if _, err := pipeline.CreateAudit(tx, current.ID, c.User, fmt.Sprintf("Restore version %d", version)); err != nil {
+		log.Warning("restorePipelineAuditHandler> Cannot create pipeline audit: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warning("restorePipelineAuditHandler> Cannot commit transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	cache.DeleteAll(cache.Key("application", key, "*"))
+	cache.Delete(cache.Key("pipeline", key, pipName))
+
+	p, err = pipeline.LoadPipeline(db, key, pipName, true)
+	if err != nil {
+		log.Warning("restorePipelineAuditHandler> Cannot reload pipeline: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	go sanity.CheckPipeline(db, proj, p)
+
+	WriteJSON(w, r, p, http.StatusOK)
+}
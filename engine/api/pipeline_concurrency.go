@@ -0,0 +1,121 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+func getPipelineConcurrencyHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	app, pip, env, err := loadConcurrencyTarget(r, db, c, permission.PermissionRead)
+	if err != nil {
+		log.Warning("getPipelineConcurrencyHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	concurrency, err := pipeline.LoadConcurrency(db, app.ID, pip.ID, env.ID)
+	if err != nil {
+		log.Warning("getPipelineConcurrencyHandler> Cannot load concurrency of pipeline %s: %s\n", pip.Name, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, concurrency, http.StatusOK)
+}
+
+func updatePipelineConcurrencyHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	app, pip, env, err := loadConcurrencyTarget(r, db, c, permission.PermissionReadWriteExecute)
+	if err != nil {
+		log.Warning("updatePipelineConcurrencyHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	var concurrency sdk.PipelineConcurrency
+	if err := json.Unmarshal(data, &concurrency); err != nil {
+		log.Warning("updatePipelineConcurrencyHandler> Cannot unmarshal body: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Warning("updatePipelineConcurrencyHandler> Cannot start transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := pipeline.UpdateConcurrencyPolicy(tx, app.ID, pip.ID, env.ID, concurrency.Policy); err != nil {
+		log.Warning("updatePipelineConcurrencyHandler> Cannot update concurrency policy of pipeline %s: %s\n", pip.Name, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := application.UpdateLastModified(tx, app); err != nil {
+		log.Warning("updatePipelineConcurrencyHandler> Cannot update application last_modified date: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warning("updatePipelineConcurrencyHandler> Cannot commit transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	getPipelineConcurrencyHandler(w, r, db, c)
+}
+
+// loadConcurrencyTarget loads the application, the pipeline and the environment a concurrency policy, or skipped builds, apply to
+func loadConcurrencyTarget(r *http.Request, db *sql.DB, c *context.Context, perm int) (*sdk.Application, *sdk.Pipeline, *sdk.Environment, error) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+	appName := vars["permApplicationName"]
+	pipelineName := vars["permPipelineKey"]
+
+	if err := r.ParseForm(); err != nil {
+		return nil, nil, nil, sdk.ErrWrongRequest
+	}
+	envName := r.Form.Get("envName")
+
+	app, err := application.LoadApplicationByName(db, key, appName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	pip, err := pipeline.LoadPipeline(db, key, pipelineName, false)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	env := &sdk.DefaultEnv
+	if envName != "" && envName != sdk.DefaultEnv.Name {
+		env, err = environment.LoadEnvironmentByName(db, key, envName)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !permission.AccessToEnvironment(env.ID, c.User, perm) {
+			return nil, nil, nil, sdk.ErrForbidden
+		}
+	}
+
+	return app, pip, env, nil
+}
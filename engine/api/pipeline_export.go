@@ -0,0 +1,51 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+func exportPipelineHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	pipelineName := vars["permPipelineKey"]
+
+	if err := r.ParseForm(); err != nil {
+		log.Warning("exportPipelineHandler> Cannot parse form: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	f, err := exportentities.GetFormat(r.Form.Get("format"))
+	if err != nil {
+		log.Warning("exportPipelineHandler> %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	p, err := pipeline.LoadPipeline(db, projectKey, pipelineName, true)
+	if err != nil {
+		log.Warning("exportPipelineHandler> Cannot load pipeline %s: %s\n", pipelineName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	data, err := exportentities.Marshal(exportentities.NewPipeline(p), f)
+	if err != nil {
+		log.Warning("exportPipelineHandler> Cannot marshal pipeline %s: %s\n", pipelineName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Add("Content-Type", f.ContentType())
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
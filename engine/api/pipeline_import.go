@@ -0,0 +1,147 @@
+package main
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/api/sanity"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+func importPipelineHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+
+	if err := r.ParseForm(); err != nil {
+		log.Warning("importPipelineHandler> Cannot parse form: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	f, err := exportentities.GetFormat(r.Form.Get("format"))
+	if err != nil {
+		log.Warning("importPipelineHandler> %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Warning("importPipelineHandler> Cannot read body: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	var e exportentities.Pipeline
+	if err := exportentities.Unmarshal(data, f, &e); err != nil {
+		log.Warning("importPipelineHandler> Cannot unmarshal body: %s\n", err)
+		WriteError(w, r, sdk.NewError(sdk.ErrWrongRequest, err))
+		return
+	}
+
+	p, err := e.Pipeline()
+	if err != nil {
+		log.Warning("importPipelineHandler> Invalid pipeline definition: %s\n", err)
+		WriteError(w, r, sdk.NewError(sdk.ErrWrongRequest, err))
+		return
+	}
+
+	// check pipeline name pattern
+	regexp := regexp.MustCompile(sdk.NamePattern)
+	if !regexp.MatchString(p.Name) {
+		log.Warning("importPipelineHandler> Pipeline name %s do not respect pattern %s", p.Name, sdk.NamePattern)
+		WriteError(w, r, sdk.ErrInvalidPipelinePattern)
+		return
+	}
+
+	proj, err := project.LoadProject(db, key, c.User)
+	if err != nil {
+		log.Warning("importPipelineHandler> Cannot load project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	exist, err := pipeline.ExistPipeline(db, proj.ID, p.Name)
+	if err != nil {
+		log.Warning("importPipelineHandler> Cannot check if pipeline %s exists: %s\n", p.Name, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if exist {
+		old, err := pipeline.LoadPipeline(db, key, p.Name, false)
+		if err != nil {
+			log.Warning("importPipelineHandler> Cannot load pipeline %s: %s\n", p.Name, err)
+			WriteError(w, r, err)
+			return
+		}
+		if permission.PipelinePermission(old.ID, c.User) < permission.PermissionReadWriteExecute {
+			log.Warning("importPipelineHandler> %s cannot update pipeline %s\n", c.User.Username, p.Name)
+			WriteError(w, r, sdk.ErrForbidden)
+			return
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Warning("importPipelineHandler> Cannot start transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if exist {
+		err = pipeline.ImportUpdate(tx, proj, p, c.User.ID)
+	} else {
+		err = pipeline.Import(tx, proj, p, c.User.ID)
+	}
+	if err != nil {
+		log.Warning("importPipelineHandler> Cannot import pipeline %s: %s\n", p.Name, err)
+		if _, ok := err.(*sdk.Error); !ok {
+			err = sdk.NewError(sdk.ErrWrongRequest, err)
+		}
+		WriteError(w, r, err)
+		return
+	}
+
+	if _, err := pipeline.CreateAudit(tx, p.ID, c.User, "Pipeline import"); err != nil {
+		log.Warning("importPipelineHandler> Cannot create pipeline audit: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warning("importPipelineHandler> Cannot commit transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	cache.DeleteAll(cache.Key("application", key, "*"))
+	cache.Delete(cache.Key("pipeline", key, p.Name))
+
+	p, err = pipeline.LoadPipeline(db, key, p.Name, true)
+	if err != nil {
+		log.Warning("importPipelineHandler> Cannot reload pipeline: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	go sanity.CheckPipeline(db, proj, p)
+
+	status := http.StatusCreated
+	if exist {
+		status = http.StatusOK
+	}
+	WriteJSON(w, r, p, status)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/testwithdb"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+func TestImportUpdatePipeline(t *testing.T) {
+	if testwithdb.DBDriver == "" {
+		t.SkipNow()
+		return
+	}
+	db, err := testwithdb.SetupPG(t)
+	assert.NoError(t, err)
+
+	u, _, err := testwithdb.InsertAdminUser(t, db)
+	assert.NoError(t, err)
+
+	key := testwithdb.RandomString(t, 10)
+	proj, err := testwithdb.InsertTestProject(t, db, key, key)
+	assert.NoError(t, err)
+	defer testwithdb.DeleteTestProject(t, db, key)
+
+	disabled := false
+	e := &exportentities.Pipeline{
+		Name: "build",
+		Type: string(sdk.BuildPipeline),
+		Parameters: []exportentities.Parameter{
+			{Name: "version", Type: string(sdk.StringParameter), Value: "1"},
+			{Name: "old", Type: string(sdk.StringParameter), Value: "x"},
+		},
+		Stages: []exportentities.Stage{
+			{
+				Name: "Compile",
+				Jobs: []exportentities.Job{
+					{
+						Name:  "make",
+						Steps: []exportentities.Step{{Action: sdk.ScriptAction, Parameters: map[string]string{"script": "make"}}},
+					},
+				},
+			},
+			{
+				Name: "Package",
+				Jobs: []exportentities.Job{
+					{
+						Name:  "tar",
+						Steps: []exportentities.Step{{Action: sdk.ScriptAction, Parameters: map[string]string{"script": "tar cf build.tar bin"}}},
+					},
+				},
+			},
+		},
+	}
+
+	p, err := e.Pipeline()
+	assert.NoError(t, err)
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, pipeline.Import(tx, proj, p, u.ID))
+	assert.NoError(t, tx.Commit())
+
+	before, err := pipeline.LoadPipeline(db, key, "build", true)
+	assert.NoError(t, err)
+	assert.Equal(t, e, exportentities.NewPipeline(before))
+
+	// Change a parameter, drop another, update a step, drop a stage, add one
+	updated := &exportentities.Pipeline{
+		Name:    "build",
+		Type:    string(sdk.BuildPipeline),
+		Timeout: 3600,
+		Parameters: []exportentities.Parameter{
+			{Name: "version", Type: string(sdk.StringParameter), Value: "2"},
+		},
+		Stages: []exportentities.Stage{
+			{
+				Name: "Compile",
+				Jobs: []exportentities.Job{
+					{
+						Name:    "make",
+						Timeout: 600,
+						Steps: []exportentities.Step{
+							{Action: sdk.ScriptAction, Parameters: map[string]string{"script": "make all"}},
+							{Action: sdk.ScriptAction, Enabled: &disabled, Final: true, Parameters: map[string]string{"script": "make clean"}},
+						},
+					},
+				},
+			},
+			{
+				Name:  "Deploy",
+				Needs: []string{"Compile"},
+				Jobs: []exportentities.Job{
+					{
+						Name:  "push",
+						Steps: []exportentities.Step{{Action: sdk.ScriptAction, Parameters: map[string]string{"script": "make deploy"}}},
+					},
+				},
+			},
+		},
+	}
+
+	p, err = updated.Pipeline()
+	assert.NoError(t, err)
+	tx, err = db.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, pipeline.ImportUpdate(tx, proj, p, u.ID))
+	assert.NoError(t, tx.Commit())
+
+	after, err := pipeline.LoadPipeline(db, key, "build", true)
+	assert.NoError(t, err)
+	assert.Equal(t, updated, exportentities.NewPipeline(after))
+
+	// Kept stages and jobs are updated in place
+	assert.Equal(t, before.ID, after.ID)
+	assert.Equal(t, before.Stages[0].ID, after.Stages[0].ID)
+	assert.Equal(t, before.Stages[0].Actions[0].ID, after.Stages[0].Actions[0].ID)
+
+	// Importing the same definition again changes nothing
+	p, err = updated.Pipeline()
+	assert.NoError(t, err)
+	tx, err = db.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, pipeline.ImportUpdate(tx, proj, p, u.ID))
+	assert.NoError(t, tx.Commit())
+
+	again, err := pipeline.LoadPipeline(db, key, "build", true)
+	assert.NoError(t, err)
+	assert.Equal(t, exportentities.NewPipeline(after), exportentities.NewPipeline(again))
+	assert.Equal(t, after.Stages[1].ID, again.Stages[1].ID)
+}
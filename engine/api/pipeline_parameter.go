@@ -74,6 +74,12 @@ func deleteParameterFromPipelineHandler(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, p.ID, c.User, "Parameter delete"); err != nil {
+		log.Warning("deleteParameterFromPipelineHandler> Cannot create pipeline audit: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Warning("deleteParameterFromPipelineHandler: Cannot commit transaction: %s\n", err)
@@ -205,6 +211,12 @@ func updateParametersInPipelineHandler(w http.ResponseWriter, r *http.Request, d
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, pip.ID, c.User, "Parameters update"); err != nil {
+		log.Warning("UpdatePipelineParameters> Cannot create pipeline audit: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Warning("updateParametersInPipelineHandler: Cannot commit transaction: %s", err)
@@ -277,6 +289,12 @@ func updateParameterInPipelineHandler(w http.ResponseWriter, r *http.Request, db
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, p.ID, c.User, "Parameter update"); err != nil {
+		log.Warning("updateParameterInPipelineHandler: Cannot create pipeline audit: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Warning("updateParameterInPipelineHandler: Cannot commit transaction:  %s\n", err)
@@ -353,6 +371,12 @@ func addParameterInPipelineHandler(w http.ResponseWriter, r *http.Request, db *s
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, p.ID, c.User, "Parameter add"); err != nil {
+		log.Warning("addParameterInPipelineHandler> Cannot create pipeline audit: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Warning("addParameterInPipelineHandler: Cannot commit transaction: %s\n", err)
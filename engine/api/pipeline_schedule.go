@@ -0,0 +1,210 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/schedule"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+func getPipelineSchedulesHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	app, pip, err := loadScheduleTarget(r, db)
+	if err != nil {
+		log.Warning("getPipelineSchedulesHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	schedules, err := schedule.LoadSchedules(db, app.ID, pip.ID)
+	if err != nil {
+		log.Warning("getPipelineSchedulesHandler> Cannot load schedules of pipeline %s: %s\n", pip.Name, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, schedules, http.StatusOK)
+}
+
+func addPipelineScheduleHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	key := mux.Vars(r)["key"]
+
+	app, pip, err := loadScheduleTarget(r, db)
+	if err != nil {
+		log.Warning("addPipelineScheduleHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	s, err := readSchedule(r, db, c, key, pip)
+	if err != nil {
+		log.Warning("addPipelineScheduleHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	s.ApplicationID = app.ID
+	s.PipelineID = pip.ID
+
+	if err := schedule.InsertSchedule(db, s); err != nil {
+		log.Warning("addPipelineScheduleHandler> Cannot insert schedule of pipeline %s: %s\n", pip.Name, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, s, http.StatusCreated)
+}
+
+func updatePipelineScheduleHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	key := mux.Vars(r)["key"]
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	app, pip, err := loadScheduleTarget(r, db)
+	if err != nil {
+		log.Warning("updatePipelineScheduleHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	s, err := readSchedule(r, db, c, key, pip)
+	if err != nil {
+		log.Warning("updatePipelineScheduleHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	s.ID = id
+	s.ApplicationID = app.ID
+	s.PipelineID = pip.ID
+
+	if err := schedule.UpdateSchedule(db, s); err != nil {
+		if err != sdk.ErrScheduleNotFound {
+			log.Warning("updatePipelineScheduleHandler> Cannot update schedule %d: %s\n", id, err)
+		}
+		WriteError(w, r, err)
+		return
+	}
+
+	s, err = schedule.LoadSchedule(db, app.ID, pip.ID, id)
+	if err != nil {
+		log.Warning("updatePipelineScheduleHandler> Cannot load schedule %d: %s\n", id, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, s, http.StatusOK)
+}
+
+func deletePipelineScheduleHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	app, pip, err := loadScheduleTarget(r, db)
+	if err != nil {
+		log.Warning("deletePipelineScheduleHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	s, err := schedule.LoadSchedule(db, app.ID, pip.ID, id)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	if s.Environment.ID != sdk.DefaultEnv.ID && !permission.AccessToEnvironment(s.Environment.ID, c.User, permission.PermissionReadExecute) {
+		WriteError(w, r, sdk.ErrNoEnvExecution)
+		return
+	}
+
+	if err := schedule.DeleteSchedule(db, app.ID, pip.ID, id); err != nil {
+		if err != sdk.ErrScheduleNotFound {
+			log.Warning("deletePipelineScheduleHandler> Cannot delete schedule %d: %s\n", id, err)
+		}
+		WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// loadScheduleTarget loads the application and the pipeline, which has to be attached to it, of a schedule
+func loadScheduleTarget(r *http.Request, db *sql.DB) (*sdk.Application, *sdk.Pipeline, error) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+	appName := vars["permApplicationName"]
+	pipelineName := vars["permPipelineKey"]
+
+	app, err := application.LoadApplicationByName(db, key, appName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pip, err := pipeline.LoadPipeline(db, key, pipelineName, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ok, err := application.PipelineAttached(db, app.ID, pip.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, sdk.ErrPipelineNotAttached
+	}
+
+	return app, pip, nil
+}
+
+// readSchedule reads a schedule from the request body, checks it and computes its next execution.
+// Builds of the schedule run without user, so the user defining it needs to be allowed to run them.
+func readSchedule(r *http.Request, db *sql.DB, c *context.Context, key string, pip *sdk.Pipeline) (*sdk.PipelineSchedule, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, sdk.ErrWrongRequest
+	}
+	var s sdk.PipelineSchedule
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, sdk.ErrWrongRequest
+	}
+
+	env := &sdk.DefaultEnv
+	if s.Environment.Name != "" && s.Environment.Name != sdk.DefaultEnv.Name {
+		env, err = environment.LoadEnvironmentByName(db, key, s.Environment.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if pip.Type == sdk.BuildPipeline && env.ID != sdk.DefaultEnv.ID {
+		return nil, sdk.ErrEnvironmentProvided
+	}
+	if pip.Type != sdk.BuildPipeline && env.ID == sdk.DefaultEnv.ID {
+		return nil, sdk.ErrNoEnvironmentProvided
+	}
+	if env.ID != sdk.DefaultEnv.ID && !permission.AccessToEnvironment(env.ID, c.User, permission.PermissionReadExecute) {
+		return nil, sdk.ErrNoEnvExecution
+	}
+	s.Environment = sdk.Environment{ID: env.ID, Name: env.Name}
+
+	if s.NextExecution, err = s.Next(time.Now()); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,221 @@
+package project
+
+import (
+	"fmt"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/group"
+	"github.com/ovh/cds/engine/api/hook"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/poller"
+	"github.com/ovh/cds/engine/api/secret"
+	"github.com/ovh/cds/engine/api/trigger"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+// Export builds the archive of a project: applications with their attached pipelines, environments,
+// triggers, hooks, pollers, groups permissions and variables. Value of password and key variables
+// is ciphered with given passphrase.
+func Export(db database.Querier, key string, u *sdk.User, passphrase string) (*exportentities.Project, error) {
+	p, err := LoadProject(db, key, u)
+	if err != nil {
+		return nil, err
+	}
+	if err := group.LoadGroupByProject(db, p); err != nil {
+		return nil, fmt.Errorf("Export> cannot load groups: %s", err)
+	}
+
+	e := &exportentities.Project{
+		Key:    p.Key,
+		Name:   p.Name,
+		Groups: exportGroups(p.ProjectGroups),
+	}
+
+	vars, err := GetAllVariableInProject(db, p.ID, WithClearPassword())
+	if err != nil {
+		return nil, fmt.Errorf("Export> cannot load variables: %s", err)
+	}
+	if e.Variables, err = exportVariables(vars, passphrase); err != nil {
+		return nil, err
+	}
+
+	envs, err := environment.LoadEnvironments(db, key, true, u)
+	if err != nil {
+		return nil, fmt.Errorf("Export> cannot load environments: %s", err)
+	}
+	for _, env := range envs {
+		vars, err := environment.GetAllVariableByID(db, env.ID, environment.WithClearPassword())
+		if err != nil {
+			return nil, fmt.Errorf("Export> cannot load variables of environment %s: %s", env.Name, err)
+		}
+		ee := exportentities.Environment{
+			Name:   env.Name,
+			Groups: exportGroups(env.EnvironmentGroups),
+		}
+		if ee.Variables, err = exportVariables(vars, passphrase); err != nil {
+			return nil, err
+		}
+		e.Environments = append(e.Environments, ee)
+	}
+
+	pips, err := pipeline.LoadPipelines(db, p.ID, false, u)
+	if err != nil {
+		return nil, fmt.Errorf("Export> cannot load pipelines: %s", err)
+	}
+	for _, pip := range pips {
+		deep, err := pipeline.LoadPipeline(db, key, pip.Name, true)
+		if err != nil {
+			return nil, fmt.Errorf("Export> cannot load pipeline %s: %s", pip.Name, err)
+		}
+		e.Pipelines = append(e.Pipelines, exportentities.ProjectPipeline{
+			Pipeline: *exportentities.NewPipeline(deep),
+			Groups:   exportGroups(deep.GroupPermission),
+		})
+	}
+
+	apps, err := application.LoadApplications(db, key, false, u)
+	if err != nil {
+		return nil, fmt.Errorf("Export> cannot load applications: %s", err)
+	}
+	for _, a := range apps {
+		ea, err := exportApplication(db, key, a.Name, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		e.Applications = append(e.Applications, *ea)
+	}
+
+	return e, nil
+}
+
+func exportApplication(db database.Querier, key, appName, passphrase string) (*exportentities.Application, error) {
+	app, err := application.LoadApplicationByName(db, key, appName, application.WithClearPassword())
+	if err != nil {
+		return nil, fmt.Errorf("Export> cannot load application %s: %s", appName, err)
+	}
+
+	ea := &exportentities.Application{
+		Name:   app.Name,
+		Groups: exportGroups(app.ApplicationGroups),
+	}
+	if ea.Variables, err = exportVariables(app.Variable, passphrase); err != nil {
+		return nil, err
+	}
+	if app.RepositoriesManager != nil {
+		ea.RepositoriesManager = app.RepositoriesManager.Name
+		ea.RepositoryFullname = app.RepositoryFullname
+	}
+
+	triggers, err := trigger.LoadTriggerByApp(db, app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Export> cannot load triggers of application %s: %s", app.Name, err)
+	}
+	hooks, err := hook.LoadApplicationHooks(db, app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Export> cannot load hooks of application %s: %s", app.Name, err)
+	}
+	pollers, err := poller.LoadPollersByApplication(db, app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Export> cannot load pollers of application %s: %s", app.Name, err)
+	}
+
+	for _, ap := range app.Pipelines {
+		eap := exportentities.ApplicationPipeline{
+			Pipeline:   ap.Pipeline.Name,
+			Parameters: exportentities.NewParameters(ap.Parameters),
+		}
+
+		for _, t := range triggers {
+			if t.SrcPipeline.ID != ap.Pipeline.ID {
+				continue
+			}
+			et := exportentities.Trigger{
+				SrcEnvironment:  exportEnvironmentName(t.SrcEnvironment),
+				DestApplication: t.DestApplication.Name,
+				DestPipeline:    t.DestPipeline.Name,
+				DestEnvironment: exportEnvironmentName(t.DestEnvironment),
+				Manual:          t.Manual,
+				Parameters:      exportentities.NewParameters(t.Parameters),
+			}
+			if t.DestProject.Key != key {
+				et.DestProject = t.DestProject.Key
+			}
+			for _, pr := range t.Prerequisites {
+				et.Prerequisites = append(et.Prerequisites, exportentities.Prerequisite{
+					Parameter:     pr.Parameter,
+					ExpectedValue: pr.ExpectedValue,
+				})
+			}
+			eap.Triggers = append(eap.Triggers, et)
+		}
+
+		for _, h := range hooks {
+			if h.Pipeline.ID != ap.Pipeline.ID {
+				continue
+			}
+			eap.Hooks = append(eap.Hooks, exportentities.Hook{
+				Kind:       h.Kind,
+				Host:       h.Host,
+				Project:    h.Project,
+				Repository: h.Repository,
+				Enabled:    h.Enabled,
+			})
+		}
+
+		for _, po := range pollers {
+			if po.Pipeline.ID != ap.Pipeline.ID {
+				continue
+			}
+			eap.Poller = &exportentities.Poller{
+				Name:    po.Name,
+				Enabled: po.Enabled,
+			}
+		}
+
+		ea.Pipelines = append(ea.Pipelines, eap)
+	}
+
+	return ea, nil
+}
+
+func exportGroups(groups []sdk.GroupPermission) []exportentities.GroupPermission {
+	var res []exportentities.GroupPermission
+	for _, g := range groups {
+		res = append(res, exportentities.GroupPermission{
+			Group:      g.Group.Name,
+			Permission: g.Permission,
+		})
+	}
+	return res
+}
+
+// exportVariables ciphers password and key variables with the passphrase
+func exportVariables(vars []sdk.Variable, passphrase string) ([]exportentities.Variable, error) {
+	var res []exportentities.Variable
+	for _, v := range vars {
+		value := v.Value
+		if sdk.NeedPlaceholder(v.Type) {
+			var err error
+			value, err = secret.EncryptWithPassphrase([]byte(v.Value), passphrase)
+			if err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, exportentities.Variable{
+			Name:  v.Name,
+			Type:  string(v.Type),
+			Value: value,
+		})
+	}
+	return res, nil
+}
+
+func exportEnvironmentName(env sdk.Environment) string {
+	if env.ID == sdk.DefaultEnv.ID {
+		return ""
+	}
+	return env.Name
+}
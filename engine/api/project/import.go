@@ -0,0 +1,533 @@
+package project
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	"github.com/ovh/cds/engine/api/action"
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/group"
+	"github.com/ovh/cds/engine/api/hook"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/poller"
+	"github.com/ovh/cds/engine/api/repositoriesmanager"
+	"github.com/ovh/cds/engine/api/secret"
+	"github.com/ovh/cds/engine/api/trigger"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+// importer holds the state of a project import: entities converted from the archive,
+// then IDs of everything inserted, indexed by name
+type importer struct {
+	tx         *sql.Tx
+	archive    *exportentities.Project
+	passphrase string
+	user       *sdk.User
+	report     *sdk.ImportReport
+
+	project      *sdk.Project
+	groups       map[string]*sdk.Group
+	pipelines    map[string]*sdk.Pipeline
+	environments map[string]int64
+	applications map[string]*sdk.Application
+	reposManager map[string]*sdk.RepositoriesManager
+}
+
+// Import creates the project described by given archive. References to groups, pipelines,
+// environments, applications, actions and repositories managers are resolved by name.
+// If anything cannot be resolved, nothing is written and the returned report lists the conflicts.
+func Import(tx *sql.Tx, e *exportentities.Project, passphrase string, u *sdk.User) (*sdk.Project, *sdk.ImportReport, error) {
+	imp := &importer{
+		tx:           tx,
+		archive:      e,
+		passphrase:   passphrase,
+		user:         u,
+		report:       &sdk.ImportReport{},
+		groups:       map[string]*sdk.Group{},
+		pipelines:    map[string]*sdk.Pipeline{},
+		environments: map[string]int64{},
+		applications: map[string]*sdk.Application{},
+		reposManager: map[string]*sdk.RepositoriesManager{},
+	}
+
+	if err := imp.check(); err != nil {
+		return nil, nil, err
+	}
+	if len(imp.report.Conflicts) > 0 {
+		return nil, imp.report, nil
+	}
+
+	if err := imp.insert(); err != nil {
+		return nil, nil, err
+	}
+	return imp.project, imp.report, nil
+}
+
+func (imp *importer) conflict(format string, args ...interface{}) {
+	imp.report.Conflicts = append(imp.report.Conflicts, fmt.Sprintf(format, args...))
+}
+
+func (imp *importer) warning(format string, args ...interface{}) {
+	imp.report.Warnings = append(imp.report.Warnings, fmt.Sprintf(format, args...))
+}
+
+// check resolves every reference of the archive without writing anything
+func (imp *importer) check() error {
+	e := imp.archive
+	nameRgxp := regexp.MustCompile(sdk.NamePattern)
+
+	if !regexp.MustCompile(sdk.ProjectKeyPattern).MatchString(e.Key) {
+		imp.conflict("project key %s does not respect pattern %s", e.Key, sdk.ProjectKeyPattern)
+	}
+	if e.Name == "" {
+		imp.conflict("project name must not be empty")
+	}
+	exist, err := Exist(imp.tx, e.Key)
+	if err != nil {
+		return err
+	}
+	if exist {
+		imp.conflict("project %s already exists", e.Key)
+	}
+
+	if err := imp.checkGroups(e.Groups); err != nil {
+		return err
+	}
+	imp.checkVariables(e.Variables, "project "+e.Key)
+
+	for _, env := range e.Environments {
+		if env.Name == "" || env.Name == sdk.DefaultEnv.Name || imp.environments[env.Name] != 0 {
+			imp.conflict("invalid or duplicated environment name '%s'", env.Name)
+		}
+		imp.environments[env.Name] = -1
+		if err := imp.checkGroups(env.Groups); err != nil {
+			return err
+		}
+		imp.checkVariables(env.Variables, "environment "+env.Name)
+	}
+
+	for i := range e.Pipelines {
+		pp := &e.Pipelines[i]
+		p, err := pp.Pipeline.Pipeline()
+		if err != nil {
+			imp.conflict("%s", err)
+			continue
+		}
+		if !nameRgxp.MatchString(p.Name) || imp.pipelines[p.Name] != nil {
+			imp.conflict("invalid or duplicated pipeline name '%s'", p.Name)
+			continue
+		}
+		imp.pipelines[p.Name] = p
+		if err := imp.checkGroups(pp.Groups); err != nil {
+			return err
+		}
+		if err := imp.checkActions(p); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range e.Applications {
+		if !nameRgxp.MatchString(a.Name) || imp.applications[a.Name] != nil {
+			imp.conflict("invalid or duplicated application name '%s'", a.Name)
+		}
+		imp.applications[a.Name] = &sdk.Application{Name: a.Name}
+	}
+	for _, a := range e.Applications {
+		if err := imp.checkApplication(a); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (imp *importer) checkGroups(groups []exportentities.GroupPermission) error {
+	for _, gp := range groups {
+		if _, ok := imp.groups[gp.Group]; ok {
+			continue
+		}
+		g, err := group.LoadGroup(imp.tx, gp.Group)
+		if err == sdk.ErrGroupNotFound {
+			if !regexp.MustCompile(sdk.NamePattern).MatchString(gp.Group) {
+				imp.conflict("group name '%s' does not respect pattern %s", gp.Group, sdk.NamePattern)
+			}
+			// Created at import, as done when adding a project
+			imp.groups[gp.Group] = nil
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		imp.groups[gp.Group] = g
+	}
+	return nil
+}
+
+// checkVariables deciphers password and key variables, in place
+func (imp *importer) checkVariables(vars []exportentities.Variable, owner string) {
+	for i := range vars {
+		ev := &vars[i]
+		if !sdk.NeedPlaceholder(sdk.VariableTypeFromString(ev.Type)) {
+			continue
+		}
+		clear, err := secret.DecryptWithPassphrase(ev.Value, imp.passphrase)
+		if err != nil {
+			imp.conflict("cannot decrypt variable %s of %s: %s", ev.Name, owner, err)
+			continue
+		}
+		ev.Value = string(clear)
+	}
+}
+
+func importVariable(ev exportentities.Variable) sdk.Variable {
+	return sdk.Variable{
+		Name:  ev.Name,
+		Type:  sdk.VariableTypeFromString(ev.Type),
+		Value: ev.Value,
+	}
+}
+
+func (imp *importer) checkActions(p *sdk.Pipeline) error {
+	for _, s := range p.Stages {
+		for _, j := range s.Actions {
+			for _, step := range j.Actions {
+				_, err := action.LoadPublicAction(imp.tx, step.Name)
+				if err == sdk.ErrNoAction {
+					imp.conflict("action %s used in pipeline %s does not exist", step.Name, p.Name)
+					continue
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (imp *importer) checkApplication(a exportentities.Application) error {
+	if err := imp.checkGroups(a.Groups); err != nil {
+		return err
+	}
+	imp.checkVariables(a.Variables, "application "+a.Name)
+
+	if a.RepositoriesManager != "" {
+		if _, ok := imp.reposManager[a.RepositoriesManager]; !ok {
+			rm, err := repositoriesmanager.LoadByName(imp.tx, a.RepositoriesManager)
+			if err == sql.ErrNoRows {
+				imp.conflict("repositories manager %s used by application %s does not exist", a.RepositoriesManager, a.Name)
+			} else if err != nil {
+				return err
+			}
+			imp.reposManager[a.RepositoriesManager] = rm
+		}
+	}
+
+	for _, ap := range a.Pipelines {
+		if imp.pipelines[ap.Pipeline] == nil {
+			imp.conflict("pipeline %s attached to application %s is not in the archive", ap.Pipeline, a.Name)
+		}
+		if _, err := exportentities.Parameters(ap.Parameters); err != nil {
+			imp.conflict("application %s pipeline %s: %s", a.Name, ap.Pipeline, err)
+		}
+		if len(ap.Hooks) > 0 && a.RepositoriesManager == "" {
+			imp.conflict("application %s has hooks but no repositories manager", a.Name)
+		}
+
+		for _, t := range ap.Triggers {
+			if _, err := exportentities.Parameters(t.Parameters); err != nil {
+				imp.conflict("trigger from %s/%s: %s", a.Name, ap.Pipeline, err)
+			}
+			if t.SrcEnvironment != "" && imp.environments[t.SrcEnvironment] == 0 {
+				imp.conflict("environment %s used by trigger from %s/%s is not in the archive", t.SrcEnvironment, a.Name, ap.Pipeline)
+			}
+			if t.DestProject == "" {
+				if imp.applications[t.DestApplication] == nil || imp.pipelines[t.DestPipeline] == nil {
+					imp.conflict("destination %s/%s of trigger from %s/%s is not in the archive", t.DestApplication, t.DestPipeline, a.Name, ap.Pipeline)
+				}
+				if t.DestEnvironment != "" && imp.environments[t.DestEnvironment] == 0 {
+					imp.conflict("environment %s used by trigger from %s/%s is not in the archive", t.DestEnvironment, a.Name, ap.Pipeline)
+				}
+				continue
+			}
+			if _, err := imp.loadTriggerDest(t); err != nil {
+				imp.conflict("destination %s/%s/%s of trigger from %s/%s cannot be found: %s", t.DestProject, t.DestApplication, t.DestPipeline, a.Name, ap.Pipeline, err)
+			}
+		}
+	}
+	return nil
+}
+
+// loadTriggerDest loads the destination of a trigger to another project
+func (imp *importer) loadTriggerDest(t exportentities.Trigger) (*sdk.PipelineTrigger, error) {
+	var res sdk.PipelineTrigger
+	proj, err := LoadProject(imp.tx, t.DestProject, imp.user)
+	if err != nil {
+		return nil, err
+	}
+	res.DestProject = *proj
+
+	app, err := application.LoadApplicationByName(imp.tx, t.DestProject, t.DestApplication)
+	if err != nil {
+		return nil, err
+	}
+	if !permission.AccessToApplication(app.ID, imp.user, permission.PermissionReadWriteExecute) {
+		return nil, sdk.ErrForbidden
+	}
+	res.DestApplication = *app
+
+	pip, err := pipeline.LoadPipeline(imp.tx, t.DestProject, t.DestPipeline, false)
+	if err != nil {
+		return nil, err
+	}
+	if !permission.AccessToPipeline(sdk.DefaultEnv.ID, pip.ID, imp.user, permission.PermissionReadWriteExecute) {
+		return nil, sdk.ErrForbidden
+	}
+	res.DestPipeline = *pip
+
+	res.DestEnvironment = sdk.DefaultEnv
+	if t.DestEnvironment != "" {
+		env, err := environment.LoadEnvironmentByName(imp.tx, t.DestProject, t.DestEnvironment)
+		if err != nil {
+			return nil, err
+		}
+		if !permission.AccessToEnvironment(env.ID, imp.user, permission.PermissionReadWriteExecute) {
+			return nil, sdk.ErrForbidden
+		}
+		res.DestEnvironment = *env
+	}
+	return &res, nil
+}
+
+// insert writes the checked archive
+func (imp *importer) insert() error {
+	e := imp.archive
+	imp.project = sdk.NewProject(e.Key)
+	imp.project.Name = e.Name
+	if err := InsertProject(imp.tx, imp.project); err != nil {
+		return fmt.Errorf("Import> cannot insert project: %s", err)
+	}
+
+	for name, g := range imp.groups {
+		if g != nil {
+			continue
+		}
+		g = &sdk.Group{Name: name}
+		if _, _, err := group.AddGroup(imp.tx, g); err != nil {
+			return fmt.Errorf("Import> cannot add group %s: %s", name, err)
+		}
+		if err := group.InsertUserInGroup(imp.tx, g.ID, imp.user.ID, true); err != nil {
+			return fmt.Errorf("Import> cannot add user %s in group %s: %s", imp.user.Username, name, err)
+		}
+		imp.groups[name] = g
+		imp.warning("group %s did not exist, it has been created with you as administrator", name)
+	}
+
+	for _, gp := range imp.groupPermissions(e.Groups) {
+		if err := group.InsertGroupInProject(imp.tx, imp.project.ID, gp.Group.ID, gp.Permission); err != nil {
+			return fmt.Errorf("Import> cannot add group %s in project: %s", gp.Group.Name, err)
+		}
+	}
+	for _, ev := range e.Variables {
+		if err := InsertVariableInProject(imp.tx, imp.project, importVariable(ev)); err != nil {
+			return fmt.Errorf("Import> cannot insert variable %s in project: %s", ev.Name, err)
+		}
+	}
+
+	for _, ee := range e.Environments {
+		if err := imp.insertEnvironment(ee); err != nil {
+			return err
+		}
+	}
+
+	for i := range e.Pipelines {
+		p := imp.pipelines[e.Pipelines[i].Name]
+		p.GroupPermission = imp.groupPermissions(e.Pipelines[i].Groups)
+		if err := pipeline.Import(imp.tx, imp.project, p, imp.user.ID); err != nil {
+			return err
+		}
+		if _, err := pipeline.CreateAudit(imp.tx, p.ID, imp.user, "Project import"); err != nil {
+			return err
+		}
+	}
+
+	for _, ea := range e.Applications {
+		if err := imp.insertApplication(ea); err != nil {
+			return err
+		}
+	}
+	// Triggers last, once all their destinations exist
+	for _, ea := range e.Applications {
+		for _, ap := range ea.Pipelines {
+			for _, et := range ap.Triggers {
+				if err := imp.insertTrigger(ea.Name, ap.Pipeline, et); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (imp *importer) groupPermissions(groups []exportentities.GroupPermission) []sdk.GroupPermission {
+	var res []sdk.GroupPermission
+	for _, gp := range groups {
+		res = append(res, sdk.GroupPermission{
+			Group:      *imp.groups[gp.Group],
+			Permission: gp.Permission,
+		})
+	}
+	return res
+}
+
+func (imp *importer) insertEnvironment(ee exportentities.Environment) error {
+	env := &sdk.Environment{
+		Name:      ee.Name,
+		ProjectID: imp.project.ID,
+	}
+	if err := environment.InsertEnvironment(imp.tx, env); err != nil {
+		return fmt.Errorf("Import> cannot insert environment %s: %s", ee.Name, err)
+	}
+	imp.environments[env.Name] = env.ID
+
+	for _, gp := range imp.groupPermissions(ee.Groups) {
+		if err := group.InsertGroupInEnvironment(imp.tx, env.ID, gp.Group.ID, gp.Permission); err != nil {
+			return fmt.Errorf("Import> cannot add group %s in environment %s: %s", gp.Group.Name, env.Name, err)
+		}
+	}
+	for _, ev := range ee.Variables {
+		v := importVariable(ev)
+		if err := environment.InsertVariable(imp.tx, env.ID, &v); err != nil {
+			return fmt.Errorf("Import> cannot insert variable %s in environment %s: %s", v.Name, env.Name, err)
+		}
+	}
+	return nil
+}
+
+func (imp *importer) insertApplication(ea exportentities.Application) error {
+	app := imp.applications[ea.Name]
+	if err := application.InsertApplication(imp.tx, imp.project, app); err != nil {
+		return fmt.Errorf("Import> cannot insert application %s: %s", ea.Name, err)
+	}
+	if err := group.InsertGroupsInApplication(imp.tx, imp.groupPermissions(ea.Groups), app.ID); err != nil {
+		return fmt.Errorf("Import> cannot add groups in application %s: %s", app.Name, err)
+	}
+	for _, ev := range ea.Variables {
+		v := importVariable(ev)
+		if err := application.InsertVariable(imp.tx, app, v); err != nil {
+			return fmt.Errorf("Import> cannot insert variable %s in application %s: %s", v.Name, app.Name, err)
+		}
+	}
+
+	if ea.RepositoriesManager != "" {
+		rm := imp.reposManager[ea.RepositoriesManager]
+		if _, err := repositoriesmanager.LoadForProject(imp.tx, imp.project.Key, rm.Name); err == sql.ErrNoRows {
+			if _, err := repositoriesmanager.InsertForProject(imp.tx, rm, imp.project.Key); err != nil {
+				return fmt.Errorf("Import> cannot link project to repositories manager %s: %s", rm.Name, err)
+			}
+			imp.warning("project must be authorized again on repositories manager %s", rm.Name)
+		} else if err != nil {
+			return err
+		}
+		app.RepositoriesManager = rm
+		app.RepositoryFullname = ea.RepositoryFullname
+		if err := repositoriesmanager.InsertForApplication(imp.tx, app, imp.project.Key); err != nil {
+			return fmt.Errorf("Import> cannot link application %s to repository %s: %s", app.Name, ea.RepositoryFullname, err)
+		}
+	}
+
+	for _, ap := range ea.Pipelines {
+		pip := imp.pipelines[ap.Pipeline]
+		if err := application.AttachPipeline(imp.tx, app.ID, pip.ID); err != nil {
+			return fmt.Errorf("Import> cannot attach pipeline %s to application %s: %s", pip.Name, app.Name, err)
+		}
+		params, _ := exportentities.Parameters(ap.Parameters)
+		if len(params) > 0 {
+			if err := application.UpdatePipelineApplication(imp.tx, app, pip.ID, params); err != nil {
+				return fmt.Errorf("Import> cannot set parameters of pipeline %s in application %s: %s", pip.Name, app.Name, err)
+			}
+		}
+
+		for _, eh := range ap.Hooks {
+			h := sdk.Hook{
+				Pipeline:      *pip,
+				ApplicationID: app.ID,
+				Kind:          eh.Kind,
+				Host:          eh.Host,
+				Project:       eh.Project,
+				Repository:    eh.Repository,
+				Enabled:       eh.Enabled,
+			}
+			if err := hook.InsertHook(imp.tx, &h); err != nil {
+				return fmt.Errorf("Import> cannot insert hook of %s/%s: %s", app.Name, pip.Name, err)
+			}
+			imp.warning("hook of %s/%s has a new link, it must be created again on repository %s/%s", app.Name, pip.Name, eh.Project, eh.Repository)
+		}
+
+		if ap.Poller != nil {
+			p := sdk.RepositoryPoller{
+				Name:        ap.Poller.Name,
+				Application: *app,
+				Pipeline:    *pip,
+				Enabled:     ap.Poller.Enabled,
+			}
+			if err := poller.InsertPoller(imp.tx, &p); err != nil {
+				return fmt.Errorf("Import> cannot insert poller of %s/%s: %s", app.Name, pip.Name, err)
+			}
+		}
+	}
+
+	log.Debug("Import> Application %s imported in project %s", app.Name, imp.project.Key)
+	return nil
+}
+
+func (imp *importer) insertTrigger(appName, pipName string, et exportentities.Trigger) error {
+	t := &sdk.PipelineTrigger{
+		SrcProject:      *imp.project,
+		SrcApplication:  *imp.applications[appName],
+		SrcPipeline:     *imp.pipelines[pipName],
+		SrcEnvironment:  sdk.DefaultEnv,
+		DestEnvironment: sdk.DefaultEnv,
+		Manual:          et.Manual,
+	}
+	if et.SrcEnvironment != "" {
+		t.SrcEnvironment = sdk.Environment{ID: imp.environments[et.SrcEnvironment], Name: et.SrcEnvironment}
+	}
+
+	if et.DestProject == "" {
+		t.DestProject = *imp.project
+		t.DestApplication = *imp.applications[et.DestApplication]
+		t.DestPipeline = *imp.pipelines[et.DestPipeline]
+		if et.DestEnvironment != "" {
+			t.DestEnvironment = sdk.Environment{ID: imp.environments[et.DestEnvironment], Name: et.DestEnvironment}
+		}
+	} else {
+		dest, err := imp.loadTriggerDest(et)
+		if err != nil {
+			return err
+		}
+		t.DestProject = dest.DestProject
+		t.DestApplication = dest.DestApplication
+		t.DestPipeline = dest.DestPipeline
+		t.DestEnvironment = dest.DestEnvironment
+	}
+
+	t.Parameters, _ = exportentities.Parameters(et.Parameters)
+	for _, pr := range et.Prerequisites {
+		t.Prerequisites = append(t.Prerequisites, sdk.Prerequisite{
+			Parameter:     pr.Parameter,
+			ExpectedValue: pr.ExpectedValue,
+		})
+	}
+
+	if err := trigger.InsertTrigger(imp.tx, t); err != nil {
+		return fmt.Errorf("Import> cannot insert trigger from %s/%s to %s/%s: %s", appName, pipName, et.DestApplication, et.DestPipeline, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+func exportProjectHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+
+	// Archive contains secrets, even if ciphered
+	if permission.ProjectPermission(key, c.User) < permission.PermissionReadWriteExecute {
+		log.Warning("exportProjectHandler> %s cannot export project %s\n", c.User.Username, key)
+		WriteError(w, r, sdk.ErrForbidden)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		log.Warning("exportProjectHandler> Cannot parse form: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	f, err := exportentities.GetFormat(r.Form.Get("format"))
+	if err != nil {
+		log.Warning("exportProjectHandler> %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	e, err := project.Export(db, key, c.User, r.Header.Get(sdk.ProjectPassphraseHeader))
+	if err != nil {
+		log.Warning("exportProjectHandler> Cannot export project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	data, err := exportentities.Marshal(e, f)
+	if err != nil {
+		log.Warning("exportProjectHandler> Cannot marshal project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Add("Content-Type", f.ContentType())
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
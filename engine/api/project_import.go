@@ -0,0 +1,74 @@
+package main
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+func importProjectHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	if err := r.ParseForm(); err != nil {
+		log.Warning("importProjectHandler> Cannot parse form: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	f, err := exportentities.GetFormat(r.Form.Get("format"))
+	if err != nil {
+		log.Warning("importProjectHandler> %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Warning("importProjectHandler> Cannot read body: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	var e exportentities.Project
+	if err := exportentities.Unmarshal(data, f, &e); err != nil {
+		log.Warning("importProjectHandler> Cannot unmarshal body: %s\n", err)
+		WriteError(w, r, sdk.NewError(sdk.ErrWrongRequest, err))
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Warning("importProjectHandler> Cannot start transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	p, report, err := project.Import(tx, &e, r.Header.Get(sdk.ProjectPassphraseHeader), c.User)
+	if err != nil {
+		log.Warning("importProjectHandler> Cannot import project %s: %s\n", e.Key, err)
+		WriteError(w, r, err)
+		return
+	}
+	if p == nil {
+		log.Warning("importProjectHandler> Cannot import project %s: %d conflicts\n", e.Key, len(report.Conflicts))
+		WriteJSON(w, r, report, http.StatusConflict)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warning("importProjectHandler> Cannot commit transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	cache.DeleteAll(cache.Key("application", p.Key, "*"))
+	cache.DeleteAll(cache.Key("pipeline", p.Key, "*"))
+
+	WriteJSON(w, r, report, http.StatusCreated)
+}
@@ -0,0 +1,219 @@
+package queue
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/ovh/cds/engine/api/action"
+	"github.com/ovh/cds/engine/api/hatchery"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/worker"
+	"github.com/ovh/cds/sdk"
+)
+
+// Explain tells, for given action build, which worker models, live workers and registered hatcheries
+// could run it, and why the others cannot
+func Explain(db *sql.DB, actionBuildID int64) (*sdk.QueueExplanation, error) {
+	e := &sdk.QueueExplanation{
+		Models:            []sdk.ModelExplanation{},
+		Workers:           []sdk.WorkerExplanation{},
+		Hatcheries:        []sdk.HatcheryExplanation{},
+		RequirementErrors: []sdk.RequirementErrorReport{},
+	}
+
+	var err error
+	e.ActionBuild, err = loadActionBuild(db, actionBuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := loadExecutingGroups(db, e.ActionBuild.PipelineID)
+	if err != nil {
+		return nil, fmt.Errorf("Explain> cannot load groups of pipeline %d: %s", e.ActionBuild.PipelineID, err)
+	}
+
+	// Workers leave hostname requirements to the worker itself, see worker.FilterQueue
+	var workerReq []sdk.Requirement
+	for _, r := range e.ActionBuild.Requirements {
+		if r.Type != sdk.HostnameRequirement {
+			workerReq = append(workerReq, r)
+		}
+	}
+
+	models, err := worker.LoadWorkerModels(db)
+	if err != nil {
+		return nil, fmt.Errorf("Explain> cannot load worker models: %s", err)
+	}
+	modelsByID := make(map[int64]*sdk.Model, len(models))
+	var runningModels int
+	for i := range models {
+		m := &models[i]
+		modelsByID[m.ID] = m
+
+		me := sdk.ModelExplanation{ModelID: m.ID, ModelName: m.Name}
+		me.UnmetRequirement = worker.UnmetRequirement(m, e.ActionBuild.Requirements, m.Capabilities)
+		me.CanRun = me.UnmetRequirement == nil
+		if me.CanRun {
+			runningModels++
+		} else {
+			me.Reason = requirementReason(me.UnmetRequirement)
+		}
+		e.Models = append(e.Models, me)
+	}
+
+	workers, err := worker.LoadWorkers(db)
+	if err != nil {
+		return nil, fmt.Errorf("Explain> cannot load workers: %s", err)
+	}
+	hatcheryWorkers := map[int64]int64{}
+	for _, w := range workers {
+		if w.HatcheryID != 0 {
+			hatcheryWorkers[w.HatcheryID]++
+		}
+
+		we := sdk.WorkerExplanation{
+			WorkerID:        w.ID,
+			WorkerName:      w.Name,
+			ModelID:         w.Model,
+			HatcheryID:      w.HatcheryID,
+			Status:          w.Status,
+			GroupPermission: groups[w.GroupID],
+			CanRun:          true,
+		}
+
+		// Workers started by hand have no model, they check requirements by themselves
+		if w.Model != 0 {
+			m, ok := modelsByID[w.Model]
+			if ok {
+				we.UnmetRequirement = worker.UnmetRequirement(m, workerReq, m.Capabilities)
+			}
+			we.CanRun = ok && we.UnmetRequirement == nil
+		}
+
+		switch {
+		case w.Status != sdk.StatusWaiting:
+			we.Reason = fmt.Sprintf("worker is %s", w.Status)
+		case !we.GroupPermission:
+			we.Reason = "the group of the worker cannot execute the pipeline"
+		case we.UnmetRequirement != nil:
+			we.Reason = requirementReason(we.UnmetRequirement)
+		case !we.CanRun:
+			we.Reason = fmt.Sprintf("unknown worker model %d", w.Model)
+		}
+		e.Workers = append(e.Workers, we)
+	}
+
+	hatcheries, err := hatchery.LoadHatcheries(db)
+	if err != nil {
+		return nil, fmt.Errorf("Explain> cannot load hatcheries: %s", err)
+	}
+	for _, h := range hatcheries {
+		he := sdk.HatcheryExplanation{
+			HatcheryID:      h.ID,
+			HatcheryName:    h.Name,
+			GroupID:         h.GroupID,
+			ModelID:         h.Model.ID,
+			Workers:         hatcheryWorkers[h.ID],
+			MaxWorker:       h.MaxWorker,
+			GroupPermission: groups[h.GroupID],
+		}
+		he.AtCapacity = he.MaxWorker > 0 && he.Workers >= he.MaxWorker
+
+		// Hatcheries declaring a model spawn it only, the others spawn the models the API asks for
+		if h.Model.ID != 0 {
+			m, ok := modelsByID[h.Model.ID]
+			if ok {
+				he.UnmetRequirement = worker.UnmetRequirement(m, e.ActionBuild.Requirements, m.Capabilities)
+			}
+			he.CanRun = ok && he.UnmetRequirement == nil
+		} else {
+			he.CanRun = runningModels > 0
+		}
+
+		switch {
+		case !he.GroupPermission:
+			he.Reason = "the group of the hatchery cannot execute the pipeline"
+		case he.AtCapacity:
+			he.Reason = fmt.Sprintf("hatchery runs %d workers, its max-worker", he.Workers)
+		case he.UnmetRequirement != nil:
+			he.Reason = requirementReason(he.UnmetRequirement)
+		case !he.CanRun:
+			he.Reason = "no worker model can run the action build"
+		}
+		e.Hatcheries = append(e.Hatcheries, he)
+	}
+
+	e.RequirementErrors, err = LoadRequirementErrors(db, actionBuildID)
+	if err != nil {
+		return nil, fmt.Errorf("Explain> cannot load requirement errors: %s", err)
+	}
+
+	return e, nil
+}
+
+func loadActionBuild(db *sql.DB, id int64) (sdk.ActionBuild, error) {
+	query := `SELECT action_build.id, action_build.pipeline_action_id, action.id, action.name, action_build.status,
+			action_build.pipeline_build_id, pipeline_build.pipeline_id, pipeline_build.build_number, action_build.queued
+		FROM action_build
+		JOIN pipeline_build ON pipeline_build.id = action_build.pipeline_build_id
+		JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
+		JOIN action ON action.id = pipeline_action.action_id
+		WHERE action_build.id = $1`
+
+	var b sdk.ActionBuild
+	var actionID int64
+	var status string
+	var queued pq.NullTime
+	err := db.QueryRow(query, id).Scan(&b.ID, &b.PipelineActionID, &actionID, &b.ActionName, &status, &b.PipelineBuildID, &b.PipelineID, &b.BuildNumber, &queued)
+	if err == sql.ErrNoRows {
+		return b, sdk.ErrActionBuildNotFound
+	}
+	if err != nil {
+		return b, err
+	}
+	b.Status = sdk.StatusFromString(status)
+	b.Queued = queued.Time
+
+	b.Requirements, err = action.LoadActionRequirements(db, actionID)
+	return b, err
+}
+
+// loadExecutingGroups returns the groups allowed to execute given pipeline, whose workers may run its builds
+func loadExecutingGroups(db *sql.DB, pipelineID int64) (map[int64]bool, error) {
+	query := `SELECT group_id FROM pipeline_group WHERE pipeline_id = $1 AND role >= $2`
+	rows, err := db.Query(query, pipelineID, permission.PermissionReadExecute)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		groups[id] = true
+	}
+	return groups, rows.Err()
+}
+
+// requirementReason explains why a requirement is not met
+func requirementReason(r *sdk.Requirement) string {
+	switch r.Type {
+	case sdk.ServiceRequirement:
+		return fmt.Sprintf("service %s needs a docker worker model", r.Name)
+	case sdk.ModelRequirement:
+		return fmt.Sprintf("requires worker model %s", r.Value)
+	case sdk.HostnameRequirement:
+		return fmt.Sprintf("requires host %s", r.Value)
+	case sdk.BinaryRequirement:
+		if r.Version != "" {
+			return fmt.Sprintf("binary %s %s not provided", r.Value, r.Version)
+		}
+		return fmt.Sprintf("binary %s not provided", r.Value)
+	}
+	return fmt.Sprintf("requirement %s (%s) not met", r.Name, r.Type)
+}
@@ -0,0 +1,50 @@
+package queue
+
+import (
+	"database/sql"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/sdk"
+)
+
+// InsertRequirementError records an error met by a worker while checking the requirements of an action build,
+// actionBuildID being 0 when the worker did not tell. Reports older than a day are dropped.
+func InsertRequirementError(db database.Executer, w *sdk.Worker, actionBuildID int64, message string) error {
+	query := `DELETE FROM worker_requirement_error WHERE created < NOW() - INTERVAL '1 day'`
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO worker_requirement_error (worker_id, worker_name, worker_model_id, action_build_id, message, created)
+		VALUES ($1, $2, $3, $4, $5, NOW())`
+	_, err := db.Exec(query, w.ID, w.Name, w.Model, sql.NullInt64{Int64: actionBuildID, Valid: actionBuildID != 0}, message)
+	return err
+}
+
+// LoadRequirementErrors loads the reports of the last hour about given action build,
+// along with the ones of workers which did not tell the action build they were checking
+func LoadRequirementErrors(db database.Querier, actionBuildID int64) ([]sdk.RequirementErrorReport, error) {
+	reports := []sdk.RequirementErrorReport{}
+
+	query := `SELECT worker_id, worker_name, worker_model_id, action_build_id, message, created
+		FROM worker_requirement_error
+		WHERE (action_build_id = $1 OR action_build_id IS NULL) AND created > NOW() - INTERVAL '1 hour'
+		ORDER BY created DESC LIMIT 20`
+	rows, err := db.Query(query, actionBuildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r sdk.RequirementErrorReport
+		var modelID, abID sql.NullInt64
+		if err := rows.Scan(&r.WorkerID, &r.WorkerName, &modelID, &abID, &r.Message, &r.Created); err != nil {
+			return nil, err
+		}
+		r.ModelID = modelID.Int64
+		r.ActionBuildID = abID.Int64
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
@@ -595,6 +595,44 @@ func getApplicationCommitsHandler(w http.ResponseWriter, r *http.Request, db *sq
 	WriteJSON(w, r, commits, http.StatusOK)
 }
 
+func getApplicationRepositoryHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	// Get project name in URL
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	appName := vars["permApplicationName"]
+
+	app, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		log.Warning("getApplicationRepositoryHandler> Cannot load application %s/%s: %s", projectKey, appName, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if app.RepositoriesManager == nil {
+		WriteError(w, r, sdk.ErrNoReposManager)
+		return
+	}
+	if app.RepositoryFullname == "" {
+		WriteError(w, r, sdk.ErrRepoNotFound)
+		return
+	}
+
+	client, err := repositoriesmanager.AuthorizedClient(db, projectKey, app.RepositoriesManager.Name)
+	if err != nil {
+		log.Warning("getApplicationRepositoryHandler> Cannot get client got %s %s : %s", projectKey, app.RepositoriesManager.Name, err)
+		WriteError(w, r, sdk.ErrNoReposManagerClientAuth)
+		return
+	}
+
+	repo, err := client.RepoByFullname(app.RepositoryFullname)
+	if err != nil {
+		log.Warning("getApplicationRepositoryHandler> Cannot get repo %s: %s", app.RepositoryFullname, err)
+		WriteError(w, r, err)
+		return
+	}
+	WriteJSON(w, r, repo, http.StatusOK)
+}
+
 func addHookOnRepositoriesManagerHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
 	// Get project name in URL
 	vars := mux.Vars(r)
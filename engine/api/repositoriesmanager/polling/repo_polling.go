@@ -339,6 +339,10 @@ func TriggerPipeline(tx *sql.Tx, rm *sdk.RepositoriesManager, poller *sdk.Reposi
 		return false, nil
 	}
 
+	if skip, err := pipeline.SkipBuiltCommit(tx, &poller.Pipeline, poller.Application.ID, sdk.DefaultEnv.ID, trigger, sdk.SkipOriginPoller); err != nil || skip {
+		return false, err
+	}
+
 	_, err = pipeline.InsertPipelineBuild(tx, projectData, &poller.Pipeline, &poller.Application, applicationPipelineArgs, args, &sdk.DefaultEnv, 0, trigger)
 	if err != nil {
 		return false, err
@@ -138,7 +138,7 @@ func checkNoWorkerModelMatchRequirement(proj string, pip string, a *sdk.Action,
 
 			found := false
 			for _, wr := range wm.Capabilities {
-				if wr.Value == ar.Value {
+				if ar.ProvidedBy(wr) {
 					found = true
 					break
 				}
@@ -200,12 +200,16 @@ func checkIncompatibleBinaryWithModelRequirement(proj string, pip string, a *sdk
 			if c.Type != sdk.BinaryRequirement {
 				continue
 			}
-			if c.Value == b.Value {
+			if b.ProvidedBy(c) {
 				found = true
 				break
 			}
 		}
 		if !found {
+			binary := b.Value
+			if b.Version != "" {
+				binary += " " + b.Version
+			}
 			w := sdk.Warning{
 				Action: sdk.Action{
 					ID: a.ID,
@@ -216,7 +220,7 @@ func checkIncompatibleBinaryWithModelRequirement(proj string, pip string, a *sdk
 					"PipelineName":      pip,
 					"ProjectKey":        proj,
 					"ModelName":         modelName,
-					"BinaryRequirement": b.Value,
+					"BinaryRequirement": binary,
 				},
 			}
 			warns = append(warns, w)
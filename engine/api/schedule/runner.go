@@ -0,0 +1,201 @@
+package schedule
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/repositoriesmanager"
+	"github.com/ovh/cds/engine/api/scheduler"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// runnerInterval is how often the due schedules are checked, cron expressions have a one minute resolution
+const runnerInterval = 10 * time.Second
+
+// Runner starts the pipelines whose schedule is due.
+// Each execution is locked and moved forward in the same transaction as the build it starts,
+// so a schedule fires once even when several instances of the API run this routine.
+// When the API was down for several executions of a schedule, only one of them is caught up.
+func Runner() {
+	// If this goroutine exits, then it's a crash
+	defer log.Fatalf("Goroutine of schedule.Runner exited - Exit CDS Engine")
+
+	for {
+		time.Sleep(runnerInterval)
+		db := database.DB()
+		if db == nil {
+			continue
+		}
+
+		ids, err := loadDueSchedules(db)
+		if err != nil {
+			log.Warning("schedule.Runner> Cannot load due schedules: %s\n", err)
+			continue
+		}
+
+		for _, id := range ids {
+			if err := execute(db, id); err != nil {
+				log.Warning("schedule.Runner> Cannot execute schedule %d: %s\n", id, err)
+				time.Sleep(1 * time.Second) // Do not spam an unavailable database
+			}
+		}
+	}
+}
+
+func loadDueSchedules(db database.Querier) ([]int64, error) {
+	query := `SELECT id FROM pipeline_schedule WHERE enabled = true AND next_execution <= now() ORDER BY next_execution`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// execute starts the pipeline of a due schedule, then computes its next execution
+func execute(db *sql.DB, id int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Another instance is executing this schedule, or already did
+	s, projectKey, appName, pipName, err := lockDueSchedule(tx, id)
+	if err == sql.ErrNoRows || database.IsLockNotAvailable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	next, err := s.NextRun(s.NextExecution, now)
+	if err != nil {
+		// The schedule cannot be computed anymore, e.g. its timezone was removed from the system
+		log.Warning("schedule.execute> Disabling schedule %d: %s\n", s.ID, err)
+		if _, err := tx.Exec(`UPDATE pipeline_schedule SET enabled = false WHERE id = $1`, s.ID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	app, err := application.LoadApplicationByName(tx, projectKey, appName, application.WithClearPassword())
+	if err != nil {
+		return postpone(db, tx, s, next, err)
+	}
+
+	if s.OnlyNewCommits && s.LastHash != "" {
+		if hash := headCommit(tx, projectKey, app, s.Parameters); hash == s.LastHash {
+			log.Info("schedule.execute> Skipping %s/%s/%s[%s]: no new commit since %s\n", projectKey, appName, pipName, s.Environment.Name, hash)
+			if _, err := tx.Exec(`UPDATE pipeline_schedule SET next_execution = $1 WHERE id = $2`, next, s.ID); err != nil {
+				return err
+			}
+			return tx.Commit()
+		}
+	}
+
+	log.Info("schedule.execute> Scheduling %s/%s/%s[%s] with %d params\n", projectKey, appName, pipName, s.Environment.Name, len(s.Parameters))
+	trigger := sdk.PipelineBuildTrigger{ScheduledTrigger: true}
+	pb, err := scheduler.Run(tx, projectKey, app, pipName, s.Environment.Name, s.Parameters, 0, trigger, nil)
+	if err != nil {
+		return postpone(db, tx, s, next, err)
+	}
+
+	query := `UPDATE pipeline_schedule SET next_execution = $1, last_execution = $2, last_hash = $3 WHERE id = $4`
+	if _, err := tx.Exec(query, next, now, pb.Trigger.VCSChangesHash, s.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// postpone moves a schedule which failed to start its pipeline to its next execution.
+// Its transaction may be aborted, so it is rolled back to release the schedule,
+// which is then updated apart, unless another instance already did.
+func postpone(db *sql.DB, tx *sql.Tx, s *sdk.PipelineSchedule, next time.Time, err error) error {
+	tx.Rollback()
+	query := `UPDATE pipeline_schedule SET next_execution = $1 WHERE id = $2 AND next_execution = $3`
+	if _, errU := db.Exec(query, next, s.ID, s.NextExecution); errU != nil {
+		log.Warning("schedule.postpone> Cannot postpone schedule %d: %s\n", s.ID, errU)
+	}
+	return err
+}
+
+// lockDueSchedule locks an enabled schedule whose next execution is reached
+func lockDueSchedule(tx *sql.Tx, id int64) (*sdk.PipelineSchedule, string, string, string, error) {
+	query := `SELECT pipeline_schedule.id, pipeline_schedule.application_id, pipeline_schedule.pipeline_id,
+			environment.id, environment.name,
+			pipeline_schedule.cron, pipeline_schedule.timezone, pipeline_schedule.args,
+			pipeline_schedule.only_new_commits, pipeline_schedule.enabled,
+			pipeline_schedule.next_execution, pipeline_schedule.last_execution, pipeline_schedule.last_hash,
+			project.projectkey, application.name, pipeline.name
+		FROM pipeline_schedule
+		JOIN environment ON environment.id = pipeline_schedule.environment_id
+		JOIN application ON application.id = pipeline_schedule.application_id
+		JOIN pipeline ON pipeline.id = pipeline_schedule.pipeline_id
+		JOIN project ON project.id = application.project_id
+		WHERE pipeline_schedule.id = $1 AND pipeline_schedule.enabled = true AND pipeline_schedule.next_execution <= now()
+		FOR UPDATE OF pipeline_schedule NOWAIT`
+
+	var s sdk.PipelineSchedule
+	var projectKey, appName, pipName string
+	err := scanSchedule(&s, &lockedScheduleRow{tx.QueryRow(query, id), []interface{}{&projectKey, &appName, &pipName}})
+	if err != nil {
+		return nil, "", "", "", err
+	}
+	return &s, projectKey, appName, pipName, nil
+}
+
+// lockedScheduleRow scans the columns of a schedule followed by extra columns
+type lockedScheduleRow struct {
+	row   *sql.Row
+	extra []interface{}
+}
+
+func (r *lockedScheduleRow) Scan(dest ...interface{}) error {
+	return r.row.Scan(append(dest, r.extra...)...)
+}
+
+// headCommit returns the latest commit of the branch a schedule builds:
+// its git.branch parameter, or the default branch of the repository
+func headCommit(tx *sql.Tx, projectKey string, app *sdk.Application, params []sdk.Parameter) string {
+	if app.RepositoriesManager == nil || app.RepositoryFullname == "" {
+		return ""
+	}
+	client, err := repositoriesmanager.AuthorizedClient(tx, projectKey, app.RepositoriesManager.Name)
+	if err != nil || client == nil {
+		log.Warning("schedule.headCommit> Cannot get client for %s: %s\n", app.RepositoriesManager.Name, err)
+		return ""
+	}
+
+	branch := ""
+	for _, p := range params {
+		if p.Name == "git.branch" {
+			branch = p.Value
+		}
+	}
+
+	branches, err := client.Branches(app.RepositoryFullname)
+	if err != nil {
+		log.Warning("schedule.headCommit> Cannot get branches of %s: %s\n", app.RepositoryFullname, err)
+		return ""
+	}
+	for _, b := range branches {
+		if (branch == "" && b.Default) || (branch != "" && b.DisplayID == branch) {
+			return b.LatestCommit
+		}
+	}
+	return ""
+}
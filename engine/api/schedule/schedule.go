@@ -0,0 +1,136 @@
+package schedule
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/sdk"
+)
+
+const loadScheduleRequest = `
+SELECT pipeline_schedule.id, pipeline_schedule.application_id, pipeline_schedule.pipeline_id,
+	environment.id, environment.name,
+	pipeline_schedule.cron, pipeline_schedule.timezone, pipeline_schedule.args,
+	pipeline_schedule.only_new_commits, pipeline_schedule.enabled,
+	pipeline_schedule.next_execution, pipeline_schedule.last_execution, pipeline_schedule.last_hash
+FROM pipeline_schedule
+JOIN environment ON environment.id = pipeline_schedule.environment_id
+`
+
+// InsertSchedule creates a schedule, its next execution has to be computed by the caller
+func InsertSchedule(db database.QueryExecuter, s *sdk.PipelineSchedule) error {
+	args, err := json.Marshal(s.Parameters)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO pipeline_schedule (application_id, pipeline_id, environment_id, cron, timezone, args, only_new_commits, enabled, next_execution)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+	return db.QueryRow(query, s.ApplicationID, s.PipelineID, s.Environment.ID, s.Cron, s.Timezone, string(args), s.OnlyNewCommits, s.Enabled, s.NextExecution).Scan(&s.ID)
+}
+
+// UpdateSchedule updates the definition of a schedule of given application pipeline
+func UpdateSchedule(db database.Executer, s *sdk.PipelineSchedule) error {
+	args, err := json.Marshal(s.Parameters)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE pipeline_schedule SET environment_id = $1, cron = $2, timezone = $3, args = $4, only_new_commits = $5, enabled = $6, next_execution = $7
+		WHERE id = $8 AND application_id = $9 AND pipeline_id = $10`
+	res, err := db.Exec(query, s.Environment.ID, s.Cron, s.Timezone, string(args), s.OnlyNewCommits, s.Enabled, s.NextExecution, s.ID, s.ApplicationID, s.PipelineID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return sdk.ErrScheduleNotFound
+	}
+	return nil
+}
+
+// DeleteSchedule removes a schedule of given application pipeline
+func DeleteSchedule(db database.Executer, applicationID, pipelineID, scheduleID int64) error {
+	query := `DELETE FROM pipeline_schedule WHERE id = $1 AND application_id = $2 AND pipeline_id = $3`
+	res, err := db.Exec(query, scheduleID, applicationID, pipelineID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return sdk.ErrScheduleNotFound
+	}
+	return nil
+}
+
+// LoadSchedules loads all the schedules of given application pipeline
+func LoadSchedules(db database.Querier, applicationID, pipelineID int64) ([]sdk.PipelineSchedule, error) {
+	schedules := []sdk.PipelineSchedule{}
+
+	query := loadScheduleRequest + `WHERE pipeline_schedule.application_id = $1 AND pipeline_schedule.pipeline_id = $2 ORDER BY pipeline_schedule.id`
+	rows, err := db.Query(query, applicationID, pipelineID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s sdk.PipelineSchedule
+		if err := scanSchedule(&s, rows); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+// LoadSchedule loads a schedule of given application pipeline
+func LoadSchedule(db database.Querier, applicationID, pipelineID, scheduleID int64) (*sdk.PipelineSchedule, error) {
+	var s sdk.PipelineSchedule
+
+	query := loadScheduleRequest + `WHERE pipeline_schedule.id = $1 AND pipeline_schedule.application_id = $2 AND pipeline_schedule.pipeline_id = $3`
+	err := scanSchedule(&s, db.QueryRow(query, scheduleID, applicationID, pipelineID))
+	if err == sql.ErrNoRows {
+		return nil, sdk.ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func scanSchedule(s *sdk.PipelineSchedule, row database.Scanner) error {
+	var timezone, args, lastHash sql.NullString
+	var lastExecution pq.NullTime
+
+	err := row.Scan(&s.ID, &s.ApplicationID, &s.PipelineID,
+		&s.Environment.ID, &s.Environment.Name,
+		&s.Cron, &timezone, &args,
+		&s.OnlyNewCommits, &s.Enabled,
+		&s.NextExecution, &lastExecution, &lastHash)
+	if err != nil {
+		return err
+	}
+
+	s.Timezone = timezone.String
+	s.LastHash = lastHash.String
+	if lastExecution.Valid {
+		t := lastExecution.Time
+		s.LastExecution = &t
+	}
+	if args.Valid && args.String != "" {
+		if err := json.Unmarshal([]byte(args.String), &s.Parameters); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/lib/pq"
@@ -21,28 +22,138 @@ import (
 	"github.com/ovh/cds/sdk"
 )
 
-// Schedule is a goroutine responsible for pushing actions of a building pipeline in queue, in the wanted order
+// SweepInterval is how often all the building pipelines are scheduled when the scheduler is woken up
+// by notifications. Without notifications, they are scheduled every pollInterval.
+var SweepInterval = 30 * time.Second
+
+const pollInterval = 2 * time.Second
+
+// Schedule is a goroutine responsible for pushing actions of a building pipeline in queue, in the wanted order.
+// Pipeline builds are scheduled as soon as a notification tells they changed, and all of them are swept regularly.
 func Schedule() {
 
 	// If this goroutine exits, then it's a crash
 	defer log.Fatalf("Goroutine of scheduler.Schedule exited - Exit CDS Engine")
 
+	var notifications <-chan *pq.Notification
+	interval := pollInterval
+	listener, err := database.NewListener(build.SchedulerChannel)
+	if err != nil {
+		log.Warning("Schedule> Cannot listen to pipeline build notifications, polling: %s\n", err)
+	}
+	if listener != nil {
+		notifications = listener.NotificationChannel()
+		interval = SweepInterval
+	}
+
+	sweep := time.NewTicker(interval)
+	defer sweep.Stop()
+
 	for {
-		time.Sleep(2 * time.Second)
+		select {
+		case n := <-notifications:
+			// Notifications sent while the listener was reconnecting are lost
+			if n == nil {
+				scheduleAll()
+				continue
+			}
 
-		db := database.DB()
-		if db != nil {
-			pipelines, err := pipeline.LoadBuildingPipelines(db)
-			if err != nil {
-				log.Warning("Schedule> Cannot load building pipelines: %s\n", err)
-				// Add some extra sleep if db is down...
-				time.Sleep(3 * time.Second)
+			// Group the notifications of a burst, a pipeline build is scheduled once
+			ids := map[int64]bool{}
+			lost := !addNotification(ids, n)
+		burst:
+			for {
+				select {
+				case n := <-notifications:
+					if !addNotification(ids, n) {
+						lost = true
+					}
+				default:
+					break burst
+				}
+			}
+
+			if lost {
+				scheduleAll()
 				continue
 			}
+			scheduleBuilds(ids)
 
-			for i := range pipelines {
-				PipelineScheduler(db, pipelines[i])
+		case <-sweep.C:
+			if listener != nil {
+				// Detect a dead listener connection
+				go listener.Ping()
 			}
+			scheduleAll()
+		}
+	}
+}
+
+// addNotification adds the pipeline build of a notification to ids, and returns false if notifications were lost
+func addNotification(ids map[int64]bool, n *pq.Notification) bool {
+	if n == nil {
+		return false
+	}
+	id, err := strconv.ParseInt(n.Extra, 10, 64)
+	if err != nil {
+		log.Warning("Schedule> Invalid pipeline build notification %s: %s\n", n.Extra, err)
+		return true
+	}
+	ids[id] = true
+	return true
+}
+
+// scheduleAll schedules all the building pipelines
+func scheduleAll() {
+	db := database.DB()
+	if db == nil {
+		return
+	}
+
+	// Start builds queued by the concurrency policy of their pipeline
+	if err := pipeline.StartQueuedPipelineBuilds(db); err != nil {
+		log.Warning("Schedule> Cannot start queued pipelines: %s\n", err)
+	}
+
+	pipelines, err := pipeline.LoadBuildingPipelines(db)
+	if err != nil {
+		log.Warning("Schedule> Cannot load building pipelines: %s\n", err)
+		// Add some extra sleep if db is down...
+		time.Sleep(3 * time.Second)
+		return
+	}
+
+	for i := range pipelines {
+		PipelineScheduler(db, pipelines[i])
+	}
+}
+
+// scheduleBuilds schedules the building pipelines of the given pipeline builds.
+// When a pipeline build is over, the next build of its application, pipeline, environment and branch is scheduled.
+func scheduleBuilds(ids map[int64]bool) {
+	db := database.DB()
+	if db == nil {
+		return
+	}
+
+	// A build which ended may let a queued build start
+	if err := pipeline.StartQueuedPipelineBuilds(db); err != nil {
+		log.Warning("Schedule> Cannot start queued pipelines: %s\n", err)
+	}
+
+	scheduled := map[int64]bool{}
+	for id := range ids {
+		pipelines, err := pipeline.LoadBuildingPipelinesLike(db, id)
+		if err != nil {
+			log.Warning("Schedule> Cannot load building pipelines like %d: %s\n", id, err)
+			continue
+		}
+		for i := range pipelines {
+			if scheduled[pipelines[i].ID] {
+				continue
+			}
+			scheduled[pipelines[i].ID] = true
+			PipelineScheduler(db, pipelines[i])
 		}
 	}
 }
@@ -82,15 +193,29 @@ func PipelineScheduler(db *sql.DB, pb sdk.PipelineBuild) {
 		return
 	}
 
-	var runningStage = -1
-	var doneStage = 0
-	for stageIndex, s := range pb.Pipeline.Stages {
-		log.Info("PipelineScheduler> Pipeline %s #%d has stage %s (%d/%d)\n",
+	// Stages start once the stages they need are done, by default all the stages before them
+	dag, err := sdk.NewStageDAG(pb.Pipeline.Stages)
+	if err != nil {
+		log.Warning("PipelineScheduler> Cannot resolve stage needs of pipeline %s(%d): %s\n", pb.Pipeline.Name, pb.ID, err)
+		return
+	}
+
+	doneStages := map[int]bool{}
+	// queued is true once action builds are pushed in queue, workers are then woken up
+	var queued bool
+	// gated is true when a stage waits for approval, running when action builds are still queued or building
+	var gated, running bool
+stages:
+	for _, stageIndex := range dag.Order {
+		s := pb.Pipeline.Stages[stageIndex]
+		ready := dag.Ready(stageIndex, doneStages)
+		log.Info("PipelineScheduler> Pipeline %s #%d has stage %s (%d/%d)[ready=%v]\n",
 			pb.Pipeline.Name,
 			pb.BuildNumber,
 			s.Name,
 			stageIndex+1,
-			len(pb.Pipeline.Stages))
+			len(pb.Pipeline.Stages),
+			ready)
 
 		// Need len(s.Actions) on Success to go to next stage, count them
 		var numberOfActionSuccess int
@@ -123,14 +248,13 @@ func PipelineScheduler(db *sql.DB, pb sdk.PipelineBuild) {
 			//If stage is disabled, we have to disable all actions
 			if !s.Enabled || !prerequisitesOK {
 				//scheduleAction, and set it to disabled
-				if errActionStatus != nil && errActionStatus == sql.ErrNoRows && (runningStage == -1 || stageIndex == runningStage) {
+				if errActionStatus != nil && errActionStatus == sql.ErrNoRows && ready {
 					var actionBuild *sdk.ActionBuild
-					actionBuild, err = scheduleAction(tx, a, pb, s.ID)
+					actionBuild, err = scheduleAction(tx, a, pb, s.ID, 1)
 					if err != nil {
 						log.Warning("PipelineScheduler> Cannot schedule action: %s\n", err)
 						return
 					}
-					runningStage = stageIndex
 
 					if !s.Enabled {
 						status = sdk.StatusDisabled
@@ -145,30 +269,65 @@ func PipelineScheduler(db *sql.DB, pb sdk.PipelineBuild) {
 
 					continue
 				}
-				numberOfActionSuccess++
+				if actionDone(status) {
+					numberOfActionSuccess++
+				}
 			} else {
-				// If no row, action should be scheduled if current stage is running
+				// If no row, action should be scheduled if the stages it needs are done
 				if errActionStatus != nil && errActionStatus == sql.ErrNoRows {
-					if runningStage == -1 || stageIndex == runningStage {
-						_, err = scheduleAction(tx, a, pb, s.ID)
+					if ready {
+						// Stage gate: wait for approval before scheduling anything, other stages go on
+						if s.Approval != nil {
+							approved, err := checkStageGate(tx, &pb, &pb.Pipeline.Stages[stageIndex])
+							if err != nil {
+								log.Warning("PipelineScheduler> Cannot check approval of stage %s: %s\n", s.Name, err)
+								return
+							}
+							if !approved {
+								gated = true
+								continue stages
+							}
+						}
+						_, err = scheduleAction(tx, a, pb, s.ID, 1)
 						if err != nil {
 							log.Warning("PipelineScheduler> Cannot schedule action: %s\n", err)
 							return
 						}
-						runningStage = stageIndex
+						queued = true
 						continue
 					}
 				}
 
 				log.Debug("PipelineScheduler> %s.%s #%d has status %s\n", pb.Pipeline.Name, a.Name, pb.BuildNumber, status)
+				if status == sdk.StatusWaiting || status == sdk.StatusBuilding {
+					running = true
+				}
 
-				if status == sdk.StatusSuccess || status == sdk.StatusDisabled {
+				if actionDone(status) {
 					numberOfActionSuccess++
 				}
 
 				//condition de sortie
-				if status == sdk.StatusFail {
-					log.Info("PipelineScheduler> %s #%d: Action %s failed, stoping\n", pb.Pipeline.Name, pb.BuildNumber, a.Name)
+				if status == sdk.StatusFail || status == sdk.StatusStopped {
+					// A stopped action is never retried
+					if status == sdk.StatusFail {
+						retrying, err := scheduleRetry(tx, a, pb, s.ID)
+						if err != nil {
+							log.Warning("PipelineScheduler> Cannot retry action %s with pipelineBuildID %d: %s\n", a.Name, pb.ID, err)
+							return
+						}
+						if retrying {
+							queued = true
+							continue
+						}
+					}
+
+					log.Info("PipelineScheduler> %s #%d: Action %s ended with status %s, stoping\n", pb.Pipeline.Name, pb.BuildNumber, a.Name, status)
+					// Stages of other branches may still be running, stop them
+					if err := pipeline.StopPipelineBuild(tx, pb.ID); err != nil {
+						log.Warning("PipelineScheduler> Cannot stop pipeline build %d: %s\n", pb.ID, err)
+						return
+					}
 					if err := pipeline.UpdatePipelineBuildStatus(tx, pb, status); err != nil {
 						log.Warning("PipelineScheduler> Cannot update pipeline status: %s\n", err)
 					} else {
@@ -181,23 +340,26 @@ func PipelineScheduler(db *sql.DB, pb sdk.PipelineBuild) {
 				}
 
 			}
-			if status == sdk.StatusBuilding || status == sdk.StatusWaiting {
-				runningStage = stageIndex
-			}
 		}
-		// If all action are done or skipped AND previous stage is done, then current stage is done
-		if numberOfActionSuccess == len(s.Actions) && doneStage == stageIndex {
-			doneStage = stageIndex + 1
-			log.Debug("PipelineScheduler> Stage #%d is DONE\n", doneStage)
+		// If all action are done or skipped AND the stages it needs are done, then current stage is done
+		if numberOfActionSuccess == len(s.Actions) && ready {
+			doneStages[stageIndex] = true
+			log.Debug("PipelineScheduler> Stage %s is DONE\n", s.Name)
 		}
+	}
 
-		// If all actions in stage are OK or skipped
-		if numberOfActionSuccess == len(s.Actions) { // Then go to next stage !
-			// But if current stage is the last one...
-			if doneStage == len(pb.Pipeline.Stages) { // Oh wait there is no next stage
-				scheduleEnd(tx, pb)
-				return
-			}
+	// All stages are done, the pipeline is over
+	if len(doneStages) == len(pb.Pipeline.Stages) {
+		scheduleEnd(tx, pb)
+		return
+	}
+
+	// Nothing else runs in the other branches, the pipeline build waits for approval
+	if gated && !queued && !running {
+		log.Info("PipelineScheduler> %s #%d is waiting for approval\n", pb.Pipeline.Name, pb.BuildNumber)
+		if err := pipeline.UpdatePipelineBuildStatus(tx, pb, sdk.StatusWaitingApproval); err != nil {
+			log.Warning("PipelineScheduler> Cannot update pipeline status: %s\n", err)
+			return
 		}
 	}
 
@@ -206,10 +368,40 @@ func PipelineScheduler(db *sql.DB, pb sdk.PipelineBuild) {
 		log.Warning("PipelineScheduler>Cannot commit transaction: %s", err)
 		return
 	}
+	if queued {
+		build.QueueUpdated(db)
+	}
 	return
 
 }
 
+// actionDone returns true when an action build with given status lets its stage complete.
+// Skipped action builds, whose steps were all skipped by their condition, do not fail the stage.
+func actionDone(status sdk.Status) bool {
+	return status == sdk.StatusSuccess || status == sdk.StatusDisabled || status == sdk.StatusSkipped
+}
+
+// checkStageGate returns true once the stage has been approved. Otherwise it requests
+// approval, unless a request is already pending, and the stage waits for it. The pipeline build
+// waits for approval too once the stages of the other branches are over.
+func checkStageGate(tx *sql.Tx, pb *sdk.PipelineBuild, s *sdk.Stage) (bool, error) {
+	gate, err := pipeline.LoadCurrentGate(tx, pb.ID, s.ID)
+	if err != nil {
+		return false, err
+	}
+	if gate != nil && gate.Status == sdk.StatusSuccess {
+		return true, nil
+	}
+	if gate != nil && gate.Status == sdk.StatusWaitingApproval {
+		return false, nil
+	}
+
+	// No request yet, or the previous one failed before the pipeline build was restarted
+	log.Info("PipelineScheduler> %s #%d: Stage %s is waiting for approval\n", pb.Pipeline.Name, pb.BuildNumber, s.Name)
+	_, err = pipeline.RequestApproval(tx, pb, s)
+	return false, err
+}
+
 func scheduleEnd(tx *sql.Tx, pb sdk.PipelineBuild) {
 	log.Debug("buildScheduler> Updating pipeline build %d status to Success", pb.ID)
 
@@ -328,7 +520,41 @@ func ParentBuildInfos(pb sdk.PipelineBuild) ([]sdk.Parameter, error) {
 	return params, nil
 }
 
-func scheduleAction(db database.QueryExecuter, a sdk.Action, pb sdk.PipelineBuild, stageID int64) (*sdk.ActionBuild, error) {
+// scheduleRetry queues a new attempt of a failed action if its retry policy allows it.
+// It returns true as long as the action is being retried, so its stage keeps running.
+func scheduleRetry(tx *sql.Tx, a sdk.Action, pb sdk.PipelineBuild, stageID int64) (bool, error) {
+	if a.Retry == nil {
+		return false, nil
+	}
+
+	current, err := pipeline.LoadCurrentAttempt(tx, a.PipelineActionID, pb.ID)
+	if err != nil {
+		return false, err
+	}
+	// A timed out attempt is not retried, its stage or pipeline may have no time left for another one
+	if current.TimedOut || !a.Retry.Retries(current.Attempt, current.AWOL) {
+		return false, nil
+	}
+
+	// Wait for the backoff delay before queueing the next attempt
+	if time.Since(current.Done) < a.Retry.NextDelay(current.Attempt) {
+		return true, nil
+	}
+
+	log.Info("scheduleRetry> %s #%d: Retrying action %s (attempt %d/%d)\n", pb.Pipeline.Name, pb.BuildNumber, a.Name, current.Attempt+1, a.Retry.Count+1)
+	if err := build.InsertLog(tx, current.ID, "SYSTEM", fmt.Sprintf("Attempt %d failed, retrying (attempt %d/%d)\n", current.Attempt, current.Attempt+1, a.Retry.Count+1)); err != nil {
+		return false, err
+	}
+	if err := pipeline.SetAttemptRetried(tx, &current); err != nil {
+		return false, err
+	}
+	if _, err := scheduleAction(tx, a, pb, stageID, current.Attempt+1); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func scheduleAction(db database.QueryExecuter, a sdk.Action, pb sdk.PipelineBuild, stageID int64, attempt int) (*sdk.ActionBuild, error) {
 	log.Info("scheduleAction> Starting action %s for pipeline %s #%d\n", a.Name,
 		pb.Pipeline.Name, pb.BuildNumber)
 
@@ -393,6 +619,12 @@ func scheduleAction(db database.QueryExecuter, a sdk.Action, pb sdk.PipelineBuil
 		Args:             params,
 		ActionName:       a.Name,
 		Status:           sdk.StatusWaiting,
+		Attempt:          attempt,
+	}
+
+	b.Timeout, err = actionBuildTimeout(db, a, pb, stageID)
+	if err != nil {
+		return nil, err
 	}
 
 	if !a.Enabled {
@@ -408,6 +640,48 @@ func scheduleAction(db database.QueryExecuter, a sdk.Action, pb sdk.PipelineBuil
 	return &b, nil
 }
 
+// actionBuildTimeout returns the number of seconds given action may run: the lowest of
+// its own timeout and of the time left to its stage and pipeline. 0 means no timeout.
+func actionBuildTimeout(db database.Querier, a sdk.Action, pb sdk.PipelineBuild, stageID int64) (int64, error) {
+	timeout := a.Timeout
+
+	for _, s := range pb.Pipeline.Stages {
+		if s.ID != stageID || s.Timeout <= 0 {
+			continue
+		}
+		query := `SELECT MIN(action_build.queued) FROM action_build
+			JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
+			WHERE action_build.pipeline_build_id = $1 AND pipeline_action.pipeline_stage_id = $2`
+		var queued pq.NullTime
+		if err := db.QueryRow(query, pb.ID, stageID).Scan(&queued); err != nil {
+			return 0, err
+		}
+		left := s.Timeout
+		if queued.Valid {
+			left -= int64(time.Since(queued.Time).Seconds())
+		}
+		timeout = minTimeout(timeout, left)
+	}
+
+	if pb.Pipeline.Timeout > 0 && !pb.Start.IsZero() {
+		timeout = minTimeout(timeout, pb.Pipeline.Timeout-int64(time.Since(pb.Start).Seconds()))
+	}
+
+	return timeout, nil
+}
+
+// minTimeout returns the lowest timeout, 0 meaning none. A deadline already passed still
+// gives a second to the action so it is not mistaken for an action without timeout.
+func minTimeout(timeout, left int64) int64 {
+	if left < 1 {
+		left = 1
+	}
+	if timeout <= 0 || left < timeout {
+		return left
+	}
+	return timeout
+}
+
 func loadPipelineActionArguments(db database.Querier, pipelineActionID int64) ([]sdk.Parameter, error) {
 	query := `SELECT args FROM pipeline_action
 		  WHERE id = $1`
@@ -428,7 +702,7 @@ func loadPipelineActionArguments(db database.Querier, pipelineActionID int64) ([
 
 // InsertBuild Insert new action build
 func InsertBuild(db database.QueryExecuter, b *sdk.ActionBuild) error {
-	query := `INSERT INTO action_build (pipeline_action_id, args, status, pipeline_build_id, queued, start, done) VALUES($1, $2, $3, $4, $5, $5, $6) RETURNING id`
+	query := `INSERT INTO action_build (pipeline_action_id, args, status, pipeline_build_id, queued, start, done, attempt, timeout) VALUES($1, $2, $3, $4, $5, $5, $6, $7, $8) RETURNING id`
 
 	if b.PipelineActionID == 0 {
 		return fmt.Errorf("invalid pipeline action ID (0)")
@@ -447,6 +721,10 @@ func InsertBuild(db database.QueryExecuter, b *sdk.ActionBuild) error {
 		b.Status = sdk.StatusWaiting
 	}
 
+	if b.Attempt == 0 {
+		b.Attempt = 1
+	}
+
 	//Set action_build.done to null is not set
 	var done interface{}
 	if b.Done.IsZero() {
@@ -458,7 +736,7 @@ func InsertBuild(db database.QueryExecuter, b *sdk.ActionBuild) error {
 		done = b.Done
 	}
 
-	err = db.QueryRow(query, b.PipelineActionID, string(argsJSON), b.Status.String(), b.PipelineBuildID, time.Now(), done).Scan(&b.ID)
+	err = db.QueryRow(query, b.PipelineActionID, string(argsJSON), b.Status.String(), b.PipelineBuildID, time.Now(), done, b.Attempt, b.Timeout).Scan(&b.ID)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,19 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestActionDone(t *testing.T) {
+	assert.True(t, actionDone(sdk.StatusSuccess))
+	assert.True(t, actionDone(sdk.StatusDisabled))
+	assert.True(t, actionDone(sdk.StatusSkipped))
+	assert.False(t, actionDone(sdk.StatusWaiting))
+	assert.False(t, actionDone(sdk.StatusBuilding))
+	assert.False(t, actionDone(sdk.StatusFail))
+	assert.False(t, actionDone(sdk.StatusStopped))
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/build"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/scheduler"
+	"github.com/ovh/cds/engine/api/testwithdb"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+// insertSchedulerTestPipeline creates a project with an application running the pipeline described by e
+func insertSchedulerTestPipeline(t *testing.T, db *sql.DB, e *exportentities.Pipeline) (*sdk.Project, *sdk.Pipeline, *sdk.Application) {
+	key := testwithdb.RandomString(t, 10)
+	proj, err := testwithdb.InsertTestProject(t, db, key, key)
+	assert.NoError(t, err)
+
+	p, err := e.Pipeline()
+	assert.NoError(t, err)
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, pipeline.Import(tx, proj, p, 0))
+	assert.NoError(t, tx.Commit())
+
+	app := &sdk.Application{Name: "TEST_APP"}
+	assert.NoError(t, application.InsertApplication(db, proj, app))
+	assert.NoError(t, application.AttachPipeline(db, app.ID, p.ID))
+
+	return proj, p, app
+}
+
+// deleteSchedulerTestPipeline removes what insertSchedulerTestPipeline created, builds included
+func deleteSchedulerTestPipeline(t *testing.T, db *sql.DB, proj *sdk.Project, p *sdk.Pipeline, app *sdk.Application) {
+	application.DeleteAllApplicationPipeline(db, app.ID)
+	if tx, err := db.Begin(); err == nil {
+		application.DeleteApplication(tx, app.ID)
+		tx.Commit()
+	}
+	pipeline.DeletePipeline(db, p.ID, 0)
+	testwithdb.DeleteTestProject(t, db, proj.Key)
+}
+
+// runTestPipeline inserts a build of the pipeline on given branch
+func runTestPipeline(t *testing.T, db *sql.DB, proj *sdk.Project, p *sdk.Pipeline, app *sdk.Application, branch string) sdk.PipelineBuild {
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	pb, err := pipeline.InsertPipelineBuild(tx, proj, p, app, nil, nil, &sdk.DefaultEnv, 0, sdk.PipelineBuildTrigger{VCSChangesBranch: branch})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	return pb
+}
+
+// scheduleTestBuild runs the scheduler on the running build of the application, pipeline and branch of given build
+func scheduleTestBuild(t *testing.T, db *sql.DB, pb sdk.PipelineBuild) {
+	pbs, err := pipeline.LoadBuildingPipelinesLike(db, pb.ID)
+	assert.NoError(t, err)
+	for i := range pbs {
+		scheduler.PipelineScheduler(db, pbs[i])
+	}
+}
+
+// endActionBuilds moves the waiting action builds of given build to status
+func endActionBuilds(t *testing.T, db *sql.DB, pb sdk.PipelineBuild, status sdk.Status) int {
+	abs, err := build.LoadBuildByPipelineBuildID(db, pb.ID)
+	assert.NoError(t, err)
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	defer tx.Rollback()
+	var n int
+	for i := range abs {
+		if abs[i].Status != sdk.StatusWaiting {
+			continue
+		}
+		if status != sdk.StatusSkipped && status != sdk.StatusDisabled {
+			assert.NoError(t, build.UpdateActionBuildStatus(tx, &abs[i], sdk.StatusBuilding))
+		}
+		if status != sdk.StatusBuilding {
+			assert.NoError(t, build.UpdateActionBuildStatus(tx, &abs[i], status))
+		}
+		n++
+	}
+	assert.NoError(t, tx.Commit())
+	return n
+}
+
+func loadTestPipelineBuild(t *testing.T, db *sql.DB, pb sdk.PipelineBuild) sdk.PipelineBuild {
+	current, err := pipeline.LoadPipelineBuild(db, pb.Pipeline.ID, pb.Application.ID, pb.BuildNumber, pb.Environment.ID)
+	assert.NoError(t, err)
+	return current
+}
+
+func testJob(name string) exportentities.Job {
+	return exportentities.Job{
+		Name:  name,
+		Steps: []exportentities.Step{{Action: sdk.ScriptAction, Parameters: map[string]string{"script": "echo " + name}}},
+	}
+}
+
+func TestPipelineSchedulerSkippedStage(t *testing.T) {
+	if testwithdb.DBDriver == "" {
+		t.SkipNow()
+		return
+	}
+	db, err := testwithdb.SetupPG(t)
+	assert.NoError(t, err)
+
+	proj, p, app := insertSchedulerTestPipeline(t, db, &exportentities.Pipeline{
+		Name: "build",
+		Type: string(sdk.BuildPipeline),
+		Stages: []exportentities.Stage{
+			{Name: "Compile", Jobs: []exportentities.Job{testJob("make"), testJob("lint")}},
+			{Name: "Package", Jobs: []exportentities.Job{testJob("tar")}},
+		},
+	})
+	defer deleteSchedulerTestPipeline(t, db, proj, p, app)
+
+	pb := runTestPipeline(t, db, proj, p, app, "master")
+	scheduleTestBuild(t, db, pb)
+
+	// All the steps of the first stage are skipped by their condition, the next stage starts
+	assert.Equal(t, 2, endActionBuilds(t, db, pb, sdk.StatusSkipped))
+	scheduleTestBuild(t, db, pb)
+	assert.Equal(t, 1, endActionBuilds(t, db, pb, sdk.StatusSuccess))
+	assert.Equal(t, sdk.StatusBuilding, loadTestPipelineBuild(t, db, pb).Status)
+
+	scheduleTestBuild(t, db, pb)
+	assert.Equal(t, sdk.StatusSuccess, loadTestPipelineBuild(t, db, pb).Status)
+}
+
+func TestPipelineSchedulerWaitingApproval(t *testing.T) {
+	if testwithdb.DBDriver == "" {
+		t.SkipNow()
+		return
+	}
+	db, err := testwithdb.SetupPG(t)
+	assert.NoError(t, err)
+
+	proj, p, app := insertSchedulerTestPipeline(t, db, &exportentities.Pipeline{
+		Name: "deploy",
+		Type: string(sdk.BuildPipeline),
+		Stages: []exportentities.Stage{
+			{Name: "Compile", Jobs: []exportentities.Job{testJob("make")}},
+			{Name: "Deploy", Needs: []string{"Compile"}, Approval: &sdk.StageApproval{Groups: []string{"release"}}, Jobs: []exportentities.Job{testJob("deploy")}},
+			{Name: "Doc", Needs: []string{"Compile"}, Jobs: []exportentities.Job{testJob("doc")}},
+		},
+	})
+	defer deleteSchedulerTestPipeline(t, db, proj, p, app)
+
+	pb := runTestPipeline(t, db, proj, p, app, "master")
+	scheduleTestBuild(t, db, pb)
+	assert.Equal(t, 1, endActionBuilds(t, db, pb, sdk.StatusSuccess))
+
+	// Deploy waits for approval while Doc builds
+	scheduleTestBuild(t, db, pb)
+	assert.Equal(t, sdk.StatusBuilding, loadTestPipelineBuild(t, db, pb).Status)
+	assert.Equal(t, 1, endActionBuilds(t, db, pb, sdk.StatusSuccess))
+
+	// Nothing else runs, the pipeline build waits for approval
+	scheduleTestBuild(t, db, pb)
+	assert.Equal(t, sdk.StatusWaitingApproval, loadTestPipelineBuild(t, db, pb).Status)
+
+	deep, err := pipeline.LoadPipeline(db, proj.Key, p.Name, true)
+	assert.NoError(t, err)
+	var stageID int64
+	for _, s := range deep.Stages {
+		if s.Name == "Deploy" {
+			stageID = s.ID
+		}
+	}
+	stage, err := pipeline.LoadStage(db, p.ID, stageID)
+	assert.NoError(t, err)
+
+	current := loadTestPipelineBuild(t, db, pb)
+	approver := &sdk.User{ID: 1, Username: "approver", Groups: []sdk.Group{{Name: "release"}}}
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	gate, err := pipeline.DecideApproval(tx, &current, stage, approver, &sdk.StageApprovalDecision{Approved: true, Comment: "go"})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, sdk.StatusSuccess, gate.Status)
+	assert.Equal(t, sdk.StatusBuilding, loadTestPipelineBuild(t, db, pb).Status)
+
+	scheduleTestBuild(t, db, pb)
+	assert.Equal(t, 1, endActionBuilds(t, db, pb, sdk.StatusSuccess))
+	scheduleTestBuild(t, db, pb)
+	assert.Equal(t, sdk.StatusSuccess, loadTestPipelineBuild(t, db, pb).Status)
+}
@@ -0,0 +1,66 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// EncryptWithPassphrase ciphers data with a key derived from given passphrase (OpenPGP symmetric encryption).
+// Unlike Encrypt, result does not depend on the AES key of this CDS instance, so it can be
+// deciphered elsewhere with DecryptWithPassphrase.
+func EncryptWithPassphrase(data []byte, passphrase string) (string, error) {
+	if passphrase == "" {
+		return "", sdk.ErrInvalidPassphrase
+	}
+
+	var buf bytes.Buffer
+	w, err := openpgp.SymmetricallyEncrypt(&buf, []byte(passphrase), nil, nil)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecryptWithPassphrase deciphers data returned by EncryptWithPassphrase
+func DecryptWithPassphrase(data string, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, sdk.ErrInvalidPassphrase
+	}
+
+	ct, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, sdk.ErrInvalidSecretFormat
+	}
+
+	// openpgp asks again while the passphrase is wrong, give it only once
+	prompted := false
+	prompt := func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+		if prompted {
+			return nil, sdk.ErrInvalidPassphrase
+		}
+		prompted = true
+		return []byte(passphrase), nil
+	}
+
+	md, err := openpgp.ReadMessage(bytes.NewReader(ct), nil, prompt, nil)
+	if err != nil {
+		if err == sdk.ErrInvalidPassphrase {
+			return nil, err
+		}
+		return nil, sdk.ErrInvalidSecretFormat
+	}
+
+	return ioutil.ReadAll(md.UnverifiedBody)
+}
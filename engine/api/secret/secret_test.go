@@ -99,3 +99,24 @@ func TestDecryptS(t *testing.T) {
 	}
 
 }
+
+func TestEncryptWithPassphrase(t *testing.T) {
+	data := []byte("Hello world !")
+
+	ct, err := EncryptWithPassphrase(data, "my passphrase")
+	if err != nil {
+		t.Fatalf("EncryptWithPassphrase failed: %s", err)
+	}
+
+	clear, err := DecryptWithPassphrase(ct, "my passphrase")
+	if err != nil {
+		t.Fatalf("DecryptWithPassphrase failed: %s", err)
+	}
+	if bytes.Compare(clear, data) != 0 {
+		t.Fatalf("Fail: Expected '%s', got '%s'", data, clear)
+	}
+
+	if _, err := DecryptWithPassphrase(ct, "wrong passphrase"); err != sdk.ErrInvalidPassphrase {
+		t.Fatalf("DecryptWithPassphrase should have failed with a wrong passphrase, got: %v", err)
+	}
+}
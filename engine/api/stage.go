@@ -37,6 +37,20 @@ func addStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *cont
 		return
 	}
 
+	if stageData.Timeout < 0 {
+		log.Warning("addStageHandler> invalid timeout %d", stageData.Timeout)
+		WriteError(w, r, sdk.ErrInvalidTimeout)
+		return
+	}
+
+	if stageData.Approval != nil {
+		if err := stageData.Approval.Check(); err != nil {
+			log.Warning("addStageHandler> Invalid approval: %s", err)
+			WriteError(w, r, err)
+			return
+		}
+	}
+
 	// Check if pipeline exist
 	pipelineData, err := pipeline.LoadPipeline(db, projectKey, pipelineKey, true)
 	if err != nil {
@@ -46,13 +60,39 @@ func addStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *cont
 
 	stageData.BuildOrder = len(pipelineData.Stages) + 1
 	stageData.PipelineID = pipelineData.ID
-	err = pipeline.InsertStage(db, stageData)
+	if err := sdk.CheckStageNeeds(append(pipelineData.Stages, *stageData)); err != nil {
+		log.Warning("addStageHandler> Invalid needs: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Warning("addStageHandler> Cannot start transaction: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	err = pipeline.InsertStage(tx, stageData)
 	if err != nil {
 		log.Warning("addStageHandler> Cannot insert stage: %s", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, pipelineData.ID, c.User, "Stage add"); err != nil {
+		log.Warning("addStageHandler> Cannot create pipeline audit: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warning("addStageHandler> Cannot commit transaction: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	k := cache.Key("application", projectKey, "*")
 	cache.DeleteAll(k)
 	cache.Delete(cache.Key("pipeline", projectKey, pipelineKey))
@@ -122,7 +162,7 @@ func moveStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *con
 
 	if stageData.BuildOrder != 0 {
 		// Check if pipeline exist
-		pipelineData, err := pipeline.LoadPipeline(db, projectKey, pipelineKey, false)
+		pipelineData, err := pipeline.LoadPipeline(db, projectKey, pipelineKey, true)
 		if err != nil {
 			WriteError(w, r, err)
 			return
@@ -136,7 +176,7 @@ func moveStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *con
 			return
 		}
 
-		if stageData.BuildOrder <= nbStage {
+		if stageData.BuildOrder > 0 && stageData.BuildOrder <= nbStage {
 			// check if stage exist
 			s, err := pipeline.LoadStage(db, pipelineData.ID, stageData.ID)
 			if err != nil {
@@ -151,12 +191,46 @@ func moveStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *con
 				return
 			}
 
-			err = pipeline.MoveStage(db, s, stageData.BuildOrder)
+			// Stages without needs depend on the stages before them
+			stages := []sdk.Stage{}
+			for _, st := range pipelineData.Stages {
+				if st.ID != s.ID {
+					stages = append(stages, st)
+				}
+			}
+			stages = append(stages[:stageData.BuildOrder-1], append([]sdk.Stage{*s}, stages[stageData.BuildOrder-1:]...)...)
+			if err := sdk.CheckStageNeeds(stages); err != nil {
+				log.Warning("moveStageHandler> Invalid needs: %s", err)
+				WriteError(w, r, err)
+				return
+			}
+
+			tx, err := db.Begin()
+			if err != nil {
+				log.Warning("moveStageHandler> Cannot start transaction: %s", err)
+				WriteError(w, r, err)
+				return
+			}
+			defer tx.Rollback()
+
+			err = pipeline.MoveStage(tx, s, stageData.BuildOrder)
 			if err != nil {
 				log.Warning("moveStageHandler> Cannot move stage: %s", err)
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
+
+			if _, err := pipeline.CreateAudit(tx, pipelineData.ID, c.User, "Stage move"); err != nil {
+				log.Warning("moveStageHandler> Cannot create pipeline audit: %s", err)
+				WriteError(w, r, err)
+				return
+			}
+
+			if err := tx.Commit(); err != nil {
+				log.Warning("moveStageHandler> Cannot commit transaction: %s", err)
+				WriteError(w, r, err)
+				return
+			}
 		}
 	}
 
@@ -190,6 +264,20 @@ func updateStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 		return
 	}
 
+	if stageData.Timeout < 0 {
+		log.Warning("updateStageHandler> Invalid timeout %d", stageData.Timeout)
+		WriteError(w, r, sdk.ErrInvalidTimeout)
+		return
+	}
+
+	if stageData.Approval != nil {
+		if err := stageData.Approval.Check(); err != nil {
+			log.Warning("updateStageHandler> Invalid approval: %s", err)
+			WriteError(w, r, err)
+			return
+		}
+	}
+
 	stageID, err := strconv.ParseInt(stageIDString, 10, 60)
 	if err != nil {
 		log.Warning("addStageHandler> Stage ID must be an int: %s", err)
@@ -203,7 +291,7 @@ func updateStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 	}
 
 	// Check if pipeline exist
-	pipelineData, err := pipeline.LoadPipeline(db, projectKey, pipelineKey, false)
+	pipelineData, err := pipeline.LoadPipeline(db, projectKey, pipelineKey, true)
 	if err != nil {
 		WriteError(w, r, err)
 		return
@@ -218,6 +306,30 @@ func updateStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 	}
 	stageData.ID = s.ID
 
+	// Stages needing the stage follow its renaming
+	var renamed []sdk.Stage
+	for i := range pipelineData.Stages {
+		st := &pipelineData.Stages[i]
+		if st.ID == s.ID {
+			*st = *stageData
+			continue
+		}
+		if s.Name == stageData.Name || !sdk.IsInArray(s.Name, st.Needs) {
+			continue
+		}
+		for j := range st.Needs {
+			if st.Needs[j] == s.Name {
+				st.Needs[j] = stageData.Name
+			}
+		}
+		renamed = append(renamed, *st)
+	}
+	if err := sdk.CheckStageNeeds(pipelineData.Stages); err != nil {
+		log.Warning("updateStageHandler> Invalid needs: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	tx, err := db.Begin()
 	if err != nil {
 		log.Warning("addStageHandler> Cannot start transaction: %s", err)
@@ -233,6 +345,14 @@ func updateStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 		return
 	}
 
+	for i := range renamed {
+		if err := pipeline.UpdateStage(tx, &renamed[i]); err != nil {
+			log.Warning("updateStageHandler> Cannot update needs of stage %s: %s", renamed[i].Name, err)
+			WriteError(w, r, err)
+			return
+		}
+	}
+
 	err = pipeline.UpdatePipelineLastModified(tx, pipelineData.ID)
 	if err != nil {
 		log.Warning("addStageHandler> Cannot update pipeline last_modified: %s", err)
@@ -240,6 +360,12 @@ func updateStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, pipelineData.ID, c.User, "Stage update"); err != nil {
+		log.Warning("updateStageHandler> Cannot create pipeline audit: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Warning("addStageHandler> Cannot commit transaction: %s", err)
@@ -263,7 +389,7 @@ func deleteStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 	stageIDString := vars["stageID"]
 
 	// Check if pipeline exist
-	pipelineData, err := pipeline.LoadPipeline(db, projectKey, pipelineKey, false)
+	pipelineData, err := pipeline.LoadPipeline(db, projectKey, pipelineKey, true)
 	if err != nil {
 		log.Warning("deleteStageHandler> Cannot load pipeline %s: %s", pipelineKey, err)
 		WriteError(w, r, err)
@@ -285,6 +411,18 @@ func deleteStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 		return
 	}
 
+	stages := []sdk.Stage{}
+	for _, st := range pipelineData.Stages {
+		if st.ID != s.ID {
+			stages = append(stages, st)
+		}
+	}
+	if err := sdk.CheckStageNeeds(stages); err != nil {
+		log.Warning("deleteStageHandler> Invalid needs: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	tx, err := db.Begin()
 	if err != nil {
 		log.Warning("deleteStageHandler> Cannot start transaction: %s", err)
@@ -307,6 +445,12 @@ func deleteStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 		return
 	}
 
+	if _, err := pipeline.CreateAudit(tx, pipelineData.ID, c.User, "Stage delete"); err != nil {
+		log.Warning("deleteStageHandler> Cannot create pipeline audit: %s", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Warning("deleteStageHandler> Cannot commit transaction: %s", err)
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/application"
+	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+func approveStageHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	pb, stage, err := loadStageBuild(r, db, c)
+	if err != nil {
+		log.Warning("approveStageHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Warning("approveStageHandler> Cannot read body: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	var decision sdk.StageApprovalDecision
+	if err := json.Unmarshal(data, &decision); err != nil {
+		log.Warning("approveStageHandler> Cannot unmarshal body: %s\n", err)
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Warning("approveStageHandler> Cannot start transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	defer tx.Rollback()
+
+	gate, err := pipeline.DecideApproval(tx, &pb, stage, c.User, &decision)
+	if err != nil {
+		log.Warning("approveStageHandler> Cannot decide on stage %s of pipeline build %d: %s\n", stage.Name, pb.ID, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warning("approveStageHandler> Cannot commit transaction: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	k := cache.Key("application", pb.Pipeline.ProjectKey, "builds", "*")
+	cache.DeleteAll(k)
+
+	WriteJSON(w, r, gate, http.StatusOK)
+}
+
+func getStageApprovalHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	pb, stage, err := loadStageBuild(r, db, c)
+	if err != nil {
+		log.Warning("getStageApprovalHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	gates, err := pipeline.LoadGates(db, pb.ID, stage.ID)
+	if err != nil {
+		log.Warning("getStageApprovalHandler> Cannot load approvals of stage %s: %s\n", stage.Name, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, gates, http.StatusOK)
+}
+
+// loadStageBuild loads the pipeline build and the stage targeted by the request
+func loadStageBuild(r *http.Request, db *sql.DB, c *context.Context) (sdk.PipelineBuild, *sdk.Stage, error) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+	appName := vars["permApplicationName"]
+	pipName := vars["permPipelineKey"]
+
+	if err := r.ParseForm(); err != nil {
+		return sdk.PipelineBuild{}, nil, sdk.ErrWrongRequest
+	}
+	envName := r.Form.Get("envName")
+
+	buildNumber, err := strconv.ParseInt(vars["build"], 10, 64)
+	if err != nil {
+		return sdk.PipelineBuild{}, nil, sdk.ErrInvalidID
+	}
+	stageID, err := strconv.ParseInt(vars["stageID"], 10, 64)
+	if err != nil {
+		return sdk.PipelineBuild{}, nil, sdk.ErrInvalidID
+	}
+
+	pip, err := pipeline.LoadPipeline(db, projectKey, pipName, false)
+	if err != nil {
+		return sdk.PipelineBuild{}, nil, err
+	}
+
+	app, err := application.LoadApplicationByName(db, projectKey, appName)
+	if err != nil {
+		return sdk.PipelineBuild{}, nil, err
+	}
+
+	if pip.Type != sdk.BuildPipeline && (envName == "" || envName == sdk.DefaultEnv.Name) {
+		return sdk.PipelineBuild{}, nil, sdk.ErrNoEnvironmentProvided
+	}
+	env := &sdk.DefaultEnv
+	if pip.Type != sdk.BuildPipeline {
+		env, err = environment.LoadEnvironmentByName(db, projectKey, envName)
+		if err != nil {
+			return sdk.PipelineBuild{}, nil, err
+		}
+		if env.ID != sdk.DefaultEnv.ID && !permission.AccessToEnvironment(env.ID, c.User, permission.PermissionRead) {
+			return sdk.PipelineBuild{}, nil, sdk.ErrForbidden
+		}
+	}
+
+	pb, err := pipeline.LoadPipelineBuild(db, pip.ID, app.ID, buildNumber, env.ID, pipeline.WithParameters())
+	if err != nil {
+		if err == sdk.ErrNoPipelineBuild {
+			err = sdk.ErrBuildArchived
+		}
+		return sdk.PipelineBuild{}, nil, err
+	}
+
+	stage, err := pipeline.LoadStage(db, pip.ID, stageID)
+	if err != nil {
+		return sdk.PipelineBuild{}, nil, err
+	}
+	if stage.ID == 0 {
+		return sdk.PipelineBuild{}, nil, sdk.ErrNotFound
+	}
+
+	return pb, stage, nil
+}
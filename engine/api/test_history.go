@@ -0,0 +1,184 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/build"
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// formLimit returns the positive integer of given form value, or else its default value
+func formLimit(r *http.Request, name string, def int) (int, error) {
+	s := r.Form.Get(name)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, sdk.ErrWrongRequest
+	}
+	return n, nil
+}
+
+func getTestCaseHistoryHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	app, pip, env, err := loadConcurrencyTarget(r, db, c, permission.PermissionRead)
+	if err != nil {
+		log.Warning("getTestCaseHistoryHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	suite, name := r.Form.Get("suite"), r.Form.Get("name")
+	if suite == "" || name == "" {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	limit, err := formLimit(r, "limit", 50)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	h, err := build.LoadTestCaseHistory(db, app.ID, pip.ID, env.ID, suite, name, limit)
+	if err != nil {
+		log.Warning("getTestCaseHistoryHandler> Cannot load history of test %s/%s on pipeline %s: %s\n", suite, name, pip.Name, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	quarantine, err := build.LoadTestQuarantine(db, pip.ProjectID)
+	if err != nil {
+		log.Warning("getTestCaseHistoryHandler> Cannot load quarantined tests: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	h.Quarantined = sdk.IsQuarantined(quarantine, suite, name)
+
+	WriteJSON(w, r, h, http.StatusOK)
+}
+
+func getFlakyTestsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	app, pip, env, err := loadConcurrencyTarget(r, db, c, permission.PermissionRead)
+	if err != nil {
+		log.Warning("getFlakyTestsHandler> %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
+	builds, err := formLimit(r, "builds", 50)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	flaky, err := build.LoadFlakyTests(db, app.ID, pip.ID, env.ID, builds)
+	if err != nil {
+		log.Warning("getFlakyTestsHandler> Cannot load flaky tests of pipeline %s: %s\n", pip.Name, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	quarantine, err := build.LoadTestQuarantine(db, pip.ProjectID)
+	if err != nil {
+		log.Warning("getFlakyTestsHandler> Cannot load quarantined tests: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+	for i := range flaky {
+		flaky[i].Quarantined = sdk.IsQuarantined(quarantine, flaky[i].Suite, flaky[i].Name)
+	}
+
+	WriteJSON(w, r, flaky, http.StatusOK)
+}
+
+func getTestQuarantineHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+
+	p, err := project.LoadProject(db, key, c.User)
+	if err != nil {
+		log.Warning("getTestQuarantineHandler> Cannot load project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	quarantine, err := build.LoadTestQuarantine(db, p.ID)
+	if err != nil {
+		log.Warning("getTestQuarantineHandler> Cannot load quarantined tests of project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, quarantine, http.StatusOK)
+}
+
+func addTestQuarantineHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+
+	p, err := project.LoadProject(db, key, c.User)
+	if err != nil {
+		log.Warning("addTestQuarantineHandler> Cannot load project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	var q sdk.TestQuarantine
+	if err := json.Unmarshal(data, &q); err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	q.Author = c.User.Username
+
+	if err := build.QuarantineTest(db, p.ID, q); err != nil {
+		log.Warning("addTestQuarantineHandler> Cannot quarantine test %s/%s of project %s: %s\n", q.Suite, q.Name, key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	log.Notice("addTestQuarantineHandler> Test %s/%s of project %s quarantined by %s\n", q.Suite, q.Name, key, q.Author)
+	w.WriteHeader(http.StatusOK)
+}
+
+func deleteTestQuarantineHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+
+	if err := r.ParseForm(); err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+	suite, name := r.Form.Get("suite"), r.Form.Get("name")
+
+	p, err := project.LoadProject(db, key, c.User)
+	if err != nil {
+		log.Warning("deleteTestQuarantineHandler> Cannot load project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := build.UnquarantineTest(db, p.ID, suite, name); err != nil {
+		if err != sdk.ErrTestQuarantineNotFound {
+			log.Warning("deleteTestQuarantineHandler> Cannot release test %s/%s of project %s: %s\n", suite, name, key, err)
+		}
+		WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -48,7 +48,7 @@ func registerWorkerHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c
 	}
 
 	// Try to register worker
-	worker, err := worker.RegisterWorker(db, params.Name, params.UserKey, params.Model, params.Hatchery, params.BinaryCapabilities)
+	worker, err := worker.RegisterWorker(db, params.Name, params.UserKey, params.Model, params.Hatchery, params.BinaryCapabilities, params.BinaryVersions)
 	if err != nil {
 		log.Warning("registerWorkerHandler: [%s] Registering failed: %s\n", params.Name, err)
 		w.WriteHeader(http.StatusUnauthorized)
@@ -159,6 +159,15 @@ func refreshWorkerHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+
+	// Tell the worker if the action it is running has been stopped
+	id, err := worker.LoadCancelledActionBuild(db, c.Worker.ID)
+	if err != nil {
+		log.Warning("refreshWorkerHandler> cannot check cancellation for %s: %s\n", c.Worker.ID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, r, sdk.ActionBuildCancel{ActionBuildID: id, Cancel: id != 0}, http.StatusOK)
 }
 
 // generateTokenHandler allows a user to generate a token associated to a group permission
@@ -293,6 +302,14 @@ func updateWorkerModel(w http.ResponseWriter, r *http.Request, db *sql.DB, c *co
 		return
 	}
 
+	for _, capa := range model.Capabilities {
+		if err := capa.CheckVersion(); err != nil {
+			log.Warning("updateWorkerModel> invalid version of capability %s: %s\n", capa.Name, err)
+			WriteError(w, r, err)
+			return
+		}
+	}
+
 	// update model in db
 	err = worker.UpdateWorkerModel(db, model)
 	if err != nil {
@@ -410,6 +427,12 @@ func addWorkerModelCapa(w http.ResponseWriter, r *http.Request, db *sql.DB, c *c
 		return
 	}
 
+	if err := capa.CheckVersion(); err != nil {
+		log.Warning("addWorkerModelCapa> invalid version: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = worker.InsertWorkerModelCapability(db, workerModelID, capa)
 	if err != nil {
 		log.Warning("addWorkerModelCapa: cannot insert new worker model capa: %s\n", err)
@@ -475,6 +498,12 @@ func updateWorkerModelCapa(w http.ResponseWriter, r *http.Request, db *sql.DB, c
 		return
 	}
 
+	if err := capa.CheckVersion(); err != nil {
+		log.Warning("updateWorkerModelCapa> invalid version: %s\n", err)
+		WriteError(w, r, err)
+		return
+	}
+
 	err = worker.UpdateWorkerModelCapability(db, capa, workerModelID)
 	if err != nil {
 		if err == sdk.ErrNoWorkerModelCapa {
@@ -556,15 +585,34 @@ func unregisterWorkerHandler(w http.ResponseWriter, r *http.Request, db *sql.DB,
 	}
 }
 
+// getWorkerModelsStatsHandler returns the use of each worker model since the beginning. With groups=true,
+// it returns them with the current use of the workers by each group, for authenticated users only.
 func getWorkerModelsStatsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
-	res := []struct {
-		Model string
-		Used  int
-	}{}
+	res := []workerModelStats{}
+
+	var groups []sdk.GroupUsage
+	withGroups := r.FormValue("groups") == "true"
+	if withGroups {
+		// The route is public, group usage is not
+		if err := router.checkAuthentication(db, r.Header, c); err != nil {
+			log.Warning("getWorkerModelsStatsHandler> Authorization denied for groups usage: %s\n", err)
+			WriteError(w, r, sdk.ErrUnauthorized)
+			return
+		}
+
+		// It is cheap enough to be loaded on each call
+		var err error
+		groups, err = group.LoadGroupsUsage(db)
+		if err != nil {
+			log.Warning("getWorkerModelsStatsHandler> Cannot load groups usage: %s\n", err)
+			WriteError(w, r, err)
+			return
+		}
+	}
 
 	cache.Get("stats:models", &res)
 	if len(res) > 0 {
-		WriteJSON(w, r, res, http.StatusOK)
+		writeWorkerModelsStats(w, r, res, groups, withGroups, http.StatusOK)
 		return
 	}
 
@@ -615,15 +663,34 @@ func getWorkerModelsStatsHandler(w http.ResponseWriter, r *http.Request, db *sql
 				WriteError(w, r, err)
 				return
 			}
-			res = append(res, struct {
-				Model string
-				Used  int
-			}{model, used})
+			res = append(res, workerModelStats{model, used})
 		}
 
 		cache.Set("stats:models", res)
 		cache.Delete("stats:models:loading")
 	}()
 
-	WriteJSON(w, r, res, http.StatusAccepted)
+	writeWorkerModelsStats(w, r, res, groups, withGroups, http.StatusAccepted)
+}
+
+// workerModelStats is the number of action builds run by a worker model
+type workerModelStats struct {
+	Model string
+	Used  int
+}
+
+// workerModelsStats is the use of each worker model since the beginning,
+// and the current use of the workers by each group
+type workerModelsStats struct {
+	Models []workerModelStats `json:"models"`
+	Groups []sdk.GroupUsage   `json:"groups"`
+}
+
+// writeWorkerModelsStats writes the worker models stats alone, as they always were, unless groups usage was asked
+func writeWorkerModelsStats(w http.ResponseWriter, r *http.Request, models []workerModelStats, groups []sdk.GroupUsage, withGroups bool, code int) {
+	if !withGroups {
+		WriteJSON(w, r, models, code)
+		return
+	}
+	WriteJSON(w, r, workerModelsStats{Models: models, Groups: groups}, code)
 }
@@ -71,20 +71,144 @@ Parse given file to extract Unit Test results.`
 		Name:        "path",
 		Description: `Path to junit xml file.`,
 		Type:        sdk.TextParameter})
+	junit.Parameter(sdk.Parameter{
+		Name:  "format",
+		Value: "auto",
+		Description: `Format of the test report: junit, tap, gotest (go test -json), nunit, xunit (xUnit.net) or mocha (mocha json reporter).
+Default to auto, detecting it from the content of the file.`,
+		Type: sdk.StringParameter})
 	if err := checkBuiltinAction(db, junit); err != nil {
 		return err
 	}
 
+	// ----------------------------------- Coverage ---------------------------
+	coverage := sdk.NewAction(sdk.CoverageAction)
+	coverage.Type = sdk.BuiltinAction
+	coverage.Description = `CDS Builtin Action.
+Parse given files to extract code coverage, by file and in total.`
+	coverage.Parameter(sdk.Parameter{
+		Name:        "path",
+		Description: `Path to the coverage reports, e.g. coverage/*.xml.`,
+		Type:        sdk.StringParameter})
+	coverage.Parameter(sdk.Parameter{
+		Name:  "format",
+		Value: "auto",
+		Description: `Format of the coverage reports: cobertura, gocover (go test -coverprofile) or lcov.
+Default to auto, detecting it from the content of the files.`,
+		Type: sdk.StringParameter})
+	coverage.Parameter(sdk.Parameter{
+		Name: "threshold",
+		Description: `Maximum drop of the coverage, in percentage points, compared to the last successful build
+on the same branch (optional). The step fails when coverage drops more. 0 fails on any drop.`,
+		Type: sdk.StringParameter})
+	if err := checkBuiltinAction(db, coverage); err != nil {
+		return err
+	}
+
+	// ----------------------------------- Findings ---------------------------
+	findings := sdk.NewAction(sdk.FindingsAction)
+	findings.Type = sdk.BuiltinAction
+	findings.Description = `CDS Builtin Action.
+Parse given files to extract the findings of static analyzers.`
+	findings.Parameter(sdk.Parameter{
+		Name:        "path",
+		Description: `Path to the analyzer reports, e.g. lint/*.sarif.`,
+		Type:        sdk.StringParameter})
+	findings.Parameter(sdk.Parameter{
+		Name:  "format",
+		Value: "auto",
+		Description: `Format of the analyzer reports: checkstyle or sarif.
+Default to auto, detecting it from the content of the files.`,
+		Type: sdk.StringParameter})
+	if err := checkBuiltinAction(db, findings); err != nil {
+		return err
+	}
+
+	// ----------------------------------- GitClone ---------------------------
+	gitClone := sdk.NewAction(sdk.GitCloneAction)
+	gitClone.Type = sdk.BuiltinAction
+	gitClone.Description = `CDS Builtin Action.
+Clone the repository of the application and check out the commit being built,
+using the ssh key of the application or of the project.
+Exports git.hash, git.branch, git.author and git.message as build variables.`
+	gitClone.Requirement("git", sdk.BinaryRequirement, "git")
+	gitClone.Parameter(sdk.Parameter{
+		Name:        "url",
+		Description: `Git URL to clone (optional). Defaults to the clone URL of the repository of the application.`,
+		Type:        sdk.StringParameter})
+	gitClone.Parameter(sdk.Parameter{
+		Name:        "branch",
+		Description: `Branch to check out (optional). Defaults to git.branch.`,
+		Type:        sdk.StringParameter})
+	gitClone.Parameter(sdk.Parameter{
+		Name:        "commit",
+		Description: `Commit to check out (optional). Defaults to git.hash, or else the head of the branch.`,
+		Type:        sdk.StringParameter})
+	gitClone.Parameter(sdk.Parameter{
+		Name:        "directory",
+		Description: `Directory to clone into (optional). Defaults to the name of the repository.`,
+		Type:        sdk.StringParameter})
+	gitClone.Parameter(sdk.Parameter{
+		Name:        "depth",
+		Value:       "50",
+		Description: `Number of commits to fetch, 0 for the whole history.`,
+		Type:        sdk.NumberParameter})
+	gitClone.Parameter(sdk.Parameter{
+		Name:        "submodules",
+		Value:       "true",
+		Description: `Whether to check out submodules, recursively.`,
+		Type:        sdk.BooleanParameter})
+	if err := checkBuiltinAction(db, gitClone); err != nil {
+		return err
+	}
+
+	// ----------------------------------- Cache Save ---------------------------
+	cacheSave := sdk.NewAction(sdk.CacheSave)
+	cacheSave.Type = sdk.BuiltinAction
+	cacheSave.Description = `CDS Builtin Action.
+Archive files of the workspace as a cache of the project,
+to be restored by next builds with Cache Restore.`
+	cacheSave.Parameter(sdk.Parameter{
+		Name: "key",
+		Description: `Key of the cache, e.g. {{.cds.application}}-{{checksum "go.sum"}}.
+checksum hashes the content of the files matching given patterns.`,
+		Type: sdk.StringParameter})
+	cacheSave.Parameter(sdk.Parameter{
+		Name:        "path",
+		Description: `Paths to archive, one per line, relative to the workspace.`,
+		Type:        sdk.TextParameter})
+	if err := checkBuiltinAction(db, cacheSave); err != nil {
+		return err
+	}
+
+	// ----------------------------------- Cache Restore ---------------------------
+	cacheRestore := sdk.NewAction(sdk.CacheRestore)
+	cacheRestore.Type = sdk.BuiltinAction
+	cacheRestore.Description = `CDS Builtin Action.
+Restore in the workspace files saved by Cache Save.`
+	cacheRestore.Parameter(sdk.Parameter{
+		Name:        "key",
+		Description: `Key of the cache, same syntax as Cache Save.`,
+		Type:        sdk.StringParameter})
+	cacheRestore.Parameter(sdk.Parameter{
+		Name: "fallback-keys",
+		Description: `Keys tried in order when there is no cache of given key, one per line.
+The most recently used cache whose key starts with a fallback key is restored.`,
+		Type: sdk.TextParameter})
+	if err := checkBuiltinAction(db, cacheRestore); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // checkBuiltinAction add builtin actions in database if needed
 func checkBuiltinAction(db *sql.DB, a *sdk.Action) error {
-	var name string
-	query := `SELECT action.name FROM action WHERE action.name = $1`
+	var id int64
+	query := `SELECT action.id FROM action WHERE action.name = $1`
 
 	// Check Script action
-	err := db.QueryRow(query, a.Name).Scan(&name)
+	err := db.QueryRow(query, a.Name).Scan(&id)
 	if err != nil && err != sql.ErrNoRows {
 		return err
 	}
@@ -94,8 +218,34 @@ func checkBuiltinAction(db *sql.DB, a *sdk.Action) error {
 		if err != nil {
 			return err
 		}
+		return nil
 	}
 
+	return addBuiltinActionParameters(db, id, a)
+}
+
+// addBuiltinActionParameters adds the parameters introduced since given builtin action was created
+func addBuiltinActionParameters(db *sql.DB, id int64, a *sdk.Action) error {
+	params, err := action.LoadActionParameters(db, id)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range a.Parameters {
+		found := false
+		for _, existing := range params {
+			if existing.Name == p.Name {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		if err := action.InsertActionParameter(db, id, p); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
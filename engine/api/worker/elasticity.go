@@ -3,12 +3,15 @@ package worker
 import (
 	"database/sql"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/ovh/cds/engine/api/action"
+	"github.com/ovh/cds/engine/api/build"
 	"github.com/ovh/cds/engine/api/context"
 	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/group"
 	"github.com/ovh/cds/engine/log"
 	"github.com/ovh/cds/sdk"
 )
@@ -128,23 +131,38 @@ func modelCanRun(db *sql.DB, name string, req []sdk.Requirement, capa []sdk.Requ
 		return false
 	}
 
+	return canRun(m, req, capa)
+}
+
+// canRun checks the requirements of an action against the capabilities of given model
+func canRun(m *sdk.Model, req []sdk.Requirement, capa []sdk.Requirement) bool {
+	return UnmetRequirement(m, req, capa) == nil
+}
+
+// UnmetRequirement returns the first requirement of an action given model cannot meet with its capabilities,
+// or nil when the model can run the action
+func UnmetRequirement(m *sdk.Model, req []sdk.Requirement, capa []sdk.Requirement) *sdk.Requirement {
+	name := m.Name
 	log.Info("Comparing %d requirements to %d capa\n", len(req), len(capa))
-	for _, r := range req {
+	for i, r := range req {
 		// service requirement are only supported by docker model
 		if r.Type == sdk.ServiceRequirement && m.Type != sdk.Docker {
-			return false
+			return &req[i]
 		}
 
 		found := false
 
 		// If requirement is a Model requirement, it's easy. It's either can or can't run
 		if r.Type == sdk.ModelRequirement {
-			return r.Value == name
+			if r.Value != name {
+				return &req[i]
+			}
+			return nil
 		}
 
 		// If requirement is an hostname requirement, it's for a specific worker
 		if r.Type == sdk.HostnameRequirement {
-			return false // TODO: update when hatchery in local mode declare an hostname capa
+			return &req[i] // TODO: update when hatchery in local mode declare an hostname capa
 		}
 
 		// Skip network access requirement as we can't check it
@@ -157,36 +175,99 @@ func modelCanRun(db *sql.DB, name string, req []sdk.Requirement, capa []sdk.Requ
 			continue
 		}
 
-		// Check binary requirement against worker model capabilities
+		// Check binary requirement, and its version, against worker model capabilities
 		for _, c := range capa {
 			log.Debug("Comparing [%s] and [%s]\n", r.Name, c.Name)
-			if r.Value == c.Value || r.Value == c.Name {
+			if r.ProvidedBy(c) {
 				found = true
 				break
 			}
 		}
 
 		if !found {
-			return false
+			return &req[i]
 		}
 	}
 
-	return true
+	return nil
+}
+
+// FilterQueue returns the action builds of the queue a worker spawned from given model can run.
+// Hostname requirements are left to the worker, which checks all the requirements before taking a build.
+func FilterQueue(db *sql.DB, modelID int64, queue []sdk.ActionBuild) ([]sdk.ActionBuild, error) {
+	// Workers started by hand have no model, they choose by themselves
+	if modelID == 0 {
+		return queue, nil
+	}
+
+	var name string
+	if err := db.QueryRow(`SELECT name FROM worker_model WHERE id = $1`, modelID).Scan(&name); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sdk.ErrNoWorkerModel
+		}
+		return nil, err
+	}
+	m, err := LoadWorkerModel(db, name)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []sdk.ActionBuild{}
+	for _, b := range queue {
+		var req []sdk.Requirement
+		for _, r := range b.Requirements {
+			if r.Type != sdk.HostnameRequirement {
+				req = append(req, r)
+			}
+		}
+		if canRun(m, req, m.Capabilities) {
+			filtered = append(filtered, b)
+		}
+	}
+	return filtered, nil
 }
 
 type actioncount struct {
-	Action sdk.Action
-	Count  int64
+	Action   sdk.Action
+	Count    int64
+	Priority int64
+	// GroupID is the group owning the actions, and Available the number of workers it can still use, -1 meaning no limit
+	GroupID   int64
+	Available int64
 }
 
+// actionCountRequest counts the waiting action builds by action and by group. Groups using less
+// than their share of the building workers come first, and counts are cut to the workers limit of the group.
+const actionCountRequest = `
+WITH %s
+SELECT COUNT(action_build.id), pipeline_action.action_id, MAX(%s) AS priority, COALESCE(owner.group_id, 0),
+	COALESCE(MAX(building.count), 0), COALESCE(MAX(group_share.max_workers), 0)
+FROM action_build
+JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
+JOIN pipeline_build ON pipeline_build.id = action_build.pipeline_build_id
+JOIN pipeline ON pipeline.id = pipeline_build.pipeline_id
+LEFT JOIN owner ON owner.pipeline_id = pipeline.id
+LEFT JOIN building ON building.group_id = owner.group_id
+LEFT JOIN group_share ON group_share.group_id = owner.group_id
+%s
+WHERE action_build.status = $1 AND %s
+GROUP BY pipeline_action.action_id, owner.group_id
+ORDER BY COALESCE(MAX(building.count), 0)::FLOAT / COALESCE(MAX(group_share.share), 1), priority DESC
+LIMIT 1000
+`
+
 func scanActionCount(db *sql.DB, s database.Scanner) (actioncount, error) {
 	ac := actioncount{}
-	var actionID int64
+	var actionID, building, maxWorkers int64
 
-	err := s.Scan(&ac.Count, &actionID)
+	err := s.Scan(&ac.Count, &actionID, &ac.Priority, &ac.GroupID, &building, &maxWorkers)
 	if err != nil {
 		return ac, fmt.Errorf("scanActionCount> cannot scan: %s", err)
 	}
+	ac.Available = -1
+	if maxWorkers > 0 {
+		ac.Available = maxWorkers - building
+	}
 
 	actionRequirementsMutex.RLock()
 	req, ok := actionRequirements[actionID]
@@ -207,62 +288,43 @@ func scanActionCount(db *sql.DB, s database.Scanner) (actioncount, error) {
 }
 
 func loadGroupActionCount(db *sql.DB, groupID int64) ([]actioncount, error) {
-	acs := []actioncount{}
-	query := `
-	SELECT COUNT(action_build.id), pipeline_action.action_id
-	FROM action_build
-	JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
-  JOIN pipeline_build ON pipeline_build.id = action_build.pipeline_build_id
-  JOIN pipeline ON pipeline.id = pipeline_build.pipeline_id
-	JOIN pipeline_group ON pipeline_group.pipeline_id = pipeline.id
-	WHERE action_build.status = $1 AND pipeline_group.group_id = $2
-	GROUP BY pipeline_action.action_id
-	LIMIT 1000
-	`
-
-	rows, err := db.Query(query, string(sdk.StatusWaiting), groupID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		ac, err := scanActionCount(db, rows)
-		if err != nil {
-			return nil, err
-		}
-
-		acs = append(acs, ac)
-	}
-
-	return acs, nil
+	query := fmt.Sprintf(actionCountRequest, group.FairShareTables, build.QueuePriority(),
+		"JOIN pipeline_group ON pipeline_group.pipeline_id = pipeline.id",
+		"pipeline_group.group_id = $2")
+	return loadActionCountQuery(db, query, groupID)
 }
+
 func loadUserActionCount(db *sql.DB, userID int64) ([]actioncount, error) {
+	query := fmt.Sprintf(actionCountRequest, group.FairShareTables, build.QueuePriority(),
+		"JOIN pipeline_group ON pipeline_group.pipeline_id = pipeline.id JOIN group_user ON group_user.group_id = pipeline_group.group_id",
+		"group_user.user_id = $2")
+	return loadActionCountQuery(db, query, userID)
+}
+
+func loadActionCountQuery(db *sql.DB, query string, id int64) ([]actioncount, error) {
 	acs := []actioncount{}
-	query := `
-	SELECT COUNT(action_build.id), pipeline_action.action_id
-	FROM action_build
-	JOIN pipeline_action ON pipeline_action.id = action_build.pipeline_action_id
-  JOIN pipeline_build ON pipeline_build.id = action_build.pipeline_build_id
-  JOIN pipeline ON pipeline.id = pipeline_build.pipeline_id
-	JOIN pipeline_group ON pipeline_group.pipeline_id = pipeline.id
-	JOIN group_user ON group_user.group_id = pipeline_group.group_id
-	WHERE action_build.status = $1 AND group_user.user_id = $2
-	GROUP BY pipeline_action.action_id
-	LIMIT 1000
-	`
-
-	rows, err := db.Query(query, string(sdk.StatusWaiting), userID)
+	rows, err := db.Query(query, string(sdk.StatusWaiting), id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	// Workers already counted for each group, so needs do not exceed workers limits
+	counted := map[int64]int64{}
 	for rows.Next() {
 		ac, err := scanActionCount(db, rows)
 		if err != nil {
 			return nil, err
 		}
+		if ac.Available >= 0 {
+			if left := ac.Available - counted[ac.GroupID]; ac.Count > left {
+				ac.Count = left
+			}
+			if ac.Count <= 0 {
+				continue
+			}
+			counted[ac.GroupID] += ac.Count
+		}
 
 		acs = append(acs, ac)
 	}
@@ -344,7 +406,7 @@ func EstimateWorkerModelNeeds(db *sql.DB, c *context.Context) ([]sdk.ModelStatus
 		return nil, fmt.Errorf("EstimateWorkerModelNeeds> cannot loadActionCount> %s", err)
 	}
 
-	// Now for each unique action in queue, find a worker model able to run it
+	// Now for each unique action in queue, by decreasing priority, find a worker model able to run it
 	var capas []sdk.Requirement
 	var ok bool
 	for _, ac := range acs {
@@ -371,12 +433,15 @@ func EstimateWorkerModelNeeds(db *sql.DB, c *context.Context) ([]sdk.ModelStatus
 						ms[i].WantedCount++
 						ac.Count--
 						loopModels = true
+						if ms[i].WantedCount == 1 || ac.Priority > ms[i].Priority {
+							ms[i].Priority = ac.Priority
+						}
 					}
 
 					//Add model requirement if action has specific kind of requirements
 					ms[i].Requirements = []sdk.Requirement{}
 					for j := range ac.Action.Requirements {
-						if ac.Action.Requirements[j].Type == sdk.ServiceRequirement {
+						if ac.Action.Requirements[j].Type == sdk.ServiceRequirement || ac.Action.Requirements[j].Version != "" {
 							ms[i].Requirements = append(ms[i].Requirements, ac.Action.Requirements[j])
 						}
 					}
@@ -387,5 +452,10 @@ func EstimateWorkerModelNeeds(db *sql.DB, c *context.Context) ([]sdk.ModelStatus
 		} // !range loopModels
 	} // !range acs
 
+	// Models needed by the most urgent builds come first, so hatcheries spawn them first
+	sort.SliceStable(ms, func(i, j int) bool {
+		return ms[i].Priority > ms[j].Priority
+	})
+
 	return ms, nil
 }
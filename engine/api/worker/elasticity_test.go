@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestUnmetRequirement(t *testing.T) {
+	m := &sdk.Model{
+		Name: "golang",
+		Type: sdk.Docker,
+		Capabilities: []sdk.Requirement{
+			{Name: "go", Type: sdk.BinaryRequirement, Value: "go", Version: "1.8.3"},
+			{Name: "git", Type: sdk.BinaryRequirement, Value: "git"},
+		},
+	}
+
+	req := []sdk.Requirement{
+		{Name: "git", Type: sdk.BinaryRequirement, Value: "git"},
+		{Name: "network", Type: sdk.NetworkAccessRequirement, Value: "github.com:443"},
+		{Name: "go", Type: sdk.BinaryRequirement, Value: "go", Version: ">= 1.7"},
+	}
+	assert.Nil(t, UnmetRequirement(m, req, m.Capabilities))
+
+	req = append(req, sdk.Requirement{Name: "node", Type: sdk.BinaryRequirement, Value: "node", Version: "~6"})
+	assert.Equal(t, &req[3], UnmetRequirement(m, req, m.Capabilities))
+
+	req = []sdk.Requirement{{Name: "go", Type: sdk.BinaryRequirement, Value: "go", Version: "^1.9"}}
+	assert.Equal(t, &req[0], UnmetRequirement(m, req, m.Capabilities))
+
+	req = []sdk.Requirement{{Name: "model", Type: sdk.ModelRequirement, Value: "java"}}
+	assert.Equal(t, &req[0], UnmetRequirement(m, req, m.Capabilities))
+}
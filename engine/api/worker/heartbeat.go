@@ -1,15 +1,23 @@
 package worker
 
 import (
+	"database/sql"
+	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/ovh/cds/engine/api/build"
 	"github.com/ovh/cds/engine/api/database"
 	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
 )
 
 // WorkerHeartbeatTimeout defines the number of seconds allowed for workers to refresh their beat
 var WorkerHeartbeatTimeout = 30.0
 
+// WorkerCancelTimeout defines how long a worker may take to stop an action before its hatchery kills it
+var WorkerCancelTimeout = 2 * time.Minute
+
 // Heartbeat runs in a goroutine and check last beat from all workers
 // on a 10s basis
 func Heartbeat() {
@@ -33,6 +41,46 @@ func Heartbeat() {
 					continue
 				}
 			}
+
+			ids, err := build.LoadUnresponsiveCancels(db, WorkerCancelTimeout)
+			if err != nil {
+				log.Warning("WorkerHeartbeat> Cannot load unresponsive cancels: %s\n", err)
+				continue
+			}
+			for _, id := range ids {
+				if err := disableUnresponsiveWorker(db, id); err != nil {
+					log.Warning("WorkerHeartbeat> Cannot stop action build %d: %s\n", id, err)
+				}
+			}
 		}
 	}
 }
+
+// disableUnresponsiveWorker stops an action build its worker did not stop in time, and disables
+// the worker so its hatchery kills it
+func disableUnresponsiveWorker(db *sql.DB, actionBuildID int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	reason := fmt.Sprintf("Worker did not stop the action within %s, it will be killed\n", WorkerCancelTimeout)
+	stopped, err := build.ForceStop(tx, actionBuildID, reason)
+	if err != nil || !stopped {
+		return err
+	}
+
+	workerID, err := FindBuildingWorker(tx, strconv.FormatInt(actionBuildID, 10))
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if workerID != "" {
+		log.Notice("WorkerHeartbeat> Disabling worker %s which did not stop action build %d\n", workerID, actionBuildID)
+		if err := UpdateWorkerStatus(tx, workerID, sdk.StatusDisabled); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
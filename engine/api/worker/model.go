@@ -144,9 +144,9 @@ func DeleteWorkerModel(tx *sql.Tx, workerModelID int64) error {
 
 // InsertWorkerModelCapability adds a capability to an existing worker model
 func InsertWorkerModelCapability(db *sql.DB, workerModelID int64, capa sdk.Requirement) error {
-	query := `INSERT INTO worker_capability (worker_model_id, type, name, argument) VALUES ($1, $2, $3, $4)`
+	query := `INSERT INTO worker_capability (worker_model_id, type, name, argument, version) VALUES ($1, $2, $3, $4, $5)`
 
-	_, err := db.Exec(query, workerModelID, string(capa.Type), capa.Name, capa.Value)
+	_, err := db.Exec(query, workerModelID, string(capa.Type), capa.Name, capa.Value, sql.NullString{String: capa.Version, Valid: capa.Version != ""})
 	if err != nil {
 		return err
 	}
@@ -157,7 +157,7 @@ func InsertWorkerModelCapability(db *sql.DB, workerModelID int64, capa sdk.Requi
 // LoadWorkerModelCapabilities retrieves capabilities of given worker model
 func LoadWorkerModelCapabilities(db database.Querier, workerID int64) ([]sdk.Requirement, error) {
 	defer logTime("LoadWorkerModelCapabilities", time.Now())
-	query := `SELECT name, type, argument FROM worker_capability WHERE worker_model_id = $1 ORDER BY name`
+	query := `SELECT name, type, argument, version FROM worker_capability WHERE worker_model_id = $1 ORDER BY name`
 
 	rows, err := db.Query(query, workerID)
 	if err != nil {
@@ -169,10 +169,12 @@ func LoadWorkerModelCapabilities(db database.Querier, workerID int64) ([]sdk.Req
 	for rows.Next() {
 		var c sdk.Requirement
 		var typeS string
-		err = rows.Scan(&c.Name, &typeS, &c.Value)
+		var version sql.NullString
+		err = rows.Scan(&c.Name, &typeS, &c.Value, &version)
 		if err != nil {
 			return nil, err
 		}
+		c.Version = version.String
 		switch typeS {
 		case string(sdk.BinaryRequirement):
 			c.Type = sdk.BinaryRequirement
@@ -216,8 +218,8 @@ func DeleteWorkerModelCapability(db *sql.DB, workerID int64, capaName string) er
 
 // UpdateWorkerModelCapability update a worker model capability
 func UpdateWorkerModelCapability(db *sql.DB, capa sdk.Requirement, modelID int64) error {
-	query := `UPDATE worker_capability SET type=$1, argument=$2 WHERE worker_model_id = $3 AND name = $4`
-	res, err := db.Exec(query, string(capa.Type), capa.Value, modelID, capa.Name)
+	query := `UPDATE worker_capability SET type=$1, argument=$2, version=$5 WHERE worker_model_id = $3 AND name = $4`
+	res, err := db.Exec(query, string(capa.Type), capa.Value, modelID, capa.Name, sql.NullString{String: capa.Version, Valid: capa.Version != ""})
 	if err != nil {
 		return err
 	}
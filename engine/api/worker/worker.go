@@ -223,10 +223,12 @@ type RegistrationForm struct {
 	Model              int64
 	Hatchery           int64
 	BinaryCapabilities []string
+	// BinaryVersions holds the version probed by the worker of some of its binary capabilities
+	BinaryVersions map[string]string
 }
 
 // RegisterWorker  Register new worker
-func RegisterWorker(db *sql.DB, name string, uk string, modelID int64, hatcheryID int64, binaryCapabilities []string) (*sdk.Worker, error) {
+func RegisterWorker(db *sql.DB, name string, uk string, modelID int64, hatcheryID int64, binaryCapabilities []string, binaryVersions map[string]string) (*sdk.Worker, error) {
 
 	if name == "" {
 		return nil, fmt.Errorf("cannot register worker with empty name")
@@ -303,6 +305,15 @@ func RegisterWorker(db *sql.DB, name string, uk string, modelID int64, hatcheryI
 				for _, c := range existingCapas {
 					if b == c.Value {
 						found = true
+						// The version probed by the worker is more accurate than the declared one
+						if v := binaryVersions[b]; v != "" && v != c.Version {
+							log.Notice("Updating model %d capability %s to version %s", modelID, c.Name, v)
+							query := `UPDATE worker_capability SET version = $1 WHERE worker_model_id = $2 AND name = $3`
+							if _, err := ntx.Exec(query, v, modelID, c.Name); err != nil {
+								log.Warning("registerWorker> Cannot update version of worker_capability: %s\n", err)
+								return
+							}
+						}
 						break
 					}
 				}
@@ -313,8 +324,9 @@ func RegisterWorker(db *sql.DB, name string, uk string, modelID int64, hatcheryI
 			if len(newCapas) > 0 {
 				log.Notice("Updating model %d binary capabilities with %d capabilities", modelID, len(newCapas))
 				for _, b := range newCapas {
-					query := `insert into worker_capability (worker_model_id, name, argument, type) values ($1, $2, $3, $4)`
-					if _, err := ntx.Exec(query, modelID, b, b, string(sdk.BinaryRequirement)); err != nil {
+					version := sql.NullString{String: binaryVersions[b], Valid: binaryVersions[b] != ""}
+					query := `insert into worker_capability (worker_model_id, name, argument, type, version) values ($1, $2, $3, $4, $5)`
+					if _, err := ntx.Exec(query, modelID, b, b, string(sdk.BinaryRequirement), version); err != nil {
 						//Ignore errors because we let the database to check constraints...
 						log.Info("registerWorker> Cannot insert into worker_capability: %s\n", err)
 						return
@@ -375,6 +387,20 @@ func UpdateWorkerStatus(db database.Executer, workerID string, status sdk.Status
 	return nil
 }
 
+// LoadCancelledActionBuild returns the action build given worker runs if it has to stop it, 0 otherwise
+func LoadCancelledActionBuild(db database.Querier, workerID string) (int64, error) {
+	query := `SELECT action_build.id FROM worker
+		JOIN action_build ON action_build.id = worker.action_build_id
+		WHERE worker.id = $1 AND action_build.status = $2 AND action_build.cancel_requested IS NOT NULL`
+
+	var id int64
+	err := db.QueryRow(query, workerID, sdk.StatusBuilding.String()).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
 // FindBuildingWorker retrieves in database the worker building given actionBuildID
 func FindBuildingWorker(db database.Querier, actionBuildID string) (string, error) {
 	query := `SELECT id FROM worker WHERE action_build_id = $1`
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/context"
+	"github.com/ovh/cds/engine/api/objectstore"
+	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/api/workspacecache"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+func resolveCacheHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+
+	if err := r.ParseForm(); err != nil {
+		WriteError(w, r, sdk.ErrWrongRequest)
+		return
+	}
+
+	p, err := project.LoadProject(db, key, c.User)
+	if err != nil {
+		log.Warning("resolveCacheHandler> Cannot load project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	cache, err := workspacecache.Resolve(db, p.ID, r.Form.Get("key"), r.Form["fallback"])
+	if err != nil {
+		if err != sdk.ErrCacheNotFound {
+			log.Warning("resolveCacheHandler> Cannot resolve cache %s of project %s: %s\n", r.Form.Get("key"), key, err)
+		}
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, cache, http.StatusOK)
+}
+
+func downloadCacheHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+	cacheKey := vars["cacheKey"]
+
+	p, err := project.LoadProject(db, key, c.User)
+	if err != nil {
+		log.Warning("downloadCacheHandler> Cannot load project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	cache, err := workspacecache.Load(db, p.ID, cacheKey)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	f, err := workspacecache.Fetch(cache)
+	if err != nil {
+		log.Warning("downloadCacheHandler> Cannot fetch cache %s of project %s: %s\n", cacheKey, key, err)
+		WriteError(w, r, err)
+		return
+	}
+	defer f.Close()
+
+	if err := workspacecache.Touch(db, cache.ID); err != nil {
+		log.Warning("downloadCacheHandler> Cannot touch cache %s of project %s: %s\n", cacheKey, key, err)
+	}
+
+	w.Header().Add("Content-Type", "application/octet-stream")
+	w.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.tar.gz\"", cache.Key))
+	if err := objectstore.StreamFile(w, f); err != nil {
+		log.Warning("downloadCacheHandler> Cannot stream cache %s of project %s: %s\n", cacheKey, key, err)
+	}
+}
+
+func uploadCacheHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+	cacheKey := vars["cacheKey"]
+	defer r.Body.Close()
+
+	p, err := project.LoadProject(db, key, c.User)
+	if err != nil {
+		log.Warning("uploadCacheHandler> Cannot load project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	cache := &sdk.Cache{ProjectKey: p.Key, Key: cacheKey}
+	if err := workspacecache.Save(db, p.ID, cache, r.Body); err != nil {
+		log.Warning("uploadCacheHandler> Cannot save cache %s of project %s: %s\n", cacheKey, key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, cache, http.StatusCreated)
+}
+
+func listCachesHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+
+	p, err := project.LoadProject(db, key, c.User)
+	if err != nil {
+		log.Warning("listCachesHandler> Cannot load project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	caches, err := workspacecache.LoadAll(db, p.ID)
+	if err != nil {
+		log.Warning("listCachesHandler> Cannot load caches of project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, r, caches, http.StatusOK)
+}
+
+func deleteCacheHandler(w http.ResponseWriter, r *http.Request, db *sql.DB, c *context.Context) {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+	cacheKey := vars["cacheKey"]
+
+	p, err := project.LoadProject(db, key, c.User)
+	if err != nil {
+		log.Warning("deleteCacheHandler> Cannot load project %s: %s\n", key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	if err := workspacecache.Delete(db, p.ID, cacheKey); err != nil {
+		log.Warning("deleteCacheHandler> Cannot delete cache %s of project %s: %s\n", cacheKey, key, err)
+		WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
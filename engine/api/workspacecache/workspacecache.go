@@ -0,0 +1,256 @@
+package workspacecache
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/objectstore"
+	"github.com/ovh/cds/engine/log"
+	"github.com/ovh/cds/sdk"
+)
+
+// Quota is the total size, in bytes, of the caches a project may keep. Least recently used caches
+// are evicted to stay under the quota, and a single cache cannot exceed it.
+var Quota int64 = 1 << 30
+
+const cacheFields = `workspace_cache.id, project.projectkey, workspace_cache.cache_key, workspace_cache.size,
+	workspace_cache.object_path, workspace_cache.created, workspace_cache.last_used`
+
+func scanCache(s database.Scanner) (sdk.Cache, error) {
+	var c sdk.Cache
+	var objectPath sql.NullString
+	var created, lastUsed pq.NullTime
+	if err := s.Scan(&c.ID, &c.ProjectKey, &c.Key, &c.Size, &objectPath, &created, &lastUsed); err != nil {
+		return c, err
+	}
+	c.ObjectPath = objectPath.String
+	c.Created = created.Time
+	c.LastUsed = lastUsed.Time
+	return c, nil
+}
+
+// Load loads the cache of given key in a project
+func Load(db database.Querier, projectID int64, key string) (*sdk.Cache, error) {
+	query := `SELECT ` + cacheFields + ` FROM workspace_cache
+		JOIN project ON project.id = workspace_cache.project_id
+		WHERE workspace_cache.project_id = $1 AND workspace_cache.cache_key = $2`
+
+	c, err := scanCache(db.QueryRow(query, projectID, key))
+	if err == sql.ErrNoRows {
+		return nil, sdk.ErrCacheNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Resolve returns the cache of given key in a project or else, trying fallback keys in order,
+// the most recently used cache whose key starts with a fallback key
+func Resolve(db database.Querier, projectID int64, key string, fallbacks []string) (*sdk.Cache, error) {
+	c, err := Load(db, projectID, key)
+	if err != sdk.ErrCacheNotFound {
+		return c, err
+	}
+
+	query := `SELECT ` + cacheFields + ` FROM workspace_cache
+		JOIN project ON project.id = workspace_cache.project_id
+		WHERE workspace_cache.project_id = $1 AND LEFT(workspace_cache.cache_key, LENGTH($2)) = $2
+		ORDER BY workspace_cache.cache_key = $2 DESC, workspace_cache.last_used DESC
+		LIMIT 1`
+	for _, f := range fallbacks {
+		if f == "" {
+			continue
+		}
+		c, err := scanCache(db.QueryRow(query, projectID, f))
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &c, nil
+	}
+	return nil, sdk.ErrCacheNotFound
+}
+
+// LoadAll loads the caches of a project, most recently used first
+func LoadAll(db database.Querier, projectID int64) ([]sdk.Cache, error) {
+	caches := []sdk.Cache{}
+
+	query := `SELECT ` + cacheFields + ` FROM workspace_cache
+		JOIN project ON project.id = workspace_cache.project_id
+		WHERE workspace_cache.project_id = $1
+		ORDER BY workspace_cache.last_used DESC`
+	rows, err := db.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanCache(rows)
+		if err != nil {
+			return nil, err
+		}
+		caches = append(caches, c)
+	}
+	return caches, rows.Err()
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Save stores the archive of a cache, replacing the cache of the same key if any, then evicts
+// the least recently used caches of the project until it is under its quota. The archive is stored
+// apart from the one it replaces, which is only deleted once the new one is accepted and recorded.
+func Save(db *sql.DB, projectID int64, c *sdk.Cache, archive io.Reader) error {
+	if err := sdk.CheckCacheKey(c.Key); err != nil {
+		return err
+	}
+
+	// Read one byte over the quota to know when the archive exceeds it
+	r := &countingReader{r: io.LimitReader(archive, Quota+1)}
+	objectPath, err := objectstore.StoreCache(*c, ioutil.NopCloser(r))
+	if err != nil {
+		return fmt.Errorf("cannot store cache %s: %s", c.Key, err)
+	}
+	c.Size = r.n
+	c.ObjectPath = objectPath
+
+	if r.n > Quota {
+		deleteArchive(*c)
+		return sdk.NewError(sdk.ErrCacheTooLarge, fmt.Errorf("cache %s exceeds the quota of %d bytes", c.Key, Quota))
+	}
+
+	previous, err := replace(db, projectID, c)
+	if err != nil {
+		deleteArchive(*c)
+		return err
+	}
+	if previous != nil {
+		deleteArchive(*previous)
+	}
+
+	return evict(db, projectID, c.ID)
+}
+
+// replace records a cache in place of the cache of the same key, and returns the replaced one if any
+func replace(db *sql.DB, projectID int64, c *sdk.Cache) (*sdk.Cache, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// A concurrent upload of the same key makes the insert fail on the unique index,
+	// rather than deleting a cache whose archive would not be deleted
+	query := `DELETE FROM workspace_cache USING project
+		WHERE project.id = workspace_cache.project_id AND workspace_cache.project_id = $1 AND workspace_cache.cache_key = $2
+		RETURNING ` + cacheFields
+	var previous *sdk.Cache
+	old, err := scanCache(tx.QueryRow(query, projectID, c.Key))
+	switch {
+	case err == nil:
+		previous = &old
+	case err != sql.ErrNoRows:
+		return nil, err
+	}
+
+	query = `INSERT INTO workspace_cache (project_id, cache_key, size, object_path, created, last_used)
+		VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id, created, last_used`
+	if err := tx.QueryRow(query, projectID, c.Key, c.Size, c.ObjectPath).Scan(&c.ID, &c.Created, &c.LastUsed); err != nil {
+		return nil, err
+	}
+
+	return previous, tx.Commit()
+}
+
+// deleteArchive removes the archive of a cache which is not recorded anymore
+func deleteArchive(c sdk.Cache) {
+	if err := objectstore.DeleteCache(c); err != nil {
+		log.Warning("workspacecache.deleteArchive> cannot delete archive %s of cache %s: %s\n", c.ObjectPath, c.Key, err)
+	}
+}
+
+// evict deletes the least recently used caches of a project, but the given one, while the project exceeds its quota
+func evict(db *sql.DB, projectID int64, keep int64) error {
+	caches, err := LoadAll(db, projectID)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, c := range caches {
+		total += c.Size
+	}
+
+	for i := len(caches) - 1; i >= 0 && total > Quota; i-- {
+		if caches[i].ID == keep {
+			continue
+		}
+		log.Notice("workspacecache.evict> Evicting cache %s of project %s (%d bytes)\n", caches[i].Key, caches[i].ProjectKey, caches[i].Size)
+		if err := Delete(db, projectID, caches[i].Key); err != nil && err != sdk.ErrCacheNotFound {
+			return err
+		}
+		total -= caches[i].Size
+	}
+	return nil
+}
+
+// Touch marks a cache as used now
+func Touch(db database.Executer, id int64) error {
+	_, err := db.Exec(`UPDATE workspace_cache SET last_used = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// Fetch opens the archive of a cache
+func Fetch(c *sdk.Cache) (io.ReadCloser, error) {
+	return objectstore.FetchCache(*c)
+}
+
+// Delete removes the cache of given key from a project, and its archive from the object store
+func Delete(db *sql.DB, projectID int64, key string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + cacheFields + ` FROM workspace_cache
+		JOIN project ON project.id = workspace_cache.project_id
+		WHERE workspace_cache.project_id = $1 AND workspace_cache.cache_key = $2
+		FOR UPDATE`
+	c, err := scanCache(tx.QueryRow(query, projectID, key))
+	if err == sql.ErrNoRows {
+		return sdk.ErrCacheNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// If it's 404, it's lost anyway...
+	if err := objectstore.DeleteCache(c); err != nil && !strings.Contains(err.Error(), "404") {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM workspace_cache WHERE id = $1`, c.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
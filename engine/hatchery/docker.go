@@ -55,15 +55,12 @@ func (hd *HatcheryDocker) Hatchery() *hatchery.Hatchery {
 }
 
 // CanSpawn return wether or not hatchery can spawn model
-// requirement are not supported
+// only binary requirements provided by the model are supported
 func (hd *HatcheryDocker) CanSpawn(model *sdk.Model, req []sdk.Requirement) bool {
 	if model.Type != sdk.Docker {
 		return false
 	}
-	if len(req) > 0 {
-		return false
-	}
-	return true
+	return binaryRequirementsProvided(model, req)
 }
 
 // Init starts cleaning routine
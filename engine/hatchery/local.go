@@ -51,15 +51,12 @@ func (h *HatcheryLocal) Hatchery() *hatchery.Hatchery {
 }
 
 // CanSpawn return wether or not hatchery can spawn model.
-// requirements are not supported
+// only binary requirements provided by the model are supported
 func (h *HatcheryLocal) CanSpawn(model *sdk.Model, req []sdk.Requirement) bool {
 	if model.ID != h.workerModelID {
 		return false
 	}
-	if len(req) > 0 {
-		return false
-	}
-	return true
+	return binaryRequirementsProvided(model, req)
 }
 
 // KillWorker kill a local process
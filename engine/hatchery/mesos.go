@@ -103,15 +103,12 @@ func (m *HatcheryMesos) KillWorker(worker sdk.Worker) error {
 }
 
 // CanSpawn return wether or not hatchery can spawn model
-// requirements are not supported
+// only binary requirements provided by the model are supported
 func (m *HatcheryMesos) CanSpawn(model *sdk.Model, req []sdk.Requirement) bool {
 	if model.Type != sdk.Docker {
 		return false
 	}
-	if len(req) > 0 {
-		return false
-	}
-	return true
+	return binaryRequirementsProvided(model, req)
 }
 
 // SpawnWorker creates an application on mesos via marathon
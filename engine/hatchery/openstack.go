@@ -70,15 +70,12 @@ func (h *HatcheryCloud) Hatchery() *hatchery.Hatchery {
 }
 
 // CanSpawn return wether or not hatchery can spawn model
-// requirements are not supported
+// only binary requirements provided by the model are supported
 func (h *HatcheryCloud) CanSpawn(model *sdk.Model, req []sdk.Requirement) bool {
 	if model.Type != sdk.Openstack {
 		return false
 	}
-	if len(req) > 0 {
-		return false
-	}
-	return true
+	return binaryRequirementsProvided(model, req)
 }
 
 // Init fetch uri from nova
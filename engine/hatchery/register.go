@@ -13,6 +13,7 @@ import (
 
 func register(h *hatchery.Hatchery) error {
 	h.UID = uk
+	h.MaxWorker = int64(maxWorker)
 	data, err := json.Marshal(h)
 	if err != nil {
 		return err
@@ -48,6 +49,15 @@ func checkCapabilities(req []sdk.Requirement) ([]sdk.Requirement, error) {
 		}
 
 		if ok {
+			// The version of a binary is only probed when an action constrains it
+			if r.Type == sdk.BinaryRequirement && r.Version != "" {
+				r.Version = ""
+				if v, err := sdk.ProbeVersion(r.Value, nil); err == nil {
+					r.Version = v.String()
+				} else {
+					log.Info("checkCapabilities> Cannot probe version of %s: %s\n", r.Value, err)
+				}
+			}
 			tmp[r.Name] = r
 		}
 	}
@@ -73,6 +83,27 @@ func checkRequirement(r sdk.Requirement) (bool, error) {
 	}
 }
 
+// binaryRequirementsProvided returns true when all given requirements are binary requirements
+// provided by the capabilities of the model, in a compatible version
+func binaryRequirementsProvided(model *sdk.Model, req []sdk.Requirement) bool {
+	for _, r := range req {
+		if r.Type != sdk.BinaryRequirement {
+			return false
+		}
+		found := false
+		for _, c := range model.Capabilities {
+			if r.ProvidedBy(c) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 func hearbeat(m HatcheryMode) {
 	for {
 		time.Sleep(5 * time.Second)
@@ -269,6 +269,10 @@ func (h *HatcherySwarm) CanSpawn(model *sdk.Model, req []sdk.Requirement) bool {
 		if r.Type == sdk.ServiceRequirement {
 			atLeastOneLink = true
 			links[r.Name] = strings.Split(r.Value, " ")[0]
+			continue
+		}
+		if !binaryRequirementsProvided(model, []sdk.Requirement{r}) {
+			return false
 		}
 	}
 
@@ -39,6 +39,8 @@ select create_foreign_key('FK_APPLICATION_VARIABLE_APPLICATION', 'application_va
 -- APPLICATION PIPELINE NOTIF
 SELECT create_foreign_key('FK_APPLICATION_PIPELINE_NOTIF_APPLICATION_PIPELINE', 'application_pipeline_notif', 'application_pipeline', 'application_pipeline_id', 'id');
 SELECT create_foreign_key('FK_APPLICATION_PIPELINE_NOTIF_ENVIRONMENT', 'application_pipeline_notif', 'environment', 'environment_id', 'id');
+SELECT create_foreign_key('FK_APPLICATION_PIPELINE_CONCURRENCY_APPLICATION_PIPELINE', 'application_pipeline_concurrency', 'application_pipeline', 'application_pipeline_id', 'id');
+SELECT create_foreign_key('FK_APPLICATION_PIPELINE_CONCURRENCY_ENVIRONMENT', 'application_pipeline_concurrency', 'environment', 'environment_id', 'id');
 
 -- BUILD_LOG
 select create_foreign_key('FK_BUILD_LOG_ACTION_BUILD', 'build_log', 'action_build', 'action_build_id', 'id');
@@ -54,6 +56,7 @@ select create_foreign_key('FK_ENVIRONMENT_GROUP_GROUP', 'environment_group', 'gr
 select create_foreign_key('FK_ENVIRONMENT_VARIABLE_ENV', 'environment_variable', 'environment', 'environment_id', 'id');
 
 -- GROUP USER
+select create_foreign_key('FK_GROUP_SHARE_GROUP', 'group_share', 'group', 'group_id', 'id');
 select create_foreign_key('FK_GROUP_USER_GROUP', 'group_user', 'group', 'group_id', 'id');
 select create_foreign_key('FK_GROUP_USER_USER', 'group_user', 'user', 'user_id', 'id');
 
@@ -68,6 +71,17 @@ select create_foreign_key('FK_PIPELINE_PROJECT', 'pipeline', 'project', 'project
 select create_foreign_key('FK_PIPELINE_ACTION_ACTION', 'pipeline_action', 'action', 'action_id', 'id');
 select create_foreign_key('FK_PIPELINE_ACTION_PIPELINE_STAGE', 'pipeline_action', 'pipeline_stage', 'pipeline_stage_id', 'id');
 
+-- PIPELINE AUDIT
+select create_foreign_key('FK_PIPELINE_AUDIT_PIPELINE', 'pipeline_audit', 'pipeline', 'pipeline_id', 'id');
+
+-- PIPELINE BUILD APPROVAL
+select create_foreign_key('FK_PIPELINE_BUILD_APPROVAL_GATE', 'pipeline_build_approval', 'pipeline_build_gate', 'gate_id', 'id');
+
+-- PIPELINE SCHEDULE
+select create_foreign_key('FK_PIPELINE_SCHEDULE_APPLICATION', 'pipeline_schedule', 'application', 'application_id', 'id');
+select create_foreign_key('FK_PIPELINE_SCHEDULE_PIPELINE', 'pipeline_schedule', 'pipeline', 'pipeline_id', 'id');
+select create_foreign_key('FK_PIPELINE_SCHEDULE_ENVIRONMENT', 'pipeline_schedule', 'environment', 'environment_id', 'id');
+
 -- PIPELINE BUILD
 select create_foreign_key('FK_PIPELINE_BUILD_PIPELINE', 'pipeline_build', 'pipeline', 'pipeline_id', 'id');
 select create_foreign_key('FK_PIPELINE_BUILD_APPLICATION', 'pipeline_build', 'application', 'application_id', 'id');
@@ -142,7 +156,21 @@ ALTER TABLE warning ADD CONSTRAINT fk_pipeline FOREIGN KEY (pip_id) references p
 ALTER TABLE warning ADD CONSTRAINT fk_environment FOREIGN KEY (env_id) references environment (id) ON delete cascade;
 ALTER TABLE warning ADD CONSTRAINT fk_action FOREIGN KEY (action_id) references action (id) ON delete cascade;
 
+-- pipeline_build_skipped
+ALTER TABLE pipeline_build_skipped ADD CONSTRAINT fk_pipeline_build_skipped_application FOREIGN KEY (application_id) references application (id) ON delete cascade;
+ALTER TABLE pipeline_build_skipped ADD CONSTRAINT fk_pipeline_build_skipped_pipeline FOREIGN KEY (pipeline_id) references pipeline (id) ON delete cascade;
+ALTER TABLE pipeline_build_skipped ADD CONSTRAINT fk_pipeline_build_skipped_environment FOREIGN KEY (environment_id) references environment (id) ON delete cascade;
+
 -- AUDIT
 ALTER TABLE project_variable_audit ADD CONSTRAINT fk_project FOREIGN KEY (project_id) references project (id) ON delete cascade;
 ALTER TABLE application_variable_audit ADD CONSTRAINT fk_application FOREIGN KEY (application_id) references application (id) ON delete cascade;
 ALTER TABLE environment_variable_audit ADD CONSTRAINT fk_environment FOREIGN KEY (environment_id) references environment (id) ON delete cascade;
+
+-- WORKSPACE CACHE
+ALTER TABLE workspace_cache ADD CONSTRAINT fk_workspace_cache_project FOREIGN KEY (project_id) references project (id) ON delete cascade;
+
+-- TEST HISTORY
+ALTER TABLE test_case_run ADD CONSTRAINT fk_test_case_run_application FOREIGN KEY (application_id) references application (id) ON delete cascade;
+ALTER TABLE test_case_run ADD CONSTRAINT fk_test_case_run_pipeline FOREIGN KEY (pipeline_id) references pipeline (id) ON delete cascade;
+ALTER TABLE test_case_run ADD CONSTRAINT fk_test_case_run_environment FOREIGN KEY (environment_id) references environment (id) ON delete cascade;
+ALTER TABLE test_quarantine ADD CONSTRAINT fk_test_quarantine_project FOREIGN KEY (project_id) references project (id) ON delete cascade;
@@ -12,13 +12,27 @@ select create_index('action_edge_parameter', 'IDX_ACTION_EDGE_PARAMETER_ACTION_E
 -- ACTION PARAMETER
 select create_unique_index('action_parameter', 'IDX_ACTION_PARAMETER_ACTION_ID', 'action_id,name');
 
+-- PIPELINE AUDIT
+select create_unique_index('pipeline_audit','IDX_PIPELINE_AUDIT_VERSION','pipeline_id,version');
+
 -- PIPELINE BUILD
 select create_index('pipeline_build', 'IDX_PIPELINE_BUILD_UNIQUE_BUILD_NUMBER', 'build_number,pipeline_id,application_id,environment_id');
+select create_index('pipeline_build', 'IDX_PIPELINE_BUILD_VCS_CHANGES_HASH', 'vcs_changes_hash');
+
+-- PIPELINE BUILD SKIPPED
+select create_index('pipeline_build_skipped', 'IDX_PIPELINE_BUILD_SKIPPED_APPLICATION_PIPELINE', 'application_id,pipeline_id,environment_id');
+
+-- PIPELINE SCHEDULE
+select create_index('pipeline_schedule', 'IDX_PIPELINE_SCHEDULE_NEXT_EXECUTION', 'next_execution');
+
+-- PIPELINE BUILD GATE
+select create_index('pipeline_build_gate', 'IDX_PIPELINE_BUILD_GATE_BUILD_STAGE', 'pipeline_build_id,pipeline_stage_id');
+select create_unique_index('pipeline_build_approval', 'IDX_PIPELINE_BUILD_APPROVAL_GATE_USER', 'gate_id,user_id');
 
 -- ACTION BUILD
 select create_index('action_build', 'IDX_ACTION_BUILD_PIPELINE_BUILD_ID', 'pipeline_build_id');
 select create_index('action_build', 'IDX_ACTION_BUILD_PIPELINE_ACTION_ID', 'pipeline_action_id');
-select create_unique_index('action_build', 'IDX_ACTION_BUILD_PIPELINE_ACTION_ID_BUILD_ID', 'pipeline_build_id,pipeline_action_id');
+select create_unique_index('action_build', 'IDX_ACTION_BUILD_PIPELINE_ACTION_ID_BUILD_ID_ATTEMPT', 'pipeline_build_id,pipeline_action_id,attempt');
 
 -- ARTIFACT
 select create_index('artifact', 'IDX_ARTIFACT_PIPELINE_ID', 'pipeline_id');
@@ -71,6 +85,7 @@ select create_unique_index('pipeline_parameter','IDX_PIPELINE_PARAMETER_NAME','p
 -- PIPELINE HISTORY
 select create_index('pipeline_history', 'IDX_PIPELINE_HISTORY', 'build_number');
 select create_index('pipeline_history','IDX_PIPELINE_HISTORY_ENVIRONMENT', 'environment_id');
+select create_index('pipeline_history', 'IDX_PIPELINE_HISTORY_VCS_CHANGES_HASH', 'vcs_changes_hash');
 
 -- PIPELINE Stage
 select create_index('pipeline_stage','IDX_PIPELINE_STAGE_BUILD_ORDER','build_order');
@@ -113,3 +128,9 @@ select create_index('worker_model','IDX_WORKER_MODEL_OWNER_ID','owner_id');
 
 -- REPOSITORIES_MANAGER_PROJECT
 select create_unique_index('repositories_manager_project', 'IDX_REPOSITORIES_MANAGER_PROJECT_ID' ,'id_repositories_manager, id_project');
+
+-- WORKER REQUIREMENT ERROR
+select create_index('worker_requirement_error', 'IDX_WORKER_REQUIREMENT_ERROR_CREATED', 'created');
+
+-- WORKSPACE CACHE
+select create_unique_index('workspace_cache', 'IDX_WORKSPACE_CACHE_PROJECT_KEY', 'project_id,cache_key');
@@ -0,0 +1,9 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "pipeline_audit" (id BIGSERIAL PRIMARY KEY, pipeline_id BIGINT, version BIGINT, user_id BIGINT, change TEXT, versionned TIMESTAMP WITH TIME ZONE, pipeline_json JSONB);
+select create_unique_index('pipeline_audit','IDX_PIPELINE_AUDIT_VERSION','pipeline_id,version');
+select create_foreign_key('FK_PIPELINE_AUDIT_PIPELINE', 'pipeline_audit', 'pipeline', 'pipeline_id', 'id');
+ALTER TABLE pipeline_build ADD COLUMN pipeline_version BIGINT;
+
+-- +migrate Down
+ALTER TABLE pipeline_build DROP COLUMN pipeline_version;
+DROP TABLE pipeline_audit;
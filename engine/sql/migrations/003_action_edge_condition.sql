@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE action_edge ADD COLUMN condition TEXT;
+
+-- +migrate Down
+ALTER TABLE action_edge DROP COLUMN condition;
@@ -0,0 +1,19 @@
+-- +migrate Up
+ALTER TABLE pipeline_action ADD COLUMN retry TEXT;
+ALTER TABLE action_build ADD COLUMN attempt INT DEFAULT 1;
+ALTER TABLE action_build ADD COLUMN retried BOOLEAN DEFAULT false;
+ALTER TABLE action_build ADD COLUMN awol BOOLEAN DEFAULT false;
+ALTER TABLE pipeline_build ADD COLUMN retried BOOLEAN DEFAULT false;
+ALTER TABLE pipeline_history ADD COLUMN retried BOOLEAN DEFAULT false;
+DROP INDEX IF EXISTS idx_action_build_pipeline_action_id_build_id;
+select create_unique_index('action_build', 'IDX_ACTION_BUILD_PIPELINE_ACTION_ID_BUILD_ID_ATTEMPT', 'pipeline_build_id,pipeline_action_id,attempt');
+
+-- +migrate Down
+-- Retried attempts are kept in the build history, the unique index on pipeline_build_id,pipeline_action_id is not restored
+DROP INDEX IF EXISTS idx_action_build_pipeline_action_id_build_id_attempt;
+ALTER TABLE pipeline_action DROP COLUMN retry;
+ALTER TABLE action_build DROP COLUMN attempt;
+ALTER TABLE action_build DROP COLUMN retried;
+ALTER TABLE action_build DROP COLUMN awol;
+ALTER TABLE pipeline_build DROP COLUMN retried;
+ALTER TABLE pipeline_history DROP COLUMN retried;
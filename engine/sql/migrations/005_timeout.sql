@@ -0,0 +1,13 @@
+-- +migrate Up
+ALTER TABLE pipeline ADD COLUMN timeout INT DEFAULT 0;
+ALTER TABLE pipeline_stage ADD COLUMN timeout INT DEFAULT 0;
+ALTER TABLE pipeline_action ADD COLUMN timeout INT DEFAULT 0;
+ALTER TABLE action_build ADD COLUMN timeout INT DEFAULT 0;
+ALTER TABLE action_build ADD COLUMN timed_out BOOLEAN DEFAULT false;
+
+-- +migrate Down
+ALTER TABLE pipeline DROP COLUMN timeout;
+ALTER TABLE pipeline_stage DROP COLUMN timeout;
+ALTER TABLE pipeline_action DROP COLUMN timeout;
+ALTER TABLE action_build DROP COLUMN timeout;
+ALTER TABLE action_build DROP COLUMN timed_out;
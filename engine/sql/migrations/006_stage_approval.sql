@@ -0,0 +1,12 @@
+-- +migrate Up
+ALTER TABLE pipeline_stage ADD COLUMN approval TEXT;
+CREATE TABLE IF NOT EXISTS "pipeline_build_gate" (id BIGSERIAL PRIMARY KEY, pipeline_build_id BIGINT, pipeline_stage_id BIGINT, status TEXT, required INT, timeout INT DEFAULT 0, requested TIMESTAMP WITH TIME ZONE, done TIMESTAMP WITH TIME ZONE);
+CREATE TABLE IF NOT EXISTS "pipeline_build_approval" (id BIGSERIAL PRIMARY KEY, gate_id BIGINT, user_id BIGINT, username TEXT, approved BOOLEAN, comment TEXT, decided TIMESTAMP WITH TIME ZONE);
+select create_index('pipeline_build_gate', 'IDX_PIPELINE_BUILD_GATE_BUILD_STAGE', 'pipeline_build_id,pipeline_stage_id');
+select create_unique_index('pipeline_build_approval', 'IDX_PIPELINE_BUILD_APPROVAL_GATE_USER', 'gate_id,user_id');
+select create_foreign_key('FK_PIPELINE_BUILD_APPROVAL_GATE', 'pipeline_build_approval', 'pipeline_build_gate', 'gate_id', 'id');
+
+-- +migrate Down
+DROP TABLE pipeline_build_approval;
+DROP TABLE pipeline_build_gate;
+ALTER TABLE pipeline_stage DROP COLUMN approval;
@@ -0,0 +1,7 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "application_pipeline_concurrency" (application_pipeline_id BIGINT, environment_id BIGINT, policy TEXT, PRIMARY KEY(application_pipeline_id, environment_id));
+SELECT create_foreign_key('FK_APPLICATION_PIPELINE_CONCURRENCY_APPLICATION_PIPELINE', 'application_pipeline_concurrency', 'application_pipeline', 'application_pipeline_id', 'id');
+SELECT create_foreign_key('FK_APPLICATION_PIPELINE_CONCURRENCY_ENVIRONMENT', 'application_pipeline_concurrency', 'environment', 'environment_id', 'id');
+
+-- +migrate Down
+DROP TABLE application_pipeline_concurrency;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE action_build ADD COLUMN cancel_requested TIMESTAMP WITH TIME ZONE;
+
+-- +migrate Down
+ALTER TABLE action_build DROP COLUMN cancel_requested;
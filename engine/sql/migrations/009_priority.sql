@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE pipeline ADD COLUMN priority INT DEFAULT 0;
+ALTER TABLE pipeline_build ADD COLUMN priority INT DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE pipeline DROP COLUMN priority;
+ALTER TABLE pipeline_build DROP COLUMN priority;
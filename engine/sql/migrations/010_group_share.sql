@@ -0,0 +1,6 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "group_share" (group_id BIGINT PRIMARY KEY, share INT DEFAULT 1, max_workers INT DEFAULT 0);
+SELECT create_foreign_key('FK_GROUP_SHARE_GROUP', 'group_share', 'group', 'group_id', 'id');
+
+-- +migrate Down
+DROP TABLE group_share;
@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "pipeline_schedule" (id BIGSERIAL PRIMARY KEY, application_id INT, pipeline_id INT, environment_id INT, cron TEXT, timezone TEXT, args TEXT, only_new_commits BOOLEAN DEFAULT false, enabled BOOLEAN DEFAULT true, next_execution TIMESTAMP WITH TIME ZONE, last_execution TIMESTAMP WITH TIME ZONE, last_hash TEXT);
+ALTER TABLE pipeline_build ADD COLUMN scheduled_trigger BOOLEAN DEFAULT false;
+ALTER TABLE pipeline_history ADD COLUMN scheduled_trigger BOOLEAN DEFAULT false;
+select create_index('pipeline_schedule', 'IDX_PIPELINE_SCHEDULE_NEXT_EXECUTION', 'next_execution');
+select create_foreign_key('FK_PIPELINE_SCHEDULE_APPLICATION', 'pipeline_schedule', 'application', 'application_id', 'id');
+select create_foreign_key('FK_PIPELINE_SCHEDULE_PIPELINE', 'pipeline_schedule', 'pipeline', 'pipeline_id', 'id');
+select create_foreign_key('FK_PIPELINE_SCHEDULE_ENVIRONMENT', 'pipeline_schedule', 'environment', 'environment_id', 'id');
+
+-- +migrate Down
+DROP TABLE pipeline_schedule;
+ALTER TABLE pipeline_build DROP COLUMN scheduled_trigger;
+ALTER TABLE pipeline_history DROP COLUMN scheduled_trigger;
@@ -0,0 +1,15 @@
+-- +migrate Up
+ALTER TABLE pipeline ADD COLUMN skip_built_commits BOOLEAN DEFAULT false;
+CREATE TABLE IF NOT EXISTS "pipeline_build_skipped" (id BIGSERIAL PRIMARY KEY, application_id INT, pipeline_id INT, environment_id INT, origin TEXT, vcs_changes_branch TEXT, vcs_changes_hash TEXT, vcs_changes_author TEXT, pipeline_build_id BIGINT, build_number INT, build_branch TEXT, skipped TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP);
+ALTER TABLE pipeline_build_skipped ADD CONSTRAINT fk_pipeline_build_skipped_application FOREIGN KEY (application_id) references application (id) ON delete cascade;
+ALTER TABLE pipeline_build_skipped ADD CONSTRAINT fk_pipeline_build_skipped_pipeline FOREIGN KEY (pipeline_id) references pipeline (id) ON delete cascade;
+ALTER TABLE pipeline_build_skipped ADD CONSTRAINT fk_pipeline_build_skipped_environment FOREIGN KEY (environment_id) references environment (id) ON delete cascade;
+select create_index('pipeline_build_skipped', 'IDX_PIPELINE_BUILD_SKIPPED_APPLICATION_PIPELINE', 'application_id,pipeline_id,environment_id');
+select create_index('pipeline_build', 'IDX_PIPELINE_BUILD_VCS_CHANGES_HASH', 'vcs_changes_hash');
+select create_index('pipeline_history', 'IDX_PIPELINE_HISTORY_VCS_CHANGES_HASH', 'vcs_changes_hash');
+
+-- +migrate Down
+DROP TABLE pipeline_build_skipped;
+ALTER TABLE pipeline DROP COLUMN skip_built_commits;
+DROP INDEX IDX_PIPELINE_BUILD_VCS_CHANGES_HASH;
+DROP INDEX IDX_PIPELINE_HISTORY_VCS_CHANGES_HASH;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE pipeline_stage ADD COLUMN needs TEXT;
+
+-- +migrate Down
+ALTER TABLE pipeline_stage DROP COLUMN needs;
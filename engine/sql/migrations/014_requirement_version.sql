@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE action_requirement ADD COLUMN version TEXT;
+ALTER TABLE worker_capability ADD COLUMN version TEXT;
+
+-- +migrate Down
+ALTER TABLE action_requirement DROP COLUMN version;
+ALTER TABLE worker_capability DROP COLUMN version;
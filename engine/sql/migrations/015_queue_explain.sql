@@ -0,0 +1,8 @@
+-- +migrate Up
+ALTER TABLE hatchery ADD COLUMN max_worker INT DEFAULT 0;
+CREATE TABLE IF NOT EXISTS "worker_requirement_error" (id BIGSERIAL PRIMARY KEY, worker_id TEXT, worker_name TEXT, worker_model_id INT, action_build_id BIGINT, message TEXT, created TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP);
+select create_index('worker_requirement_error', 'IDX_WORKER_REQUIREMENT_ERROR_CREATED', 'created');
+
+-- +migrate Down
+DROP TABLE worker_requirement_error;
+ALTER TABLE hatchery DROP COLUMN max_worker;
@@ -0,0 +1,7 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "workspace_cache" (id BIGSERIAL PRIMARY KEY, project_id INT, cache_key TEXT, size BIGINT, object_path TEXT, created TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP, last_used TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP);
+ALTER TABLE workspace_cache ADD CONSTRAINT fk_workspace_cache_project FOREIGN KEY (project_id) references project (id) ON delete cascade;
+select create_unique_index('workspace_cache', 'IDX_WORKSPACE_CACHE_PROJECT_KEY', 'project_id,cache_key');
+
+-- +migrate Down
+DROP TABLE workspace_cache;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE action_build ADD COLUMN log_size BIGINT DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE action_build DROP COLUMN log_size;
@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "test_case_run" (id BIGSERIAL PRIMARY KEY, application_id INT, pipeline_id INT, environment_id INT, pipeline_build_id BIGINT, build_number INT, vcs_changes_branch TEXT, vcs_changes_hash TEXT, suite TEXT, name TEXT, status TEXT, duration FLOAT, created TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP);
+ALTER TABLE test_case_run ADD CONSTRAINT fk_test_case_run_application FOREIGN KEY (application_id) references application (id) ON delete cascade;
+ALTER TABLE test_case_run ADD CONSTRAINT fk_test_case_run_pipeline FOREIGN KEY (pipeline_id) references pipeline (id) ON delete cascade;
+ALTER TABLE test_case_run ADD CONSTRAINT fk_test_case_run_environment FOREIGN KEY (environment_id) references environment (id) ON delete cascade;
+select create_index('test_case_run', 'IDX_TEST_CASE_RUN_TEST', 'application_id,pipeline_id,environment_id,suite,name');
+select create_index('test_case_run', 'IDX_TEST_CASE_RUN_PIPELINE_BUILD', 'pipeline_build_id');
+
+CREATE TABLE IF NOT EXISTS "test_quarantine" (id BIGSERIAL PRIMARY KEY, project_id INT, suite TEXT, name TEXT, reason TEXT, author TEXT, created TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP);
+ALTER TABLE test_quarantine ADD CONSTRAINT fk_test_quarantine_project FOREIGN KEY (project_id) references project (id) ON delete cascade;
+select create_unique_index('test_quarantine', 'IDX_TEST_QUARANTINE_PROJECT_TEST', 'project_id,suite,name');
+
+-- +migrate Down
+DROP TABLE test_case_run;
+DROP TABLE test_quarantine;
@@ -0,0 +1,7 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "pipeline_build_coverage" (pipeline_build_id BIGINT PRIMARY KEY, coverage TEXT);
+CREATE TABLE IF NOT EXISTS "pipeline_build_findings" (pipeline_build_id BIGINT PRIMARY KEY, findings TEXT);
+
+-- +migrate Down
+DROP TABLE pipeline_build_coverage;
+DROP TABLE pipeline_build_findings;
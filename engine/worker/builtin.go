@@ -22,6 +22,16 @@ func runBuiltin(a *sdk.Action, actionBuild sdk.ActionBuild) sdk.Result {
 		return runNotifAction(a, actionBuild)
 	case sdk.JUnitAction:
 		return runParseJunitTestResultAction(a, actionBuild)
+	case sdk.GitCloneAction:
+		return runGitClone(a, actionBuild)
+	case sdk.CoverageAction:
+		return runCoverageAction(a, actionBuild)
+	case sdk.FindingsAction:
+		return runFindingsAction(a, actionBuild)
+	case sdk.CacheSave:
+		return runCacheSave(a, actionBuild)
+	case sdk.CacheRestore:
+		return runCacheRestore(a, actionBuild)
 	}
 
 	sendLog(actionBuild.ID, name, fmt.Sprintf("Unknown builtin step: %s\n", name))
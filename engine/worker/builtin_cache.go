@@ -0,0 +1,405 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func runCacheSave(a *sdk.Action, actionBuild sdk.ActionBuild) sdk.Result {
+	res := sdk.Result{Status: sdk.StatusFail}
+	var keyTemplate, paths string
+	for _, p := range a.Parameters {
+		switch p.Name {
+		case "key":
+			keyTemplate = p.Value
+		case "path":
+			paths = p.Value
+		}
+	}
+	project, application, pipeline := cacheScope(actionBuild)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		sendLog(actionBuild.ID, sdk.CacheSave, fmt.Sprintf("Cannot get working directory: %s\n", err))
+		return res
+	}
+
+	key, err := cacheKey(keyTemplate, actionBuild.Args, wd)
+	if err != nil {
+		sendLog(actionBuild.ID, sdk.CacheSave, fmt.Sprintf("%s\n", err))
+		return res
+	}
+
+	if restoredCaches[key] {
+		sendLog(actionBuild.ID, sdk.CacheSave, fmt.Sprintf("Cache %s was restored by this build, not saving it again\n", key))
+		res.Status = sdk.StatusSuccess
+		return res
+	}
+
+	files, err := cachePaths(paths)
+	if err != nil {
+		sendLog(actionBuild.ID, sdk.CacheSave, fmt.Sprintf("%s\n", err))
+		return res
+	}
+	if len(files) == 0 {
+		sendLog(actionBuild.ID, sdk.CacheSave, "path is empty. aborting\n")
+		return res
+	}
+
+	sendLog(actionBuild.ID, sdk.CacheSave, fmt.Sprintf("Saving %s as cache %s...\n", strings.Join(files, ", "), key))
+
+	// Stream the archive to the API while it is built
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(archiveCache(pw, wd, files))
+	}()
+
+	if err := sdk.UploadCache(project, application, pipeline, key, pr); err != nil {
+		pr.CloseWithError(err)
+		sendLog(actionBuild.ID, sdk.CacheSave, fmt.Sprintf("Cannot save cache %s: %s\n", key, err))
+		return res
+	}
+
+	sendLog(actionBuild.ID, sdk.CacheSave, fmt.Sprintf("Cache %s saved\n", key))
+	res.Status = sdk.StatusSuccess
+	return res
+}
+
+func runCacheRestore(a *sdk.Action, actionBuild sdk.ActionBuild) sdk.Result {
+	res := sdk.Result{Status: sdk.StatusFail}
+	var keyTemplate, fallbackTemplates string
+	for _, p := range a.Parameters {
+		switch p.Name {
+		case "key":
+			keyTemplate = p.Value
+		case "fallback-keys":
+			fallbackTemplates = p.Value
+		}
+	}
+	project, application, pipeline := cacheScope(actionBuild)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		sendLog(actionBuild.ID, sdk.CacheRestore, fmt.Sprintf("Cannot get working directory: %s\n", err))
+		return res
+	}
+
+	key, err := cacheKey(keyTemplate, actionBuild.Args, wd)
+	if err != nil {
+		sendLog(actionBuild.ID, sdk.CacheRestore, fmt.Sprintf("%s\n", err))
+		return res
+	}
+
+	var fallbacks []string
+	for _, t := range strings.Split(fallbackTemplates, "\n") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		f, err := cacheKey(t, actionBuild.Args, wd)
+		if err != nil {
+			sendLog(actionBuild.ID, sdk.CacheRestore, fmt.Sprintf("Ignoring fallback key: %s\n", err))
+			continue
+		}
+		fallbacks = append(fallbacks, f)
+	}
+
+	c, err := sdk.ResolveCache(project, application, pipeline, key, fallbacks)
+	if err == sdk.ErrCacheNotFound {
+		// A cache miss is not an error, the build just takes longer
+		sendLog(actionBuild.ID, sdk.CacheRestore, fmt.Sprintf("No cache matches key %s\n", key))
+		res.Status = sdk.StatusSuccess
+		return res
+	}
+	if err != nil {
+		sendLog(actionBuild.ID, sdk.CacheRestore, fmt.Sprintf("Cannot resolve cache %s: %s\n", key, err))
+		return res
+	}
+
+	sendLog(actionBuild.ID, sdk.CacheRestore, fmt.Sprintf("Restoring cache %s (%d bytes)...\n", c.Key, c.Size))
+	reader, err := sdk.DownloadCache(project, application, pipeline, c.Key)
+	if err != nil {
+		sendLog(actionBuild.ID, sdk.CacheRestore, fmt.Sprintf("Cannot download cache %s: %s\n", c.Key, err))
+		return res
+	}
+	defer reader.Close()
+
+	if err := extractCache(reader, wd); err != nil {
+		sendLog(actionBuild.ID, sdk.CacheRestore, fmt.Sprintf("Cannot extract cache %s: %s\n", c.Key, err))
+		return res
+	}
+
+	if c.Key == key {
+		restoredCaches[key] = true
+	}
+	sendLog(actionBuild.ID, sdk.CacheRestore, fmt.Sprintf("Cache %s restored\n", c.Key))
+	res.Status = sdk.StatusSuccess
+	return res
+}
+
+func cacheScope(actionBuild sdk.ActionBuild) (project, application, pipeline string) {
+	for _, p := range actionBuild.Args {
+		switch p.Name {
+		case "cds.project":
+			project = p.Value
+		case "cds.application":
+			application = p.Value
+		case "cds.pipeline":
+			pipeline = p.Value
+		}
+	}
+	return
+}
+
+// cacheKey executes a cache key template, whose data are the build arguments and variables,
+// e.g. {{.cds.application}}, and where checksum hashes the files of the workspace matching given patterns
+func cacheKey(keyTemplate string, args []sdk.Parameter, wd string) (string, error) {
+	data := map[string]interface{}{}
+	for _, p := range args {
+		setTemplateData(data, p.Name, p.Value)
+	}
+	for _, v := range buildVariables {
+		setTemplateData(data, "cds.build."+v.Name, v.Value)
+	}
+
+	funcs := template.FuncMap{
+		"checksum": func(patterns ...string) (string, error) {
+			return checksumFiles(wd, patterns)
+		},
+	}
+
+	t, err := template.New("key").Funcs(funcs).Option("missingkey=error").Parse(keyTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid cache key %s: %s", keyTemplate, err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("cannot compute cache key %s: %s", keyTemplate, err)
+	}
+
+	key := strings.TrimSpace(buf.String())
+	if err := sdk.CheckCacheKey(key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// setTemplateData sets a dotted name, such as cds.application, as nested maps.
+// Names clashing with a prefix of another name are dropped.
+func setTemplateData(data map[string]interface{}, name, value string) {
+	parts := strings.Split(name, ".")
+	m := data
+	for _, p := range parts[:len(parts)-1] {
+		sub, ok := m[p].(map[string]interface{})
+		if !ok {
+			if _, exists := m[p]; exists {
+				return
+			}
+			sub = map[string]interface{}{}
+			m[p] = sub
+		}
+		m = sub
+	}
+	if _, exists := m[parts[len(parts)-1]]; exists {
+		return
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+// checksumFiles returns the sha256 of the names and contents of the files matching given patterns
+func checksumFiles(wd string, patterns []string) (string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(wd, pattern))
+		if err != nil {
+			return "", fmt.Errorf("cannot perform globbing of pattern '%s': %s", pattern, err)
+		}
+		for _, m := range matches {
+			fi, err := os.Stat(m)
+			if err != nil {
+				return "", err
+			}
+			if fi.Mode().IsRegular() {
+				files = append(files, m)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("checksum: patterns %s matched no file", strings.Join(patterns, ", "))
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for i, f := range files {
+		if i > 0 && files[i-1] == f {
+			continue
+		}
+		rel, _ := filepath.Rel(wd, f)
+		fmt.Fprintf(h, "%s\x00", filepath.ToSlash(rel))
+
+		file, err := os.Open(f)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cachePaths parses the paths to archive, one per line. They are relative to the workspace,
+// ~/ standing for the workspace itself, and cannot escape it.
+func cachePaths(paths string) ([]string, error) {
+	var files []string
+	for _, p := range strings.Split(paths, "\n") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		p = strings.TrimPrefix(p, "~/")
+		clean := filepath.Clean(p)
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("path %s is outside of the workspace", p)
+		}
+		files = append(files, clean)
+	}
+	return files, nil
+}
+
+// archiveCache writes a tar.gz archive of given paths, relative to wd. Missing paths are skipped.
+func archiveCache(w io.Writer, wd string, paths []string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, p := range paths {
+		err := filepath.Walk(filepath.Join(wd, p), func(file string, fi os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+
+			rel, err := filepath.Rel(wd, file)
+			if err != nil {
+				return err
+			}
+
+			var link string
+			if fi.Mode()&os.ModeSymlink != 0 {
+				if link, err = os.Readlink(file); err != nil {
+					return err
+				}
+			}
+
+			hdr, err := tar.FileInfoHeader(fi, link)
+			if err != nil {
+				return err
+			}
+			hdr.Name = filepath.ToSlash(rel)
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+
+			if !fi.Mode().IsRegular() {
+				return nil
+			}
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// extractCache extracts a tar.gz archive in wd, refusing entries and links escaping it
+func extractCache(r io.Reader, wd string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(wd, filepath.FromSlash(hdr.Name))
+		if !insideDir(wd, target) {
+			return fmt.Errorf("%s is outside of the workspace", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(hdr.Mode)|0700); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			dest := hdr.Linkname
+			if !filepath.IsAbs(dest) {
+				dest = filepath.Join(filepath.Dir(target), dest)
+			}
+			if !insideDir(wd, dest) {
+				return fmt.Errorf("%s links outside of the workspace", hdr.Name)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(hdr.Mode))
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func insideDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
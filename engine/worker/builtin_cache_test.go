@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestCacheKey(t *testing.T) {
+	wd, err := ioutil.TempDir("", "cds-cache-key")
+	assert.NoError(t, err)
+	defer os.RemoveAll(wd)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(wd, "go.sum"), []byte("foo v1.0.0"), 0644))
+
+	args := []sdk.Parameter{{Name: "cds.application", Value: "myapp"}}
+
+	key, err := cacheKey(`{{.cds.application}}-{{checksum "go.sum"}}`, args, wd)
+	assert.NoError(t, err)
+	assert.Len(t, key, len("myapp-")+64)
+
+	same, err := cacheKey(`{{.cds.application}}-{{checksum "*.sum"}}`, args, wd)
+	assert.NoError(t, err)
+	assert.Equal(t, key, same)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(wd, "go.sum"), []byte("foo v1.0.1"), 0644))
+	changed, err := cacheKey(`{{.cds.application}}-{{checksum "go.sum"}}`, args, wd)
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, changed)
+
+	_, err = cacheKey(`{{checksum "missing.lock"}}`, args, wd)
+	assert.Error(t, err)
+	_, err = cacheKey(`{{.cds.unknown}}`, args, wd)
+	assert.Error(t, err)
+	_, err = cacheKey(`my key`, args, wd)
+	assert.Error(t, err)
+}
+
+func TestCacheArchive(t *testing.T) {
+	src, err := ioutil.TempDir("", "cds-cache-src")
+	assert.NoError(t, err)
+	defer os.RemoveAll(src)
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, "vendor", "lib"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "vendor", "lib", "lib.go"), []byte("package lib"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "main.go"), []byte("package main"), 0644))
+
+	paths, err := cachePaths("~/vendor\n\nmissing\n")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vendor", "missing"}, paths)
+	_, err = cachePaths("../outside")
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, archiveCache(&buf, src, paths))
+
+	dst, err := ioutil.TempDir("", "cds-cache-dst")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dst)
+	assert.NoError(t, extractCache(&buf, dst))
+
+	content, err := ioutil.ReadFile(filepath.Join(dst, "vendor", "lib", "lib.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package lib", string(content))
+	_, err = os.Stat(filepath.Join(dst, "main.go"))
+	assert.True(t, os.IsNotExist(err))
+}
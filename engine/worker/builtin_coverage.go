@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// buildReportURI returns the URI of given report of the pipeline build running an action build
+func buildReportURI(ab sdk.ActionBuild, report string) string {
+	var proj, app, pip, bnS, envName string
+	for _, p := range ab.Args {
+		switch p.Name {
+		case "cds.pipeline":
+			pip = p.Value
+		case "cds.project":
+			proj = p.Value
+		case "cds.application":
+			app = p.Value
+		case "cds.buildNumber":
+			bnS = p.Value
+		case "cds.environment":
+			envName = p.Value
+		}
+	}
+	return fmt.Sprintf("/project/%s/application/%s/pipeline/%s/build/%s/%s?envName=%s", proj, app, pip, bnS, report, envName)
+}
+
+// reportFile is a report read from the workspace
+type reportFile struct {
+	path string
+	data []byte
+}
+
+// readReports reads the files matching a glob pattern, in order
+func readReports(pattern string) ([]reportFile, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("path not provided")
+	}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s", pattern)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file matching %s", pattern)
+	}
+
+	var reports []reportFile
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read file %s (%s)", f, err)
+		}
+		reports = append(reports, reportFile{path: f, data: data})
+	}
+	return reports, nil
+}
+
+func runCoverageAction(a *sdk.Action, ab sdk.ActionBuild) sdk.Result {
+	res := sdk.Result{Status: sdk.StatusFail}
+
+	var path, format, thresholdS string
+	for _, p := range a.Parameters {
+		switch p.Name {
+		case "path":
+			path = p.Value
+		case "format":
+			format = p.Value
+		case "threshold":
+			thresholdS = p.Value
+		}
+	}
+
+	threshold := -1.0
+	if thresholdS != "" {
+		t, err := strconv.ParseFloat(thresholdS, 64)
+		if err != nil || t < 0 {
+			sendLog(ab.ID, sdk.CoverageAction, fmt.Sprintf("Coverage: invalid threshold %s", thresholdS))
+			return res
+		}
+		threshold = t
+	}
+
+	reports, err := readReports(path)
+	if err != nil {
+		sendLog(ab.ID, sdk.CoverageAction, fmt.Sprintf("Coverage: %s", err))
+		return res
+	}
+
+	counter := coverageCounter{}
+	for _, r := range reports {
+		if err := parseCoverageReport(counter, r.data, format); err != nil {
+			sendLog(ab.ID, sdk.CoverageAction, fmt.Sprintf("Coverage: cannot interpret file %s (%s)", r.path, err))
+			return res
+		}
+	}
+
+	data, err := json.Marshal(counter.coverage())
+	if err != nil {
+		sendLog(ab.ID, sdk.CoverageAction, fmt.Sprintf("Coverage: failed to send coverage: %s", err))
+		return res
+	}
+
+	// The API answers with the coverage of the build, merging previous reports, and its reference
+	data, code, err := sdk.Request("POST", buildReportURI(ab, "coverage"), data)
+	if err == nil && code > 300 {
+		err = fmt.Errorf("HTTP %d", code)
+	}
+	if err != nil {
+		sendLog(ab.ID, sdk.CoverageAction, fmt.Sprintf("Coverage: failed to send coverage: %s", err))
+		return res
+	}
+	var c sdk.Coverage
+	if err := json.Unmarshal(data, &c); err != nil {
+		sendLog(ab.ID, sdk.CoverageAction, fmt.Sprintf("Coverage: cannot read coverage of the build: %s", err))
+		return res
+	}
+
+	sendLog(ab.ID, sdk.CoverageAction, fmt.Sprintf("Coverage: %.2f%% (%d/%d) in %d files", c.Percent, c.Covered, c.Total, len(c.Files)))
+	res.Status = sdk.StatusSuccess
+
+	if c.Reference == nil {
+		return res
+	}
+	sendLog(ab.ID, sdk.CoverageAction, fmt.Sprintf("Coverage: %.2f%% in build #%d, last successful build on the branch", c.Reference.Percent, c.Reference.BuildNumber))
+	if drop, failed := c.Drop(threshold); failed {
+		sendLog(ab.ID, sdk.CoverageAction, fmt.Sprintf("Coverage: dropped by %.2f points, more than the threshold of %.2f", drop, threshold))
+		res.Status = sdk.StatusFail
+	}
+	return res
+}
+
+func runFindingsAction(a *sdk.Action, ab sdk.ActionBuild) sdk.Result {
+	res := sdk.Result{Status: sdk.StatusFail}
+
+	var path, format string
+	for _, p := range a.Parameters {
+		switch p.Name {
+		case "path":
+			path = p.Value
+		case "format":
+			format = p.Value
+		}
+	}
+
+	reports, err := readReports(path)
+	if err != nil {
+		sendLog(ab.ID, sdk.FindingsAction, fmt.Sprintf("Findings: %s", err))
+		return res
+	}
+
+	var v sdk.Findings
+	for _, r := range reports {
+		findings, err := parseFindingsReport(r.data, format, filepath.Base(r.path))
+		if err != nil {
+			sendLog(ab.ID, sdk.FindingsAction, fmt.Sprintf("Findings: cannot interpret file %s (%s)", r.path, err))
+			return res
+		}
+		v.Findings = append(v.Findings, findings...)
+	}
+	v.UpdateTotals()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		sendLog(ab.ID, sdk.FindingsAction, fmt.Sprintf("Findings: failed to send findings: %s", err))
+		return res
+	}
+
+	_, code, err := sdk.Request("POST", buildReportURI(ab, "findings"), data)
+	if err == nil && code > 300 {
+		err = fmt.Errorf("HTTP %d", code)
+	}
+	if err != nil {
+		sendLog(ab.ID, sdk.FindingsAction, fmt.Sprintf("Findings: failed to send findings: %s", err))
+		return res
+	}
+
+	sendLog(ab.ID, sdk.FindingsAction, fmt.Sprintf("Findings: %d errors, %d warnings, %d infos", v.Errors, v.Warnings, v.Infos))
+	res.Status = sdk.StatusSuccess
+	return res
+}
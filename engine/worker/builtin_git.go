@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func runGitClone(a *sdk.Action, actionBuild sdk.ActionBuild) sdk.Result {
+	res := sdk.Result{Status: sdk.StatusFail}
+	var url, branch, commit, directory, depthS, submodules string
+	for _, p := range a.Parameters {
+		switch p.Name {
+		case "url":
+			url = p.Value
+		case "branch":
+			branch = p.Value
+		case "commit":
+			commit = p.Value
+		case "directory":
+			directory = p.Value
+		case "depth":
+			depthS = p.Value
+		case "submodules":
+			submodules = p.Value
+		}
+	}
+
+	var project, application, gitURL, gitBranch, gitHash string
+	for _, p := range actionBuild.Args {
+		switch p.Name {
+		case "cds.project":
+			project = p.Value
+		case "cds.application":
+			application = p.Value
+		case "git.url":
+			gitURL = p.Value
+		case "git.branch":
+			gitBranch = p.Value
+		case "git.hash":
+			gitHash = p.Value
+		}
+	}
+	if url == "" {
+		url = gitURL
+	}
+	if branch == "" {
+		branch = gitBranch
+	}
+	if commit == "" {
+		commit = gitHash
+	}
+
+	if strings.HasPrefix(commit, "-") {
+		sendLog(actionBuild.ID, sdk.GitCloneAction, fmt.Sprintf("invalid commit '%s'. aborting\n", commit))
+		return res
+	}
+
+	var depth int
+	if depthS != "" {
+		var err error
+		depth, err = strconv.Atoi(depthS)
+		if err != nil || depth < 0 {
+			sendLog(actionBuild.ID, sdk.GitCloneAction, fmt.Sprintf("invalid depth '%s'. aborting\n", depthS))
+			return res
+		}
+	}
+
+	// Clone the repository the application is attached to
+	if url == "" {
+		repo, err := sdk.GetApplicationRepository(project, application)
+		if err != nil {
+			sendLog(actionBuild.ID, sdk.GitCloneAction, fmt.Sprintf("Cannot get repository of application %s: %s\n", application, err))
+			return res
+		}
+		url = repo.SSHCloneURL
+		if url == "" || (pkey == "" && repo.HTTPCloneURL != "") {
+			url = repo.HTTPCloneURL
+		}
+		if url == "" {
+			sendLog(actionBuild.ID, sdk.GitCloneAction, fmt.Sprintf("Repository %s has no clone URL. aborting\n", repo.Fullname))
+			return res
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		sendLog(actionBuild.ID, sdk.GitCloneAction, fmt.Sprintf("Cannot get working directory: %s\n", err))
+		return res
+	}
+	if directory == "" {
+		directory = gitRepositoryName(url)
+	}
+	dir := filepath.Join(wd, directory)
+	if !insideDir(wd, dir) || dir == wd {
+		sendLog(actionBuild.ID, sdk.GitCloneAction, fmt.Sprintf("directory %s is outside of the workspace. aborting\n", directory))
+		return res
+	}
+
+	cloneArgs := []string{"clone"}
+	if depth > 0 {
+		cloneArgs = append(cloneArgs, "--depth", strconv.Itoa(depth))
+	}
+	if branch != "" {
+		cloneArgs = append(cloneArgs, "--branch", branch)
+	}
+	// The url comes from the user, it must not be taken for an option
+	cloneArgs = append(cloneArgs, "--", url, dir)
+	if _, err := runGit(actionBuild.ID, wd, cloneArgs...); err != nil {
+		return res
+	}
+
+	if commit != "" {
+		checkoutArgs := []string{"checkout", "-q", commit}
+		if branch != "" {
+			checkoutArgs = []string{"checkout", "-q", "-B", branch, commit}
+		}
+		if _, err := runGit(actionBuild.ID, dir, checkoutArgs...); err != nil {
+			if depth == 0 {
+				return res
+			}
+			// The commit may be older than the fetched history
+			if _, err := runGit(actionBuild.ID, dir, "fetch", "-q", "--unshallow", "origin"); err != nil {
+				return res
+			}
+			if _, err := runGit(actionBuild.ID, dir, checkoutArgs...); err != nil {
+				return res
+			}
+		}
+	}
+
+	if submodules == "true" {
+		if _, err := runGit(actionBuild.ID, dir, "submodule", "update", "--init", "--recursive"); err != nil {
+			return res
+		}
+	}
+
+	out, err := runGit(actionBuild.ID, dir, "log", "-1", "--format=%H%n%an%n%B")
+	if err != nil {
+		return res
+	}
+	hash, author, message := parseGitLog(out)
+	if branch == "" {
+		if out, err := runGit(actionBuild.ID, dir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+			branch = strings.TrimSpace(out)
+		}
+	}
+
+	vars := []sdk.Variable{
+		{Name: "git.hash", Type: sdk.StringVariable, Value: hash},
+		{Name: "git.branch", Type: sdk.StringVariable, Value: branch},
+		{Name: "git.author", Type: sdk.StringVariable, Value: author},
+		{Name: "git.message", Type: sdk.StringVariable, Value: message},
+	}
+	for _, v := range vars {
+		if err := addBuildVariable(v); err != nil {
+			sendLog(actionBuild.ID, sdk.GitCloneAction, fmt.Sprintf("Cannot export %s: %s\n", v.Name, err))
+			return res
+		}
+	}
+
+	sendLog(actionBuild.ID, sdk.GitCloneAction, fmt.Sprintf("Checked out %s (%s) in %s\n", hash, branch, directory))
+	res.Status = sdk.StatusSuccess
+	return res
+}
+
+// runGit runs a git command in given directory with the ssh key of the build, and returns its standard output.
+// The command and its errors are logged in the step.
+func runGit(buildID int64, dir string, args ...string) (string, error) {
+	sendLog(buildID, sdk.GitCloneAction, fmt.Sprintf("git %s\n", strings.Join(args, " ")))
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = os.Environ()
+	// Fail instead of waiting for credentials
+	cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0")
+	if pkey != "" && gitssh != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", pKEY, pkey))
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", GitSSH, gitssh))
+	}
+
+	if err := startProcess(cmd); err != nil {
+		sendLog(buildID, sdk.GitCloneAction, fmt.Sprintf("%s\n", err))
+		return "", err
+	}
+	err := cmd.Wait()
+	endProcess()
+	if err != nil {
+		sendLog(buildID, sdk.GitCloneAction, fmt.Sprintf("%s%s\n", stderr.String(), err))
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// gitRepositoryName returns the directory git clone would create for given URL
+func gitRepositoryName(url string) string {
+	name := strings.TrimRight(url, "/")
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(name, ".git")
+}
+
+// parseGitLog parses the output of git log -1 --format=%H%n%an%n%B
+func parseGitLog(out string) (hash, author, message string) {
+	lines := strings.SplitN(out, "\n", 3)
+	hash = strings.TrimSpace(lines[0])
+	if len(lines) > 1 {
+		author = strings.TrimSpace(lines[1])
+	}
+	if len(lines) > 2 {
+		message = strings.TrimSpace(lines[2])
+	}
+	return
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitRepositoryName(t *testing.T) {
+	assert.Equal(t, "my-repo", gitRepositoryName("ssh://git@stash.example.com:7999/PRJ/my-repo.git"))
+	assert.Equal(t, "cds", gitRepositoryName("git@github.com:ovh/cds.git"))
+	assert.Equal(t, "cds", gitRepositoryName("https://github.com/ovh/cds/"))
+	assert.Equal(t, "repo", gitRepositoryName("git@host:repo.git"))
+}
+
+func TestParseGitLog(t *testing.T) {
+	hash, author, message := parseGitLog("0123abcd\nJohn Doe\nFix build\n\nLonger description\n")
+	assert.Equal(t, "0123abcd", hash)
+	assert.Equal(t, "John Doe", author)
+	assert.Equal(t, "Fix build\n\nLonger description", message)
+
+	hash, author, message = parseGitLog("0123abcd\n")
+	assert.Equal(t, "0123abcd", hash)
+	assert.Equal(t, "", author)
+	assert.Equal(t, "", message)
+}
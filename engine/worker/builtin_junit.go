@@ -36,11 +36,13 @@ func runParseJunitTestResultAction(a *sdk.Action, ab sdk.ActionBuild) sdk.Result
 		}
 	}
 
-	var p string
+	var p, format string
 	for _, a := range a.Parameters {
-		if a.Name == "path" {
+		switch a.Name {
+		case "path":
 			p = a.Value
-			break
+		case "format":
+			format = a.Value
 		}
 	}
 
@@ -57,39 +59,42 @@ func runParseJunitTestResultAction(a *sdk.Action, ab sdk.ActionBuild) sdk.Result
 
 	var v sdk.Tests
 	for _, f := range files {
-		var ftests sdk.Tests
-
 		data, err := ioutil.ReadFile(f)
 		if err != nil {
 			sendLog(ab.ID, sdk.JUnitAction, fmt.Sprintf("UnitTest parser: cannot read file %s (%s)", f, err))
 			return res
 		}
 
-		err = xml.Unmarshal([]byte(data), &v)
+		suites, err := parseTestReport(data, format, filepath.Base(f))
 		if err != nil {
 			sendLog(ab.ID, sdk.JUnitAction, fmt.Sprintf("UnitTest parser: cannot interpret file %s (%s)", f, err))
 			return res
 		}
 
-		// Is it nosetests format ?
-		if s, ok := parseNoseTests(data); ok {
-			ftests.TestSuites = append(ftests.TestSuites, s)
-		}
-
-		v.TestSuites = append(v.TestSuites, ftests.TestSuites...)
+		v.TestSuites = append(v.TestSuites, suites...)
 	}
 	// update global stats
 	for _, s := range v.TestSuites {
 		v.Total += s.Total
-		v.TotalOK += (s.Total - s.Failures)
-		v.TotalKO += s.Failures
+		v.TotalOK += s.Total - s.Failures - s.Errors - s.Skip
+		v.TotalKO += s.Failures + s.Errors
 		v.TotalSkipped += s.Skip
 	}
 
+	// Failures of the tests quarantined by the project do not fail the step
+	quarantine, err := sdk.GetTestQuarantine(proj)
+	if err != nil {
+		sendLog(ab.ID, sdk.JUnitAction, fmt.Sprintf("JUnit parser: cannot load quarantined tests, none is ignored (%s)", err))
+	}
+
 	res.Status = sdk.StatusSuccess
 	for _, s := range v.TestSuites {
-		if s.Failures > 0 {
-			sendLog(ab.ID, sdk.JUnitAction, fmt.Sprintf("JUnit parser: %s has %d failed tests", s.Name, s.Failures))
+		failed, quarantined := failedTests(s, quarantine)
+		for _, t := range quarantined {
+			sendLog(ab.ID, sdk.JUnitAction, fmt.Sprintf("JUnit parser: %s/%s failed but is quarantined, ignoring its failure", s.Name, t))
+		}
+		if failed > 0 {
+			sendLog(ab.ID, sdk.JUnitAction, fmt.Sprintf("JUnit parser: %s has %d failed tests", s.Name, failed))
 			res.Status = sdk.StatusFail
 		}
 	}
@@ -120,6 +125,17 @@ func runParseJunitTestResultAction(a *sdk.Action, ab sdk.ActionBuild) sdk.Result
 	return res
 }
 
+// failedTests counts the failed tests of a suite which are not quarantined, and lists the quarantined ones which failed
+func failedTests(s sdk.TestSuite, quarantine []sdk.TestQuarantine) (int, []string) {
+	var quarantined []string
+	for _, t := range s.Tests {
+		if sdk.TestCaseStatus(t) == sdk.TestCaseFail && sdk.IsQuarantined(quarantine, s.Name, t.Name) {
+			quarantined = append(quarantined, t.Name)
+		}
+	}
+	return s.Failures + s.Errors - len(quarantined), quarantined
+}
+
 func parseNoseTests(data []byte) (sdk.TestSuite, bool) {
 	var s sdk.TestSuite
 	err := xml.Unmarshal([]byte(data), &s)
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestFailedTests(t *testing.T) {
+	skipped := ""
+	s := sdk.TestSuite{
+		Name:     "com.example.FooTest",
+		Failures: 2,
+		Errors:   1,
+		Tests: []sdk.Test{
+			{Name: "testOk"},
+			{Name: "testFlaky", Failure: "timeout"},
+			{Name: "testKo", Failure: "expected 1"},
+			{Name: "testBroken", Error: "NullPointerException"},
+			{Name: "testSkipped", Skip: &skipped},
+		},
+	}
+
+	failed, quarantined := failedTests(s, nil)
+	assert.Equal(t, 3, failed)
+	assert.Len(t, quarantined, 0)
+
+	quarantine := []sdk.TestQuarantine{
+		{Suite: "com.example.FooTest", Name: "testFlaky"},
+		{Suite: "com.example.FooTest", Name: "testOk"},
+		{Suite: "com.example.BarTest", Name: "testKo"},
+	}
+	failed, quarantined = failedTests(s, quarantine)
+	assert.Equal(t, 2, failed)
+	assert.Equal(t, []string{"testFlaky"}, quarantined)
+}
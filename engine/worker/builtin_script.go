@@ -179,7 +179,7 @@ func runScriptAction(a *sdk.Action, actionBuild sdk.ActionBuild) sdk.Result {
 		}
 	}()
 
-	err = cmd.Start()
+	err = startProcess(cmd)
 	if err != nil {
 		sendLog(actionBuild.ID, sdk.ScriptAction, fmt.Sprintf("%s\n", err))
 		res.Status = sdk.StatusFail
@@ -189,6 +189,7 @@ func runScriptAction(a *sdk.Action, actionBuild sdk.ActionBuild) sdk.Result {
 	_ = <-outchan
 	_ = <-errchan
 	err = cmd.Wait()
+	endProcess()
 	if err != nil {
 		sendLog(actionBuild.ID, sdk.ScriptAction, fmt.Sprintf("%s\n", err))
 		res.Status = sdk.StatusFail
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// Coverage report formats understood by the Coverage builtin action
+const (
+	coverageFormatAuto      = "auto"
+	coverageFormatCobertura = "cobertura"
+	coverageFormatGoCover   = "gocover"
+	coverageFormatLCOV      = "lcov"
+)
+
+// Analyzer report formats understood by the Findings builtin action
+const (
+	findingsFormatAuto       = "auto"
+	findingsFormatCheckstyle = "checkstyle"
+	findingsFormatSARIF      = "sarif"
+)
+
+// coverageElement is a line, a block of statements or a branch, covered or not
+type coverageElement struct {
+	weight  int
+	covered bool
+}
+
+type fileCounter struct {
+	elements map[string]*coverageElement
+	branches map[string]*coverageElement
+}
+
+// coverageCounter accumulates the coverage of the files of several reports. An element reported
+// several times, by different classes of a same file or by merged profiles, is counted once,
+// covered if any report covers it.
+type coverageCounter map[string]*fileCounter
+
+func (c coverageCounter) file(path string) *fileCounter {
+	f, ok := c[path]
+	if !ok {
+		f = &fileCounter{elements: map[string]*coverageElement{}, branches: map[string]*coverageElement{}}
+		c[path] = f
+	}
+	return f
+}
+
+func addElement(elements map[string]*coverageElement, key string, weight int, covered bool) {
+	e, ok := elements[key]
+	if !ok {
+		elements[key] = &coverageElement{weight: weight, covered: covered}
+		return
+	}
+	e.covered = e.covered || covered
+}
+
+// coverage returns the coverage of the files, sorted by path, and its totals
+func (c coverageCounter) coverage() sdk.Coverage {
+	var cov sdk.Coverage
+	for path, f := range c {
+		fc := sdk.FileCoverage{Path: path}
+		for _, e := range f.elements {
+			fc.Total += e.weight
+			if e.covered {
+				fc.Covered += e.weight
+			}
+		}
+		for _, e := range f.branches {
+			fc.TotalBranches += e.weight
+			if e.covered {
+				fc.CoveredBranches += e.weight
+			}
+		}
+		cov.Files = append(cov.Files, fc)
+	}
+	cov.UpdateTotals()
+	return cov
+}
+
+// parseCoverageReport adds the coverage of a report of given format to a counter, detecting the format
+// from the content when auto
+func parseCoverageReport(c coverageCounter, data []byte, format string) error {
+	if format == "" || format == coverageFormatAuto {
+		format = detectCoverageFormat(data)
+		if format == "" {
+			return fmt.Errorf("unknown coverage report format")
+		}
+	}
+
+	switch format {
+	case coverageFormatCobertura:
+		return parseCobertura(c, data)
+	case coverageFormatGoCover:
+		return parseGoCoverProfile(c, data)
+	case coverageFormatLCOV:
+		return parseLCOV(c, data)
+	}
+	return fmt.Errorf("unknown coverage report format %s", format)
+}
+
+// xmlRoot returns the name of the root element of a XML document
+func xmlRoot(data []byte) string {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return ""
+		}
+		if se, ok := t.(xml.StartElement); ok {
+			return se.Name.Local
+		}
+	}
+}
+
+var lcovLineRegexp = regexp.MustCompile(`^(TN|SF):`)
+
+// detectCoverageFormat guesses the format of a coverage report from its first element or line
+func detectCoverageFormat(data []byte) string {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	switch {
+	case len(data) == 0:
+		return ""
+	case data[0] == '<':
+		if xmlRoot(data) == "coverage" {
+			return coverageFormatCobertura
+		}
+	case bytes.HasPrefix(data, []byte("mode:")):
+		return coverageFormatGoCover
+	case lcovLineRegexp.Match(data):
+		return coverageFormatLCOV
+	}
+	return ""
+}
+
+var conditionCoverageRegexp = regexp.MustCompile(`\((\d+)/(\d+)\)`)
+
+func parseCobertura(c coverageCounter, data []byte) error {
+	var report struct {
+		Classes []struct {
+			Filename string `xml:"filename,attr"`
+			Lines    []struct {
+				Number            int    `xml:"number,attr"`
+				Hits              int64  `xml:"hits,attr"`
+				ConditionCoverage string `xml:"condition-coverage,attr"`
+			} `xml:"lines>line"`
+		} `xml:"packages>package>classes>class"`
+	}
+	if err := xml.Unmarshal(data, &report); err != nil {
+		return err
+	}
+
+	for _, cl := range report.Classes {
+		f := c.file(cl.Filename)
+		for _, l := range cl.Lines {
+			line := strconv.Itoa(l.Number)
+			addElement(f.elements, line, 1, l.Hits > 0)
+
+			// condition-coverage="50% (1/2)"
+			m := conditionCoverageRegexp.FindStringSubmatch(l.ConditionCoverage)
+			if m == nil {
+				continue
+			}
+			covered, _ := strconv.Atoi(m[1])
+			total, _ := strconv.Atoi(m[2])
+			for i := 0; i < total; i++ {
+				addElement(f.branches, line+"#"+strconv.Itoa(i), 1, i < covered)
+			}
+		}
+	}
+	return nil
+}
+
+// parseGoCoverProfile parses the blocks of a profile written by go test -coverprofile,
+// e.g. "github.com/ovh/cds/sdk/error.go:12.40,14.2 1 3", counting statements
+func parseGoCoverProfile(c coverageCounter, data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		i := strings.LastIndex(line, ":")
+		if len(fields) < 3 || i < 0 {
+			return fmt.Errorf("invalid coverprofile line %q", line)
+		}
+		statements, err := strconv.Atoi(fields[len(fields)-2])
+		if err != nil {
+			return fmt.Errorf("invalid coverprofile line %q", line)
+		}
+		count, err := strconv.ParseInt(fields[len(fields)-1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid coverprofile line %q", line)
+		}
+
+		block := strings.Fields(line[i+1:])[0]
+		addElement(c.file(line[:i]).elements, block, statements, count > 0)
+	}
+	return scanner.Err()
+}
+
+// parseLCOV parses the records of a LCOV tracefile, counting lines (DA) and branches (BRDA)
+func parseLCOV(c coverageCounter, data []byte) error {
+	var f *fileCounter
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			// end_of_record
+			f = nil
+			continue
+		}
+		key, value := line[:i], line[i+1:]
+
+		switch key {
+		case "SF":
+			f = c.file(value)
+		case "DA":
+			// DA:<line>,<hits>[,<checksum>]
+			v := strings.Split(value, ",")
+			if f == nil || len(v) < 2 {
+				return fmt.Errorf("invalid LCOV line %q", line)
+			}
+			hits, _ := strconv.ParseInt(v[1], 10, 64)
+			addElement(f.elements, v[0], 1, hits > 0)
+		case "BRDA":
+			// BRDA:<line>,<block>,<branch>,<taken or ->
+			v := strings.Split(value, ",")
+			if f == nil || len(v) < 4 {
+				return fmt.Errorf("invalid LCOV line %q", line)
+			}
+			taken, _ := strconv.ParseInt(v[3], 10, 64)
+			addElement(f.branches, strings.Join(v[:3], ","), 1, taken > 0)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseFindingsReport parses an analyzer report of given format, detecting it from the content when auto.
+// tool names the findings of formats which do not name their tool, such as checkstyle.
+func parseFindingsReport(data []byte, format, tool string) ([]sdk.Finding, error) {
+	if format == "" || format == findingsFormatAuto {
+		format = detectFindingsFormat(data)
+		if format == "" {
+			return nil, fmt.Errorf("unknown analyzer report format")
+		}
+	}
+
+	switch format {
+	case findingsFormatCheckstyle:
+		return parseCheckstyle(data, tool)
+	case findingsFormatSARIF:
+		return parseSARIF(data)
+	}
+	return nil, fmt.Errorf("unknown analyzer report format %s", format)
+}
+
+// detectFindingsFormat guesses the format of an analyzer report from its root
+func detectFindingsFormat(data []byte) string {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	switch {
+	case len(data) == 0:
+		return ""
+	case data[0] == '<':
+		if xmlRoot(data) == "checkstyle" {
+			return findingsFormatCheckstyle
+		}
+	case data[0] == '{':
+		var report map[string]json.RawMessage
+		if json.Unmarshal(data, &report) == nil {
+			if _, ok := report["runs"]; ok {
+				return findingsFormatSARIF
+			}
+		}
+	}
+	return ""
+}
+
+func parseCheckstyle(data []byte, tool string) ([]sdk.Finding, error) {
+	var report struct {
+		Files []struct {
+			Name   string `xml:"name,attr"`
+			Errors []struct {
+				Line     int    `xml:"line,attr"`
+				Column   int    `xml:"column,attr"`
+				Severity string `xml:"severity,attr"`
+				Message  string `xml:"message,attr"`
+				Source   string `xml:"source,attr"`
+			} `xml:"error"`
+		} `xml:"file"`
+	}
+	if err := xml.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	findings := []sdk.Finding{}
+	for _, f := range report.Files {
+		for _, e := range f.Errors {
+			severity := sdk.FindingWarning
+			switch e.Severity {
+			case "error":
+				severity = sdk.FindingError
+			case "info", "ignore":
+				severity = sdk.FindingInfo
+			}
+			findings = append(findings, sdk.Finding{
+				Tool:     tool,
+				Rule:     e.Source,
+				Severity: severity,
+				Path:     f.Name,
+				Line:     e.Line,
+				Column:   e.Column,
+				Message:  e.Message,
+			})
+		}
+	}
+	return findings, nil
+}
+
+func parseSARIF(data []byte) ([]sdk.Finding, error) {
+	var report struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Name string `json:"name"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID  string `json:"ruleId"`
+				Level   string `json:"level"`
+				Message struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	findings := []sdk.Finding{}
+	for _, run := range report.Runs {
+		for _, r := range run.Results {
+			// SARIF defaults results to warnings
+			severity := sdk.FindingWarning
+			switch r.Level {
+			case "error":
+				severity = sdk.FindingError
+			case "note", "none":
+				severity = sdk.FindingInfo
+			}
+			n := sdk.Finding{
+				Tool:     run.Tool.Driver.Name,
+				Rule:     r.RuleID,
+				Severity: severity,
+				Message:  r.Message.Text,
+			}
+			if len(r.Locations) > 0 {
+				l := r.Locations[0].PhysicalLocation
+				n.Path = strings.TrimPrefix(l.ArtifactLocation.URI, "file://")
+				n.Line = l.Region.StartLine
+				n.Column = l.Region.StartColumn
+			}
+			findings = append(findings, n)
+		}
+	}
+	return findings, nil
+}
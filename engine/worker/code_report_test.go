@@ -0,0 +1,181 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+const coberturaReport = `<?xml version="1.0" ?>
+<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">
+<coverage line-rate="0.6" branch-rate="0.5" version="1.9" timestamp="1500000000">
+  <sources><source>/src</source></sources>
+  <packages>
+    <package name="calc" line-rate="0.6">
+      <classes>
+        <class name="Calc" filename="calc/Calc.java" line-rate="0.75">
+          <methods>
+            <method name="add" signature="(II)I"><lines><line number="3" hits="4"/></lines></method>
+          </methods>
+          <lines>
+            <line number="3" hits="4"/>
+            <line number="4" hits="4" branch="true" condition-coverage="50% (1/2)"/>
+            <line number="5" hits="0"/>
+            <line number="6" hits="2"/>
+          </lines>
+        </class>
+        <class name="Calc$Inner" filename="calc/Calc.java" line-rate="0.5">
+          <lines>
+            <line number="5" hits="1"/>
+            <line number="9" hits="0"/>
+          </lines>
+        </class>
+        <class name="Util" filename="calc/Util.java" line-rate="0">
+          <lines><line number="1" hits="0"/></lines>
+        </class>
+      </classes>
+    </package>
+  </packages>
+</coverage>`
+
+const goCoverProfile = `mode: set
+example.com/calc/calc.go:3.24,5.2 2 1
+example.com/calc/calc.go:7.24,9.2 1 0
+example.com/calc/calc.go:7.24,9.2 1 1
+example.com/calc/util.go:3.20,6.2 3 0
+`
+
+const lcovReport = `TN:
+SF:src/calc.js
+FN:1,add
+FNDA:3,add
+DA:1,3
+DA:2,3
+DA:5,0
+BRDA:2,0,0,3
+BRDA:2,0,1,-
+LF:3
+LH:2
+end_of_record
+SF:src/util.js
+DA:1,0
+end_of_record
+`
+
+const checkstyleReport = `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="5.0">
+  <file name="sdk/error.go">
+    <error line="12" column="2" severity="error" message="Error return value is not checked" source="errcheck"/>
+    <error line="40" column="1" severity="warning" message="exported func should have comment" source="golint"/>
+  </file>
+  <file name="sdk/tests.go">
+    <error line="3" severity="info" message="line is 130 characters" source="lll"/>
+  </file>
+</checkstyle>`
+
+const sarifReport = `{
+  "$schema": "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {"driver": {"name": "ESLint"}},
+    "results": [
+      {"ruleId": "no-unused-vars", "level": "error", "message": {"text": "'x' is defined but never used."},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///src/calc.js"}, "region": {"startLine": 3, "startColumn": 7}}}]},
+      {"ruleId": "eqeqeq", "message": {"text": "Expected '===' and instead saw '=='."},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/util.js"}, "region": {"startLine": 8}}}]},
+      {"ruleId": "no-console", "level": "note", "message": {"text": "Unexpected console statement."}}
+    ]
+  }]
+}`
+
+func fileCoverage(c sdk.Coverage, path string) sdk.FileCoverage {
+	for _, f := range c.Files {
+		if f.Path == path {
+			return f
+		}
+	}
+	return sdk.FileCoverage{}
+}
+
+func TestDetectCoverageFormat(t *testing.T) {
+	assert.Equal(t, coverageFormatCobertura, detectCoverageFormat([]byte(coberturaReport)))
+	assert.Equal(t, coverageFormatGoCover, detectCoverageFormat([]byte(goCoverProfile)))
+	assert.Equal(t, coverageFormatLCOV, detectCoverageFormat([]byte(lcovReport)))
+	assert.Equal(t, coverageFormatLCOV, detectCoverageFormat([]byte("SF:a.js\nDA:1,1\nend_of_record\n")))
+	assert.Equal(t, "", detectCoverageFormat([]byte(checkstyleReport)))
+	assert.Equal(t, "", detectCoverageFormat([]byte("hello")))
+}
+
+func TestParseCobertura(t *testing.T) {
+	c := coverageCounter{}
+	assert.NoError(t, parseCoverageReport(c, []byte(coberturaReport), coverageFormatAuto))
+	cov := c.coverage()
+
+	assert.Len(t, cov.Files, 2)
+	assert.Equal(t, "calc/Calc.java", cov.Files[0].Path)
+	calc := fileCoverage(cov, "calc/Calc.java")
+	assert.Equal(t, 5, calc.Total)
+	assert.Equal(t, 4, calc.Covered)
+	assert.Equal(t, 2, calc.TotalBranches)
+	assert.Equal(t, 1, calc.CoveredBranches)
+	assert.Equal(t, 80.0, calc.Percent)
+
+	assert.Equal(t, 6, cov.Total)
+	assert.Equal(t, 4, cov.Covered)
+}
+
+func TestParseGoCoverProfile(t *testing.T) {
+	c := coverageCounter{}
+	assert.NoError(t, parseCoverageReport(c, []byte(goCoverProfile), coverageFormatAuto))
+	cov := c.coverage()
+
+	calc := fileCoverage(cov, "example.com/calc/calc.go")
+	assert.Equal(t, 3, calc.Total)
+	assert.Equal(t, 3, calc.Covered)
+	assert.Equal(t, 6, cov.Total)
+	assert.Equal(t, 50.0, cov.Percent)
+
+	assert.Error(t, parseCoverageReport(coverageCounter{}, []byte("mode: set\nfoo.go:1.1,2.2 x 1\n"), coverageFormatGoCover))
+}
+
+func TestParseLCOV(t *testing.T) {
+	c := coverageCounter{}
+	assert.NoError(t, parseCoverageReport(c, []byte(lcovReport), coverageFormatAuto))
+	cov := c.coverage()
+
+	calc := fileCoverage(cov, "src/calc.js")
+	assert.Equal(t, 3, calc.Total)
+	assert.Equal(t, 2, calc.Covered)
+	assert.Equal(t, 2, calc.TotalBranches)
+	assert.Equal(t, 1, calc.CoveredBranches)
+	assert.Equal(t, 4, cov.Total)
+	assert.Equal(t, 50.0, cov.Percent)
+
+	// Reports of several test runs are merged
+	assert.NoError(t, parseCoverageReport(c, []byte("SF:src/util.js\nDA:1,2\nend_of_record\n"), coverageFormatLCOV))
+	assert.Equal(t, 75.0, c.coverage().Percent)
+}
+
+func TestParseFindingsReport(t *testing.T) {
+	assert.Equal(t, findingsFormatCheckstyle, detectFindingsFormat([]byte(checkstyleReport)))
+	assert.Equal(t, findingsFormatSARIF, detectFindingsFormat([]byte(sarifReport)))
+	assert.Equal(t, "", detectFindingsFormat([]byte(coberturaReport)))
+
+	findings, err := parseFindingsReport([]byte(checkstyleReport), findingsFormatAuto, "golangci.xml")
+	assert.NoError(t, err)
+	assert.Len(t, findings, 3)
+	assert.Equal(t, sdk.Finding{Tool: "golangci.xml", Rule: "errcheck", Severity: sdk.FindingError, Path: "sdk/error.go", Line: 12, Column: 2, Message: "Error return value is not checked"}, findings[0])
+	assert.Equal(t, sdk.FindingWarning, findings[1].Severity)
+	assert.Equal(t, sdk.FindingInfo, findings[2].Severity)
+
+	findings, err = parseFindingsReport([]byte(sarifReport), findingsFormatAuto, "eslint.sarif")
+	assert.NoError(t, err)
+	assert.Len(t, findings, 3)
+	assert.Equal(t, sdk.Finding{Tool: "ESLint", Rule: "no-unused-vars", Severity: sdk.FindingError, Path: "/src/calc.js", Line: 3, Column: 7, Message: "'x' is defined but never used."}, findings[0])
+	assert.Equal(t, sdk.FindingWarning, findings[1].Severity)
+	assert.Equal(t, "src/util.js", findings[1].Path)
+	assert.Equal(t, sdk.FindingInfo, findings[2].Severity)
+	assert.Equal(t, "", findings[2].Path)
+}
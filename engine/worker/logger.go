@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ovh/cds/sdk"
+)
+
+const (
+	// logChanSize is the number of lines waiting to be batched, steps writing more wait for the logger
+	logChanSize = 1000
+	// logBatchSize is the size of the lines sent in a single request
+	logBatchSize = 64 * 1024
+	// logBatchInterval is the maximum time a line waits before being sent
+	logBatchInterval = time.Second
+	// logRetries is the number of attempts to send a batch before spooling it
+	logRetries = 4
+	// logSpoolMaxSize is the size of the spool file beyond which batches are dropped
+	logSpoolMaxSize = 50 * 1024 * 1024
+)
+
+// logRetryDelay is the delay before the first retry to send a batch, doubled before each following one
+var logRetryDelay = time.Second
+
+// logger batches the lines sent by sendLog by size and time and ships them to the API.
+// Batches the API cannot take are written to a spool file, sent again before the next batches.
+func logger(inputChan chan sdk.Log) {
+	ticker := time.NewTicker(logBatchInterval)
+	defer ticker.Stop()
+
+	var pending []sdk.Log
+	var size int
+	for {
+		select {
+		case l, ok := <-inputChan:
+			if !ok {
+				shipLogs(compactLogs(pending))
+				return
+			}
+			pending = append(pending, l)
+			size += len(l.Value)
+			if size < logBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+
+		if len(pending) == 0 {
+			if err := flushLogSpool(); err != nil {
+				fmt.Printf("Error: cannot send spooled logs: %s\n", err)
+			}
+			continue
+		}
+		shipLogs(compactLogs(pending))
+		pending = nil
+		size = 0
+	}
+}
+
+// compactLogs merges consecutive identical lines, prefixing them with their count
+func compactLogs(lines []sdk.Log) []sdk.Log {
+	var logs []sdk.Log
+	for i := 0; i < len(lines); {
+		l := lines[i]
+
+		// count how many lines are exactly the same
+		count := 1
+		for i+count < len(lines) && lines[i+count].Value == l.Value && lines[i+count].ActionBuildID == l.ActionBuildID {
+			count++
+		}
+		i += count
+
+		// and if count > 1, then add it at the beginning of the log
+		if count > 1 {
+			l.Value = fmt.Sprintf("[x%d] %s", count, l.Value)
+		}
+		l.Value = strings.Trim(strings.Replace(l.Value, "\n", " ", -1), " \t\n") + "\n"
+		logs = append(logs, l)
+	}
+	return logs
+}
+
+// shipLogs sends logs to the API, one batch per action build, after the spooled ones.
+// Batches are spooled when the API cannot be reached, or when older ones are still spooled.
+func shipLogs(logs []sdk.Log) {
+	if len(logs) == 0 {
+		return
+	}
+
+	var batches [][]sdk.Log
+	for start, i := 0, 1; i <= len(logs); i++ {
+		if i == len(logs) || logs[i].ActionBuildID != logs[start].ActionBuildID {
+			batches = append(batches, logs[start:i])
+			start = i
+		}
+	}
+
+	spoolErr := flushLogSpool()
+	for _, b := range batches {
+		if spoolErr == nil {
+			if spoolErr = sendLogBatchWithRetry(b); spoolErr == nil {
+				continue
+			}
+			fmt.Printf("Error: cannot send logs, spooling them: %s\n", spoolErr)
+		}
+		if err := spoolLogBatch(b); err != nil {
+			fmt.Printf("Error: cannot spool logs, dropping %d lines: %s\n", len(b), err)
+		}
+	}
+}
+
+// sendLogBatchWithRetry sends a batch, waiting longer after each failure. Meanwhile steps
+// block on sendLog once the channel is full, so that a build cannot outpace the API.
+func sendLogBatchWithRetry(logs []sdk.Log) error {
+	delay := logRetryDelay
+	var err error
+	for i := 0; i < logRetries; i++ {
+		if i > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		var retry bool
+		if retry, err = sendLogBatch(logs); err == nil || !retry {
+			return nil
+		}
+	}
+	return err
+}
+
+// sendLogBatch sends a gzipped batch of lines of an action build. It returns whether it is
+// worth retrying when it fails, the API refusing the batch being final.
+func sendLogBatch(logs []sdk.Log) (bool, error) {
+	data, err := json.Marshal(logs)
+	if err != nil {
+		fmt.Printf("Error: cannot marshal logs: %s\n", err)
+		return false, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return false, err
+	}
+	if err := gz.Close(); err != nil {
+		return false, err
+	}
+
+	path := fmt.Sprintf("/build/%d/log", logs[0].ActionBuildID)
+	data, code, err := sdk.Request("POST", path, buf.Bytes(), sdk.SetHeader("Content-Encoding", "gzip"))
+	if code >= 400 && code < 500 {
+		fmt.Printf("Error: logs refused by API (HTTP %d): %s\n", code, err)
+		return false, err
+	}
+	if err != nil {
+		return true, err
+	}
+	if code >= 300 {
+		return true, fmt.Errorf("HTTP %d", code)
+	}
+	checkCancel(data)
+	return false, nil
+}
+
+func logSpoolPath() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("cds-worker-%s-logs.spool", name))
+}
+
+// spoolLogBatch appends a batch to the spool file, one JSON batch per line
+func spoolLogBatch(logs []sdk.Log) error {
+	p := logSpoolPath()
+	if fi, err := os.Stat(p); err == nil && fi.Size() > logSpoolMaxSize {
+		return fmt.Errorf("spool file %s is full", p)
+	}
+
+	data, err := json.Marshal(logs)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// flushLogSpool sends the spooled batches in order. The ones not sent are kept in the spool file.
+func flushLogSpool() error {
+	p := logSpoolPath()
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var batches [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 2*logBatchSize+1024*1024)
+	for scanner.Scan() {
+		batches = append(batches, append([]byte(nil), scanner.Bytes()...))
+	}
+	f.Close()
+	if err := scanner.Err(); err != nil {
+		os.Remove(p)
+		return fmt.Errorf("corrupted spool file dropped: %s", err)
+	}
+
+	for i, b := range batches {
+		var logs []sdk.Log
+		if err := json.Unmarshal(b, &logs); err != nil || len(logs) == 0 {
+			continue
+		}
+		retry, err := sendLogBatch(logs)
+		if err != nil && retry {
+			// Keep the batches not sent yet
+			remaining := bytes.Join(batches[i:], []byte("\n"))
+			if werr := writeFileAtomic(p, append(remaining, '\n')); werr != nil {
+				return werr
+			}
+			return err
+		}
+	}
+	return os.Remove(p)
+}
+
+func writeFileAtomic(p string, data []byte) error {
+	tmp := p + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestCompactLogs(t *testing.T) {
+	logs := compactLogs([]sdk.Log{
+		{ActionBuildID: 1, Value: "building\n"},
+		{ActionBuildID: 1, Value: "waiting\n"},
+		{ActionBuildID: 1, Value: "waiting\n"},
+		{ActionBuildID: 1, Value: "waiting\n"},
+		{ActionBuildID: 2, Value: "waiting\n"},
+		{ActionBuildID: 2, Value: "  multi\nline  "},
+	})
+
+	assert.Len(t, logs, 4)
+	assert.Equal(t, "building\n", logs[0].Value)
+	assert.Equal(t, "[x3] waiting\n", logs[1].Value)
+	assert.Equal(t, int64(2), logs[2].ActionBuildID)
+	assert.Equal(t, "waiting\n", logs[2].Value)
+	assert.Equal(t, "multi line\n", logs[3].Value)
+
+	assert.Len(t, compactLogs(nil), 0)
+}
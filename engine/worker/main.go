@@ -32,9 +32,13 @@ var (
 	// current actionBuild is here to allow var export
 	ab             sdk.ActionBuild
 	buildVariables []sdk.Variable
+	// cache keys restored exactly by the current actionBuild, saving them again is useless
+	restoredCaches map[string]bool
 	// Git ssh configuration
 	pkey   string
 	gitssh string
+	// commands printing the version of binaries
+	versionProbes map[string]string
 )
 
 var mainCmd = &cobra.Command{
@@ -85,6 +89,12 @@ var mainCmd = &cobra.Command{
 
 		model = int64(viper.GetInt("model"))
 
+		versionProbes, err = sdk.ParseVersionProbes(viper.GetString("version_probes"))
+		if err != nil {
+			fmt.Printf("Invalid --version-probes (%s), aborting.\n", err)
+			return
+		}
+
 		port, err := exportHandler()
 		if err != nil {
 			sdk.Exit("cannot bind port for worker export: %s\n", err)
@@ -92,7 +102,7 @@ var mainCmd = &cobra.Command{
 		exportport = port
 
 		// start logger routine
-		logChan = make(chan sdk.Log)
+		logChan = make(chan sdk.Log, logChanSize)
 		go logger(logChan)
 
 		go heartbeat()
@@ -133,6 +143,9 @@ func init() {
 	flags.String("basedir", "", "Worker working directory")
 	viper.BindPFlag("basedir", flags.Lookup("basedir"))
 
+	flags.String("version-probes", "", "Commands printing the version of binaries, like \"node=node -v;mvn=mvn -v\" (default \"<binary> --version\")")
+	viper.BindPFlag("version_probes", flags.Lookup("version-probes"))
+
 	mainCmd.AddCommand(cmdExport)
 }
 
@@ -142,8 +155,16 @@ func main() {
 	mainCmd.Execute()
 }
 
-// Will be removed when websocket conn is implemented
-// for now, poll the /queue
+// queueWait is false once the API is known not to push action builds to workers
+var queueWait = true
+
+// queueBackoff is how long the worker waits before asking again for action builds it cannot run
+var queueBackoff time.Duration
+
+const queueMaxBackoff = 30 * time.Second
+
+// queuePolling waits for the API to push action builds to the worker,
+// and falls back on polling the /queue when it cannot
 func queuePolling() {
 	for {
 		if WorkerID == "" {
@@ -156,11 +177,42 @@ func queuePolling() {
 			}
 		}
 
+		if queueWait && waitQueue() {
+			continue
+		}
+
 		checkQueue()
 		time.Sleep(5 * time.Second)
 	}
 }
 
+// waitQueue runs the action builds pushed by the API. It returns false when the queue has to be polled instead.
+func waitQueue() bool {
+	queue, err := sdk.WaitBuildQueue(30 * time.Second)
+	if err == sdk.ErrNotFound {
+		log.Notice("waitQueue> API does not push builds, polling the queue\n")
+		queueWait = false
+		return false
+	}
+	if err != nil {
+		log.Notice("waitQueue> Cannot wait for build queue: %s\n", err)
+		return false
+	}
+
+	if runQueue(queue) || len(queue) == 0 {
+		queueBackoff = 0
+		return true
+	}
+
+	// None of the action builds returned can run here, the API would return them again right away
+	queueBackoff = 2*queueBackoff + time.Second
+	if queueBackoff > queueMaxBackoff {
+		queueBackoff = queueMaxBackoff
+	}
+	time.Sleep(queueBackoff)
+	return true
+}
+
 func checkQueue() {
 
 	queue, err := sdk.GetBuildQueue()
@@ -171,13 +223,20 @@ func checkQueue() {
 		return
 	}
 
+	runQueue(queue)
+}
+
+// runQueue takes the action builds whose requirements are met, and runs them.
+// It returns false when no action build met the requirements.
+func runQueue(queue []sdk.ActionBuild) bool {
+	var run bool
 	for i := range queue {
 		requirementsOK := true
 		// Check requirement
 		for _, r := range queue[i].Requirements {
 			ok, err := checkRequirement(r)
 			if err != nil {
-				postCheckRequirementError(&r, err)
+				postCheckRequirementError(queue[i].ID, &r, err)
 				requirementsOK = false
 				continue
 			}
@@ -188,15 +247,18 @@ func checkQueue() {
 		}
 
 		if requirementsOK {
+			run = true
 			takeAction(queue[i])
 		}
 	}
+	return run
 }
 
-func postCheckRequirementError(r *sdk.Requirement, err error) {
+func postCheckRequirementError(actionBuildID int64, r *sdk.Requirement, err error) {
 	s := fmt.Sprintf("Error checking requirement Name=%s Type=%s Value=%s :%s", r.Name, r.Type, r.Value, err)
 	btes := []byte(s)
-	sdk.Request("POST", "/queue/requirements/errors", btes)
+	path := fmt.Sprintf("/queue/requirements/errors?actionBuild=%d", actionBuildID)
+	sdk.Request("POST", path, btes)
 }
 
 func takeAction(b sdk.ActionBuild) {
@@ -222,6 +284,7 @@ func takeAction(b sdk.ActionBuild) {
 	// Reset build variables
 	ab = abi.ActionBuild
 	buildVariables = nil
+	restoredCaches = map[string]bool{}
 	res := run(abi.Action, abi.ActionBuild, abi.Secrets)
 	// Give time to buffered logs to be sent
 	time.Sleep(3 * time.Second)
@@ -289,11 +352,13 @@ func heartbeat() {
 			}
 		}
 
-		_, code, err := sdk.Request("POST", "/worker/refresh", nil)
+		data, code, err := sdk.Request("POST", "/worker/refresh", nil)
 		if err != nil || code >= 300 {
 			log.Notice("heartbeat> cannot refresh beat: %d %s\n", code, err)
 			WorkerID = ""
+			continue
 		}
+		checkCancel(data)
 	}
 }
 
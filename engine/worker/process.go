@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/ovh/cds/engine/log"
+)
+
+// stopGracePeriod is the time a cancelled step has to exit on SIGTERM before it is killed
+var stopGracePeriod = 10 * time.Second
+
+// running holds the process of the current step, so it can be killed when the action times out or is cancelled
+var running struct {
+	sync.Mutex
+	cmd       *exec.Cmd
+	timedOut  bool
+	cancelled bool
+}
+
+// startProcess starts given command in its own process group and keeps track of it
+func startProcess(cmd *exec.Cmd) error {
+	running.Lock()
+	defer running.Unlock()
+
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	running.cmd = cmd
+	return nil
+}
+
+// endProcess forgets the process of the current step once it has exited
+func endProcess() {
+	running.Lock()
+	defer running.Unlock()
+	running.cmd = nil
+}
+
+// timeoutProcess marks the action as timed out and kills the process group of the current step
+func timeoutProcess() {
+	running.Lock()
+	defer running.Unlock()
+
+	running.timedOut = true
+	if running.cmd == nil || running.cmd.Process == nil {
+		return
+	}
+	if err := killProcessGroup(running.cmd.Process); err != nil {
+		log.Warning("timeoutProcess> Cannot kill process %d: %s\n", running.cmd.Process.Pid, err)
+	}
+}
+
+// cancelProcess marks the action as cancelled and terminates the process group of the current step,
+// which is killed if it is still running after stopGracePeriod. It returns false if it was already cancelled.
+func cancelProcess() bool {
+	running.Lock()
+	defer running.Unlock()
+
+	if running.cancelled {
+		return false
+	}
+	running.cancelled = true
+	if running.cmd == nil || running.cmd.Process == nil {
+		return true
+	}
+
+	cmd := running.cmd
+	if err := terminateProcessGroup(cmd.Process); err != nil {
+		log.Warning("cancelProcess> Cannot terminate process %d: %s\n", cmd.Process.Pid, err)
+	}
+	time.AfterFunc(stopGracePeriod, func() {
+		running.Lock()
+		defer running.Unlock()
+		// The step may have exited, or another one started
+		if running.cmd != cmd {
+			return
+		}
+		if err := killProcessGroup(cmd.Process); err != nil {
+			log.Warning("cancelProcess> Cannot kill process %d: %s\n", cmd.Process.Pid, err)
+		}
+	})
+	return true
+}
+
+// timedOut returns true if the current action exceeded its timeout
+func timedOut() bool {
+	running.Lock()
+	defer running.Unlock()
+	return running.timedOut
+}
+
+// cancelled returns true if the current action has been stopped from the API
+func cancelled() bool {
+	running.Lock()
+	defer running.Unlock()
+	return running.cancelled
+}
+
+// resetProcessState clears the timeout and cancellation states before running another action
+func resetProcessState() {
+	running.Lock()
+	defer running.Unlock()
+	running.timedOut = false
+	running.cancelled = false
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing on windows, which has no process groups
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessGroup kills given process, windows has no SIGTERM
+func terminateProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
+
+// killProcessGroup kills given process, its children are left running on windows
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
@@ -31,6 +31,7 @@ func register(cdsURI string, name string, uk string) error {
 		Model:              model,
 		Hatchery:           hatchery,
 		BinaryCapabilities: binaryCapabilities,
+		BinaryVersions:     probeVersions(requirements, binaryCapabilities),
 	}
 
 	body, err := json.MarshalIndent(in, " ", " ")
@@ -68,6 +69,8 @@ func LoopPath(reqs []sdk.Requirement) []string {
 	binaries := []string{}
 	for _, req := range reqs {
 		if req.Type == sdk.BinaryRequirement {
+			// Only look for the binary, whatever its version
+			req.Version = ""
 			if b, _ := checkBinaryRequirement(req); b {
 				binaries = append(binaries, req.Value)
 			}
@@ -75,3 +78,32 @@ func LoopPath(reqs []sdk.Requirement) []string {
 	}
 	return binaries
 }
+
+// probeVersions returns the version of the available binaries which are required with a version constraint
+// or have a version probe configured
+func probeVersions(reqs []sdk.Requirement, binaries []string) map[string]string {
+	versions := map[string]string{}
+	for _, b := range binaries {
+		if _, ok := versions[b]; ok {
+			continue
+		}
+		_, probed := versionProbes[b]
+		for _, req := range reqs {
+			if req.Type == sdk.BinaryRequirement && req.Value == b && req.Version != "" {
+				probed = true
+				break
+			}
+		}
+		if !probed {
+			continue
+		}
+
+		v, err := sdk.ProbeVersion(b, versionProbes)
+		if err != nil {
+			log.Info("register> Cannot probe version of %s: %s\n", b, err)
+			continue
+		}
+		versions[b] = v.String()
+	}
+	return versions
+}
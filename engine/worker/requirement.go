@@ -75,7 +75,21 @@ func checkBinaryRequirement(r sdk.Requirement) (bool, error) {
 		return false, nil
 	}
 
-	return true, nil
+	if r.Version == "" {
+		return true, nil
+	}
+
+	c, err := sdk.ParseVersionConstraint(r.Version)
+	if err != nil {
+		return false, err
+	}
+	v, err := sdk.ProbeVersion(r.Value, versionProbes)
+	if err != nil {
+		log.Printf("checkBinaryRequirement> Cannot probe version of %s: %s\n", r.Value, err)
+		return false, nil
+	}
+
+	return c.Check(v), nil
 }
 
 func checkModelRequirement(r sdk.Requirement) (bool, error) {
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"container/list"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
@@ -89,6 +88,19 @@ func replaceBuildVariablesPlaceholder(a *sdk.Action) {
 	}
 }
 
+// conditionVariables returns what a step condition can refer to: build arguments,
+// secrets, and variables exported by previous steps as cds.build.*
+func conditionVariables(actionBuild sdk.ActionBuild) map[string]string {
+	vars := make(map[string]string, len(actionBuild.Args)+len(buildVariables))
+	for _, p := range actionBuild.Args {
+		vars[p.Name] = p.Value
+	}
+	for _, v := range buildVariables {
+		vars["cds.build."+v.Name] = v.Value
+	}
+	return vars
+}
+
 func runAction(a *sdk.Action, actionBuild sdk.ActionBuild) sdk.Result {
 	r := sdk.Result{
 		Status:  sdk.StatusFail,
@@ -111,7 +123,7 @@ func runAction(a *sdk.Action, actionBuild sdk.ActionBuild) sdk.Result {
 		return r
 	}
 
-	var nbDisabledChildren int
+	var nbDisabledChildren, nbSkippedChildren int
 
 	finalActions := []sdk.Action{}
 	var doNotRunChildrenAnymore bool
@@ -126,8 +138,24 @@ func runAction(a *sdk.Action, actionBuild sdk.ActionBuild) sdk.Result {
 		if child.Final {
 			finalActions = append(finalActions, child)
 		} else {
+			if !doNotRunChildrenAnymore && (timedOut() || cancelled()) {
+				r = sdk.Result{Status: sdk.StatusFail, BuildID: actionBuild.ID}
+				doNotRunChildrenAnymore = true
+			}
 			if !doNotRunChildrenAnymore {
 				childName := fmt.Sprintf("%s/%s-%d", a.Name, child.Name, i+1)
+				ok, err := sdk.EvaluateCondition(child.Condition, conditionVariables(actionBuild))
+				if err != nil {
+					sendLog(actionBuild.ID, childName, fmt.Sprintf("%s: Cannot evaluate condition of step %s: %s\n", name, childName, err))
+					r = sdk.Result{Status: sdk.StatusFail, BuildID: actionBuild.ID}
+					doNotRunChildrenAnymore = true
+					continue
+				}
+				if !ok {
+					sendLog(actionBuild.ID, childName, fmt.Sprintf("%s: Step %s skipped, condition '%s' is false\n", name, childName, child.Condition))
+					nbSkippedChildren++
+					continue
+				}
 				log.Printf("Running %s\n", childName)
 				sendLog(actionBuild.ID, childName, fmt.Sprintf("%s: Starting step %s...\n", name, childName))
 				r = startAction(&child, actionBuild)
@@ -141,17 +169,30 @@ func runAction(a *sdk.Action, actionBuild sdk.ActionBuild) sdk.Result {
 	}
 
 	//If all steps are disabled, set action status to disabled
-	if nbDisabledChildren >= (len(a.Actions) - len(finalActions)) {
+	//If some of them are skipped by their condition, set action status to skipped
+	if nbDisabledChildren+nbSkippedChildren >= (len(a.Actions) - len(finalActions)) {
 		r.Status = sdk.StatusDisabled
+		if nbSkippedChildren > 0 {
+			r.Status = sdk.StatusSkipped
+		}
 	}
 
 	for i, child := range finalActions {
 		childName := fmt.Sprintf("%s/%s-%d", a.Name, child.Name, i+1)
+		ok, err := sdk.EvaluateCondition(child.Condition, conditionVariables(actionBuild))
+		if err != nil {
+			sendLog(actionBuild.ID, childName, fmt.Sprintf("%s: Cannot evaluate condition of final step %s: %s\n", name, childName, err))
+			return sdk.Result{Status: sdk.StatusFail, BuildID: actionBuild.ID}
+		}
+		if !ok {
+			sendLog(actionBuild.ID, childName, fmt.Sprintf("%s: Final step %s skipped, condition '%s' is false\n", name, childName, child.Condition))
+			continue
+		}
 		log.Printf("Running final action : %s\n", childName)
 		sendLog(actionBuild.ID, childName, fmt.Sprintf("%s: Starting final step %s...\n", name, childName))
 		finalActionResult := startAction(&child, actionBuild)
-		//If action is success or disabled we consider final action status
-		if r.Status == sdk.StatusSuccess || r.Status == sdk.StatusDisabled {
+		//If action is success, disabled or skipped we consider final action status
+		if r.Status == sdk.StatusSuccess || r.Status == sdk.StatusDisabled || r.Status == sdk.StatusSkipped {
 			r = finalActionResult
 		}
 		if finalActionResult.Status != sdk.StatusSuccess {
@@ -164,82 +205,29 @@ func runAction(a *sdk.Action, actionBuild sdk.ActionBuild) sdk.Result {
 	return r
 }
 
-var logsecrets []sdk.Variable
+// checkCancel stops the running action if the API answered that it has been cancelled
+func checkCancel(data []byte) {
+	var c sdk.ActionBuildCancel
+	if err := json.Unmarshal(data, &c); err != nil || !c.Cancel || c.ActionBuildID != ab.ID {
+		return
+	}
+	if cancelProcess() {
+		// Called by the logger itself, which must not wait for its own channel
+		go sendLog(c.ActionBuildID, "SYSTEM", "Action stopped, terminating running step\n")
+	}
+}
+
+// logMasker hides the secrets of the current action from its logs
+var logMasker *sdk.SecretMasker
 
 func sendLog(buildid int64, step string, value string) error {
-	for i := range logsecrets {
-		if len(logsecrets[i].Value) >= 6 {
-			value = strings.Replace(value, logsecrets[i].Value, "**"+logsecrets[i].Name+"**", -1)
-		}
-	}
+	value = logMasker.Mask(value)
 
 	l := sdk.NewLog(buildid, step, value)
 	logChan <- *l
 	return nil
 }
 
-func logger(inputChan chan sdk.Log) {
-	llist := list.New()
-
-	for {
-		select {
-		case l, ok := <-inputChan:
-			if ok {
-				llist.PushBack(l)
-			}
-			break
-		case <-time.After(1 * time.Second):
-			var logs []sdk.Log
-
-			// While list is not empty
-			for llist.Len() > 0 {
-				// get older log line
-				l := llist.Front().Value.(sdk.Log)
-				llist.Remove(llist.Front())
-
-				// then count how many lines are exactly the same
-				count := 1
-				for llist.Len() > 0 {
-					n := llist.Front().Value.(sdk.Log)
-					if n.Value != l.Value {
-						break
-					}
-					count++
-					llist.Remove(llist.Front())
-				}
-
-				// and if count > 1, then add it at the beginning of the log
-				if count > 1 {
-					l.Value = fmt.Sprintf("[x%d] %s", count, l.Value)
-				}
-				// and append to the logs batch
-				l.Value = strings.Trim(strings.Replace(l.Value, "\n", " ", -1), " \t\n") + "\n"
-				logs = append(logs, l)
-			}
-
-			if len(logs) == 0 {
-				continue
-			}
-
-			// Buffer log list is empty, sending batch to API
-			data, err := json.Marshal(logs)
-			if err != nil {
-				fmt.Printf("Error: cannot marshal logs: %s\n", err)
-				continue
-			}
-
-			path := fmt.Sprintf("/build/%d/log", logs[0].ActionBuildID)
-			_, _, err = sdk.Request("POST", path, data)
-			if err != nil {
-				fmt.Printf("error: cannot send logs: %s\n", err)
-				continue
-			}
-
-			break
-		}
-	}
-}
-
 // creates a working directory in $HOME/PROJECT/APP/PIP/BN
 func setupBuildDirectory(wd string) error {
 
@@ -299,6 +287,9 @@ func workingDirectory(basedir string, ab sdk.ActionBuild) string {
 }
 
 func run(a sdk.Action, ab sdk.ActionBuild, secrets []sdk.Variable) sdk.Result {
+	// Forget the timeout or the cancellation of the previous action
+	resetProcessState()
+
 	// REPLACE ALL VARIABLE EVEN SECRETS HERE
 	err := processActionVariables(&a, nil, ab, secrets)
 	if err != nil {
@@ -352,10 +343,30 @@ func run(a sdk.Action, ab sdk.ActionBuild, secrets []sdk.Variable) sdk.Result {
 		return sdk.Result{Status: sdk.StatusFail}
 	}
 
-	logsecrets = secrets
+	// Kill running step when action timeout is reached, final steps still run
+	var timer *time.Timer
+	if ab.Timeout > 0 {
+		timeout := time.Duration(ab.Timeout) * time.Second
+		timer = time.AfterFunc(timeout, func() {
+			sendLog(ab.ID, "SYSTEM", fmt.Sprintf("Timed out after %s, killing running step\n", timeout))
+			timeoutProcess()
+		})
+	}
+
+	logMasker = sdk.NewSecretMasker(secrets)
 	res := startAction(&a, ab)
 	close(doneChan)
-	logsecrets = nil
+	logMasker = nil
+
+	if timer != nil {
+		timer.Stop()
+	}
+	if timedOut() {
+		res.Status = sdk.StatusFail
+	}
+	if cancelled() {
+		res.Status = sdk.StatusStopped
+	}
 
 	err = teardownBuildDirectory(wd)
 	if err != nil {
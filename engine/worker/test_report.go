@@ -0,0 +1,602 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// Test report formats understood by the JUnit builtin action
+const (
+	testFormatAuto   = "auto"
+	testFormatJUnit  = "junit"
+	testFormatTAP    = "tap"
+	testFormatGoTest = "gotest"
+	testFormatNUnit  = "nunit"
+	testFormatXUnit  = "xunit"
+	testFormatMocha  = "mocha"
+)
+
+// parseTestReport parses a test report of given format, detecting it from the content when auto.
+// name names the suite of formats which do not name it, such as TAP.
+func parseTestReport(data []byte, format, name string) ([]sdk.TestSuite, error) {
+	if format == "" || format == testFormatAuto {
+		format = detectTestFormat(data)
+		if format == "" {
+			return nil, fmt.Errorf("unknown test report format")
+		}
+	}
+
+	switch format {
+	case testFormatJUnit:
+		return parseJUnit(data)
+	case testFormatTAP:
+		return parseTAP(data, name)
+	case testFormatGoTest:
+		return parseGoTestJSON(data)
+	case testFormatNUnit:
+		return parseNUnit(data)
+	case testFormatXUnit:
+		return parseXUnit(data)
+	case testFormatMocha:
+		return parseMocha(data, name)
+	}
+	return nil, fmt.Errorf("unknown test report format %s", format)
+}
+
+var tapLineRegexp = regexp.MustCompile(`^(TAP version \d+|1\.\.\d+|(not )?ok\b)`)
+
+// detectTestFormat guesses the format of a test report from its first element or line
+func detectTestFormat(data []byte) string {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(data) == 0 {
+		return ""
+	}
+
+	switch data[0] {
+	case '<':
+		d := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			t, err := d.Token()
+			if err != nil {
+				return ""
+			}
+			if se, ok := t.(xml.StartElement); ok {
+				switch se.Name.Local {
+				case "testsuites", "testsuite":
+					return testFormatJUnit
+				case "test-run", "test-results":
+					return testFormatNUnit
+				case "assemblies", "assembly":
+					return testFormatXUnit
+				}
+				return ""
+			}
+		}
+	case '{':
+		// go test -json writes one event per line
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = data[:i]
+		}
+		var event map[string]interface{}
+		if json.Unmarshal(line, &event) == nil {
+			if _, ok := event["Action"]; ok {
+				return testFormatGoTest
+			}
+		}
+		var report map[string]json.RawMessage
+		if json.Unmarshal(data, &report) == nil {
+			if _, ok := report["stats"]; ok {
+				return testFormatMocha
+			}
+		}
+		return ""
+	}
+
+	if tapLineRegexp.Match(data) {
+		return testFormatTAP
+	}
+	return ""
+}
+
+// formatSeconds formats a duration in seconds as sdk.Test expects it
+func formatSeconds(s float64) string {
+	return strconv.FormatFloat(s, 'f', 3, 64)
+}
+
+// countTests sets the counters of a suite from its test cases
+func countTests(s *sdk.TestSuite) {
+	s.Total = len(s.Tests)
+	s.Failures, s.Errors, s.Skip = 0, 0, 0
+	for _, t := range s.Tests {
+		switch {
+		case t.Skip != nil:
+			s.Skip++
+		case t.Error != "":
+			s.Errors++
+		case t.Failure != "":
+			s.Failures++
+		}
+	}
+}
+
+func parseJUnit(data []byte) ([]sdk.TestSuite, error) {
+	var v sdk.Tests
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	// Is it nosetests format, or a single test suite ?
+	if root.XMLName.Local == "testsuite" {
+		s, _ := parseNoseTests(data)
+		v.TestSuites = append(v.TestSuites, s)
+	} else if err := xml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	// Count from test cases, reports disagree on the name of the skipped counter
+	for i := range v.TestSuites {
+		if len(v.TestSuites[i].Tests) > 0 {
+			countTests(&v.TestSuites[i])
+		}
+	}
+	return v.TestSuites, nil
+}
+
+var (
+	tapTestRegexp      = regexp.MustCompile(`^(not )?ok\b\s*(\d+)?\s*(?:-\s*)?([^#]*?)\s*(?:#\s*(.*))?$`)
+	tapDirectiveRegexp = regexp.MustCompile(`(?i)^(skip|todo)\b\s*(.*)$`)
+	tapDurationRegexp  = regexp.MustCompile(`(?m)^\s*duration_ms:\s*([0-9.]+)`)
+)
+
+// parseTAP parses a Test Anything Protocol stream. Comments following a test are its output,
+// and its YAML diagnostics explain its failure. TODO tests are reported as skipped.
+func parseTAP(data []byte, name string) ([]sdk.TestSuite, error) {
+	s := sdk.TestSuite{Name: name}
+	var current *sdk.Test
+	var failed, inYAML bool
+	var yaml []string
+
+	endTest := func() {
+		if current == nil {
+			return
+		}
+		diag := strings.Join(yaml, "\n")
+		if m := tapDurationRegexp.FindStringSubmatch(diag); m != nil {
+			if ms, err := strconv.ParseFloat(m[1], 64); err == nil {
+				current.Time = formatSeconds(ms / 1000)
+			}
+		}
+		if failed && current.Skip == nil {
+			current.Failure = current.Name
+			if diag != "" {
+				current.Failure += "\n" + diag
+			}
+		}
+		current.SystemOut = strings.TrimSuffix(current.SystemOut, "\n")
+		s.Tests = append(s.Tests, *current)
+		current = nil
+		yaml = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if inYAML {
+			if trimmed == "..." {
+				inYAML = false
+			} else {
+				yaml = append(yaml, strings.TrimPrefix(line, "  "))
+			}
+			continue
+		}
+		if trimmed == "---" && current != nil && len(yaml) == 0 {
+			inYAML = true
+			continue
+		}
+
+		// Subtests are indented, their summary line only is taken into account
+		if line != trimmed {
+			continue
+		}
+
+		if m := tapTestRegexp.FindStringSubmatch(line); m != nil {
+			endTest()
+			failed = m[1] != ""
+			current = &sdk.Test{Name: m[3], ClassName: name}
+			if current.Name == "" {
+				current.Name = "test " + m[2]
+			}
+			if d := tapDirectiveRegexp.FindStringSubmatch(m[4]); d != nil {
+				reason := d[2]
+				current.Skip = &reason
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") && current != nil {
+			current.SystemOut += strings.TrimSpace(strings.TrimPrefix(line, "#")) + "\n"
+			continue
+		}
+		if strings.HasPrefix(line, "Bail out!") {
+			endTest()
+			s.Tests = append(s.Tests, sdk.Test{Name: "Bail out", ClassName: name, Error: line})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	endTest()
+
+	countTests(&s)
+	return []sdk.TestSuite{s}, nil
+}
+
+// goTestEvent is a line of go test -json output
+type goTestEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// parseGoTestJSON parses go test -json output, with one suite per package.
+// Subtests are reported as tests of their own.
+func parseGoTestJSON(data []byte) ([]sdk.TestSuite, error) {
+	type goTest struct {
+		test   sdk.Test
+		output bytes.Buffer
+		done   bool
+	}
+	packages := map[string]map[string]*goTest{}
+	var order []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var e goTestEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("invalid go test event %s: %s", line, err)
+		}
+		if e.Test == "" {
+			continue
+		}
+
+		tests, ok := packages[e.Package]
+		if !ok {
+			tests = map[string]*goTest{}
+			packages[e.Package] = tests
+		}
+		t, ok := tests[e.Test]
+		if !ok {
+			t = &goTest{test: sdk.Test{Name: e.Test, ClassName: e.Package}}
+			tests[e.Test] = t
+			order = append(order, e.Package+"\x00"+e.Test)
+		}
+
+		switch e.Action {
+		case "output":
+			t.output.WriteString(e.Output)
+		case "pass", "fail", "skip":
+			t.done = true
+			t.test.Time = formatSeconds(e.Elapsed)
+			out := strings.TrimSuffix(t.output.String(), "\n")
+			t.test.SystemOut = out
+			switch e.Action {
+			case "fail":
+				t.test.Failure = out
+				if t.test.Failure == "" {
+					t.test.Failure = "failed"
+				}
+			case "skip":
+				t.test.Skip = &out
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	suites := map[string]*sdk.TestSuite{}
+	var names []string
+	for _, o := range order {
+		parts := strings.SplitN(o, "\x00", 2)
+		t := packages[parts[0]][parts[1]]
+		if !t.done {
+			// The test binary crashed or timed out while running it
+			t.test.SystemOut = strings.TrimSuffix(t.output.String(), "\n")
+			t.test.Error = "test did not complete"
+		}
+		s, ok := suites[parts[0]]
+		if !ok {
+			s = &sdk.TestSuite{Name: parts[0]}
+			suites[parts[0]] = s
+			names = append(names, parts[0])
+		}
+		s.Tests = append(s.Tests, t.test)
+	}
+
+	sort.Strings(names)
+	res := make([]sdk.TestSuite, 0, len(names))
+	for _, n := range names {
+		countTests(suites[n])
+		res = append(res, *suites[n])
+	}
+	return res, nil
+}
+
+// nunitSuite is a test-suite of NUnit 2 (test-results) and 3 (test-run) reports
+type nunitSuite struct {
+	Name          string       `xml:"name,attr"`
+	FullName      string       `xml:"fullname,attr"`
+	Suites        []nunitSuite `xml:"test-suite"`
+	Cases         []nunitCase  `xml:"test-case"`
+	ResultsSuites []nunitSuite `xml:"results>test-suite"`
+	ResultsCases  []nunitCase  `xml:"results>test-case"`
+}
+
+type nunitCase struct {
+	Name      string `xml:"name,attr"`
+	FullName  string `xml:"fullname,attr"`
+	ClassName string `xml:"classname,attr"`
+	Result    string `xml:"result,attr"`
+	Label     string `xml:"label,attr"`
+	Executed  string `xml:"executed,attr"`
+	Duration  string `xml:"duration,attr"`
+	Time      string `xml:"time,attr"`
+	Failure   struct {
+		Message    string `xml:"message"`
+		StackTrace string `xml:"stack-trace"`
+	} `xml:"failure"`
+	Reason struct {
+		Message string `xml:"message"`
+	} `xml:"reason"`
+	Output string `xml:"output"`
+}
+
+// parseNUnit parses NUnit 2 and 3 reports, with one suite per fixture
+func parseNUnit(data []byte) ([]sdk.TestSuite, error) {
+	var root nunitSuite
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	var res []sdk.TestSuite
+	var walk func(s nunitSuite)
+	walk = func(s nunitSuite) {
+		cases := append(s.Cases, s.ResultsCases...)
+		if len(cases) > 0 {
+			ts := sdk.TestSuite{Name: s.FullName}
+			if ts.Name == "" {
+				ts.Name = s.Name
+			}
+			for _, c := range cases {
+				ts.Tests = append(ts.Tests, nunitTest(c, ts.Name))
+			}
+			countTests(&ts)
+			res = append(res, ts)
+		}
+		for _, sub := range append(s.Suites, s.ResultsSuites...) {
+			walk(sub)
+		}
+	}
+	walk(root)
+	return res, nil
+}
+
+func nunitTest(c nunitCase, suite string) sdk.Test {
+	t := sdk.Test{Name: c.Name, ClassName: c.ClassName, SystemOut: strings.TrimSpace(c.Output)}
+	if t.ClassName == "" {
+		t.ClassName = suite
+		// NUnit 2 names test cases by their full name
+		if strings.HasPrefix(c.Name, suite+".") {
+			t.Name = strings.TrimPrefix(c.Name, suite+".")
+		}
+	}
+
+	d := c.Duration
+	if d == "" {
+		d = c.Time
+	}
+	if f, err := strconv.ParseFloat(d, 64); err == nil {
+		t.Time = formatSeconds(f)
+	}
+
+	failure := strings.TrimSpace(c.Failure.Message)
+	if st := strings.TrimSpace(c.Failure.StackTrace); st != "" {
+		failure += "\n" + st
+	}
+	switch strings.ToLower(c.Result) {
+	case "failed", "failure":
+		if strings.ToLower(c.Label) == "error" {
+			t.Error = nonEmpty(failure, c.Result)
+		} else {
+			t.Failure = nonEmpty(failure, c.Result)
+		}
+	case "error", "cancelled", "notrunnable":
+		t.Error = nonEmpty(failure, c.Result)
+	case "skipped", "ignored", "inconclusive", "notrun":
+		reason := strings.TrimSpace(c.Reason.Message)
+		t.Skip = &reason
+	case "":
+		if strings.ToLower(c.Executed) == "false" {
+			reason := strings.TrimSpace(c.Reason.Message)
+			t.Skip = &reason
+		}
+	}
+	return t
+}
+
+type xunitAssembly struct {
+	Name        string `xml:"name,attr"`
+	Collections []struct {
+		Name  string      `xml:"name,attr"`
+		Tests []xunitTest `xml:"test"`
+	} `xml:"collection"`
+}
+
+type xunitTest struct {
+	Name    string `xml:"name,attr"`
+	Type    string `xml:"type,attr"`
+	Method  string `xml:"method,attr"`
+	Time    string `xml:"time,attr"`
+	Result  string `xml:"result,attr"`
+	Failure struct {
+		ExceptionType string `xml:"exception-type,attr"`
+		Message       string `xml:"message"`
+		StackTrace    string `xml:"stack-trace"`
+	} `xml:"failure"`
+	Reason string `xml:"reason"`
+	Output string `xml:"output"`
+}
+
+// parseXUnit parses xUnit.net v2 reports, with one suite per test collection
+func parseXUnit(data []byte) ([]sdk.TestSuite, error) {
+	var assemblies []xunitAssembly
+	var root struct {
+		XMLName    xml.Name
+		Assemblies []xunitAssembly `xml:"assembly"`
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if root.XMLName.Local == "assembly" {
+		var a xunitAssembly
+		if err := xml.Unmarshal(data, &a); err != nil {
+			return nil, err
+		}
+		assemblies = append(assemblies, a)
+	} else {
+		assemblies = root.Assemblies
+	}
+
+	var res []sdk.TestSuite
+	for _, a := range assemblies {
+		for _, c := range a.Collections {
+			ts := sdk.TestSuite{Name: c.Name}
+			for _, x := range c.Tests {
+				t := sdk.Test{Name: x.Name, ClassName: x.Type, Time: x.Time, SystemOut: strings.TrimSpace(x.Output)}
+				if x.Method != "" && x.Type != "" && strings.HasPrefix(x.Name, x.Type+".") {
+					t.Name = strings.TrimPrefix(x.Name, x.Type+".")
+				}
+				if f, err := strconv.ParseFloat(x.Time, 64); err == nil {
+					t.Time = formatSeconds(f)
+				}
+				switch strings.ToLower(x.Result) {
+				case "fail":
+					failure := strings.TrimSpace(x.Failure.Message)
+					if x.Failure.ExceptionType != "" {
+						failure = x.Failure.ExceptionType + ": " + failure
+					}
+					if st := strings.TrimSpace(x.Failure.StackTrace); st != "" {
+						failure += "\n" + st
+					}
+					t.Failure = nonEmpty(failure, x.Result)
+				case "skip":
+					reason := strings.TrimSpace(x.Reason)
+					t.Skip = &reason
+				}
+				ts.Tests = append(ts.Tests, t)
+			}
+			countTests(&ts)
+			res = append(res, ts)
+		}
+	}
+	return res, nil
+}
+
+type mochaTest struct {
+	Title     string   `json:"title"`
+	FullTitle string   `json:"fullTitle"`
+	File      string   `json:"file"`
+	Duration  *float64 `json:"duration"`
+	Pending   bool     `json:"pending"`
+	Err       struct {
+		Message string `json:"message"`
+		Stack   string `json:"stack"`
+	} `json:"err"`
+}
+
+// parseMocha parses reports of mocha json reporter, with one suite per test file
+func parseMocha(data []byte, name string) ([]sdk.TestSuite, error) {
+	var report struct {
+		Tests   []mochaTest `json:"tests"`
+		Pending []mochaTest `json:"pending"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	pending := map[string]bool{}
+	for _, p := range report.Pending {
+		pending[p.FullTitle] = true
+	}
+
+	suites := map[string]*sdk.TestSuite{}
+	var names []string
+	for _, m := range report.Tests {
+		suiteName := m.File
+		if suiteName == "" {
+			suiteName = name
+		}
+		s, ok := suites[suiteName]
+		if !ok {
+			s = &sdk.TestSuite{Name: suiteName}
+			suites[suiteName] = s
+			names = append(names, suiteName)
+		}
+
+		t := sdk.Test{
+			Name:      m.Title,
+			ClassName: strings.TrimSpace(strings.TrimSuffix(m.FullTitle, m.Title)),
+		}
+		if m.Duration != nil {
+			t.Time = formatSeconds(*m.Duration / 1000)
+		}
+		switch {
+		case m.Pending || pending[m.FullTitle]:
+			reason := ""
+			t.Skip = &reason
+		case m.Err.Message != "" || m.Err.Stack != "":
+			t.Failure = m.Err.Message
+			if m.Err.Stack != "" && !strings.Contains(m.Err.Message, m.Err.Stack) {
+				t.Failure = strings.TrimSpace(t.Failure + "\n" + m.Err.Stack)
+			}
+		}
+		s.Tests = append(s.Tests, t)
+	}
+
+	res := make([]sdk.TestSuite, 0, len(names))
+	for _, n := range names {
+		countTests(suites[n])
+		res = append(res, *suites[n])
+	}
+	return res, nil
+}
+
+func nonEmpty(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const junitReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="com.example.FooTest" tests="3" failures="1">
+    <testcase classname="com.example.FooTest" name="testOk" time="0.012">
+      <system-out>hello</system-out>
+    </testcase>
+    <testcase classname="com.example.FooTest" name="testKo" time="0.100">
+      <failure message="expected 1">expected 1 but was 2</failure>
+      <system-err>oops</system-err>
+    </testcase>
+    <testcase classname="com.example.FooTest" name="testSkipped"><skipped/></testcase>
+  </testsuite>
+</testsuites>`
+
+const tapReport = `TAP version 13
+1..4
+ok 1 - parses config
+not ok 2 - connects to db
+  ---
+  message: connection refused
+  duration_ms: 1500
+  ...
+# retrying later
+ok 3 - sends mail # SKIP no smtp
+ok 4 - frobnicates # TODO not implemented
+`
+
+const goTestReport = `{"Action":"run","Package":"example.com/foo","Test":"TestOk"}
+{"Action":"output","Package":"example.com/foo","Test":"TestOk","Output":"=== RUN   TestOk\n"}
+{"Action":"pass","Package":"example.com/foo","Test":"TestOk","Elapsed":0.01}
+{"Action":"run","Package":"example.com/foo","Test":"TestKo"}
+{"Action":"output","Package":"example.com/foo","Test":"TestKo","Output":"    foo_test.go:12: expected 1\n"}
+{"Action":"fail","Package":"example.com/foo","Test":"TestKo","Elapsed":0.2}
+{"Action":"run","Package":"example.com/bar","Test":"TestSkip"}
+{"Action":"skip","Package":"example.com/bar","Test":"TestSkip","Elapsed":0}
+{"Action":"fail","Package":"example.com/foo","Elapsed":0.3}
+`
+
+const nunit3Report = `<?xml version="1.0" encoding="utf-8"?>
+<test-run id="2" testcasecount="3" result="Failed">
+  <test-suite type="Assembly" name="Foo.Tests.dll" fullname="Foo.Tests.dll">
+    <test-suite type="TestFixture" name="CalcTests" fullname="Foo.Tests.CalcTests">
+      <test-case name="Add" fullname="Foo.Tests.CalcTests.Add" classname="Foo.Tests.CalcTests" result="Passed" duration="0.0123">
+        <output>computing</output>
+      </test-case>
+      <test-case name="Div" fullname="Foo.Tests.CalcTests.Div" classname="Foo.Tests.CalcTests" result="Failed" duration="0.5">
+        <failure><message>Expected 2</message><stack-trace>at CalcTests.Div()</stack-trace></failure>
+      </test-case>
+      <test-case name="Mul" fullname="Foo.Tests.CalcTests.Mul" classname="Foo.Tests.CalcTests" result="Skipped">
+        <reason><message>later</message></reason>
+      </test-case>
+    </test-suite>
+  </test-suite>
+</test-run>`
+
+const nunit2Report = `<?xml version="1.0" encoding="utf-8"?>
+<test-results name="Foo.Tests.dll" total="2" failures="1">
+  <test-suite type="Assembly" name="Foo.Tests.dll">
+    <results>
+      <test-suite type="TestFixture" name="Foo.Tests.CalcTests">
+        <results>
+          <test-case name="Foo.Tests.CalcTests.Add" executed="True" result="Success" success="True" time="0.010" />
+          <test-case name="Foo.Tests.CalcTests.Div" executed="True" result="Failure" success="False" time="0.020">
+            <failure><message>Expected 2</message></failure>
+          </test-case>
+        </results>
+      </test-suite>
+    </results>
+  </test-suite>
+</test-results>`
+
+const xunitReport = `<?xml version="1.0" encoding="utf-8"?>
+<assemblies>
+  <assembly name="Foo.Tests.dll" total="3">
+    <collection name="Test collection for Foo.Tests.CalcTests" total="3">
+      <test name="Foo.Tests.CalcTests.Add" type="Foo.Tests.CalcTests" method="Add" time="0.0100" result="Pass">
+        <output>computing</output>
+      </test>
+      <test name="Foo.Tests.CalcTests.Div" type="Foo.Tests.CalcTests" method="Div" time="0.2" result="Fail">
+        <failure exception-type="Xunit.Sdk.EqualException"><message>Assert.Equal() Failure</message><stack-trace>at Div()</stack-trace></failure>
+      </test>
+      <test name="Foo.Tests.CalcTests.Mul" type="Foo.Tests.CalcTests" method="Mul" time="0" result="Skip">
+        <reason>later</reason>
+      </test>
+    </collection>
+  </assembly>
+</assemblies>`
+
+const mochaReport = `{
+  "stats": {"suites": 1, "tests": 3, "passes": 1, "pending": 1, "failures": 1},
+  "tests": [
+    {"title": "adds", "fullTitle": "Calc adds", "file": "test/calc.js", "duration": 12, "err": {}},
+    {"title": "divides", "fullTitle": "Calc divides", "file": "test/calc.js", "duration": 1500, "err": {"message": "expected 2", "stack": "AssertionError: expected 2\n    at Context.<anonymous>"}},
+    {"title": "multiplies", "fullTitle": "Calc multiplies", "file": "test/calc.js", "err": {}}
+  ],
+  "pending": [{"title": "multiplies", "fullTitle": "Calc multiplies", "err": {}}],
+  "failures": [],
+  "passes": []
+}`
+
+func TestDetectTestFormat(t *testing.T) {
+	assert.Equal(t, testFormatJUnit, detectTestFormat([]byte(junitReport)))
+	assert.Equal(t, testFormatTAP, detectTestFormat([]byte(tapReport)))
+	assert.Equal(t, testFormatTAP, detectTestFormat([]byte("1..2\nok 1\nok 2\n")))
+	assert.Equal(t, testFormatGoTest, detectTestFormat([]byte(goTestReport)))
+	assert.Equal(t, testFormatNUnit, detectTestFormat([]byte(nunit3Report)))
+	assert.Equal(t, testFormatNUnit, detectTestFormat([]byte(nunit2Report)))
+	assert.Equal(t, testFormatXUnit, detectTestFormat([]byte(xunitReport)))
+	assert.Equal(t, testFormatMocha, detectTestFormat([]byte(mochaReport)))
+	assert.Equal(t, "", detectTestFormat([]byte("hello world")))
+	assert.Equal(t, "", detectTestFormat([]byte(`<html></html>`)))
+}
+
+func TestParseTestReportJUnit(t *testing.T) {
+	suites, err := parseTestReport([]byte(junitReport), testFormatAuto, "report.xml")
+	assert.NoError(t, err)
+	assert.Len(t, suites, 1)
+	s := suites[0]
+	assert.Equal(t, "com.example.FooTest", s.Name)
+	assert.Equal(t, 3, s.Total)
+	assert.Equal(t, 1, s.Failures)
+	assert.Equal(t, 1, s.Skip)
+	assert.Equal(t, "com.example.FooTest", s.Tests[0].ClassName)
+	assert.Equal(t, "hello", s.Tests[0].SystemOut)
+	assert.Equal(t, "expected 1 but was 2", s.Tests[1].Failure)
+	assert.Equal(t, "oops", s.Tests[1].SystemErr)
+	assert.Equal(t, "0.100", s.Tests[1].Time)
+
+	// Nose writes a single test suite
+	suites, err = parseTestReport([]byte(`<testsuite name="nosetests" tests="1"><testcase classname="test_foo" name="test_bar" time="0.1"/></testsuite>`), testFormatJUnit, "nose.xml")
+	assert.NoError(t, err)
+	assert.Len(t, suites, 1)
+	assert.Equal(t, 1, suites[0].Total)
+}
+
+func TestParseTestReportTAP(t *testing.T) {
+	suites, err := parseTestReport([]byte(tapReport), testFormatAuto, "t/basic.t")
+	assert.NoError(t, err)
+	assert.Len(t, suites, 1)
+	s := suites[0]
+	assert.Equal(t, "t/basic.t", s.Name)
+	assert.Equal(t, 4, s.Total)
+	assert.Equal(t, 1, s.Failures)
+	assert.Equal(t, 2, s.Skip)
+	assert.Equal(t, "parses config", s.Tests[0].Name)
+	assert.Equal(t, "connects to db", s.Tests[1].Name)
+	assert.Contains(t, s.Tests[1].Failure, "connection refused")
+	assert.Equal(t, "1.500", s.Tests[1].Time)
+	assert.Equal(t, "retrying later", s.Tests[1].SystemOut)
+	assert.Equal(t, "no smtp", *s.Tests[2].Skip)
+	assert.Equal(t, "not implemented", *s.Tests[3].Skip)
+}
+
+func TestParseTestReportGoTest(t *testing.T) {
+	suites, err := parseTestReport([]byte(goTestReport), testFormatAuto, "go.json")
+	assert.NoError(t, err)
+	assert.Len(t, suites, 2)
+	assert.Equal(t, "example.com/bar", suites[0].Name)
+	assert.Equal(t, 1, suites[0].Skip)
+
+	foo := suites[1]
+	assert.Equal(t, "example.com/foo", foo.Name)
+	assert.Equal(t, 2, foo.Total)
+	assert.Equal(t, 1, foo.Failures)
+	assert.Equal(t, "example.com/foo", foo.Tests[0].ClassName)
+	assert.Equal(t, "=== RUN   TestOk", foo.Tests[0].SystemOut)
+	assert.Equal(t, "0.010", foo.Tests[0].Time)
+	assert.Equal(t, "    foo_test.go:12: expected 1", foo.Tests[1].Failure)
+}
+
+func TestParseTestReportNUnit(t *testing.T) {
+	suites, err := parseTestReport([]byte(nunit3Report), testFormatAuto, "nunit.xml")
+	assert.NoError(t, err)
+	assert.Len(t, suites, 1)
+	s := suites[0]
+	assert.Equal(t, "Foo.Tests.CalcTests", s.Name)
+	assert.Equal(t, 3, s.Total)
+	assert.Equal(t, 1, s.Failures)
+	assert.Equal(t, 1, s.Skip)
+	assert.Equal(t, "Add", s.Tests[0].Name)
+	assert.Equal(t, "Foo.Tests.CalcTests", s.Tests[0].ClassName)
+	assert.Equal(t, "computing", s.Tests[0].SystemOut)
+	assert.Equal(t, "0.012", s.Tests[0].Time)
+	assert.Equal(t, "Expected 2\nat CalcTests.Div()", s.Tests[1].Failure)
+	assert.Equal(t, "later", *s.Tests[2].Skip)
+
+	suites, err = parseTestReport([]byte(nunit2Report), testFormatNUnit, "nunit.xml")
+	assert.NoError(t, err)
+	assert.Len(t, suites, 1)
+	assert.Equal(t, 2, suites[0].Total)
+	assert.Equal(t, 1, suites[0].Failures)
+	assert.Equal(t, "Add", suites[0].Tests[0].Name)
+	assert.Equal(t, "Foo.Tests.CalcTests", suites[0].Tests[0].ClassName)
+}
+
+func TestParseTestReportXUnit(t *testing.T) {
+	suites, err := parseTestReport([]byte(xunitReport), testFormatAuto, "xunit.xml")
+	assert.NoError(t, err)
+	assert.Len(t, suites, 1)
+	s := suites[0]
+	assert.Equal(t, 3, s.Total)
+	assert.Equal(t, 1, s.Failures)
+	assert.Equal(t, 1, s.Skip)
+	assert.Equal(t, "Add", s.Tests[0].Name)
+	assert.Equal(t, "Foo.Tests.CalcTests", s.Tests[0].ClassName)
+	assert.Equal(t, "computing", s.Tests[0].SystemOut)
+	assert.Equal(t, "Xunit.Sdk.EqualException: Assert.Equal() Failure\nat Div()", s.Tests[1].Failure)
+	assert.Equal(t, "0.200", s.Tests[1].Time)
+}
+
+func TestParseTestReportMocha(t *testing.T) {
+	suites, err := parseTestReport([]byte(mochaReport), testFormatAuto, "mocha.json")
+	assert.NoError(t, err)
+	assert.Len(t, suites, 1)
+	s := suites[0]
+	assert.Equal(t, "test/calc.js", s.Name)
+	assert.Equal(t, 3, s.Total)
+	assert.Equal(t, 1, s.Failures)
+	assert.Equal(t, 1, s.Skip)
+	assert.Equal(t, "Calc", s.Tests[0].ClassName)
+	assert.Equal(t, "0.012", s.Tests[0].Time)
+	assert.Contains(t, s.Tests[1].Failure, "expected 2")
+	assert.Equal(t, "1.500", s.Tests[1].Time)
+}
@@ -20,6 +20,9 @@ type Action struct {
 	PipelineStageID  int64         `json:"pipeline_stage_id" yaml:"-"`
 	PipelineActionID int64         `json:"pipeline_action_id" yaml:"-"`
 	Final            bool          `json:"final" yaml:"-"`
+	Condition        string        `json:"condition,omitempty" yaml:"condition,omitempty"`
+	Retry            *ActionRetry  `json:"retry,omitempty" yaml:"retry,omitempty"`
+	Timeout          int64         `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 	LastModified     int64         `json:"last_modified"`
 }
 
@@ -57,9 +60,12 @@ const (
 
 // Builtin Action
 const (
-	ScriptAction = "Script"
-	NotifAction  = "Notif"
-	JUnitAction  = "JUnit"
+	ScriptAction   = "Script"
+	NotifAction    = "Notif"
+	JUnitAction    = "JUnit"
+	GitCloneAction = "GitClone"
+	CoverageAction = "Coverage"
+	FindingsAction = "Findings"
 )
 
 // RequirementType define the type of requirement for an action to be run
@@ -95,10 +101,13 @@ var (
 // Requirement can be :
 // - a binary "which /usr/bin/docker"
 // - a network access "telnet google.com 443"
+// Binary requirements may constrain the version of the binary, like ">= 1.7", and
+// binary capabilities of worker models may tell which version they provide.
 type Requirement struct {
-	Name  string          `json:"name"`
-	Type  RequirementType `json:"type" yaml:"-"`
-	Value string          `json:"value" yaml:"-"`
+	Name    string          `json:"name"`
+	Type    RequirementType `json:"type" yaml:"-"`
+	Value   string          `json:"value" yaml:"-"`
+	Version string          `json:"version,omitempty" yaml:"-"`
 }
 
 // NewAction instanciate a new Action
@@ -0,0 +1,112 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StageApproval is a gate a pipeline build has to pass before running a stage:
+// Required users from Groups have to approve it. Without any decision within
+// Timeout seconds, when set, the pipeline build fails.
+type StageApproval struct {
+	Groups   []string `json:"groups" yaml:"groups"`
+	Required int      `json:"required,omitempty" yaml:"required,omitempty"`
+	Timeout  int64    `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// Check validates the approval policy
+func (a *StageApproval) Check() error {
+	if len(a.Groups) == 0 {
+		return NewError(ErrInvalidStageApproval, fmt.Errorf("no approver group"))
+	}
+	if a.Required < 0 {
+		return NewError(ErrInvalidStageApproval, fmt.Errorf("required approvals must be positive"))
+	}
+	if a.Timeout < 0 {
+		return NewError(ErrInvalidStageApproval, fmt.Errorf("timeout must be positive"))
+	}
+	return nil
+}
+
+// RequiredApprovals returns the number of approvals needed to open the gate, one at least
+func (a *StageApproval) RequiredApprovals() int {
+	if a.Required < 1 {
+		return 1
+	}
+	return a.Required
+}
+
+// String returns a short description of the approval policy
+func (a *StageApproval) String() string {
+	if a == nil {
+		return ""
+	}
+	s := fmt.Sprintf("%d from %s", a.RequiredApprovals(), strings.Join(a.Groups, ","))
+	if a.Timeout > 0 {
+		s += fmt.Sprintf(" within %ds", a.Timeout)
+	}
+	return s
+}
+
+// StageGate is the approval request of a stage in a pipeline build
+type StageGate struct {
+	ID              int64                   `json:"id"`
+	PipelineBuildID int64                   `json:"pipeline_build_id"`
+	StageID         int64                   `json:"stage_id"`
+	Status          Status                  `json:"status"`
+	Required        int                     `json:"required"`
+	Timeout         int64                   `json:"timeout,omitempty"`
+	Requested       time.Time               `json:"requested"`
+	Done            time.Time               `json:"done,omitempty"`
+	Decisions       []StageApprovalDecision `json:"decisions"`
+}
+
+// Approvals returns the number of users who approved the gate
+func (g *StageGate) Approvals() int {
+	var n int
+	for _, d := range g.Decisions {
+		if d.Approved {
+			n++
+		}
+	}
+	return n
+}
+
+// StageApprovalDecision is the approval or the rejection of a stage gate by a user
+type StageApprovalDecision struct {
+	ID       int64     `json:"id"`
+	UserID   int64     `json:"user_id"`
+	Username string    `json:"username"`
+	Approved bool      `json:"approved"`
+	Comment  string    `json:"comment"`
+	Date     time.Time `json:"date"`
+}
+
+// ApproveStage approves or rejects a stage waiting for approval in a pipeline build
+func ApproveStage(projectKey, appName, pipelineName, env string, buildNumber int, stageID int64, approved bool, comment string) (*StageGate, error) {
+	path := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/build/%d/stage/%d/approve?envName=%s", projectKey, appName, pipelineName, buildNumber, stageID, env)
+
+	body, err := json.Marshal(StageApprovalDecision{Approved: approved, Comment: comment})
+	if err != nil {
+		return nil, err
+	}
+
+	data, code, err := Request("POST", path, body)
+	if err != nil {
+		return nil, err
+	}
+	if e := DecodeError(data); e != nil {
+		return nil, e
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	gate := &StageGate{}
+	if err := json.Unmarshal(data, gate); err != nil {
+		return nil, err
+	}
+	return gate, nil
+}
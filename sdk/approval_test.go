@@ -0,0 +1,32 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStageApproval(t *testing.T) {
+	a := &StageApproval{Groups: []string{"ops", "qa"}}
+	assert.NoError(t, a.Check())
+	assert.Equal(t, 1, a.RequiredApprovals())
+	assert.Equal(t, "1 from ops,qa", a.String())
+
+	a.Required, a.Timeout = 2, 3600
+	assert.Equal(t, 2, a.RequiredApprovals())
+	assert.Equal(t, "2 from ops,qa within 3600s", a.String())
+
+	var none *StageApproval
+	assert.Equal(t, "", none.String())
+
+	for _, invalid := range []*StageApproval{
+		{},
+		{Groups: []string{"ops"}, Required: -1},
+		{Groups: []string{"ops"}, Timeout: -1},
+	} {
+		assert.Error(t, invalid.Check())
+	}
+
+	g := &StageGate{Decisions: []StageApprovalDecision{{Approved: true}, {Approved: false}, {Approved: true}}}
+	assert.Equal(t, 2, g.Approvals())
+}
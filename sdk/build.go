@@ -3,6 +3,7 @@ package sdk
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 )
 
@@ -23,6 +24,12 @@ type ActionBuild struct {
 	Done             time.Time     `json:"done,omitempty"`
 	Logs             string        `json:"logs,omitempty"`
 	Model            string        `json:"model,omitempty"`
+	Attempt          int           `json:"attempt"`
+	Retried          bool          `json:"retried,omitempty"`
+	AWOL             bool          `json:"awol,omitempty"`
+	Timeout          int64         `json:"timeout,omitempty"`
+	TimedOut         bool          `json:"timed_out,omitempty"`
+	Priority         int64         `json:"priority"`
 }
 
 // BuildState define struct returned when looking for build state informations
@@ -52,6 +59,10 @@ func StatusFromString(in string) Status {
 		return StatusDisabled
 	case StatusSkipped.String():
 		return StatusSkipped
+	case StatusWaitingApproval.String():
+		return StatusWaitingApproval
+	case StatusStopped.String():
+		return StatusStopped
 	default:
 		return StatusUnknown
 	}
@@ -71,8 +82,17 @@ const (
 	StatusNeverBuilt Status = "Never Built"
 	StatusUnknown    Status = "Unknown"
 	StatusSkipped    Status = "Skipped"
+
+	StatusWaitingApproval Status = "Waiting for approval"
+	StatusStopped         Status = "Stopped"
 )
 
+// ActionBuildCancel tells a worker whether the action build it is running has been stopped
+type ActionBuildCancel struct {
+	ActionBuildID int64 `json:"action_build_id"`
+	Cancel        bool  `json:"cancel"`
+}
+
 // GetBuildQueue retrieves current CDS build in queue
 func GetBuildQueue() ([]ActionBuild, error) {
 	var q []ActionBuild
@@ -95,6 +115,32 @@ func GetBuildQueue() ([]ActionBuild, error) {
 	return q, nil
 }
 
+// WaitBuildQueue waits for action builds the calling worker can run, up to given timeout.
+// It returns ErrNotFound if the API does not push action builds to workers.
+func WaitBuildQueue(timeout time.Duration) ([]ActionBuild, error) {
+	var q []ActionBuild
+
+	path := fmt.Sprintf("/queue/wait?timeout=%d", int64(timeout.Seconds()))
+
+	data, code, err := Request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	err = json.Unmarshal(data, &q)
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
 // GetBuildState Get the state of given build
 func GetBuildState(projectKey, appName, pipelineName, env, buildID string) (PipelineBuild, error) {
 	var buildState PipelineBuild
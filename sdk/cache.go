@@ -0,0 +1,128 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// Cache is an archive of files of a workspace, saved by a build to be restored by the next ones.
+// Caches are shared by the pipelines of a project and identified by their key.
+type Cache struct {
+	ID         int64     `json:"id"`
+	ProjectKey string    `json:"project_key"`
+	Key        string    `json:"key"`
+	Size       int64     `json:"size"`
+	ObjectPath string    `json:"-"`
+	Created    time.Time `json:"created"`
+	LastUsed   time.Time `json:"last_used"`
+}
+
+// Builtin cache actions
+const (
+	CacheSave    = "Cache Save"
+	CacheRestore = "Cache Restore"
+)
+
+var cacheKeyRegexp = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,250}$`)
+
+// CheckCacheKey verifies that a cache key is made of at most 250 letters, digits, dots, dashes and underscores
+func CheckCacheKey(key string) error {
+	if !cacheKeyRegexp.MatchString(key) {
+		return NewError(ErrInvalidCacheKey, fmt.Errorf("invalid cache key '%s'", key))
+	}
+	return nil
+}
+
+func cachePath(project, app, pipeline string) string {
+	return fmt.Sprintf("/project/%s/application/%s/pipeline/%s/cache", project, app, pipeline)
+}
+
+// ResolveCache returns the cache of given key, or else the most recently used cache matching one of the
+// fallback keys, in order. A fallback key matches the caches whose key starts with it.
+// It returns ErrCacheNotFound when no cache matches.
+func ResolveCache(project, app, pipeline, key string, fallbacks []string) (*Cache, error) {
+	v := url.Values{}
+	v.Set("key", key)
+	for _, f := range fallbacks {
+		v.Add("fallback", f)
+	}
+
+	data, code, err := Request("GET", cachePath(project, app, pipeline)+"?"+v.Encode(), nil)
+	if code == http.StatusNotFound {
+		return nil, ErrCacheNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	c := &Cache{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DownloadCache streams the archive of the cache of given key
+func DownloadCache(project, app, pipeline, key string) (io.ReadCloser, error) {
+	reader, code, err := Stream("GET", cachePath(project, app, pipeline)+"/"+key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		reader.Close()
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+	return reader, nil
+}
+
+// UploadCache saves given archive as the cache of given key, replacing any existing one
+func UploadCache(project, app, pipeline, key string, archive io.ReadCloser) error {
+	data, code, err := Upload("POST", cachePath(project, app, pipeline)+"/"+key, archive)
+	if err != nil {
+		return err
+	}
+	if e := DecodeError(data); e != nil {
+		return e
+	}
+	if code >= 300 {
+		return fmt.Errorf("HTTP %d", code)
+	}
+	return nil
+}
+
+// ListCaches lists the caches of a project, most recently used first
+func ListCaches(project string) ([]Cache, error) {
+	data, code, err := Request("GET", fmt.Sprintf("/project/%s/cache", project), nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var caches []Cache
+	if err := json.Unmarshal(data, &caches); err != nil {
+		return nil, err
+	}
+	return caches, nil
+}
+
+// DeleteCache deletes the cache of given key from a project
+func DeleteCache(project, key string) error {
+	_, code, err := Request("DELETE", fmt.Sprintf("/project/%s/cache/%s", project, key), nil)
+	if err != nil {
+		return err
+	}
+	if code >= 300 {
+		return fmt.Errorf("HTTP %d", code)
+	}
+	return nil
+}
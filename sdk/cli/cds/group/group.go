@@ -9,6 +9,7 @@ func init() {
 	Cmd.AddCommand(cmdGroupInfo())
 	Cmd.AddCommand(cmdGroupRemove())
 	Cmd.AddCommand(cmdGroupRename())
+	Cmd.AddCommand(cmdGroupShare())
 	Cmd.AddCommand(cmdGroupList)
 	Cmd.AddCommand(cmdGroupSetAdmin())
 	Cmd.AddCommand(cmdGroupUnsetAdmin())
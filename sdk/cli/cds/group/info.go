@@ -32,6 +32,13 @@ func infoGroup(cmd *cobra.Command, args []string) {
 
 	fmt.Printf("Groupname: %s\n", group.Name)
 
+	if group.Share != nil {
+		fmt.Printf("Share: %d\n", group.Share.Share)
+		if group.Share.MaxWorkers > 0 {
+			fmt.Printf("Max workers: %d\n", group.Share.MaxWorkers)
+		}
+	}
+
 	if group.Users != nil || group.Admins != nil {
 		fmt.Printf("Users:\n")
 		for _, u := range group.Admins {
@@ -0,0 +1,46 @@
+package group
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ovh/cds/sdk"
+
+	"github.com/spf13/cobra"
+)
+
+func cmdGroupShare() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "share",
+		Short: "cds group share <groupName> <share> [<maxWorkers>]",
+		Long:  `Set the weight of a group in the fair-share scheduling of the build queue, and the maximum number of workers its builds can use at the same time (0 for no limit)`,
+		Run:   shareGroup,
+	}
+
+	return cmd
+}
+
+func shareGroup(cmd *cobra.Command, args []string) {
+	if len(args) != 2 && len(args) != 3 {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+	groupName := args[0]
+
+	var share sdk.GroupShare
+	var err error
+	share.Share, err = strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		sdk.Exit("Error: invalid share %s (%s)\n", args[1], err)
+	}
+	if len(args) == 3 {
+		share.MaxWorkers, err = strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			sdk.Exit("Error: invalid max workers %s (%s)\n", args[2], err)
+		}
+	}
+
+	if err := sdk.SetGroupShare(groupName, share); err != nil {
+		sdk.Exit("Error: cannot set share of group %s (%s)\n", groupName, err)
+	}
+	fmt.Printf("OK\n")
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/ovh/cds/sdk/cli/cds/pipeline"
 	"github.com/ovh/cds/sdk/cli/cds/plugin"
 	"github.com/ovh/cds/sdk/cli/cds/project"
+	"github.com/ovh/cds/sdk/cli/cds/queue"
 	"github.com/ovh/cds/sdk/cli/cds/repositoriesmanager"
 	"github.com/ovh/cds/sdk/cli/cds/track"
 	"github.com/ovh/cds/sdk/cli/cds/trigger"
@@ -72,6 +73,7 @@ func main() {
 	rootCmd.AddCommand(repositoriesmanager.Cmd())
 	rootCmd.AddCommand(plugin.Cmd())
 	rootCmd.AddCommand(generate.Cmd())
+	rootCmd.AddCommand(queue.Cmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func pipelineAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "cds pipeline audit <projectKey> <pipelineName>",
+		Long:  `List all versions of a pipeline definition`,
+		Run:   auditPipeline,
+	}
+
+	cmd.AddCommand(pipelineAuditDiffCmd())
+	cmd.AddCommand(pipelineAuditRestoreCmd())
+	return cmd
+}
+
+func auditPipeline(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+	projectKey := args[0]
+	name := args[1]
+
+	audits, err := sdk.GetPipelineAudit(projectKey, name)
+	if err != nil {
+		sdk.Exit("Error: cannot retrieve versions of pipeline %s (%s)\n", name, err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 10, 1, 2, ' ', 0)
+	titles := []string{"VERSION", "DATE", "USER", "CHANGE"}
+	fmt.Fprintln(w, strings.Join(titles, "\t"))
+	for _, a := range audits {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", a.Version, a.Versionned.Format("2006-01-02 15:04:05"), a.User.Username, a.Change)
+	}
+	w.Flush()
+}
+
+func pipelineAuditDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "cds pipeline audit diff <projectKey> <pipelineName> <fromVersion> [toVersion]",
+		Long:  `Show changes between two versions of a pipeline definition, or between a version and the current definition`,
+		Run:   diffPipelineVersions,
+	}
+	return cmd
+}
+
+func diffPipelineVersions(cmd *cobra.Command, args []string) {
+	if len(args) < 3 || len(args) > 4 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+	projectKey := args[0]
+	name := args[1]
+
+	from, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		sdk.Exit("Error: version must be an integer (%s)\n", err)
+	}
+	var to int64
+	if len(args) == 4 {
+		to, err = strconv.ParseInt(args[3], 10, 64)
+		if err != nil {
+			sdk.Exit("Error: version must be an integer (%s)\n", err)
+		}
+	}
+
+	changes, err := sdk.DiffPipelineVersions(projectKey, name, from, to)
+	if err != nil {
+		sdk.Exit("Error: cannot compare versions of pipeline %s (%s)\n", name, err)
+	}
+
+	for _, c := range changes {
+		switch c.Type {
+		case sdk.PipelineChangeAdded:
+			fmt.Printf("+ %s: %s\n", c.Path, c.To)
+		case sdk.PipelineChangeRemoved:
+			fmt.Printf("- %s: %s\n", c.Path, c.From)
+		default:
+			fmt.Printf("~ %s: %s -> %s\n", c.Path, c.From, c.To)
+		}
+	}
+}
+
+func pipelineAuditRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "cds pipeline audit restore <projectKey> <pipelineName> <version>",
+		Long:  `Restore a previous version of a pipeline definition`,
+		Run:   restorePipelineVersion,
+	}
+	return cmd
+}
+
+func restorePipelineVersion(cmd *cobra.Command, args []string) {
+	if len(args) != 3 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+	projectKey := args[0]
+	name := args[1]
+
+	version, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		sdk.Exit("Error: version must be an integer (%s)\n", err)
+	}
+
+	if _, err := sdk.RestorePipelineVersion(projectKey, name, version); err != nil {
+		sdk.Exit("Error: cannot restore version %d of pipeline %s (%s)\n", version, name, err)
+	}
+	fmt.Printf("Pipeline %s restored to version %d\n", name, version)
+}
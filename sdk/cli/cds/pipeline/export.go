@@ -0,0 +1,49 @@
+package pipeline
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var cmdPipelineExportFormat string
+var cmdPipelineExportOutput string
+
+func pipelineExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "cds pipeline export <projectKey> <pipelineName> [--format yaml|json] [--output file]",
+		Long:  ``,
+		Run:   exportPipeline,
+	}
+
+	cmd.Flags().StringVarP(&cmdPipelineExportFormat, "format", "", "yaml", "Export format {yaml,json}")
+	cmd.Flags().StringVarP(&cmdPipelineExportOutput, "output", "o", "", "Write to file instead of stdout")
+	return cmd
+}
+
+func exportPipeline(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	projectKey := args[0]
+	pipelineName := args[1]
+	data, err := sdk.ExportPipeline(projectKey, pipelineName, cmdPipelineExportFormat)
+	if err != nil {
+		sdk.Exit("Error: cannot export pipeline %s (%s)\n", pipelineName, err)
+	}
+
+	if cmdPipelineExportOutput == "" {
+		fmt.Println(string(data))
+		return
+	}
+
+	if err := ioutil.WriteFile(cmdPipelineExportOutput, data, 0644); err != nil {
+		sdk.Exit("Error: cannot write %s (%s)\n", cmdPipelineExportOutput, err)
+	}
+	fmt.Printf("Pipeline %s exported to %s\n", pipelineName, cmdPipelineExportOutput)
+}
@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var cmdPipelineImportFormat string
+
+func pipelineImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "cds pipeline import <projectKey> <file> [--format yaml|json]",
+		Long:  `Create or update a pipeline from a yaml or json definition. Format is guessed from the file extension unless --format is given.`,
+		Run:   importPipeline,
+	}
+
+	cmd.Flags().StringVarP(&cmdPipelineImportFormat, "format", "", "", "Import format {yaml,json}")
+	return cmd
+}
+
+func importPipeline(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	projectKey := args[0]
+	file := args[1]
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		sdk.Exit("Error: cannot read %s (%s)\n", file, err)
+	}
+
+	format := cmdPipelineImportFormat
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(file), ".")
+	}
+
+	p, err := sdk.ImportPipeline(projectKey, data, format)
+	if err != nil {
+		sdk.Exit("Error: cannot import pipeline from %s (%s)\n", file, err)
+	}
+
+	fmt.Printf("Pipeline %s imported in project %s\n", p.Name, projectKey)
+}
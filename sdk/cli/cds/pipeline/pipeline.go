@@ -15,9 +15,13 @@ func Cmd() *cobra.Command {
 
 	cmd.AddCommand(pipelineActionCmd)
 	cmd.AddCommand(pipelineAddCmd())
+	cmd.AddCommand(pipelineAuditCmd())
 	cmd.AddCommand(pipelineDeleteCmd())
+	cmd.AddCommand(pipelineExportCmd())
 	cmd.AddCommand(pipelineGroupCmd)
 	cmd.AddCommand(pipelineHistoryCmd())
+	cmd.AddCommand(pipelineSkippedCmd())
+	cmd.AddCommand(pipelineImportCmd())
 	cmd.AddCommand(pipelineListCmd())
 	cmd.AddCommand(pipelineRunCmd())
 	cmd.AddCommand(pipelineRestartCmd())
@@ -26,6 +30,7 @@ func Cmd() *cobra.Command {
 	cmd.AddCommand(pipelineShowCmd())
 	cmd.AddCommand(pipelineStageCmd)
 	cmd.AddCommand(pipelineHookCmd)
+	cmd.AddCommand(pipelineScheduleCmd)
 	cmd.AddCommand(pipelineParameterCmd)
 	cmd.AddCommand(pipelineJoinedCmd())
 	cmd.AddCommand(pipelineBuildCmd())
@@ -16,6 +16,7 @@ var batch bool
 var env string
 var parentInfo string
 var parentBuildNumber int64
+var priority int64
 
 func pipelineRunCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -30,6 +31,7 @@ func pipelineRunCmd() *cobra.Command {
 	cmd.Flags().StringSliceVarP(&cmdPipelineRunArguments, "parameter", "p", nil, "Pipeline parameters")
 	cmd.Flags().StringVarP(&parentInfo, "parent", "", "", "Parent build (format: app/pip[/env])")
 	cmd.Flags().Int64VarP(&parentBuildNumber, "parent-build", "", 0, "Parent build number")
+	cmd.Flags().Int64VarP(&priority, "priority", "", 0, "Build priority, overrides the pipeline priority (-100 to 100)")
 
 	return cmd
 }
@@ -133,6 +135,9 @@ func runPipeline(cmd *cobra.Command, args []string) {
 		ParentApplicationID: pappID,
 		ParentEnvironmentID: penvID,
 	}
+	if cmd.Flags().Changed("priority") {
+		r.Priority = &priority
+	}
 
 	ch, err := sdk.RunPipeline(projectKey, appName, name, envName, stream, r, false)
 	if err != nil {
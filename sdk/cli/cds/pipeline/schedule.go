@@ -0,0 +1,216 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var scheduleEnv string
+var scheduleTimezone string
+var scheduleOnlyNewCommits bool
+var scheduleDisabled bool
+var scheduleParameters []string
+
+func init() {
+	pipelineScheduleCmd.AddCommand(pipelineAddScheduleCmd())
+	pipelineScheduleCmd.AddCommand(pipelineUpdateScheduleCmd())
+	pipelineScheduleCmd.AddCommand(pipelineDeleteScheduleCmd())
+	pipelineScheduleCmd.AddCommand(pipelineListScheduleCmd())
+}
+
+var pipelineScheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Run pipelines on a cron schedule",
+	Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func addScheduleFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&scheduleEnv, "env", "", "", "Environment the pipeline runs on")
+	cmd.Flags().StringVarP(&scheduleTimezone, "timezone", "", "", "Timezone of the cron expression, e.g. Europe/Paris (default UTC)")
+	cmd.Flags().BoolVarP(&scheduleOnlyNewCommits, "only-new-commits", "", false, "Skip the run if the branch has no new commit since the last run")
+	cmd.Flags().BoolVarP(&scheduleDisabled, "disabled", "", false, "Disable the schedule")
+	cmd.Flags().StringSliceVarP(&scheduleParameters, "parameter", "p", nil, "Pipeline parameters")
+}
+
+func pipelineAddScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "cds pipeline schedule add <projectKey> <applicationName> <pipelineName> \"<cron expression>\"",
+		Long:  ``,
+		Run:   addPipelineSchedule,
+	}
+	addScheduleFlags(cmd)
+
+	return cmd
+}
+
+func pipelineUpdateScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "cds pipeline schedule update <projectKey> <applicationName> <pipelineName> <scheduleID> [\"<cron expression>\"]",
+		Long:  ``,
+		Run:   updatePipelineSchedule,
+	}
+	addScheduleFlags(cmd)
+
+	return cmd
+}
+
+func pipelineDeleteScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "cds pipeline schedule delete <projectKey> <applicationName> <pipelineName> <scheduleID>",
+		Long:  ``,
+		Run:   deletePipelineSchedule,
+	}
+
+	return cmd
+}
+
+func pipelineListScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "cds pipeline schedule list <projectKey> <applicationName> <pipelineName>",
+		Long:  ``,
+		Run:   listPipelineSchedule,
+	}
+
+	return cmd
+}
+
+func addPipelineSchedule(cmd *cobra.Command, args []string) {
+	if len(args) != 4 {
+		sdk.Exit("Wrong usage: See %s\n", cmd.Short)
+	}
+
+	s := sdk.PipelineSchedule{
+		Cron:           args[3],
+		Environment:    sdk.Environment{Name: scheduleEnv},
+		Timezone:       scheduleTimezone,
+		Parameters:     scheduleParams(),
+		OnlyNewCommits: scheduleOnlyNewCommits,
+		Enabled:        !scheduleDisabled,
+	}
+
+	if err := sdk.AddPipelineSchedule(args[0], args[1], args[2], &s); err != nil {
+		sdk.Exit("✘ Error: Cannot add schedule to pipeline %s-%s-%s (%s)\n", args[0], args[1], args[2], err)
+	}
+	fmt.Printf("✔ Schedule %d added, next run at %s\n", s.ID, s.NextExecution.Format(time.RFC1123))
+}
+
+func updatePipelineSchedule(cmd *cobra.Command, args []string) {
+	if len(args) != 4 && len(args) != 5 {
+		sdk.Exit("Wrong usage: See %s\n", cmd.Short)
+	}
+
+	s := findSchedule(args[0], args[1], args[2], args[3])
+	if len(args) == 5 {
+		s.Cron = args[4]
+	}
+	if cmd.Flags().Changed("env") {
+		s.Environment = sdk.Environment{Name: scheduleEnv}
+	}
+	if cmd.Flags().Changed("timezone") {
+		s.Timezone = scheduleTimezone
+	}
+	if cmd.Flags().Changed("only-new-commits") {
+		s.OnlyNewCommits = scheduleOnlyNewCommits
+	}
+	if cmd.Flags().Changed("disabled") {
+		s.Enabled = !scheduleDisabled
+	}
+	if cmd.Flags().Changed("parameter") {
+		s.Parameters = scheduleParams()
+	}
+
+	if err := sdk.UpdatePipelineSchedule(args[0], args[1], args[2], s); err != nil {
+		sdk.Exit("✘ Error: Cannot update schedule %d (%s)\n", s.ID, err)
+	}
+	fmt.Printf("✔ Schedule %d updated, next run at %s\n", s.ID, s.NextExecution.Format(time.RFC1123))
+}
+
+func deletePipelineSchedule(cmd *cobra.Command, args []string) {
+	if len(args) != 4 {
+		sdk.Exit("Wrong usage: See %s\n", cmd.Short)
+	}
+
+	id, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		sdk.Exit("Schedule id must be a number (%s)\n", err)
+	}
+
+	if err := sdk.DeletePipelineSchedule(args[0], args[1], args[2], id); err != nil {
+		sdk.Exit("✘ Error: Cannot delete schedule %d (%s)\n", id, err)
+	}
+	fmt.Println("✔ Success")
+}
+
+func listPipelineSchedule(cmd *cobra.Command, args []string) {
+	if len(args) != 3 {
+		sdk.Exit("Wrong usage: See %s\n", cmd.Short)
+	}
+
+	schedules, err := sdk.GetPipelineSchedules(args[0], args[1], args[2])
+	if err != nil {
+		sdk.Exit("Cannot retrieve schedules from %s/%s/%s (%s)\n", args[0], args[1], args[2], err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(w, "ID\tCRON\tTIMEZONE\tENV\tNEW COMMITS ONLY\tENABLED\tNEXT RUN\tLAST RUN\t")
+	for _, s := range schedules {
+		tz := s.Timezone
+		if tz == "" {
+			tz = "UTC"
+		}
+		last := "-"
+		if s.LastExecution != nil {
+			last = s.LastExecution.Format(time.RFC1123)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%t\t%t\t%s\t%s\t\n", s.ID, s.Cron, tz, s.Environment.Name, s.OnlyNewCommits, s.Enabled, s.NextExecution.Format(time.RFC1123), last)
+	}
+	w.Flush()
+}
+
+// findSchedule returns the schedule with given id of a pipeline
+func findSchedule(projectKey, appName, pipelineName, idArg string) *sdk.PipelineSchedule {
+	id, err := strconv.ParseInt(idArg, 10, 64)
+	if err != nil {
+		sdk.Exit("Schedule id must be a number (%s)\n", err)
+	}
+
+	schedules, err := sdk.GetPipelineSchedules(projectKey, appName, pipelineName)
+	if err != nil {
+		sdk.Exit("Cannot retrieve schedules from %s/%s/%s (%s)\n", projectKey, appName, pipelineName, err)
+	}
+	for i := range schedules {
+		if schedules[i].ID == id {
+			return &schedules[i]
+		}
+	}
+	sdk.Exit("✘ Error: Schedule %d not found on %s/%s/%s\n", id, projectKey, appName, pipelineName)
+	return nil
+}
+
+// scheduleParams parses the parameters given as name=value
+func scheduleParams() []sdk.Parameter {
+	var params []sdk.Parameter
+	for _, elt := range scheduleParameters {
+		t := strings.SplitN(elt, "=", 2)
+		if len(t) != 2 {
+			sdk.Exit("Error: malformed parameter '%s' (must be format 'name=value')\n", elt)
+		}
+		params = append(params, sdk.Parameter{Name: t[0], Value: t[1], Type: sdk.StringParameter})
+	}
+	return params
+}
@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func pipelineSkippedCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "skipped",
+		Short: "cds pipeline skipped <projectKey> <applicationName> <pipelineName> [envName]",
+		Long:  `List the hooks and pollers which did not start a build since their commit was already built`,
+		Run:   skippedPipeline,
+	}
+
+	return cmd
+}
+
+func skippedPipeline(cmd *cobra.Command, args []string) {
+
+	if len(args) < 3 || len(args) > 4 {
+		sdk.Exit("Wrong usage: see %s\n", cmd.Short)
+	}
+
+	projectKey := args[0]
+	appName := args[1]
+	pipelineName := args[2]
+	var envName string
+	if len(args) == 4 {
+		envName = args[3]
+	}
+	skips, err := sdk.GetPipelineBuildSkips(projectKey, appName, pipelineName, envName)
+	if err != nil {
+		sdk.Exit("Error: cannot retrieve skipped builds (%s)\n", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 20, 1, 2, ' ', 0)
+	titles := []string{"DATE", "ORIGIN", "BRANCH", "HASH", "BUILT BY"}
+	fmt.Fprintln(w, strings.Join(titles, "\t"))
+
+	for _, s := range skips {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t#%d on %s\n",
+			s.Skipped.Format(time.RFC3339),
+			s.Origin,
+			s.Branch,
+			s.Hash,
+			s.BuildNumber,
+			s.BuildBranch,
+		)
+	}
+	w.Flush()
+}
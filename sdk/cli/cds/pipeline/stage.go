@@ -23,6 +23,7 @@ func init() {
 	pipelineStageCmd.AddCommand(pipelineMoveStageCmd())
 	pipelineStageCmd.AddCommand(pipelineRenameStageCmd())
 	pipelineStageCmd.AddCommand(pipelineChangeStateStageCmd())
+	pipelineStageCmd.AddCommand(pipelineNeedsStageCmd())
 }
 
 func cmdPipelineAddStage() *cobra.Command {
@@ -101,6 +102,31 @@ func changeStateStage(cmd *cobra.Command, args []string) {
 	fmt.Printf("Stage updated.\n")
 }
 
+func pipelineNeedsStageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "needs",
+		Short: "cds pipeline stage needs <projectKey> <pipelineName> <pipelineStageID> [<stageName>...]",
+		Long:  "Set the stages a stage waits for. Without stage name, the stage waits for all the stages before it.",
+		Run:   needsStage,
+	}
+	return cmd
+}
+
+func needsStage(cmd *cobra.Command, args []string) {
+	if len(args) < 3 {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+	projectKey := args[0]
+	pipelineName := args[1]
+	pipelineStageIDString := args[2]
+
+	err := sdk.SetStageNeeds(projectKey, pipelineName, pipelineStageIDString, args[3:])
+	if err != nil {
+		sdk.Exit("Error: cannot set stage needs (%s)\n", err)
+	}
+	fmt.Printf("Stage updated.\n")
+}
+
 func pipelineRenameStageCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "rename",
@@ -0,0 +1,50 @@
+package project
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var cmdProjectExportFormat string
+var cmdProjectExportOutput string
+var cmdProjectExportPassphrase string
+
+func cmdProjectExport() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "cds project export <projectUniqueKey> --passphrase <passphrase> [--format yaml|json] [--output file]",
+		Long:  `Export a project with its applications, pipelines, environments, triggers, hooks, pollers, groups permissions and variables. Password and key variables are ciphered with the passphrase.`,
+		Run:   exportProject,
+	}
+
+	cmd.Flags().StringVarP(&cmdProjectExportFormat, "format", "", "yaml", "Export format {yaml,json}")
+	cmd.Flags().StringVarP(&cmdProjectExportOutput, "output", "o", "", "Write to file instead of stdout")
+	cmd.Flags().StringVarP(&cmdProjectExportPassphrase, "passphrase", "", "", "Passphrase ciphering password and key variables")
+	return cmd
+}
+
+func exportProject(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+	key := args[0]
+
+	data, err := sdk.ExportProject(key, cmdProjectExportFormat, cmdProjectExportPassphrase)
+	if err != nil {
+		sdk.Exit("Error: cannot export project %s (%s)\n", key, err)
+	}
+
+	if cmdProjectExportOutput == "" {
+		fmt.Println(string(data))
+		return
+	}
+
+	if err := ioutil.WriteFile(cmdProjectExportOutput, data, 0600); err != nil {
+		sdk.Exit("Error: cannot write %s (%s)\n", cmdProjectExportOutput, err)
+	}
+	fmt.Printf("Project %s exported to %s\n", key, cmdProjectExportOutput)
+}
@@ -0,0 +1,60 @@
+package project
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+var cmdProjectImportFormat string
+var cmdProjectImportPassphrase string
+
+func cmdProjectImport() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "cds project import <file> --passphrase <passphrase> [--format yaml|json]",
+		Long:  `Create a project from an archive made by cds project export. Format is guessed from the file extension unless --format is given.`,
+		Run:   importProject,
+	}
+
+	cmd.Flags().StringVarP(&cmdProjectImportFormat, "format", "", "", "Import format {yaml,json}")
+	cmd.Flags().StringVarP(&cmdProjectImportPassphrase, "passphrase", "", "", "Passphrase given at export")
+	return cmd
+}
+
+func importProject(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+	file := args[0]
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		sdk.Exit("Error: cannot read %s (%s)\n", file, err)
+	}
+
+	format := cmdProjectImportFormat
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(file), ".")
+	}
+
+	report, err := sdk.ImportProject(data, format, cmdProjectImportPassphrase)
+	if report != nil {
+		for _, c := range report.Conflicts {
+			fmt.Printf("Conflict: %s\n", c)
+		}
+		for _, w := range report.Warnings {
+			fmt.Printf("Warning: %s\n", w)
+		}
+	}
+	if err != nil {
+		sdk.Exit("Error: cannot import project from %s (%s)\n", file, err)
+	}
+
+	fmt.Printf("OK\n")
+}
@@ -10,6 +10,8 @@ func init() {
 	Cmd.AddCommand(cmdProjectAdd())
 	Cmd.AddCommand(cmdProjectRename())
 	Cmd.AddCommand(cmdProjectInfo())
+	Cmd.AddCommand(cmdProjectExport())
+	Cmd.AddCommand(cmdProjectImport())
 
 	Cmd.AddCommand(cmdProjectRemove())
 	Cmd.AddCommand(cmdProjectList)
@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func cmdQueueExplain() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain",
+		Short: "cds queue explain <actionBuildID>",
+		Long:  `Explain why an action build in queue is not taken by a worker: which worker models, workers and hatcheries could run it, and why the others cannot`,
+		Run:   explainQueue,
+	}
+
+	return cmd
+}
+
+func explainQueue(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		sdk.Exit("Wrong usage: %s\n", cmd.Short)
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		sdk.Exit("Error: invalid action build id %s\n", args[0])
+	}
+
+	e, err := sdk.ExplainBuildQueue(id)
+	if err != nil {
+		sdk.Exit("Error: cannot explain action build %d (%s)\n", id, err)
+	}
+
+	ab := e.ActionBuild
+	fmt.Printf("Action build %d: %s (pipeline build %d, #%d) is %s", ab.ID, ab.ActionName, ab.PipelineBuildID, ab.BuildNumber, ab.Status)
+	if ab.Status == sdk.StatusWaiting && !ab.Queued.IsZero() {
+		fmt.Printf(" since %s", time.Since(ab.Queued).Round(time.Second))
+	}
+	fmt.Printf("\n")
+	if len(ab.Requirements) > 0 {
+		fmt.Printf("Requirements:\n")
+		for _, r := range ab.Requirements {
+			fmt.Printf(" - %s %s: %s %s\n", r.Type, r.Name, r.Value, r.Version)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 10, 1, 2, ' ', 0)
+
+	fmt.Fprintf(w, "\nMODEL\tCAN RUN\tREASON\n")
+	for _, m := range e.Models {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", m.ModelName, yesNo(m.CanRun), m.Reason)
+	}
+
+	fmt.Fprintf(w, "\nWORKER\tSTATUS\tGROUP\tCAN RUN\tREASON\n")
+	for _, wk := range e.Workers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", wk.WorkerName, wk.Status, yesNo(wk.GroupPermission), yesNo(wk.CanRun), wk.Reason)
+	}
+
+	fmt.Fprintf(w, "\nHATCHERY\tWORKERS\tGROUP\tCAN RUN\tREASON\n")
+	for _, h := range e.Hatcheries {
+		workers := strconv.FormatInt(h.Workers, 10)
+		if h.MaxWorker > 0 {
+			workers += "/" + strconv.FormatInt(h.MaxWorker, 10)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", h.HatcheryName, workers, yesNo(h.GroupPermission), yesNo(h.CanRun), h.Reason)
+	}
+	w.Flush()
+
+	if len(e.RequirementErrors) > 0 {
+		fmt.Printf("\nRequirement errors reported by workers:\n")
+		for _, r := range e.RequirementErrors {
+			fmt.Printf(" - %s %s: %s\n", r.Created.Format(time.RFC3339), r.WorkerName, strings.TrimSpace(r.Message))
+		}
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,15 @@
+package queue
+
+import "github.com/spf13/cobra"
+
+func init() {
+	Cmd.AddCommand(cmdQueueExplain())
+}
+
+// Cmd queue
+var Cmd = &cobra.Command{
+	Use:     "queue",
+	Short:   "Build queue",
+	Long:    ``,
+	Aliases: []string{"q"},
+}
@@ -14,6 +14,7 @@ var (
 	imageP                 string
 	openstackFlavorP       string
 	openstackUserDataFileP string
+	capabilityVersionP     string
 )
 
 func cmdWorkerModelAdd() *cobra.Command {
@@ -96,10 +97,13 @@ func cmdWorkerModelCapabilityAdd() *cobra.Command {
 		Available capability types:
 		- Binary installed ("binary")
 		- Network access ("network")
+
+		Binary capabilities may provide a version, such as "1.8.3" or "1.x".
 		`,
 		Run: addWorkerModelCapability,
 	}
 
+	cmd.Flags().StringVar(&capabilityVersionP, "version", "", "Version of the binary (binary)")
 	return cmd
 }
 
@@ -127,7 +131,7 @@ func addWorkerModelCapability(cmd *cobra.Command, args []string) {
 		sdk.Exit("Error: cannot retrieve worker model %s (%s)\n", workerModelName, err)
 	}
 
-	err = sdk.AddCapabilityToWorkerModel(m.ID, name, t, value)
+	err = sdk.AddCapabilityToWorkerModel(m.ID, name, t, value, capabilityVersionP)
 	if err != nil {
 		sdk.Exit("Error: cannot add capability to model (%s)\n", err)
 	}
@@ -0,0 +1,79 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Concurrency policies of a pipeline on an application and an environment
+const (
+	// ConcurrencyAllow lets builds run side by side, it is the default
+	ConcurrencyAllow = "allow"
+	// ConcurrencyQueue runs one build at a time, the next one waits for the running one to finish
+	ConcurrencyQueue = "queue"
+	// ConcurrencyCancelPrevious stops older builds of the same branch when a new one starts
+	ConcurrencyCancelPrevious = "cancel-previous"
+)
+
+// PipelineConcurrency is the concurrency policy of a pipeline on an application and an environment,
+// with the builds holding its lock and the builds queued behind them
+type PipelineConcurrency struct {
+	Policy  string          `json:"policy"`
+	Locked  bool            `json:"locked"`
+	Running []PipelineBuild `json:"running"`
+	Queued  []PipelineBuild `json:"queued"`
+}
+
+// CheckConcurrencyPolicy returns an error if given policy is unknown
+func CheckConcurrencyPolicy(policy string) error {
+	switch policy {
+	case ConcurrencyAllow, ConcurrencyQueue, ConcurrencyCancelPrevious:
+		return nil
+	}
+	return NewError(ErrInvalidConcurrencyPolicy, fmt.Errorf("unknown policy %s", policy))
+}
+
+// GetPipelineConcurrency retrieves the concurrency policy and the lock state of a pipeline
+func GetPipelineConcurrency(key, appName, pipelineName, env string) (*PipelineConcurrency, error) {
+	path := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/concurrency?envName=%s", key, appName, pipelineName, url.QueryEscape(env))
+
+	data, code, err := Request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if e := DecodeError(data); e != nil {
+		return nil, e
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	c := &PipelineConcurrency{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SetPipelineConcurrency changes the concurrency policy of a pipeline
+func SetPipelineConcurrency(key, appName, pipelineName, env, policy string) error {
+	path := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/concurrency?envName=%s", key, appName, pipelineName, url.QueryEscape(env))
+
+	body, err := json.Marshal(PipelineConcurrency{Policy: policy})
+	if err != nil {
+		return err
+	}
+
+	data, code, err := Request("PUT", path, body)
+	if err != nil {
+		return err
+	}
+	if e := DecodeError(data); e != nil {
+		return e
+	}
+	if code >= 300 {
+		return fmt.Errorf("HTTP %d", code)
+	}
+	return nil
+}
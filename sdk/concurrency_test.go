@@ -0,0 +1,15 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckConcurrencyPolicy(t *testing.T) {
+	for _, p := range []string{ConcurrencyAllow, ConcurrencyQueue, ConcurrencyCancelPrevious} {
+		assert.NoError(t, CheckConcurrencyPolicy(p))
+	}
+	assert.Error(t, CheckConcurrencyPolicy(""))
+	assert.Error(t, CheckConcurrencyPolicy("cancel"))
+}
@@ -0,0 +1,317 @@
+package sdk
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Step conditions are boolean expressions over build parameters and variables, e.g.
+//
+//	cds.environment == "prod" && git.branch =~ "^release/"
+//
+// Supported operators are ==, != (string equality), =~, !~ (regular expression match),
+// <, <=, >, >= (numeric comparison when both sides are numbers, lexical otherwise),
+// &&, ||, ! and parentheses. Operands are quoted strings, numbers, true, false or
+// variable names. An unknown variable is an empty string, and an operand alone is
+// true unless it is empty, "false" or "0".
+
+// CheckCondition validates the syntax of a step condition
+func CheckCondition(expr string) error {
+	_, err := parseCondition(expr)
+	return err
+}
+
+// EvaluateCondition evaluates a step condition with given variables. An empty condition is always true.
+func EvaluateCondition(expr string, vars map[string]string) (bool, error) {
+	n, err := parseCondition(expr)
+	if err != nil {
+		return false, err
+	}
+	if n == nil {
+		return true, nil
+	}
+	return n.eval(vars)
+}
+
+type condNode interface {
+	eval(vars map[string]string) (bool, error)
+}
+
+type condOperand struct {
+	value    string
+	variable bool
+}
+
+func (o condOperand) get(vars map[string]string) string {
+	if o.variable {
+		return vars[o.value]
+	}
+	return o.value
+}
+
+type condAnd struct{ left, right condNode }
+
+func (n condAnd) eval(vars map[string]string) (bool, error) {
+	l, err := n.left.eval(vars)
+	if err != nil || !l {
+		return false, err
+	}
+	return n.right.eval(vars)
+}
+
+type condOr struct{ left, right condNode }
+
+func (n condOr) eval(vars map[string]string) (bool, error) {
+	l, err := n.left.eval(vars)
+	if err != nil || l {
+		return l, err
+	}
+	return n.right.eval(vars)
+}
+
+type condNot struct{ node condNode }
+
+func (n condNot) eval(vars map[string]string) (bool, error) {
+	b, err := n.node.eval(vars)
+	return !b, err
+}
+
+type condTruthy struct{ operand condOperand }
+
+func (n condTruthy) eval(vars map[string]string) (bool, error) {
+	v := n.operand.get(vars)
+	return v != "" && v != "false" && v != "0", nil
+}
+
+type condCompare struct {
+	op          string
+	left, right condOperand
+	re          *regexp.Regexp
+}
+
+func (n condCompare) eval(vars map[string]string) (bool, error) {
+	l, r := n.left.get(vars), n.right.get(vars)
+	switch n.op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	case "=~", "!~":
+		re := n.re
+		if re == nil {
+			var err error
+			if re, err = regexp.Compile(r); err != nil {
+				return false, fmt.Errorf("invalid regular expression %q: %s", r, err)
+			}
+		}
+		return re.MatchString(l) == (n.op == "=~"), nil
+	}
+
+	c := strings.Compare(l, r)
+	lf, errl := strconv.ParseFloat(l, 64)
+	rf, errr := strconv.ParseFloat(r, 64)
+	if errl == nil && errr == nil {
+		switch {
+		case lf < rf:
+			c = -1
+		case lf > rf:
+			c = 1
+		default:
+			c = 0
+		}
+	}
+	switch n.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+type condToken struct {
+	kind  string // "op", "string" or "word"
+	value string
+}
+
+func tokenizeCondition(expr string) ([]condToken, error) {
+	var tokens []condToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			var s []byte
+			j := i + 1
+			for ; j < len(expr) && expr[j] != c; j++ {
+				if expr[j] == '\\' && j+1 < len(expr) {
+					j++
+				}
+				s = append(s, expr[j])
+			}
+			if j >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			tokens = append(tokens, condToken{kind: "string", value: string(s)})
+			i = j + 1
+		case i+1 < len(expr) && strings.Contains(" == != =~ !~ <= >= && || ", " "+expr[i:i+2]+" "):
+			tokens = append(tokens, condToken{kind: "op", value: expr[i : i+2]})
+			i += 2
+		case strings.IndexByte("<>!()", c) >= 0:
+			tokens = append(tokens, condToken{kind: "op", value: string(c)})
+			i++
+		case isConditionWordChar(c):
+			j := i
+			for j < len(expr) && isConditionWordChar(expr[j]) {
+				j++
+			}
+			tokens = append(tokens, condToken{kind: "word", value: expr[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i+1)
+		}
+	}
+	return tokens, nil
+}
+
+func isConditionWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-'
+}
+
+type condParser struct {
+	tokens []condToken
+	pos    int
+}
+
+func parseCondition(expr string) (condNode, error) {
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %s", expr, err)
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := &condParser{tokens: tokens}
+	n, err := p.or()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %s", expr, err)
+	}
+	return n, nil
+}
+
+func (p *condParser) peek(op string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == "op" && p.tokens[p.pos].value == op
+}
+
+func (p *condParser) or() (condNode, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("||") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = condOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *condParser) and() (condNode, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("&&") {
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = condAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *condParser) unary() (condNode, error) {
+	if p.peek("!") {
+		p.pos++
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return condNot{n}, nil
+	}
+
+	if p.peek("(") {
+		p.pos++
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return n, nil
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == "op" {
+		switch op := p.tokens[p.pos].value; op {
+		case "==", "!=", "=~", "!~", "<", "<=", ">", ">=":
+			p.pos++
+			right, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			n := condCompare{op: op, left: left, right: right}
+			if (op == "=~" || op == "!~") && !right.variable {
+				if n.re, err = regexp.Compile(right.value); err != nil {
+					return nil, fmt.Errorf("invalid regular expression %q: %s", right.value, err)
+				}
+			}
+			return n, nil
+		}
+	}
+
+	return condTruthy{left}, nil
+}
+
+func (p *condParser) operand() (condOperand, error) {
+	if p.pos >= len(p.tokens) {
+		return condOperand{}, fmt.Errorf("unexpected end of expression")
+	}
+	t := p.tokens[p.pos]
+	switch t.kind {
+	case "string":
+		p.pos++
+		return condOperand{value: t.value}, nil
+	case "word":
+		p.pos++
+		if t.value == "true" || t.value == "false" {
+			return condOperand{value: t.value}, nil
+		}
+		if _, err := strconv.ParseFloat(t.value, 64); err == nil {
+			return condOperand{value: t.value}, nil
+		}
+		return condOperand{value: t.value, variable: true}, nil
+	}
+	return condOperand{}, fmt.Errorf("unexpected %q", t.value)
+}
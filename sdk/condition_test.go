@@ -0,0 +1,48 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	vars := map[string]string{
+		"cds.environment": "prod",
+		"git.branch":      "release/1.2",
+		"cds.buildNumber": "12",
+		"cds.pip.deploy":  "true",
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{``, true},
+		{`cds.environment == "prod" && git.branch =~ "^release/"`, true},
+		{`cds.environment == 'prod' && git.branch !~ "^release/"`, false},
+		{`cds.environment != "prod" || cds.buildNumber > 9`, true},
+		{`cds.buildNumber >= 100`, false},
+		{`!(cds.environment == "dev")`, true},
+		{`cds.pip.deploy`, true},
+		{`cds.pip.unknown`, false},
+		{`cds.pip.unknown == ""`, true},
+	}
+	for _, tt := range tests {
+		got, err := EvaluateCondition(tt.expr, vars)
+		assert.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.want, got, tt.expr)
+	}
+}
+
+func TestCheckCondition(t *testing.T) {
+	for _, expr := range []string{
+		`cds.environment ==`,
+		`(cds.environment == "prod"`,
+		`git.branch =~ "(["`,
+		`cds.environment = "prod"`,
+		`"unterminated`,
+	} {
+		assert.Error(t, CheckCondition(expr), expr)
+	}
+}
@@ -0,0 +1,188 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Coverage contains the code coverage of a pipeline build. Covered and Total count lines, or statements
+// for Go coverprofiles, and branches are counted when reports provide them. Reference is the coverage
+// of the last successful build of the pipeline on the same branch, if any.
+type Coverage struct {
+	PipelineBuildID int64              `json:"pipeline_build_id"`
+	Covered         int                `json:"covered"`
+	Total           int                `json:"total"`
+	CoveredBranches int                `json:"covered_branches"`
+	TotalBranches   int                `json:"total_branches"`
+	Percent         float64            `json:"percent"`
+	Files           []FileCoverage     `json:"files"`
+	Reference       *CoverageReference `json:"reference,omitempty"`
+}
+
+// FileCoverage is the code coverage of a single file
+type FileCoverage struct {
+	Path            string  `json:"path"`
+	Covered         int     `json:"covered"`
+	Total           int     `json:"total"`
+	CoveredBranches int     `json:"covered_branches"`
+	TotalBranches   int     `json:"total_branches"`
+	Percent         float64 `json:"percent"`
+}
+
+// CoverageReference is the coverage of the build a pipeline build is compared to
+type CoverageReference struct {
+	PipelineBuildID int64   `json:"pipeline_build_id"`
+	BuildNumber     int64   `json:"build_number"`
+	Percent         float64 `json:"percent"`
+}
+
+// Severities of an analyzer finding
+const (
+	FindingError   = "error"
+	FindingWarning = "warning"
+	FindingInfo    = "info"
+)
+
+// Finding is an issue reported by a static analyzer
+type Finding struct {
+	Tool     string `json:"tool"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+}
+
+// Findings contains the issues reported by static analyzers in a pipeline build
+type Findings struct {
+	PipelineBuildID int64     `json:"pipeline_build_id"`
+	Total           int       `json:"total"`
+	Errors          int       `json:"errors"`
+	Warnings        int       `json:"warnings"`
+	Infos           int       `json:"infos"`
+	Findings        []Finding `json:"findings"`
+}
+
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) * 100 / float64(total)
+}
+
+// Merge replaces the coverage of the files of c by the one of the files of other, adds the other
+// files, and updates the totals
+func (c *Coverage) Merge(other Coverage) {
+	index := map[string]int{}
+	for i, f := range c.Files {
+		index[f.Path] = i
+	}
+	for _, f := range other.Files {
+		if i, ok := index[f.Path]; ok {
+			c.Files[i] = f
+			continue
+		}
+		index[f.Path] = len(c.Files)
+		c.Files = append(c.Files, f)
+	}
+	c.UpdateTotals()
+}
+
+// UpdateTotals sorts files by path, and computes the percentage of each file and the totals
+func (c *Coverage) UpdateTotals() {
+	sort.Slice(c.Files, func(i, j int) bool { return c.Files[i].Path < c.Files[j].Path })
+
+	c.Covered, c.Total, c.CoveredBranches, c.TotalBranches = 0, 0, 0, 0
+	for i := range c.Files {
+		f := &c.Files[i]
+		f.Percent = percent(f.Covered, f.Total)
+		c.Covered += f.Covered
+		c.Total += f.Total
+		c.CoveredBranches += f.CoveredBranches
+		c.TotalBranches += f.TotalBranches
+	}
+	c.Percent = percent(c.Covered, c.Total)
+}
+
+// Drop returns by how many points the coverage dropped since its reference, and whether it is more
+// than given threshold. A negative threshold never fails.
+func (c *Coverage) Drop(threshold float64) (float64, bool) {
+	if c.Reference == nil {
+		return 0, false
+	}
+	drop := c.Reference.Percent - c.Percent
+	return drop, threshold >= 0 && drop > threshold
+}
+
+// Merge replaces the findings of the tools reported by other, and updates the totals
+func (f *Findings) Merge(other Findings) {
+	tools := map[string]bool{}
+	for _, n := range other.Findings {
+		tools[n.Tool] = true
+	}
+
+	findings := []Finding{}
+	for _, n := range f.Findings {
+		if !tools[n.Tool] {
+			findings = append(findings, n)
+		}
+	}
+	f.Findings = append(findings, other.Findings...)
+	f.UpdateTotals()
+}
+
+// UpdateTotals counts findings by severity
+func (f *Findings) UpdateTotals() {
+	f.Total, f.Errors, f.Warnings, f.Infos = len(f.Findings), 0, 0, 0
+	for _, n := range f.Findings {
+		switch n.Severity {
+		case FindingError:
+			f.Errors++
+		case FindingWarning:
+			f.Warnings++
+		default:
+			f.Infos++
+		}
+	}
+}
+
+func buildReportPath(proj, app, pip, env string, bn int, report string) string {
+	if env == "" {
+		env = DefaultEnv.Name
+	}
+	return fmt.Sprintf("/project/%s/application/%s/pipeline/%s/build/%d/%s?envName=%s", proj, app, pip, bn, report, env)
+}
+
+// GetCoverage retrieves the code coverage of a specific build
+func GetCoverage(proj, app, pip, env string, bn int) (Coverage, error) {
+	var c Coverage
+
+	data, code, err := Request("GET", buildReportPath(proj, app, pip, env, bn, "coverage"), nil)
+	if err != nil {
+		return c, err
+	}
+	if code >= 300 {
+		return c, fmt.Errorf("HTTP %d", code)
+	}
+
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+// GetFindings retrieves the findings of static analyzers in a specific build
+func GetFindings(proj, app, pip, env string, bn int) (Findings, error) {
+	var f Findings
+
+	data, code, err := Request("GET", buildReportPath(proj, app, pip, env, bn, "findings"), nil)
+	if err != nil {
+		return f, err
+	}
+	if code >= 300 {
+		return f, fmt.Errorf("HTTP %d", code)
+	}
+
+	err = json.Unmarshal(data, &f)
+	return f, err
+}
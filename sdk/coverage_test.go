@@ -0,0 +1,62 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverageMerge(t *testing.T) {
+	c := Coverage{Files: []FileCoverage{
+		{Path: "b.go", Covered: 1, Total: 4},
+		{Path: "a.go", Covered: 2, Total: 2},
+	}}
+	c.UpdateTotals()
+	assert.Equal(t, "a.go", c.Files[0].Path)
+	assert.Equal(t, 50.0, c.Percent)
+	assert.Equal(t, 25.0, c.Files[1].Percent)
+
+	c.Merge(Coverage{Files: []FileCoverage{
+		{Path: "b.go", Covered: 4, Total: 4},
+		{Path: "c.go", Covered: 0, Total: 2, TotalBranches: 2},
+	}})
+	assert.Len(t, c.Files, 3)
+	assert.Equal(t, 6, c.Covered)
+	assert.Equal(t, 8, c.Total)
+	assert.Equal(t, 2, c.TotalBranches)
+	assert.Equal(t, 75.0, c.Percent)
+}
+
+func TestCoverageDrop(t *testing.T) {
+	c := Coverage{Percent: 70}
+	_, failed := c.Drop(0)
+	assert.False(t, failed)
+
+	c.Reference = &CoverageReference{Percent: 80}
+	drop, failed := c.Drop(5)
+	assert.Equal(t, 10.0, drop)
+	assert.True(t, failed)
+	_, failed = c.Drop(10)
+	assert.False(t, failed)
+	_, failed = c.Drop(-1)
+	assert.False(t, failed)
+}
+
+func TestFindingsMerge(t *testing.T) {
+	f := Findings{Findings: []Finding{
+		{Tool: "golint", Severity: FindingWarning},
+		{Tool: "errcheck", Severity: FindingError},
+	}}
+	f.UpdateTotals()
+	assert.Equal(t, 2, f.Total)
+	assert.Equal(t, 1, f.Errors)
+
+	f.Merge(Findings{Findings: []Finding{
+		{Tool: "errcheck", Severity: FindingInfo},
+		{Tool: "vet", Severity: FindingError},
+	}})
+	assert.Equal(t, 3, f.Total)
+	assert.Equal(t, 1, f.Errors)
+	assert.Equal(t, 1, f.Warnings)
+	assert.Equal(t, 1, f.Infos)
+}
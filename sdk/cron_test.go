@@ -0,0 +1,84 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2017, time.January, 31, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2017, time.January, 31, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2017, time.January, 31, 10, 45, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2017, time.February, 1, 2, 0, 0, 0, time.UTC)},
+		{"30 8 * * mon-fri", time.Date(2017, time.February, 1, 8, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2017, time.February, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * fri", time.Date(2017, time.February, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2017, time.February, 1, 12, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2017, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if !assert.NoError(t, err, tt.expr) {
+			continue
+		}
+		assert.Equal(t, tt.next, c.Next(from), tt.expr)
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "* * * foo *"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestPipelineScheduleNext(t *testing.T) {
+	s := PipelineSchedule{Cron: "0 9 * * *", Timezone: "Europe/Paris"}
+	next, err := s.Next(time.Date(2017, time.July, 1, 8, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.True(t, next.Equal(time.Date(2017, time.July, 2, 7, 0, 0, 0, time.UTC)), next.String())
+
+	s.Timezone = "Nowhere/Somewhere"
+	_, err = s.Next(time.Now())
+	assert.Error(t, err)
+}
+
+func TestPipelineScheduleNextRunEndOfDST(t *testing.T) {
+	// Paris goes back from 03:00 CEST to 02:00 CET at 01:00 UTC
+	last := time.Date(2017, time.October, 29, 0, 30, 0, 0, time.UTC)
+	now := last.Add(5 * time.Second)
+
+	tests := []struct {
+		cron string
+		next time.Time
+	}{
+		{"30 2 * * *", time.Date(2017, time.October, 30, 1, 30, 0, 0, time.UTC)},
+		{"0,30 2 * * *", time.Date(2017, time.October, 30, 1, 0, 0, 0, time.UTC)},
+		{"30 * * * *", time.Date(2017, time.October, 29, 2, 30, 0, 0, time.UTC)},
+		{"45 3 * * *", time.Date(2017, time.October, 29, 2, 45, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		s := PipelineSchedule{Cron: tt.cron, Timezone: "Europe/Paris"}
+		next, err := s.NextRun(last, now)
+		assert.NoError(t, err, tt.cron)
+		assert.True(t, next.Equal(tt.next), "%s: %s", tt.cron, next)
+	}
+
+	// Outside of the repeated hour, the next run is the next execution
+	s := PipelineSchedule{Cron: "30 2 * * *", Timezone: "Europe/Paris"}
+	last = time.Date(2017, time.October, 30, 1, 30, 0, 0, time.UTC)
+	next, err := s.NextRun(last, last.Add(5*time.Second))
+	assert.NoError(t, err)
+	assert.True(t, next.Equal(time.Date(2017, time.October, 31, 1, 30, 0, 0, time.UTC)), next.String())
+}
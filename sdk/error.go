@@ -87,6 +87,29 @@ var (
 	ErrUserConflict                 = &Error{ID: 73, Status: http.StatusBadRequest}
 	ErrWrongRequest                 = &Error{ID: 74, Status: http.StatusBadRequest}
 	ErrAlreadyExist                 = &Error{ID: 75, Status: http.StatusConflict}
+	ErrInvalidPassphrase            = &Error{ID: 76, Status: http.StatusBadRequest}
+	ErrPipelineAuditNotFound        = &Error{ID: 77, Status: http.StatusNotFound}
+	ErrInvalidCondition             = &Error{ID: 78, Status: http.StatusBadRequest}
+	ErrInvalidRetry                 = &Error{ID: 79, Status: http.StatusBadRequest}
+	ErrInvalidTimeout               = &Error{ID: 80, Status: http.StatusBadRequest}
+	ErrInvalidStageApproval         = &Error{ID: 81, Status: http.StatusBadRequest}
+	ErrNotStageApprover             = &Error{ID: 82, Status: http.StatusForbidden}
+	ErrNoPendingApproval            = &Error{ID: 83, Status: http.StatusConflict}
+	ErrApprovalCommentRequired      = &Error{ID: 84, Status: http.StatusBadRequest}
+	ErrStageAlreadyDecided          = &Error{ID: 85, Status: http.StatusConflict}
+	ErrInvalidConcurrencyPolicy     = &Error{ID: 86, Status: http.StatusBadRequest}
+	ErrInvalidPriority              = &Error{ID: 87, Status: http.StatusBadRequest}
+	ErrInvalidGroupShare            = &Error{ID: 88, Status: http.StatusBadRequest}
+	ErrInvalidSchedule              = &Error{ID: 89, Status: http.StatusBadRequest}
+	ErrScheduleNotFound             = &Error{ID: 90, Status: http.StatusNotFound}
+	ErrInvalidStageNeeds            = &Error{ID: 91, Status: http.StatusBadRequest}
+	ErrInvalidVersionConstraint     = &Error{ID: 92, Status: http.StatusBadRequest}
+	ErrActionBuildNotFound          = &Error{ID: 93, Status: http.StatusNotFound}
+	ErrCacheNotFound                = &Error{ID: 94, Status: http.StatusNotFound}
+	ErrCacheTooLarge                = &Error{ID: 95, Status: http.StatusRequestEntityTooLarge}
+	ErrInvalidCacheKey              = &Error{ID: 96, Status: http.StatusBadRequest}
+	ErrTestQuarantineNotFound       = &Error{ID: 97, Status: http.StatusNotFound}
+	ErrInvalidTestQuarantine        = &Error{ID: 98, Status: http.StatusBadRequest}
 )
 
 // SupportedLanguages on API errors
@@ -173,6 +196,29 @@ You can safely use them in a String or Text parameter`,
 	ErrUserConflict.ID:                 "this user already exist",
 	ErrWrongRequest.ID:                 "wrong request",
 	ErrAlreadyExist.ID:                 "already exist",
+	ErrInvalidPassphrase.ID:            "passphrase missing or invalid",
+	ErrPipelineAuditNotFound.ID:        "pipeline version does not exist",
+	ErrInvalidCondition.ID:             "invalid step condition",
+	ErrInvalidRetry.ID:                 "invalid retry policy",
+	ErrInvalidTimeout.ID:               "timeout must be a positive number of seconds",
+	ErrInvalidStageApproval.ID:         "invalid stage approval",
+	ErrNotStageApprover.ID:             "you are not an approver of this stage",
+	ErrNoPendingApproval.ID:            "this stage is not waiting for approval",
+	ErrApprovalCommentRequired.ID:      "a comment is required to approve or reject a stage",
+	ErrStageAlreadyDecided.ID:          "you already approved or rejected this stage",
+	ErrInvalidConcurrencyPolicy.ID:     "invalid concurrency policy",
+	ErrInvalidPriority.ID:              "priority must be between -100 and 100",
+	ErrInvalidGroupShare.ID:            "invalid group share",
+	ErrInvalidSchedule.ID:              "invalid schedule",
+	ErrScheduleNotFound.ID:             "schedule not found",
+	ErrInvalidStageNeeds.ID:            "invalid stage needs",
+	ErrInvalidVersionConstraint.ID:     "invalid version constraint",
+	ErrActionBuildNotFound.ID:          "action build not found",
+	ErrCacheNotFound.ID:                "cache not found",
+	ErrCacheTooLarge.ID:                "cache exceeds the quota of the project",
+	ErrInvalidCacheKey.ID:              "invalid cache key",
+	ErrTestQuarantineNotFound.ID:       "test not in quarantine",
+	ErrInvalidTestQuarantine.ID:        "a quarantined test needs a suite and a name",
 }
 
 var errorsFrench = map[int]string{
@@ -253,6 +299,29 @@ Vous pouvez les utiliser sans problème dans un paramêtre de type String ou Tex
 	ErrUserConflict.ID:                 "cet utilisateur existe deja",
 	ErrWrongRequest.ID:                 "la requête est incorrecte",
 	ErrAlreadyExist.ID:                 "conflit",
+	ErrInvalidPassphrase.ID:            "phrase secrète absente ou invalide",
+	ErrPipelineAuditNotFound.ID:        "cette version du pipeline n'existe pas",
+	ErrInvalidCondition.ID:             "condition d'étape invalide",
+	ErrInvalidRetry.ID:                 "politique de relance invalide",
+	ErrInvalidTimeout.ID:               "le délai d'expiration doit être un nombre positif de secondes",
+	ErrInvalidStageApproval.ID:         "validation d'étape invalide",
+	ErrNotStageApprover.ID:             "vous ne faites pas partie des valideurs de cette étape",
+	ErrNoPendingApproval.ID:            "cette étape n'attend pas de validation",
+	ErrApprovalCommentRequired.ID:      "un commentaire est requis pour valider ou rejeter une étape",
+	ErrStageAlreadyDecided.ID:          "vous avez déjà validé ou rejeté cette étape",
+	ErrInvalidConcurrencyPolicy.ID:     "politique de concurrence invalide",
+	ErrInvalidPriority.ID:              "la priorité doit être comprise entre -100 et 100",
+	ErrInvalidGroupShare.ID:            "part du groupe invalide",
+	ErrInvalidSchedule.ID:              "planification invalide",
+	ErrScheduleNotFound.ID:             "planification introuvable",
+	ErrInvalidStageNeeds.ID:            "dépendances d'étapes invalides",
+	ErrInvalidVersionConstraint.ID:     "contrainte de version invalide",
+	ErrActionBuildNotFound.ID:          "exécution d'action introuvable",
+	ErrCacheNotFound.ID:                "cache introuvable",
+	ErrCacheTooLarge.ID:                "le cache dépasse le quota du projet",
+	ErrInvalidCacheKey.ID:              "clé de cache invalide",
+	ErrTestQuarantineNotFound.ID:       "test absent de la quarantaine",
+	ErrInvalidTestQuarantine.ID:        "un test en quarantaine doit avoir une suite et un nom",
 }
 
 var matcher = language.NewMatcher(SupportedLanguages)
@@ -0,0 +1,216 @@
+package exportentities
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// Diff computes the structural differences between two pipeline definitions.
+// Parameters, stages and jobs are matched by name, steps by their position in the job.
+func Diff(from, to *Pipeline) []sdk.PipelineChange {
+	d := &differ{}
+	d.value("name", from.Name, to.Name)
+	d.value("type", from.Type, to.Type)
+	d.value("timeout", timeout(from.Timeout), timeout(to.Timeout))
+	d.value("priority", strconv.FormatInt(from.Priority, 10), strconv.FormatInt(to.Priority, 10))
+	d.value("skip_built_commits", strconv.FormatBool(from.SkipBuiltCommits), strconv.FormatBool(to.SkipBuiltCommits))
+	d.parameters("parameters", from.Parameters, to.Parameters)
+	d.stages(from.Stages, to.Stages)
+	return d.changes
+}
+
+type differ struct {
+	changes []sdk.PipelineChange
+}
+
+func (d *differ) add(path, value string) {
+	d.changes = append(d.changes, sdk.PipelineChange{Type: sdk.PipelineChangeAdded, Path: path, To: value})
+}
+
+func (d *differ) remove(path, value string) {
+	d.changes = append(d.changes, sdk.PipelineChange{Type: sdk.PipelineChangeRemoved, Path: path, From: value})
+}
+
+func (d *differ) value(path, from, to string) {
+	if from != to {
+		d.changes = append(d.changes, sdk.PipelineChange{Type: sdk.PipelineChangeModified, Path: path, From: from, To: to})
+	}
+}
+
+// set reports added and removed elements of two unordered lists
+func (d *differ) set(path string, from, to []string) {
+	in := func(s string, l []string) bool {
+		for _, e := range l {
+			if e == s {
+				return true
+			}
+		}
+		return false
+	}
+	for _, s := range from {
+		if !in(s, to) {
+			d.remove(path, s)
+		}
+	}
+	for _, s := range to {
+		if !in(s, from) {
+			d.add(path, s)
+		}
+	}
+}
+
+func (d *differ) parameters(path string, from, to []Parameter) {
+	old := map[string]Parameter{}
+	for _, p := range from {
+		old[p.Name] = p
+	}
+	cur := map[string]bool{}
+	for _, p := range to {
+		cur[p.Name] = true
+		o, ok := old[p.Name]
+		if !ok {
+			d.add(path+"/"+p.Name, p.Value)
+			continue
+		}
+		d.value(path+"/"+p.Name+"/type", o.Type, p.Type)
+		d.value(path+"/"+p.Name+"/value", o.Value, p.Value)
+		d.value(path+"/"+p.Name+"/description", o.Description, p.Description)
+	}
+	for _, p := range from {
+		if !cur[p.Name] {
+			d.remove(path+"/"+p.Name, p.Value)
+		}
+	}
+}
+
+func (d *differ) stages(from, to []Stage) {
+	old := map[string]int{}
+	for i, s := range from {
+		old[s.Name] = i
+	}
+	cur := map[string]bool{}
+	for i, s := range to {
+		cur[s.Name] = true
+		path := "stages/" + s.Name
+		j, ok := old[s.Name]
+		if !ok {
+			d.add(path, strconv.Itoa(i+1))
+			continue
+		}
+		o := from[j]
+		d.value(path+"/order", strconv.Itoa(j+1), strconv.Itoa(i+1))
+		d.value(path+"/enabled", strconv.FormatBool(enabled(o.Enabled)), strconv.FormatBool(enabled(s.Enabled)))
+		d.value(path+"/timeout", timeout(o.Timeout), timeout(s.Timeout))
+		d.value(path+"/approval", o.Approval.String(), s.Approval.String())
+		d.set(path+"/needs", o.Needs, s.Needs)
+		d.set(path+"/prerequisites", prerequisites(o.Prerequisites), prerequisites(s.Prerequisites))
+		d.jobs(path+"/jobs", o.Jobs, s.Jobs)
+	}
+	for i, s := range from {
+		if !cur[s.Name] {
+			d.remove("stages/"+s.Name, strconv.Itoa(i+1))
+		}
+	}
+}
+
+func (d *differ) jobs(path string, from, to []Job) {
+	old := map[string]Job{}
+	for _, j := range from {
+		old[j.Name] = j
+	}
+	cur := map[string]bool{}
+	for _, j := range to {
+		cur[j.Name] = true
+		o, ok := old[j.Name]
+		if !ok {
+			d.add(path+"/"+j.Name, j.Name)
+			continue
+		}
+		jpath := path + "/" + j.Name
+		d.value(jpath+"/description", o.Description, j.Description)
+		d.value(jpath+"/enabled", strconv.FormatBool(enabled(o.Enabled)), strconv.FormatBool(enabled(j.Enabled)))
+		d.value(jpath+"/retry", o.Retry.String(), j.Retry.String())
+		d.value(jpath+"/timeout", timeout(o.Timeout), timeout(j.Timeout))
+		d.set(jpath+"/requirements", requirements(o.Requirements), requirements(j.Requirements))
+		d.steps(jpath+"/steps", o.Steps, j.Steps)
+	}
+	for _, j := range from {
+		if !cur[j.Name] {
+			d.remove(path+"/"+j.Name, j.Name)
+		}
+	}
+}
+
+func (d *differ) steps(path string, from, to []Step) {
+	for i := 0; i < len(from) || i < len(to); i++ {
+		spath := path + "/" + strconv.Itoa(i+1)
+		switch {
+		case i >= len(from):
+			d.add(spath, to[i].Action)
+		case i >= len(to):
+			d.remove(spath, from[i].Action)
+		default:
+			o, s := from[i], to[i]
+			d.value(spath+"/action", o.Action, s.Action)
+			d.value(spath+"/enabled", strconv.FormatBool(enabled(o.Enabled)), strconv.FormatBool(enabled(s.Enabled)))
+			d.value(spath+"/final", strconv.FormatBool(o.Final), strconv.FormatBool(s.Final))
+			d.value(spath+"/condition", o.Condition, s.Condition)
+
+			var names []string
+			for k := range o.Parameters {
+				names = append(names, k)
+			}
+			for k := range s.Parameters {
+				if _, ok := o.Parameters[k]; !ok {
+					names = append(names, k)
+				}
+			}
+			sort.Strings(names)
+			for _, k := range names {
+				ov, inOld := o.Parameters[k]
+				nv, inNew := s.Parameters[k]
+				switch {
+				case !inOld:
+					d.add(spath+"/parameters/"+k, nv)
+				case !inNew:
+					d.remove(spath+"/parameters/"+k, ov)
+				default:
+					d.value(spath+"/parameters/"+k, ov, nv)
+				}
+			}
+		}
+	}
+}
+
+// timeout formats a number of seconds, an empty string meaning no timeout
+func timeout(seconds int64) string {
+	if seconds <= 0 {
+		return ""
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func prerequisites(l []Prerequisite) []string {
+	var res []string
+	for _, p := range l {
+		res = append(res, fmt.Sprintf("%s=%s", p.Parameter, p.ExpectedValue))
+	}
+	return res
+}
+
+func requirements(l []Requirement) []string {
+	var res []string
+	for _, r := range l {
+		req := strings.Join([]string{r.Type, r.Name, r.Value}, ":")
+		if r.Version != "" {
+			req += " " + r.Version
+		}
+		res = append(res, req)
+	}
+	return res
+}
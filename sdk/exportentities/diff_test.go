@@ -0,0 +1,76 @@
+package exportentities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestDiff(t *testing.T) {
+	disabled := false
+	from := &Pipeline{
+		Name: "build",
+		Type: "build",
+		Parameters: []Parameter{
+			{Name: "version", Type: "string", Value: "1"},
+			{Name: "old", Type: "string", Value: "x"},
+		},
+		Stages: []Stage{
+			{
+				Name: "Compile",
+				Jobs: []Job{
+					{
+						Name:         "make",
+						Requirements: []Requirement{{Name: "go", Type: "binary", Value: "go"}},
+						Steps: []Step{
+							{Action: "Script", Parameters: map[string]string{"script": "make"}},
+						},
+					},
+				},
+			},
+			{Name: "Package"},
+		},
+	}
+	to := &Pipeline{
+		Name: "build",
+		Type: "build",
+		Parameters: []Parameter{
+			{Name: "version", Type: "string", Value: "2"},
+		},
+		Stages: []Stage{
+			{Name: "Package", Enabled: &disabled},
+			{
+				Name: "Compile",
+				Jobs: []Job{
+					{
+						Name:         "make",
+						Timeout:      600,
+						Requirements: []Requirement{{Name: "go", Type: "binary", Value: "go", Version: ">= 1.7"}},
+						Steps: []Step{
+							{Action: "Script", Parameters: map[string]string{"script": "make all"}},
+							{Action: "Artifact Upload"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	changes := Diff(from, to)
+	assert.Equal(t, []sdk.PipelineChange{
+		{Type: sdk.PipelineChangeModified, Path: "parameters/version/value", From: "1", To: "2"},
+		{Type: sdk.PipelineChangeRemoved, Path: "parameters/old", From: "x"},
+		{Type: sdk.PipelineChangeModified, Path: "stages/Package/order", From: "2", To: "1"},
+		{Type: sdk.PipelineChangeModified, Path: "stages/Package/enabled", From: "true", To: "false"},
+		{Type: sdk.PipelineChangeModified, Path: "stages/Compile/order", From: "1", To: "2"},
+		{Type: sdk.PipelineChangeModified, Path: "stages/Compile/jobs/make/timeout", From: "", To: "10m0s"},
+		{Type: sdk.PipelineChangeRemoved, Path: "stages/Compile/jobs/make/requirements", From: "binary:go:go"},
+		{Type: sdk.PipelineChangeAdded, Path: "stages/Compile/jobs/make/requirements", To: "binary:go:go >= 1.7"},
+		{Type: sdk.PipelineChangeModified, Path: "stages/Compile/jobs/make/steps/1/parameters/script", From: "make", To: "make all"},
+		{Type: sdk.PipelineChangeAdded, Path: "stages/Compile/jobs/make/steps/2", To: "Artifact Upload"},
+	}, changes)
+
+	assert.Empty(t, Diff(to, to))
+}
@@ -0,0 +1,66 @@
+package exportentities
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Format is the format of an exported entity
+type Format string
+
+// Available export formats
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// AvailableFormats List of all export formats
+var AvailableFormats = []string{
+	string(FormatYAML),
+	string(FormatJSON),
+}
+
+// GetFormat returns the Format matching given string, or an error if unknown
+func GetFormat(f string) (Format, error) {
+	switch strings.ToLower(f) {
+	case "", "yaml", "yml":
+		return FormatYAML, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported format %s", f)
+	}
+}
+
+// ContentType returns the HTTP content type of the format
+func (f Format) ContentType() string {
+	if f == FormatJSON {
+		return "application/json"
+	}
+	return "application/x-yaml"
+}
+
+// Marshal an entity in given format
+func Marshal(i interface{}, f Format) ([]byte, error) {
+	switch f {
+	case FormatJSON:
+		return json.MarshalIndent(i, "", "\t")
+	case FormatYAML:
+		return yaml.Marshal(i)
+	}
+	return nil, fmt.Errorf("unsupported format %s", f)
+}
+
+// Unmarshal data in given format into an entity
+func Unmarshal(data []byte, f Format, i interface{}) error {
+	switch f {
+	case FormatJSON:
+		return json.Unmarshal(data, i)
+	case FormatYAML:
+		return yaml.Unmarshal(data, i)
+	}
+	return fmt.Errorf("unsupported format %s", f)
+}
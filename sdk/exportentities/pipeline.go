@@ -0,0 +1,358 @@
+package exportentities
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// Pipeline is the portable definition of a CDS pipeline
+type Pipeline struct {
+	Name             string      `json:"name" yaml:"name"`
+	Type             string      `json:"type" yaml:"type"`
+	Timeout          int64       `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Priority         int64       `json:"priority,omitempty" yaml:"priority,omitempty"`
+	SkipBuiltCommits bool        `json:"skip_built_commits,omitempty" yaml:"skip_built_commits,omitempty"`
+	Parameters       []Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Stages           []Stage     `json:"stages,omitempty" yaml:"stages,omitempty"`
+}
+
+// Parameter is the portable definition of a pipeline parameter
+type Parameter struct {
+	Name        string `json:"name" yaml:"name"`
+	Type        string `json:"type" yaml:"type"`
+	Value       string `json:"value" yaml:"value"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// Stage is the portable definition of a pipeline stage.
+// A nil Enabled means the stage is enabled.
+type Stage struct {
+	Name          string             `json:"name" yaml:"name"`
+	Enabled       *bool              `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Timeout       int64              `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Approval      *sdk.StageApproval `json:"approval,omitempty" yaml:"approval,omitempty"`
+	Needs         []string           `json:"needs,omitempty" yaml:"needs,omitempty"`
+	Prerequisites []Prerequisite     `json:"prerequisites,omitempty" yaml:"prerequisites,omitempty"`
+	Jobs          []Job              `json:"jobs,omitempty" yaml:"jobs,omitempty"`
+}
+
+// Prerequisite is the portable definition of a stage prerequisite
+type Prerequisite struct {
+	Parameter     string `json:"parameter" yaml:"parameter"`
+	ExpectedValue string `json:"expected_value" yaml:"expected_value"`
+}
+
+// Job is the portable definition of a joined action in a stage
+type Job struct {
+	Name         string           `json:"name" yaml:"name"`
+	Description  string           `json:"description,omitempty" yaml:"description,omitempty"`
+	Enabled      *bool            `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Requirements []Requirement    `json:"requirements,omitempty" yaml:"requirements,omitempty"`
+	Steps        []Step           `json:"steps,omitempty" yaml:"steps,omitempty"`
+	Retry        *sdk.ActionRetry `json:"retry,omitempty" yaml:"retry,omitempty"`
+	Timeout      int64            `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// Requirement is the portable definition of an action requirement
+type Requirement struct {
+	Name    string `json:"name" yaml:"name"`
+	Type    string `json:"type" yaml:"type"`
+	Value   string `json:"value" yaml:"value"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// Step is a call to an existing action inside a job
+type Step struct {
+	Action     string            `json:"action" yaml:"action"`
+	Enabled    *bool             `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Final      bool              `json:"final,omitempty" yaml:"final,omitempty"`
+	Condition  string            `json:"condition,omitempty" yaml:"condition,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// NewPipeline creates the portable definition of given pipeline
+func NewPipeline(p *sdk.Pipeline) *Pipeline {
+	e := &Pipeline{
+		Name:             p.Name,
+		Type:             string(p.Type),
+		Timeout:          p.Timeout,
+		Priority:         p.Priority,
+		SkipBuiltCommits: p.SkipBuiltCommits,
+	}
+
+	e.Parameters = NewParameters(p.Parameter)
+
+	stages := make([]sdk.Stage, len(p.Stages))
+	copy(stages, p.Stages)
+	sort.Sort(byBuildOrder(stages))
+	for _, s := range stages {
+		e.Stages = append(e.Stages, newStage(s))
+	}
+
+	return e
+}
+
+func newStage(s sdk.Stage) Stage {
+	e := Stage{
+		Name:     s.Name,
+		Enabled:  disabled(s.Enabled),
+		Timeout:  s.Timeout,
+		Approval: s.Approval,
+		Needs:    s.Needs,
+	}
+
+	for _, p := range s.Prerequisites {
+		e.Prerequisites = append(e.Prerequisites, Prerequisite{
+			Parameter:     p.Parameter,
+			ExpectedValue: p.ExpectedValue,
+		})
+	}
+
+	for _, a := range s.Actions {
+		e.Jobs = append(e.Jobs, NewJob(a))
+	}
+
+	return e
+}
+
+// NewJob creates the portable definition of given joined action
+func NewJob(a sdk.Action) Job {
+	j := Job{
+		Name:        a.Name,
+		Description: a.Description,
+		Enabled:     disabled(a.Enabled),
+		Retry:       a.Retry,
+		Timeout:     a.Timeout,
+	}
+
+	for _, r := range a.Requirements {
+		j.Requirements = append(j.Requirements, Requirement{
+			Name:    r.Name,
+			Type:    string(r.Type),
+			Value:   r.Value,
+			Version: r.Version,
+		})
+	}
+	sort.Sort(byRequirement(j.Requirements))
+
+	for _, c := range a.Actions {
+		s := Step{
+			Action:    c.Name,
+			Enabled:   disabled(c.Enabled),
+			Final:     c.Final,
+			Condition: c.Condition,
+		}
+		if len(c.Parameters) > 0 {
+			s.Parameters = make(map[string]string, len(c.Parameters))
+			for _, p := range c.Parameters {
+				s.Parameters[p.Name] = p.Value
+			}
+		}
+		j.Steps = append(j.Steps, s)
+	}
+
+	return j
+}
+
+// Pipeline returns the sdk.Pipeline described by the portable definition.
+// Stages get their build order from their position, joined actions reference
+// their children by name only.
+func (e *Pipeline) Pipeline() (*sdk.Pipeline, error) {
+	if e.Name == "" {
+		return nil, sdk.ErrInvalidName
+	}
+	if !sdk.IsInArray(e.Type, sdk.AvailablePipelineType) {
+		return nil, fmt.Errorf("invalid pipeline type '%s'", e.Type)
+	}
+
+	if e.Timeout < 0 {
+		return nil, fmt.Errorf("pipeline %s: %s", e.Name, sdk.ErrInvalidTimeout)
+	}
+	if err := sdk.CheckPriority(e.Priority); err != nil {
+		return nil, fmt.Errorf("pipeline %s: %s", e.Name, err)
+	}
+
+	p := &sdk.Pipeline{
+		Name:             e.Name,
+		Type:             sdk.PipelineTypeFromString(e.Type),
+		Timeout:          e.Timeout,
+		Priority:         e.Priority,
+		SkipBuiltCommits: e.SkipBuiltCommits,
+	}
+
+	params, err := Parameters(e.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline %s: %s", e.Name, err)
+	}
+	p.Parameter = params
+
+	for i, s := range e.Stages {
+		if s.Name == "" {
+			return nil, fmt.Errorf("stage %d has no name", i+1)
+		}
+		if s.Timeout < 0 {
+			return nil, fmt.Errorf("stage %s: %s", s.Name, sdk.ErrInvalidTimeout)
+		}
+		if s.Approval != nil {
+			if err := s.Approval.Check(); err != nil {
+				return nil, fmt.Errorf("stage %s: %s", s.Name, err)
+			}
+		}
+		stage := sdk.Stage{
+			Name:          s.Name,
+			BuildOrder:    i + 1,
+			Enabled:       enabled(s.Enabled),
+			Timeout:       s.Timeout,
+			Approval:      s.Approval,
+			Needs:         s.Needs,
+			Prerequisites: []sdk.Prerequisite{},
+		}
+		for _, pr := range s.Prerequisites {
+			stage.Prerequisites = append(stage.Prerequisites, sdk.Prerequisite{
+				Parameter:     pr.Parameter,
+				ExpectedValue: pr.ExpectedValue,
+			})
+		}
+		for _, j := range s.Jobs {
+			a, err := j.Action()
+			if err != nil {
+				return nil, fmt.Errorf("stage %s: %s", s.Name, err)
+			}
+			stage.Actions = append(stage.Actions, *a)
+		}
+		p.Stages = append(p.Stages, stage)
+	}
+	if err := sdk.CheckStageNeeds(p.Stages); err != nil {
+		return nil, fmt.Errorf("pipeline %s: %s", e.Name, err)
+	}
+
+	return p, nil
+}
+
+// NewParameters creates the portable definition of given parameters
+func NewParameters(params []sdk.Parameter) []Parameter {
+	var res []Parameter
+	for _, p := range params {
+		res = append(res, Parameter{
+			Name:        p.Name,
+			Type:        string(p.Type),
+			Value:       p.Value,
+			Description: p.Description,
+		})
+	}
+	return res
+}
+
+// Parameters returns the sdk.Parameter described by the portable definitions
+func Parameters(params []Parameter) ([]sdk.Parameter, error) {
+	var res []sdk.Parameter
+	for _, p := range params {
+		if p.Name == "" {
+			return nil, fmt.Errorf("parameter without name")
+		}
+		if !sdk.IsInArray(p.Type, sdk.AvailableParameterType) {
+			return nil, fmt.Errorf("invalid type '%s' for parameter %s", p.Type, p.Name)
+		}
+		res = append(res, sdk.Parameter{
+			Name:        p.Name,
+			Type:        sdk.ParameterTypeFromString(p.Type),
+			Value:       p.Value,
+			Description: p.Description,
+		})
+	}
+	return res, nil
+}
+
+// Action returns the joined action described by the job
+func (j *Job) Action() (*sdk.Action, error) {
+	if j.Name == "" {
+		return nil, fmt.Errorf("job without name")
+	}
+
+	a := sdk.NewAction(j.Name)
+	a.Type = sdk.JoinedAction
+	a.Description = j.Description
+	a.Enabled = enabled(j.Enabled)
+
+	if j.Timeout < 0 {
+		return nil, fmt.Errorf("job %s: %s", j.Name, sdk.ErrInvalidTimeout)
+	}
+	a.Timeout = j.Timeout
+
+	if j.Retry != nil {
+		if err := j.Retry.Check(); err != nil {
+			return nil, fmt.Errorf("job %s: %s", j.Name, err)
+		}
+		a.Retry = j.Retry
+	}
+
+	for _, r := range j.Requirements {
+		if !sdk.IsInArray(r.Type, sdk.AvailableRequirementsType) {
+			return nil, fmt.Errorf("job %s: invalid requirement type '%s'", j.Name, r.Type)
+		}
+		req := sdk.Requirement{Name: r.Name, Type: sdk.RequirementType(r.Type), Value: r.Value, Version: r.Version}
+		if err := req.CheckVersion(); err != nil {
+			return nil, fmt.Errorf("job %s: requirement %s: %s", j.Name, r.Name, err)
+		}
+		a.Requirements = append(a.Requirements, req)
+	}
+
+	for _, s := range j.Steps {
+		if s.Action == "" {
+			return nil, fmt.Errorf("job %s: step without action", j.Name)
+		}
+		if err := sdk.CheckCondition(s.Condition); err != nil {
+			return nil, fmt.Errorf("job %s: step %s: %s", j.Name, s.Action, err)
+		}
+		child := sdk.Action{
+			Name:      s.Action,
+			Enabled:   enabled(s.Enabled),
+			Final:     s.Final,
+			Condition: s.Condition,
+		}
+		names := make([]string, 0, len(s.Parameters))
+		for n := range s.Parameters {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			child.Parameters = append(child.Parameters, sdk.Parameter{Name: n, Value: s.Parameters[n]})
+		}
+		a.Add(child)
+	}
+
+	return a, nil
+}
+
+// disabled returns a pointer to false when the entity is disabled, nil otherwise,
+// so enabled entities do not clutter the export
+func disabled(b bool) *bool {
+	if b {
+		return nil
+	}
+	f := false
+	return &f
+}
+
+func enabled(b *bool) bool {
+	return b == nil || *b
+}
+
+type byBuildOrder []sdk.Stage
+
+func (s byBuildOrder) Len() int           { return len(s) }
+func (s byBuildOrder) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byBuildOrder) Less(i, j int) bool { return s[i].BuildOrder < s[j].BuildOrder }
+
+type byRequirement []Requirement
+
+func (r byRequirement) Len() int      { return len(r) }
+func (r byRequirement) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r byRequirement) Less(i, j int) bool {
+	if r[i].Type != r[j].Type {
+		return r[i].Type < r[j].Type
+	}
+	return r[i].Value < r[j].Value
+}
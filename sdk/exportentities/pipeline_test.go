@@ -0,0 +1,109 @@
+package exportentities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func testPipeline() *sdk.Pipeline {
+	build := sdk.Action{
+		Name:        "compile",
+		Type:        sdk.JoinedAction,
+		Description: "Compile sources",
+		Enabled:     true,
+		Timeout:     600,
+		Retry:       &sdk.ActionRetry{Count: 2, Delay: 10, Backoff: 2, On: []string{sdk.RetryOnAWOL}},
+		Requirements: []sdk.Requirement{
+			{Name: "go", Type: sdk.BinaryRequirement, Value: "go", Version: ">= 1.7"},
+			{Name: "docker", Type: sdk.ModelRequirement, Value: "golang:1.7"},
+		},
+		Actions: []sdk.Action{
+			{
+				Name:      "Script",
+				Enabled:   true,
+				Condition: `git.branch == "master"`,
+				Parameters: []sdk.Parameter{
+					{Name: "script", Value: "make"},
+				},
+			},
+			{Name: "Artifact Upload", Enabled: false, Final: true},
+		},
+	}
+	lint := sdk.Action{
+		Name:    "lint",
+		Type:    sdk.JoinedAction,
+		Enabled: false,
+	}
+
+	return &sdk.Pipeline{
+		Name:             "build",
+		Type:             sdk.BuildPipeline,
+		Timeout:          3600,
+		Priority:         10,
+		SkipBuiltCommits: true,
+		Parameter: []sdk.Parameter{
+			{Name: "version", Type: sdk.StringParameter, Value: "1.0", Description: "Version to build"},
+		},
+		Stages: []sdk.Stage{
+			{
+				Name:          "Compile",
+				BuildOrder:    1,
+				Enabled:       true,
+				Timeout:       1800,
+				Prerequisites: []sdk.Prerequisite{},
+				Actions:       []sdk.Action{build, lint},
+			},
+			{
+				Name:       "Deploy",
+				BuildOrder: 2,
+				Enabled:    false,
+				Approval:   &sdk.StageApproval{Groups: []string{"ops"}, Required: 1},
+				Needs:      []string{"Compile"},
+				Prerequisites: []sdk.Prerequisite{
+					{Parameter: "git.branch", ExpectedValue: "master"},
+				},
+			},
+		},
+	}
+}
+
+func TestPipelineRoundTrip(t *testing.T) {
+	for _, f := range []Format{FormatYAML, FormatJSON} {
+		p := testPipeline()
+
+		data, err := Marshal(NewPipeline(p), f)
+		assert.NoError(t, err)
+
+		var e Pipeline
+		assert.NoError(t, Unmarshal(data, f, &e))
+		assert.Equal(t, NewPipeline(p), &e, "format %s", f)
+
+		imported, err := e.Pipeline()
+		assert.NoError(t, err)
+		assert.Equal(t, p, imported, "format %s", f)
+	}
+}
+
+func TestPipelineStagesInBuildOrder(t *testing.T) {
+	p := testPipeline()
+	p.Stages[0], p.Stages[1] = p.Stages[1], p.Stages[0]
+
+	e := NewPipeline(p)
+	assert.Equal(t, "Compile", e.Stages[0].Name)
+	assert.Equal(t, "Deploy", e.Stages[1].Name)
+}
+
+func TestPipelineInvalid(t *testing.T) {
+	e := NewPipeline(testPipeline())
+	e.Stages[1].Needs = []string{"Unknown"}
+	_, err := e.Pipeline()
+	assert.Error(t, err)
+
+	e = NewPipeline(testPipeline())
+	e.Stages[0].Jobs[0].Timeout = -1
+	_, err = e.Pipeline()
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package exportentities
+
+// Project is the portable definition of a whole CDS project. Secret variables
+// are ciphered with a passphrase chosen at export time.
+type Project struct {
+	Key          string            `json:"key" yaml:"key"`
+	Name         string            `json:"name" yaml:"name"`
+	Groups       []GroupPermission `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Variables    []Variable        `json:"variables,omitempty" yaml:"variables,omitempty"`
+	Environments []Environment     `json:"environments,omitempty" yaml:"environments,omitempty"`
+	Pipelines    []ProjectPipeline `json:"pipelines,omitempty" yaml:"pipelines,omitempty"`
+	Applications []Application     `json:"applications,omitempty" yaml:"applications,omitempty"`
+}
+
+// GroupPermission is the permission of a group, referenced by name
+type GroupPermission struct {
+	Group      string `json:"group" yaml:"group"`
+	Permission int    `json:"permission" yaml:"permission"`
+}
+
+// Variable is the portable definition of a project, application or environment variable.
+// Value of password and key variables is ciphered.
+type Variable struct {
+	Name  string `json:"name" yaml:"name"`
+	Type  string `json:"type" yaml:"type"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// Environment is the portable definition of an environment
+type Environment struct {
+	Name      string            `json:"name" yaml:"name"`
+	Groups    []GroupPermission `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Variables []Variable        `json:"variables,omitempty" yaml:"variables,omitempty"`
+}
+
+// ProjectPipeline is a pipeline with its groups permissions
+type ProjectPipeline struct {
+	Pipeline `yaml:",inline"`
+	Groups   []GroupPermission `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// Application is the portable definition of an application
+type Application struct {
+	Name                string                `json:"name" yaml:"name"`
+	Groups              []GroupPermission     `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Variables           []Variable            `json:"variables,omitempty" yaml:"variables,omitempty"`
+	RepositoriesManager string                `json:"repositories_manager,omitempty" yaml:"repositories_manager,omitempty"`
+	RepositoryFullname  string                `json:"repository_fullname,omitempty" yaml:"repository_fullname,omitempty"`
+	Pipelines           []ApplicationPipeline `json:"pipelines,omitempty" yaml:"pipelines,omitempty"`
+}
+
+// ApplicationPipeline is a pipeline attached to an application, with everything starting it
+type ApplicationPipeline struct {
+	Pipeline   string      `json:"pipeline" yaml:"pipeline"`
+	Parameters []Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Triggers   []Trigger   `json:"triggers,omitempty" yaml:"triggers,omitempty"`
+	Hooks      []Hook      `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+	Poller     *Poller     `json:"poller,omitempty" yaml:"poller,omitempty"`
+}
+
+// Trigger is the portable definition of a trigger whose source is the application pipeline.
+// An empty DestProject means the trigger stays in the project, empty environments mean NoEnv.
+type Trigger struct {
+	SrcEnvironment  string         `json:"src_environment,omitempty" yaml:"src_environment,omitempty"`
+	DestProject     string         `json:"dest_project,omitempty" yaml:"dest_project,omitempty"`
+	DestApplication string         `json:"dest_application" yaml:"dest_application"`
+	DestPipeline    string         `json:"dest_pipeline" yaml:"dest_pipeline"`
+	DestEnvironment string         `json:"dest_environment,omitempty" yaml:"dest_environment,omitempty"`
+	Manual          bool           `json:"manual,omitempty" yaml:"manual,omitempty"`
+	Parameters      []Parameter    `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Prerequisites   []Prerequisite `json:"prerequisites,omitempty" yaml:"prerequisites,omitempty"`
+}
+
+// Hook is the portable definition of a repository hook
+type Hook struct {
+	Kind       string `json:"kind" yaml:"kind"`
+	Host       string `json:"host" yaml:"host"`
+	Project    string `json:"project" yaml:"project"`
+	Repository string `json:"repository" yaml:"repository"`
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+}
+
+// Poller is the portable definition of a repository poller
+type Poller struct {
+	Name    string `json:"name" yaml:"name"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+}
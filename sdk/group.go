@@ -16,6 +16,41 @@ type Group struct {
 	PipelineGroups    []PipelineGroup    `json:"pipelines,omitempty"`
 	ApplicationGroups []ApplicationGroup `json:"applications,omitempty"`
 	EnvironmentGroups []EnvironmentGroup `json:"environments,omitempty"`
+	Share             *GroupShare        `json:"share,omitempty"`
+}
+
+// DefaultGroupShare is the share of groups which have not been configured otherwise
+const DefaultGroupShare = 1
+
+// GroupShare is the weight of a group in the fair-share scheduling of the build queue,
+// and the maximum number of workers its builds can use at the same time, 0 meaning no limit
+type GroupShare struct {
+	Share      int64 `json:"share"`
+	MaxWorkers int64 `json:"max_workers"`
+}
+
+// Check returns an error if the share is not positive or the worker limit is negative
+func (s GroupShare) Check() error {
+	if s.Share <= 0 {
+		return NewError(ErrInvalidGroupShare, fmt.Errorf("share must be positive, got %d", s.Share))
+	}
+	if s.MaxWorkers < 0 {
+		return NewError(ErrInvalidGroupShare, fmt.Errorf("max workers cannot be negative, got %d", s.MaxWorkers))
+	}
+	return nil
+}
+
+// GroupUsage is the current use of the worker pool by the builds of a group
+type GroupUsage struct {
+	Group      string `json:"group"`
+	Share      int64  `json:"share"`
+	MaxWorkers int64  `json:"max_workers,omitempty"`
+	Building   int64  `json:"building"`
+	Waiting    int64  `json:"waiting"`
+	// FairShare is the part of the building workers the group is entitled to among the groups having builds
+	FairShare float64 `json:"fair_share"`
+	// Usage is the part of the building workers the group actually uses
+	Usage float64 `json:"usage"`
 }
 
 // GroupPermission represent a group and his role in the project
@@ -266,3 +301,22 @@ func UnsetUserGroupAdmin(groupName string, userName string) error {
 
 	return nil
 }
+
+// SetGroupShare changes the share and the worker limit of a group in the build queue
+func SetGroupShare(groupName string, share GroupShare) error {
+	data, err := json.Marshal(share)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("/group/%s/share", groupName)
+	data, code, err := Request("PUT", url, data)
+	if err != nil {
+		return err
+	}
+
+	if code != http.StatusCreated && code != http.StatusOK {
+		return fmt.Errorf("Error [%d]: %s", code, data)
+	}
+	return DecodeError(data)
+}
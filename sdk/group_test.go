@@ -0,0 +1,15 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupShareCheck(t *testing.T) {
+	assert.NoError(t, GroupShare{Share: DefaultGroupShare}.Check())
+	assert.NoError(t, GroupShare{Share: 3, MaxWorkers: 10}.Check())
+	assert.Error(t, GroupShare{}.Check())
+	assert.Error(t, GroupShare{Share: -1}.Check())
+	assert.Error(t, GroupShare{Share: 1, MaxWorkers: -1}.Check())
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 )
@@ -22,6 +23,9 @@ type Pipeline struct {
 	AttachedApplication []Application     `json:"attached_application,omitempty"`
 	Permission          int               `json:"permission"`
 	LastModified        int64             `json:"last_modified"`
+	Timeout             int64             `json:"timeout,omitempty"`
+	Priority            int64             `json:"priority,omitempty"`
+	SkipBuiltCommits    bool              `json:"skip_built_commits,omitempty"`
 }
 
 // PipelineBuild Struct for history table
@@ -39,12 +43,44 @@ type PipelineBuild struct {
 	Application Application `json:"application"`
 	Environment Environment `json:"environment"`
 
-	Trigger PipelineBuildTrigger `json:"trigger"`
+	Trigger         PipelineBuildTrigger `json:"trigger"`
+	PipelineVersion int64                `json:"pipeline_version"`
+	Retried         bool                 `json:"retried,omitempty"`
+	Priority        int64                `json:"priority,omitempty"`
+	Graph           *StageGraph          `json:"graph,omitempty"`
+}
+
+// PipelineAudit is a version of a pipeline definition
+type PipelineAudit struct {
+	ID         int64     `json:"id"`
+	PipelineID int64     `json:"pipeline_id"`
+	Version    int64     `json:"version"`
+	User       User      `json:"user"`
+	Change     string    `json:"change"`
+	Versionned time.Time `json:"versionned"`
+	Pipeline   Pipeline  `json:"pipeline"`
+}
+
+// Kinds of PipelineChange
+const (
+	PipelineChangeAdded    = "added"
+	PipelineChangeRemoved  = "removed"
+	PipelineChangeModified = "modified"
+)
+
+// PipelineChange is a structural difference between two versions of a pipeline definition.
+// Path locates the changed element, e.g. "stages/Deploy/jobs/Upload/steps/2/parameters/file"
+type PipelineChange struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
 }
 
 // PipelineBuildTrigger Struct for history table
 type PipelineBuildTrigger struct {
 	ManualTrigger       bool           `json:"manual_trigger"`
+	ScheduledTrigger    bool           `json:"scheduled_trigger"`
 	TriggeredBy         *User          `json:"triggered_by"`
 	ParentPipelineBuild *PipelineBuild `json:"parent_pipeline_build"`
 	VCSChangesBranch    string         `json:"vcs_branch"`
@@ -108,6 +144,22 @@ type RunRequest struct {
 	ParentPipelineID    int64       `json:"parent_pipeline_id,omitempty"`
 	ParentEnvironmentID int64       `json:"parent_environment_id,omitempty"`
 	ParentApplicationID int64       `json:"parent_application_id,omitempty"`
+	Priority            *int64      `json:"priority,omitempty"`
+}
+
+// Bounds of a pipeline priority. Waiting builds are dequeued by decreasing priority,
+// pipelines have priority 0 unless configured otherwise
+const (
+	PriorityMin = -100
+	PriorityMax = 100
+)
+
+// CheckPriority returns an error if given priority is out of bounds
+func CheckPriority(priority int64) error {
+	if priority < PriorityMin || priority > PriorityMax {
+		return NewError(ErrInvalidPriority, fmt.Errorf("priority %d is out of bounds", priority))
+	}
+	return nil
 }
 
 // ListPipelines retrieves all available pipelines to called
@@ -460,6 +512,111 @@ func DeletePipeline(key, name string) error {
 	return nil
 }
 
+// ExportPipeline retrieves the portable definition of a pipeline in given format (yaml or json)
+func ExportPipeline(projectKey, name, format string) ([]byte, error) {
+	path := fmt.Sprintf("/project/%s/pipeline/%s/export?format=%s", projectKey, name, url.QueryEscape(format))
+
+	data, code, err := Request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		if e := DecodeError(data); e != nil {
+			return nil, e
+		}
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	return data, nil
+}
+
+// ImportPipeline creates or updates a pipeline from its portable definition in given format (yaml or json)
+func ImportPipeline(projectKey string, data []byte, format string) (*Pipeline, error) {
+	path := fmt.Sprintf("/project/%s/pipeline/import?format=%s", projectKey, url.QueryEscape(format))
+
+	data, code, err := Request("POST", path, data)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		if e := DecodeError(data); e != nil {
+			return nil, e
+		}
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	p := &Pipeline{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// GetPipelineAudit retrieves all versions of a pipeline definition, most recent first
+func GetPipelineAudit(projectKey, name string) ([]PipelineAudit, error) {
+	path := fmt.Sprintf("/project/%s/pipeline/%s/audit", projectKey, name)
+
+	data, code, err := Request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var audits []PipelineAudit
+	if err := json.Unmarshal(data, &audits); err != nil {
+		return nil, err
+	}
+
+	return audits, nil
+}
+
+// DiffPipelineVersions computes the changes between two versions of a pipeline definition.
+// A zero "to" version means the current definition.
+func DiffPipelineVersions(projectKey, name string, from, to int64) ([]PipelineChange, error) {
+	path := fmt.Sprintf("/project/%s/pipeline/%s/audit/diff?from=%d", projectKey, name, from)
+	if to > 0 {
+		path = fmt.Sprintf("%s&to=%d", path, to)
+	}
+
+	data, code, err := Request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var changes []PipelineChange
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// RestorePipelineVersion applies a previous version of a pipeline definition
+func RestorePipelineVersion(projectKey, name string, version int64) (*Pipeline, error) {
+	path := fmt.Sprintf("/project/%s/pipeline/%s/audit/%d", projectKey, name, version)
+
+	data, code, err := Request("PUT", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	p := &Pipeline{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
 // RemoveGroupFromPipeline  call api to remove a group from the given pipeline
 func RemoveGroupFromPipeline(projectKey, pipelineName, groupName string) error {
 
@@ -0,0 +1,53 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Origins of a skipped build
+const (
+	SkipOriginHook   = "hook"
+	SkipOriginPoller = "poller"
+)
+
+// PipelineBuildSkip records a hook or a poller which did not start a build of a pipeline skipping already built commits,
+// since its commit was already built successfully by the linked build.
+type PipelineBuildSkip struct {
+	ID              int64     `json:"id"`
+	ApplicationID   int64     `json:"application_id"`
+	PipelineID      int64     `json:"pipeline_id"`
+	EnvironmentID   int64     `json:"environment_id"`
+	Origin          string    `json:"origin"`
+	Branch          string    `json:"branch"`
+	Hash            string    `json:"hash"`
+	Author          string    `json:"author"`
+	PipelineBuildID int64     `json:"pipeline_build_id"`
+	BuildNumber     int64     `json:"build_number"`
+	BuildBranch     string    `json:"build_branch"`
+	Skipped         time.Time `json:"skipped"`
+}
+
+// GetPipelineBuildSkips lists the latest triggers skipped since their commit was already built
+func GetPipelineBuildSkips(key, appName, pipelineName, env string) ([]PipelineBuildSkip, error) {
+	var skips []PipelineBuildSkip
+
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/skipped", key, appName, pipelineName)
+	if env != "" {
+		uri = fmt.Sprintf("%s?envName=%s", uri, url.QueryEscape(env))
+	}
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	if err := json.Unmarshal(data, &skips); err != nil {
+		return nil, err
+	}
+	return skips, nil
+}
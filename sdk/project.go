@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 )
@@ -40,6 +41,15 @@ type ProjectLastUpdates struct {
 // ProjectKeyPattern  pattern for project key
 const ProjectKeyPattern = "^[A-Z0-9]{1,}$"
 
+// ProjectPassphraseHeader is the header carrying the passphrase which ciphers secrets of a project archive
+const ProjectPassphraseHeader = "PROJECT-PASSPHRASE"
+
+// ImportReport lists the conflicts which prevented an import, and what needs attention once the import is done
+type ImportReport struct {
+	Conflicts []string `json:"conflicts,omitempty" yaml:"conflicts,omitempty"`
+	Warnings  []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
 // NewProject instanciate a new NewProject
 func NewProject(key string) *Project {
 	p := &Project{
@@ -85,6 +95,49 @@ func RemoveProject(key string) error {
 	return nil
 }
 
+// ExportProject retrieves the archive of a project in given format (yaml or json).
+// Password and key variables are ciphered with given passphrase.
+func ExportProject(key, format, passphrase string) ([]byte, error) {
+	path := fmt.Sprintf("/project/%s/export?format=%s", key, url.QueryEscape(format))
+
+	data, code, err := Request("GET", path, nil, SetHeader(ProjectPassphraseHeader, passphrase))
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	return data, nil
+}
+
+// ImportProject creates a project from its archive in given format (yaml or json).
+// On conflicts, nothing is created and the returned report lists them.
+func ImportProject(data []byte, format, passphrase string) (*ImportReport, error) {
+	path := fmt.Sprintf("/project/import?format=%s", url.QueryEscape(format))
+
+	data, code, err := Request("POST", path, data, SetHeader(ProjectPassphraseHeader, passphrase))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{}
+	if code == http.StatusConflict {
+		if err := json.Unmarshal(data, report); err != nil {
+			return nil, err
+		}
+		return report, fmt.Errorf("%d conflict(s) found", len(report.Conflicts))
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
 // RenameProject call API to update project
 func RenameProject(key, newName string) error {
 
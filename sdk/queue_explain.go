@@ -0,0 +1,88 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// QueueExplanation tells, for an action build in queue, which worker models, workers and hatcheries
+// could run it, and why the others cannot
+type QueueExplanation struct {
+	ActionBuild       ActionBuild              `json:"action_build"`
+	Models            []ModelExplanation       `json:"models"`
+	Workers           []WorkerExplanation      `json:"workers"`
+	Hatcheries        []HatcheryExplanation    `json:"hatcheries"`
+	RequirementErrors []RequirementErrorReport `json:"requirement_errors"`
+}
+
+// ModelExplanation tells whether a worker model meets the requirements of an action build
+type ModelExplanation struct {
+	ModelID          int64        `json:"model_id"`
+	ModelName        string       `json:"model_name"`
+	CanRun           bool         `json:"can_run"`
+	UnmetRequirement *Requirement `json:"unmet_requirement,omitempty"`
+	Reason           string       `json:"reason,omitempty"`
+}
+
+// WorkerExplanation tells whether a live worker can take an action build
+type WorkerExplanation struct {
+	WorkerID         string       `json:"worker_id"`
+	WorkerName       string       `json:"worker_name"`
+	ModelID          int64        `json:"model_id"`
+	HatcheryID       int64        `json:"hatchery_id"`
+	Status           Status       `json:"status"`
+	GroupPermission  bool         `json:"group_permission"`
+	CanRun           bool         `json:"can_run"`
+	UnmetRequirement *Requirement `json:"unmet_requirement,omitempty"`
+	Reason           string       `json:"reason,omitempty"`
+}
+
+// HatcheryExplanation tells whether a registered hatchery may spawn a worker for an action build.
+// MaxWorker is 0 for hatcheries not reporting their capacity.
+type HatcheryExplanation struct {
+	HatcheryID       int64        `json:"hatchery_id"`
+	HatcheryName     string       `json:"hatchery_name"`
+	GroupID          int64        `json:"group_id"`
+	ModelID          int64        `json:"model_id,omitempty"`
+	Workers          int64        `json:"workers"`
+	MaxWorker        int64        `json:"max_worker"`
+	AtCapacity       bool         `json:"at_capacity"`
+	GroupPermission  bool         `json:"group_permission"`
+	CanRun           bool         `json:"can_run"`
+	UnmetRequirement *Requirement `json:"unmet_requirement,omitempty"`
+	Reason           string       `json:"reason,omitempty"`
+}
+
+// RequirementErrorReport is an error met by a worker while checking requirements
+type RequirementErrorReport struct {
+	WorkerID      string    `json:"worker_id"`
+	WorkerName    string    `json:"worker_name"`
+	ModelID       int64     `json:"model_id"`
+	ActionBuildID int64     `json:"action_build_id,omitempty"`
+	Message       string    `json:"message"`
+	Created       time.Time `json:"created"`
+}
+
+// ExplainBuildQueue tells why given action build in queue is not taken by a worker
+func ExplainBuildQueue(actionBuildID int64) (*QueueExplanation, error) {
+	path := fmt.Sprintf("/queue/%d/explain", actionBuildID)
+
+	data, code, err := Request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if e := DecodeError(data); e != nil {
+		return nil, e
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var e QueueExplanation
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
@@ -187,6 +187,25 @@ func GetProjectReposFromReposManager(k, n string) ([]VCSRepo, error) {
 	return repos, nil
 }
 
+//GetApplicationRepository returns the repository the application is attached to
+func GetApplicationRepository(projectKey, appName string) (*VCSRepo, error) {
+	uri := fmt.Sprintf("/project/%s/application/%s/repository", projectKey, appName)
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var repo VCSRepo
+	if err := json.Unmarshal(data, &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
 //GetCommits returns the commits
 func GetCommits(key, repoManagername, repoFullname, since, until string) ([]VCSCommit, error) {
 	var commits []VCSCommit
@@ -0,0 +1,83 @@
+package sdk
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Failures an action build can be retried on
+const (
+	RetryOnFail = "Fail"
+	RetryOnAWOL = "AWOL"
+)
+
+// ActionRetry defines how a failed action build of a pipeline is attempted again.
+// Delay is the number of seconds to wait before the first retry, it is multiplied
+// by Backoff before each following one.
+type ActionRetry struct {
+	Count   int      `json:"count" yaml:"count"`
+	Delay   int64    `json:"delay,omitempty" yaml:"delay,omitempty"`
+	Backoff float64  `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	On      []string `json:"on,omitempty" yaml:"on,omitempty"`
+}
+
+// Check validates the retry policy
+func (r *ActionRetry) Check() error {
+	if r.Count < 0 {
+		return NewError(ErrInvalidRetry, fmt.Errorf("count must be positive"))
+	}
+	if r.Delay < 0 {
+		return NewError(ErrInvalidRetry, fmt.Errorf("delay must be positive"))
+	}
+	if r.Backoff != 0 && r.Backoff < 1 {
+		return NewError(ErrInvalidRetry, fmt.Errorf("backoff must be greater or equal to 1"))
+	}
+	for _, on := range r.On {
+		if on != RetryOnFail && on != RetryOnAWOL {
+			return NewError(ErrInvalidRetry, fmt.Errorf("cannot retry on '%s', expected %s or %s", on, RetryOnFail, RetryOnAWOL))
+		}
+	}
+	return nil
+}
+
+// Retries returns true if given failed attempt should be followed by another one.
+// A policy without failure kind retries on Fail only.
+func (r *ActionRetry) Retries(attempt int, awol bool) bool {
+	if attempt > r.Count {
+		return false
+	}
+	on := RetryOnFail
+	if awol {
+		on = RetryOnAWOL
+	}
+	if len(r.On) == 0 {
+		return on == RetryOnFail
+	}
+	return IsInArray(on, r.On)
+}
+
+// NextDelay returns how long to wait after given failed attempt before starting the next one
+func (r *ActionRetry) NextDelay(attempt int) time.Duration {
+	delay := time.Duration(r.Delay) * time.Second
+	if r.Backoff > 1 && attempt > 1 {
+		delay = time.Duration(float64(delay) * math.Pow(r.Backoff, float64(attempt-1)))
+	}
+	return delay
+}
+
+// String returns a short description of the retry policy
+func (r *ActionRetry) String() string {
+	if r == nil {
+		return ""
+	}
+	s := fmt.Sprintf("%d times after %ds", r.Count, r.Delay)
+	if r.Backoff > 1 {
+		s += fmt.Sprintf(" (backoff %g)", r.Backoff)
+	}
+	if len(r.On) > 0 {
+		s += " on " + strings.Join(r.On, ",")
+	}
+	return s
+}
@@ -0,0 +1,32 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionRetry(t *testing.T) {
+	r := &ActionRetry{Count: 3, Delay: 10, Backoff: 2}
+	assert.NoError(t, r.Check())
+	assert.True(t, r.Retries(1, false))
+	assert.True(t, r.Retries(3, false))
+	assert.False(t, r.Retries(4, false))
+	assert.False(t, r.Retries(1, true))
+	assert.Equal(t, 10*time.Second, r.NextDelay(1))
+	assert.Equal(t, 40*time.Second, r.NextDelay(3))
+
+	r.On = []string{RetryOnAWOL}
+	assert.False(t, r.Retries(1, false))
+	assert.True(t, r.Retries(1, true))
+
+	for _, invalid := range []*ActionRetry{
+		{Count: -1},
+		{Count: 1, Delay: -5},
+		{Count: 1, Backoff: 0.5},
+		{Count: 1, On: []string{"Success"}},
+	} {
+		assert.Error(t, invalid.Check())
+	}
+}
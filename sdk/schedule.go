@@ -0,0 +1,149 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PipelineSchedule runs a pipeline of an application on an environment at the times given by a cron expression.
+// With OnlyNewCommits, a run is skipped if the branch has no commit since the last run of the schedule.
+type PipelineSchedule struct {
+	ID             int64       `json:"id"`
+	ApplicationID  int64       `json:"application_id"`
+	PipelineID     int64       `json:"pipeline_id"`
+	Environment    Environment `json:"environment"`
+	Cron           string      `json:"cron"`
+	Timezone       string      `json:"timezone"`
+	Parameters     []Parameter `json:"parameters"`
+	OnlyNewCommits bool        `json:"only_new_commits"`
+	Enabled        bool        `json:"enabled"`
+	NextExecution  time.Time   `json:"next_execution"`
+	LastExecution  *time.Time  `json:"last_execution,omitempty"`
+	LastHash       string      `json:"last_hash,omitempty"`
+}
+
+// Location returns the time zone of the schedule, UTC if none is set
+func (s *PipelineSchedule) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, NewError(ErrInvalidSchedule, fmt.Errorf("unknown timezone '%s'", s.Timezone))
+	}
+	return loc, nil
+}
+
+// Next returns the first execution time of the schedule after t
+func (s *PipelineSchedule) Next(t time.Time) (time.Time, error) {
+	c, err := ParseCron(s.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := s.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := c.Next(t.In(loc))
+	if next.IsZero() {
+		return next, NewError(ErrInvalidSchedule, fmt.Errorf("cron expression '%s' never matches", s.Cron))
+	}
+	return next, nil
+}
+
+// NextRun returns the first execution time of the schedule after now, following the run due at last.
+// When daylight saving time ends, the wall clock times of the repeated hour which were already
+// reached before the run due at last do not run a second time.
+func (s *PipelineSchedule) NextRun(last, now time.Time) (time.Time, error) {
+	next, err := s.Next(now)
+	for err == nil && !firstOccurrence(next).After(last) {
+		next, err = s.Next(next)
+	}
+	return next, err
+}
+
+// firstOccurrence returns the first time showing the same wall clock as t,
+// which is earlier than t only when t is in the hour repeated at the end of daylight saving time
+func firstOccurrence(t time.Time) time.Time {
+	_, offset := t.Zone()
+	_, before := t.Add(-24 * time.Hour).Zone()
+	if before <= offset {
+		return t
+	}
+	earlier := t.Add(-time.Duration(before-offset) * time.Second)
+	if earlier.Format("2006-01-02 15:04") != t.Format("2006-01-02 15:04") {
+		return t
+	}
+	return earlier
+}
+
+// AddPipelineSchedule creates a schedule running given pipeline of given application
+func AddPipelineSchedule(projectKey, appName, pipelineName string, s *PipelineSchedule) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/schedule", projectKey, appName, pipelineName)
+	data, code, err := Request("POST", uri, data)
+	if err != nil {
+		return err
+	}
+	if code >= 300 {
+		return fmt.Errorf("HTTP %d", code)
+	}
+
+	return json.Unmarshal(data, s)
+}
+
+// UpdatePipelineSchedule updates a schedule of given pipeline of given application
+func UpdatePipelineSchedule(projectKey, appName, pipelineName string, s *PipelineSchedule) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/schedule/%d", projectKey, appName, pipelineName, s.ID)
+	data, code, err := Request("PUT", uri, data)
+	if err != nil {
+		return err
+	}
+	if code >= 300 {
+		return fmt.Errorf("HTTP %d", code)
+	}
+
+	return json.Unmarshal(data, s)
+}
+
+// GetPipelineSchedules lists the schedules of given pipeline of given application
+func GetPipelineSchedules(projectKey, appName, pipelineName string) ([]PipelineSchedule, error) {
+	var schedules []PipelineSchedule
+
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/schedule", projectKey, appName, pipelineName)
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// DeletePipelineSchedule removes a schedule of given pipeline of given application
+func DeletePipelineSchedule(projectKey, appName, pipelineName string, id int64) error {
+	uri := fmt.Sprintf("/project/%s/application/%s/pipeline/%s/schedule/%d", projectKey, appName, pipelineName, id)
+	_, code, err := Request("DELETE", uri, nil)
+	if err != nil {
+		return err
+	}
+	if code >= 300 {
+		return fmt.Errorf("HTTP %d", code)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package sdk
+
+import (
+	"encoding/base64"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MinMaskedSecretLength is the length under which secret values are not masked,
+// shorter values would hide too much of the logs
+const MinMaskedSecretLength = 6
+
+// SecretMasker replaces the clear values of secret variables with PasswordPlaceholder
+type SecretMasker struct {
+	replacer *strings.Replacer
+}
+
+// NewSecretMasker returns a SecretMasker of the secret and key variables among vars.
+// Each value is masked as is, line by line for multi-line values such as keys,
+// and in its base64, URL-encoded and quoted forms.
+func NewSecretMasker(vars []Variable) *SecretMasker {
+	forms := map[string]bool{}
+	add := func(s string) {
+		if len(s) >= MinMaskedSecretLength {
+			forms[s] = true
+		}
+	}
+
+	for _, v := range vars {
+		if !NeedPlaceholder(v.Type) || v.Value == PasswordPlaceholder {
+			continue
+		}
+
+		values := []string{v.Value}
+		if strings.Contains(v.Value, "\n") {
+			for _, line := range strings.Split(v.Value, "\n") {
+				values = append(values, strings.TrimSpace(line))
+			}
+		}
+
+		for _, s := range values {
+			if len(s) < MinMaskedSecretLength {
+				continue
+			}
+			add(s)
+			add(base64.StdEncoding.EncodeToString([]byte(s)))
+			add(base64.RawStdEncoding.EncodeToString([]byte(s)))
+			add(base64.URLEncoding.EncodeToString([]byte(s)))
+			add(base64.RawURLEncoding.EncodeToString([]byte(s)))
+			add(url.QueryEscape(s))
+			add(url.PathEscape(s))
+			quoted := strconv.Quote(s)
+			add(quoted[1 : len(quoted)-1])
+		}
+	}
+
+	// Replace longest forms first, so that a secret containing another one is masked as a whole
+	sorted := make([]string, 0, len(forms))
+	for s := range forms {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	oldnew := make([]string, 0, 2*len(sorted))
+	for _, s := range sorted {
+		oldnew = append(oldnew, s, PasswordPlaceholder)
+	}
+	return &SecretMasker{replacer: strings.NewReplacer(oldnew...)}
+}
+
+// Mask replaces the secrets found in s
+func (m *SecretMasker) Mask(s string) string {
+	if m == nil {
+		return s
+	}
+	return m.replacer.Replace(s)
+}
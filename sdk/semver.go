@@ -0,0 +1,358 @@
+package sdk
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is a semantic version. Pre-release and build metadata are ignored.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// VersionConstraint is a set of accepted versions, such as ">= 1.7", "~6", "1.2 - 2.0" or "^1.2 || 2.x".
+// Comparators separated by spaces or commas must all match, alternatives are separated by "||".
+// Partial versions follow npm rules: "1.7" stands for any 1.7.x, "~1.7.2" for 1.7.x from 1.7.2,
+// "^1.2" for any 1.x from 1.2.0, "1.2 - 2.0" for 1.2.0 to any 2.0.x.
+type VersionConstraint []versionRange
+
+// versionRange holds the versions from min, included, to max, excluded. A nil max means no upper bound.
+type versionRange struct {
+	min Version
+	max *Version
+}
+
+// DefaultVersionProbes are the commands printing the version of common binaries not supporting --version
+var DefaultVersionProbes = map[string]string{
+	"go":   "go version",
+	"java": "java -version",
+}
+
+var versionRegexp = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+var operatorSpaceRegexp = regexp.MustCompile(`(>=|<=|>|<|==|=|~|\^)\s+`)
+
+// ParseVersion parses a version such as "1.7.3", "v1.8" or "6"
+func ParseVersion(s string) (Version, error) {
+	v, parts, err := parsePartialVersion(s)
+	if err != nil {
+		return v, err
+	}
+	if parts == 0 {
+		return v, fmt.Errorf("invalid version '%s'", s)
+	}
+	return v, nil
+}
+
+// FindVersion returns the first version found in given text, like the output of "go version"
+func FindVersion(s string) (Version, bool) {
+	m := versionRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, false
+	}
+	v := Version{}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	return v, true
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less returns true when v is lower than o
+func (v Version) Less(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+// next returns the lowest version above all the versions starting with the given parts of v
+func (v Version) next(parts int) Version {
+	switch parts {
+	case 1:
+		return Version{Major: v.Major + 1}
+	case 2:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	}
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
+
+// parsePartialVersion parses a version which may miss its minor and patch numbers, or have
+// wildcards instead, and returns how many numbers were given
+func parsePartialVersion(s string) (Version, int, error) {
+	var v Version
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "=")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	if s == "" || s == "*" || s == "x" || s == "X" {
+		return v, 0, nil
+	}
+
+	fields := strings.Split(s, ".")
+	if len(fields) > 3 {
+		return v, 0, fmt.Errorf("invalid version '%s'", s)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	parts := 0
+	for i, f := range fields {
+		if f == "*" || f == "x" || f == "X" {
+			break
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return v, 0, fmt.Errorf("invalid version '%s'", s)
+		}
+		*numbers[i] = n
+		parts++
+	}
+	return v, parts, nil
+}
+
+// ParseVersionConstraint parses a version constraint, an empty constraint accepts any version
+func ParseVersionConstraint(s string) (VersionConstraint, error) {
+	var c VersionConstraint
+	s = operatorSpaceRegexp.ReplaceAllString(s, "$1")
+	for _, alt := range strings.Split(s, "||") {
+		r := versionRange{}
+		comps := strings.FieldsFunc(alt, func(r rune) bool { return r == ' ' || r == ',' })
+		for i := 0; i < len(comps); i++ {
+			var cr versionRange
+			var err error
+			if i+1 < len(comps) && comps[i+1] == "-" {
+				if i+2 == len(comps) {
+					return nil, fmt.Errorf("invalid version range '%s'", strings.TrimSpace(alt))
+				}
+				cr, err = parseHyphenRange(comps[i], comps[i+2])
+				i += 2
+			} else {
+				cr, err = parseComparator(comps[i])
+			}
+			if err != nil {
+				return nil, err
+			}
+			r = r.intersect(cr)
+		}
+		if r.empty() {
+			return nil, fmt.Errorf("version constraint '%s' matches no version", strings.TrimSpace(alt))
+		}
+		c = append(c, r)
+	}
+	return c, nil
+}
+
+// parseComparator parses a single comparator such as ">=1.7" into the range of versions it accepts
+func parseComparator(s string) (versionRange, error) {
+	op := ""
+	for _, o := range []string{">=", "<=", "==", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(s, o) {
+			op = o
+			break
+		}
+	}
+	if strings.HasPrefix(s[len(op):], "-") {
+		return versionRange{}, fmt.Errorf("invalid version constraint '%s'", s)
+	}
+	v, parts, err := parsePartialVersion(s[len(op):])
+	if err != nil {
+		return versionRange{}, fmt.Errorf("invalid version constraint '%s'", s)
+	}
+
+	// Wildcards match all versions, a range with max equal to min matches none
+	none := versionRange{max: &Version{}}
+	if parts == 0 {
+		if op == ">" || op == "<" {
+			return none, nil
+		}
+		return versionRange{}, nil
+	}
+
+	switch op {
+	case ">":
+		return versionRange{min: v.next(parts)}, nil
+	case ">=":
+		return versionRange{min: v}, nil
+	case "<":
+		return versionRange{max: &v}, nil
+	case "<=":
+		max := v.next(parts)
+		return versionRange{max: &max}, nil
+	case "~":
+		if parts > 2 {
+			parts = 2
+		}
+		max := v.next(parts)
+		return versionRange{min: v, max: &max}, nil
+	case "^":
+		switch {
+		case v.Major > 0 || parts == 1:
+			parts = 1
+		case v.Minor > 0 || parts == 2:
+			parts = 2
+		}
+		max := v.next(parts)
+		return versionRange{min: v, max: &max}, nil
+	}
+	max := v.next(parts)
+	return versionRange{min: v, max: &max}, nil
+}
+
+// parseHyphenRange parses the bounds of a range such as "1.2 - 2.0", both included
+func parseHyphenRange(from, to string) (versionRange, error) {
+	min, err := parseComparator(">=" + from)
+	if err != nil {
+		return versionRange{}, err
+	}
+	max, err := parseComparator("<=" + to)
+	if err != nil {
+		return versionRange{}, err
+	}
+	return min.intersect(max), nil
+}
+
+func (r versionRange) contains(v Version) bool {
+	return !v.Less(r.min) && (r.max == nil || v.Less(*r.max))
+}
+
+func (r versionRange) empty() bool {
+	return r.max != nil && !r.min.Less(*r.max)
+}
+
+func (r versionRange) intersect(o versionRange) versionRange {
+	res := r
+	if res.min.Less(o.min) {
+		res.min = o.min
+	}
+	if res.max == nil || (o.max != nil && o.max.Less(*res.max)) {
+		res.max = o.max
+	}
+	return res
+}
+
+// Check returns true when the constraint accepts given version
+func (c VersionConstraint) Check(v Version) bool {
+	if len(c) == 0 {
+		return true
+	}
+	for _, r := range c {
+		if r.contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Intersects returns true when a version is accepted by both constraints
+func (c VersionConstraint) Intersects(o VersionConstraint) bool {
+	if len(c) == 0 || len(o) == 0 {
+		return true
+	}
+	for _, r := range c {
+		for _, s := range o {
+			if !r.intersect(s).empty() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckVersion verifies that the version of the requirement is a valid constraint
+func (r Requirement) CheckVersion() error {
+	if r.Version == "" {
+		return nil
+	}
+	if r.Type != BinaryRequirement {
+		return NewError(ErrInvalidVersionConstraint, fmt.Errorf("only binary requirements have versions"))
+	}
+	if _, err := ParseVersionConstraint(r.Version); err != nil {
+		return NewError(ErrInvalidVersionConstraint, err)
+	}
+	return nil
+}
+
+// ProvidedBy returns true when given capability provides what the requirement needs, in a compatible version.
+// A capability without version is expected to provide any version.
+func (r Requirement) ProvidedBy(c Requirement) bool {
+	if r.Value != c.Value && r.Value != c.Name {
+		return false
+	}
+	if r.Type != BinaryRequirement || r.Version == "" || c.Version == "" {
+		return true
+	}
+	rc, err := ParseVersionConstraint(r.Version)
+	if err != nil {
+		return false
+	}
+	cc, err := ParseVersionConstraint(c.Version)
+	if err != nil {
+		return false
+	}
+	return rc.Intersects(cc)
+}
+
+// ParseVersionProbes reads version probes given as binary=command, separated by semicolons
+func ParseVersionProbes(s string) (map[string]string, error) {
+	probes := map[string]string{}
+	for _, p := range strings.Split(s, ";") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		t := strings.SplitN(p, "=", 2)
+		if len(t) != 2 || strings.TrimSpace(t[0]) == "" || strings.TrimSpace(t[1]) == "" {
+			return nil, fmt.Errorf("malformed version probe '%s' (must be format 'binary=command')", p)
+		}
+		probes[strings.TrimSpace(t[0])] = strings.TrimSpace(t[1])
+	}
+	return probes, nil
+}
+
+// ProbeVersion runs the probe command of a binary, given probes first then default ones, or "<binary> --version",
+// and returns the first version found in its output
+func ProbeVersion(binary string, probes map[string]string) (Version, error) {
+	probe, ok := probes[binary]
+	if !ok {
+		probe, ok = DefaultVersionProbes[binary]
+	}
+	if !ok {
+		probe = binary + " --version"
+	}
+
+	args := strings.Fields(probe)
+	cmd := exec.Command(args[0], args[1:]...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Start(); err != nil {
+		return Version{}, err
+	}
+	timer := time.AfterFunc(10*time.Second, func() {
+		cmd.Process.Kill()
+	})
+	err := cmd.Wait()
+	timer.Stop()
+
+	// Some binaries print their version with a non zero exit code
+	v, found := FindVersion(out.String())
+	if !found {
+		if err != nil {
+			return v, fmt.Errorf("%s: %s", probe, err)
+		}
+		return v, fmt.Errorf("%s: no version found", probe)
+	}
+	return v, nil
+}
@@ -0,0 +1,62 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		match      bool
+	}{
+		{">= 1.7", "1.7.0", true},
+		{">= 1.7", "1.6.4", false},
+		{">1.7", "1.7.5", false},
+		{">1.7", "1.8.0", true},
+		{"<=1.7", "1.7.9", true},
+		{"~1.7.2", "1.7.9", true},
+		{"~1.7.2", "1.8.0", false},
+		{"^1.2", "1.9.0", true},
+		{"^1.2", "2.0.0", false},
+		{"^0.2.3", "0.3.0", false},
+		{"1.7", "1.7.3", true},
+		{">=1.6, <1.8", "1.8.0", false},
+		{"^1.2 || 2.x", "2.4.1", true},
+		{"*", "0.0.1", true},
+		{"1.2 - 2.0", "1.2.0", true},
+		{"1.2 - 2.0", "2.0.7", true},
+		{"1.2 - 2.0", "1.1.9", false},
+		{"1.2 - 2.0", "2.1.0", false},
+		{"1.2.3 - 2 || >= 4", "2.9.0", true},
+	}
+
+	for _, test := range tests {
+		c, err := ParseVersionConstraint(test.constraint)
+		assert.NoError(t, err)
+		v, err := ParseVersion(test.version)
+		assert.NoError(t, err)
+		assert.Equal(t, test.match, c.Check(v), "%s on %s", test.constraint, test.version)
+	}
+
+	for _, constraint := range []string{">= 1.a", "1.2 -", "- 2.0", "1.2 - >=2.0", ">2 <1"} {
+		_, err := ParseVersionConstraint(constraint)
+		assert.Error(t, err, constraint)
+	}
+}
+
+func TestRequirementProvidedBy(t *testing.T) {
+	r := Requirement{Name: "go", Type: BinaryRequirement, Value: "go", Version: ">= 1.7"}
+
+	assert.True(t, r.ProvidedBy(Requirement{Name: "go", Type: BinaryRequirement, Value: "go"}))
+	assert.True(t, r.ProvidedBy(Requirement{Name: "go", Type: BinaryRequirement, Value: "go", Version: "1.8.1"}))
+	assert.True(t, r.ProvidedBy(Requirement{Name: "go", Type: BinaryRequirement, Value: "go", Version: "1.x"}))
+	assert.False(t, r.ProvidedBy(Requirement{Name: "go", Type: BinaryRequirement, Value: "go", Version: "1.6"}))
+	assert.False(t, r.ProvidedBy(Requirement{Name: "git", Type: BinaryRequirement, Value: "git", Version: "2.1"}))
+
+	v, found := FindVersion("go version go1.8.3 linux/amd64")
+	assert.True(t, found)
+	assert.Equal(t, Version{Major: 1, Minor: 8, Patch: 3}, v)
+}
@@ -17,6 +17,9 @@ type Stage struct {
 	ActionBuilds  []ActionBuild  `json:"builds"`
 	Prerequisites []Prerequisite `json:"prerequisites"`
 	LastModified  int64          `json:"last_modified"`
+	Timeout       int64          `json:"timeout,omitempty"`
+	Approval      *StageApproval `json:"approval,omitempty"`
+	Needs         []string       `json:"needs,omitempty"`
 }
 
 // NewStage instanciate a new Stage
@@ -134,6 +137,17 @@ func ChangeStageState(projectKey, pipelineName, pipelineStageID string, enabled
 	return updateStage(projectKey, pipelineName, pipelineStageID, s)
 }
 
+// SetStageNeeds sets the stages a stage waits for. Without needs, a stage waits for all the stages before it.
+func SetStageNeeds(projectKey, pipelineName, pipelineStageID string, needs []string) error {
+
+	s, err := GetStage(projectKey, pipelineName, pipelineStageID)
+	if err != nil {
+		return err
+	}
+	s.Needs = needs
+	return updateStage(projectKey, pipelineName, pipelineStageID, s)
+}
+
 // MoveStage Change stage buildOrder
 func MoveStage(projectKey, pipelineName string, pipelineStageID int64, buildOrder int) error {
 
@@ -0,0 +1,195 @@
+package sdk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StageDAG is the dependency graph of the stages of a pipeline, given in build order.
+// A stage runs once all the stages it needs are done. A stage without needs depends
+// on all the stages before it, so pipelines without needs run their stages one after another.
+type StageDAG struct {
+	// Needs holds, for each stage, the indexes of the stages it depends on
+	Needs [][]int
+	// Order holds the indexes of the stages in an order respecting their needs
+	Order []int
+}
+
+// StageGraph is the graph of the stages of a pipeline build along their needs.
+// Edges only link a stage to the stages it directly depends on.
+type StageGraph struct {
+	Nodes []StageNode `json:"nodes"`
+	Edges []StageEdge `json:"edges"`
+}
+
+// StageNode is a stage of a StageGraph
+type StageNode struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+}
+
+// StageEdge links a stage to a stage it needs
+type StageEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// NewStageDAG resolves the needs of given stages, sorted by build order. It fails when a stage
+// needs an unknown or ambiguous stage, or when needs are cyclic.
+func NewStageDAG(stages []Stage) (*StageDAG, error) {
+	index := make(map[string]int, len(stages))
+	duplicated := map[string]bool{}
+	for i, s := range stages {
+		if _, ok := index[s.Name]; ok {
+			duplicated[s.Name] = true
+		}
+		index[s.Name] = i
+	}
+
+	d := &StageDAG{Needs: make([][]int, len(stages))}
+	for i, s := range stages {
+		if len(s.Needs) == 0 {
+			for j := 0; j < i; j++ {
+				d.Needs[i] = append(d.Needs[i], j)
+			}
+			continue
+		}
+
+		seen := map[int]bool{}
+		for _, n := range s.Needs {
+			j, ok := index[n]
+			if !ok {
+				return nil, NewError(ErrInvalidStageNeeds, fmt.Errorf("stage %s needs unknown stage %s", s.Name, n))
+			}
+			if duplicated[n] {
+				return nil, NewError(ErrInvalidStageNeeds, fmt.Errorf("stage %s needs stage %s, which is not unique", s.Name, n))
+			}
+			if j == i {
+				return nil, NewError(ErrInvalidStageNeeds, fmt.Errorf("stage %s needs itself", s.Name))
+			}
+			if !seen[j] {
+				seen[j] = true
+				d.Needs[i] = append(d.Needs[i], j)
+			}
+		}
+	}
+
+	// Pick the first stage in build order whose needs are done, until all stages are picked
+	done := map[int]bool{}
+	for len(d.Order) < len(stages) {
+		next := -1
+		for i := range stages {
+			if !done[i] && d.Ready(i, done) {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			var cycle []string
+			for i, s := range stages {
+				if !done[i] {
+					cycle = append(cycle, s.Name)
+				}
+			}
+			return nil, NewError(ErrInvalidStageNeeds, fmt.Errorf("cyclic needs between stages %s", strings.Join(cycle, ", ")))
+		}
+		done[next] = true
+		d.Order = append(d.Order, next)
+	}
+
+	return d, nil
+}
+
+// Ready returns true when all the stages needed by the i-th stage are done
+func (d *StageDAG) Ready(i int, done map[int]bool) bool {
+	for _, j := range d.Needs[i] {
+		if !done[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// Graph returns the graph of given stages, the ones the DAG was built from, with the status of their action builds
+func (d *StageDAG) Graph(stages []Stage) *StageGraph {
+	g := &StageGraph{
+		Nodes: []StageNode{},
+		Edges: []StageEdge{},
+	}
+
+	// ancestors[i] holds all the stages the i-th stage depends on, directly or not
+	ancestors := make([]map[int]bool, len(stages))
+	for _, i := range d.Order {
+		ancestors[i] = map[int]bool{}
+		for _, j := range d.Needs[i] {
+			ancestors[i][j] = true
+			for k := range ancestors[j] {
+				ancestors[i][k] = true
+			}
+		}
+	}
+
+	for i, s := range stages {
+		g.Nodes = append(g.Nodes, StageNode{ID: s.ID, Name: s.Name, Status: s.BuildStatus()})
+
+		for _, j := range d.Needs[i] {
+			indirect := false
+			for _, k := range d.Needs[i] {
+				if k != j && ancestors[k][j] {
+					indirect = true
+					break
+				}
+			}
+			if !indirect {
+				g.Edges = append(g.Edges, StageEdge{From: stages[j].Name, To: s.Name})
+			}
+		}
+	}
+
+	return g
+}
+
+// CheckStageNeeds verifies that the needs of given stages, sorted by build order, can be resolved
+func CheckStageNeeds(stages []Stage) error {
+	_, err := NewStageDAG(stages)
+	return err
+}
+
+// BuildStatus returns the status of the stage from its action builds, StatusNeverBuilt if none was scheduled yet
+func (s *Stage) BuildStatus() Status {
+	var builds, disabled, skipped int
+	var building, waiting bool
+	for _, ab := range s.ActionBuilds {
+		if ab.Retried {
+			continue
+		}
+		builds++
+		switch ab.Status {
+		case StatusFail, StatusStopped:
+			return ab.Status
+		case StatusBuilding:
+			building = true
+		case StatusWaiting:
+			waiting = true
+		case StatusDisabled:
+			disabled++
+		case StatusSkipped:
+			skipped++
+		}
+	}
+
+	switch {
+	case builds == 0:
+		return StatusNeverBuilt
+	case building:
+		return StatusBuilding
+	case waiting:
+		return StatusWaiting
+	case disabled == builds:
+		return StatusDisabled
+	case disabled+skipped == builds:
+		return StatusSkipped
+	}
+	return StatusSuccess
+}
@@ -0,0 +1,59 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStageDAGLinear(t *testing.T) {
+	stages := []Stage{{Name: "build"}, {Name: "test"}, {Name: "deploy"}}
+
+	d, err := NewStageDAG(stages)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, d.Order)
+	assert.False(t, d.Ready(2, map[int]bool{1: true}))
+	assert.True(t, d.Ready(2, map[int]bool{0: true, 1: true}))
+
+	g := d.Graph(stages)
+	assert.Equal(t, []StageEdge{{From: "build", To: "test"}, {From: "test", To: "deploy"}}, g.Edges)
+}
+
+func TestStageDAGNeeds(t *testing.T) {
+	stages := []Stage{
+		{Name: "build", ActionBuilds: []ActionBuild{{Status: StatusSuccess}}},
+		{Name: "deploy", Needs: []string{"unit", "integration", "build"}},
+		{Name: "unit", Needs: []string{"build"}, ActionBuilds: []ActionBuild{{Status: StatusFail, Retried: true}, {Status: StatusBuilding}}},
+		{Name: "integration", Needs: []string{"build"}},
+	}
+
+	d, err := NewStageDAG(stages)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2, 3, 1}, d.Order)
+	assert.True(t, d.Ready(3, map[int]bool{0: true}))
+
+	g := d.Graph(stages)
+	assert.Equal(t, []StageEdge{
+		{From: "unit", To: "deploy"},
+		{From: "integration", To: "deploy"},
+		{From: "build", To: "unit"},
+		{From: "build", To: "integration"},
+	}, g.Edges)
+	assert.Equal(t, StatusSuccess, g.Nodes[0].Status)
+	assert.Equal(t, StatusNeverBuilt, g.Nodes[1].Status)
+	assert.Equal(t, StatusBuilding, g.Nodes[2].Status)
+}
+
+func TestStageDAGInvalid(t *testing.T) {
+	tests := [][]Stage{
+		{{Name: "build", Needs: []string{"test"}}, {Name: "test", Needs: []string{"build"}}},
+		{{Name: "build", Needs: []string{"test"}}, {Name: "test"}},
+		{{Name: "build"}, {Name: "test", Needs: []string{"test"}}},
+		{{Name: "build"}, {Name: "test", Needs: []string{"package"}}},
+		{{Name: "build"}, {Name: "build"}, {Name: "test", Needs: []string{"build"}}},
+	}
+
+	for _, stages := range tests {
+		assert.Error(t, CheckStageNeeds(stages))
+	}
+}
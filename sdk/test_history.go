@@ -0,0 +1,182 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Statuses of a test case in a pipeline build
+const (
+	TestCasePass = "pass"
+	TestCaseFail = "fail"
+	TestCaseSkip = "skip"
+)
+
+// TestCaseRun is the result of a test case in a pipeline build, Duration being in seconds
+type TestCaseRun struct {
+	PipelineBuildID int64     `json:"pipeline_build_id"`
+	BuildNumber     int64     `json:"build_number"`
+	Branch          string    `json:"branch"`
+	Hash            string    `json:"hash"`
+	Status          string    `json:"status"`
+	Duration        float64   `json:"duration"`
+	Created         time.Time `json:"created"`
+}
+
+// TestCaseHistory is the timeline of a test case, identified by its suite and its name, on a pipeline,
+// latest runs first. FirstFailure is the run which started the latest series of failures, if any.
+// A test case is flaky when it both passed and failed on a same commit.
+type TestCaseHistory struct {
+	Suite           string        `json:"suite"`
+	Name            string        `json:"name"`
+	Runs            []TestCaseRun `json:"runs"`
+	AverageDuration float64       `json:"average_duration"`
+	FirstFailure    *TestCaseRun  `json:"first_failure,omitempty"`
+	Flaky           bool          `json:"flaky"`
+	Quarantined     bool          `json:"quarantined"`
+}
+
+// FlakyTest is a test case which both passed and failed on a same commit
+type FlakyTest struct {
+	Suite       string    `json:"suite"`
+	Name        string    `json:"name"`
+	Hash        string    `json:"hash"`
+	Passes      int       `json:"passes"`
+	Failures    int       `json:"failures"`
+	LastSeen    time.Time `json:"last_seen"`
+	Quarantined bool      `json:"quarantined"`
+}
+
+// TestQuarantine is a known flaky test of a project, whose failures do not fail the JUnit action
+type TestQuarantine struct {
+	ID      int64     `json:"id"`
+	Suite   string    `json:"suite"`
+	Name    string    `json:"name"`
+	Reason  string    `json:"reason"`
+	Author  string    `json:"author"`
+	Created time.Time `json:"created"`
+}
+
+// TestCaseStatus returns whether a test passed, failed or was skipped
+func TestCaseStatus(t Test) string {
+	switch {
+	case t.Failure != "" || t.Error != "":
+		return TestCaseFail
+	case t.Skip != nil:
+		return TestCaseSkip
+	default:
+		return TestCasePass
+	}
+}
+
+// IsQuarantined returns whether the test of given suite and name is quarantined
+func IsQuarantined(quarantine []TestQuarantine, suite, name string) bool {
+	for _, q := range quarantine {
+		if q.Suite == suite && q.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func testsPath(key, appName, pipelineName string) string {
+	return fmt.Sprintf("/project/%s/application/%s/pipeline/%s/test", key, appName, pipelineName)
+}
+
+// GetTestCaseHistory retrieves the timeline of a test case on a pipeline
+func GetTestCaseHistory(key, appName, pipelineName, env, suite, name string) (*TestCaseHistory, error) {
+	v := url.Values{}
+	v.Set("suite", suite)
+	v.Set("name", name)
+	if env != "" {
+		v.Set("envName", env)
+	}
+
+	data, code, err := Request("GET", testsPath(key, appName, pipelineName)+"/history?"+v.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var h TestCaseHistory
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// GetFlakyTests lists the test cases which both passed and failed on a same commit in the latest builds of a pipeline
+func GetFlakyTests(key, appName, pipelineName, env string) ([]FlakyTest, error) {
+	uri := testsPath(key, appName, pipelineName) + "/flaky"
+	if env != "" {
+		uri = fmt.Sprintf("%s?envName=%s", uri, url.QueryEscape(env))
+	}
+
+	data, code, err := Request("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var flaky []FlakyTest
+	if err := json.Unmarshal(data, &flaky); err != nil {
+		return nil, err
+	}
+	return flaky, nil
+}
+
+// GetTestQuarantine lists the quarantined tests of a project
+func GetTestQuarantine(key string) ([]TestQuarantine, error) {
+	data, code, err := Request("GET", fmt.Sprintf("/project/%s/test/quarantine", key), nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("HTTP %d", code)
+	}
+
+	var quarantine []TestQuarantine
+	if err := json.Unmarshal(data, &quarantine); err != nil {
+		return nil, err
+	}
+	return quarantine, nil
+}
+
+// QuarantineTest quarantines a test of a project
+func QuarantineTest(key, suite, name, reason string) error {
+	data, err := json.Marshal(TestQuarantine{Suite: suite, Name: name, Reason: reason})
+	if err != nil {
+		return err
+	}
+
+	_, code, err := Request("POST", fmt.Sprintf("/project/%s/test/quarantine", key), data)
+	if err != nil {
+		return err
+	}
+	if code >= 300 {
+		return fmt.Errorf("HTTP %d", code)
+	}
+	return nil
+}
+
+// UnquarantineTest releases a test of a project from quarantine
+func UnquarantineTest(key, suite, name string) error {
+	v := url.Values{}
+	v.Set("suite", suite)
+	v.Set("name", name)
+
+	_, code, err := Request("DELETE", fmt.Sprintf("/project/%s/test/quarantine?%s", key, v.Encode()), nil)
+	if err != nil {
+		return err
+	}
+	if code >= 300 {
+		return fmt.Errorf("HTTP %d", code)
+	}
+	return nil
+}
@@ -25,13 +25,16 @@ type TestSuite struct {
 	Tests    []Test `xml:"testcase" json:"tests"`
 }
 
-// Test define a single test
+// Test define a single test, Time being its duration in seconds
 type Test struct {
-	Name    string  `xml:"name,attr" json:"name"`
-	Time    string  `xml:"time,attr" json:"time"`
-	Failure string  `xml:"failure" json:"failure"`
-	Error   string  `xml:"error" json:"error"`
-	Skip    *string `xml:"skipped" json:"skipped"`
+	Name      string  `xml:"name,attr" json:"name"`
+	ClassName string  `xml:"classname,attr" json:"classname,omitempty"`
+	Time      string  `xml:"time,attr" json:"time"`
+	Failure   string  `xml:"failure" json:"failure"`
+	Error     string  `xml:"error" json:"error"`
+	Skip      *string `xml:"skipped" json:"skipped"`
+	SystemOut string  `xml:"system-out" json:"system_out,omitempty"`
+	SystemErr string  `xml:"system-err" json:"system_err,omitempty"`
 }
 
 // GetTestResults retrieves tests results for a specific build
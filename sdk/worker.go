@@ -58,6 +58,7 @@ type ModelStatus struct {
 	WantedCount   int64         `json:"wanted_count" yaml:"wanted"`
 	BuildingCount int64         `json:"building_count" yaml:"building"`
 	Requirements  []Requirement `json:"requirements"`
+	Priority      int64         `json:"priority" yaml:"priority"`
 }
 
 // OpenstackModelData type details the "Image" field of Openstack type model
@@ -210,15 +211,16 @@ func DeleteWorkerModel(workerModelID int64) error {
 	return nil
 }
 
-// AddCapabilityToWorkerModel adds a capability to given model
-func AddCapabilityToWorkerModel(modelID int64, name string, capaType RequirementType, value string) error {
+// AddCapabilityToWorkerModel adds a capability to given model, version may be empty
+func AddCapabilityToWorkerModel(modelID int64, name string, capaType RequirementType, value string, version string) error {
 
 	uri := fmt.Sprintf("/worker/model/%d/capability", modelID)
 
 	r := Requirement{
-		Name:  name,
-		Type:  capaType,
-		Value: value,
+		Name:    name,
+		Type:    capaType,
+		Value:   value,
+		Version: version,
 	}
 	data, err := json.Marshal(r)
 	if err != nil {